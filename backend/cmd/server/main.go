@@ -14,9 +14,14 @@ import (
 
 	"backend/internal/config"
 	"backend/internal/handlers"
+	"backend/internal/jobs"
 	"backend/internal/middleware"
 	"backend/internal/repositories"
 	"backend/internal/services"
+	"backend/pkg/cookie"
+	"backend/pkg/mailer"
+	"backend/pkg/oidc"
+	"backend/pkg/ratelimit"
 	"backend/pkg/redis"
 )
 
@@ -25,6 +30,9 @@ import (
 func main() {
 	// Load configuration from environment variables
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Error 1008: Insecure configuration: %v", err)
+	}
 
 	// Set Gin mode based on environment
 	if cfg.IsProduction() {
@@ -40,8 +48,47 @@ func main() {
 	}
 	defer redisClient.Close()
 
+	// Convert category indexes written by earlier versions as plain sets (skipped once recorded as done)
+	taskRepo := repositories.NewTaskRepository(redisClient)
+	migrated, err := taskRepo.MigrateCategoryIndexes()
+	if err != nil {
+		log.Fatalf("Error 1005: Failed to migrate category indexes: %v", err)
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d category indexes to sorted sets", migrated)
+	}
+
+	// Backfill the user, session and deleted task indexes that replaced KEYS scans (skipped once recorded as done)
+	userRepo := repositories.NewUserRepository(redisClient)
+	indexed, err := userRepo.BackfillIndexes()
+	if err != nil {
+		log.Fatalf("Error 1007: Failed to backfill Redis indexes: %v", err)
+	}
+	deletedUsers, err := taskRepo.BackfillDeletedTaskUsers()
+	if err != nil {
+		log.Fatalf("Error 1007: Failed to backfill Redis indexes: %v", err)
+	}
+	if indexed+deletedUsers > 0 {
+		log.Printf("Backfilled %d Redis index entries", indexed+deletedUsers)
+	}
+
+	// Initialize the mailer used for password reset emails
+	mail, err := mailer.New(&mailer.Config{
+		Transport:    cfg.Email.Transport,
+		SMTPHost:     cfg.Email.SMTPHost,
+		SMTPPort:     cfg.Email.SMTPPort,
+		SMTPUsername: cfg.Email.SMTPUsername,
+		SMTPPassword: cfg.Email.SMTPPassword,
+		FromAddress:  cfg.Email.FromAddress,
+		FromName:     cfg.Email.FromName,
+		FileDir:      cfg.Email.FileDir,
+	})
+	if err != nil {
+		log.Fatalf("Error 1009: Failed to configure mailer: %v", err)
+	}
+
 	// Initialize Gin router
-	router := setupRouter(cfg, redisClient)
+	router := setupRouter(cfg, redisClient, mail)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -59,6 +106,21 @@ func main() {
 		}
 	}()
 
+	// Start the background job that purges expired soft-deleted tasks
+	var cleanupRunner *jobs.Runner
+	if cfg.Cleanup.Enabled && cfg.Cleanup.Interval > 0 {
+		cleanupRunner = jobs.NewRunner(redisClient, "cleanup-expired-tasks", time.Duration(cfg.Cleanup.Interval)*time.Minute, func(ctx context.Context) error {
+			report, err := taskRepo.CleanupExpiredTasks(ctx)
+			if report != nil {
+				for userID, count := range report.PerUser {
+					log.Printf("Purged %d expired tasks for user %s", count, userID)
+				}
+			}
+			return err
+		})
+		cleanupRunner.Start()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -73,14 +135,26 @@ func main() {
 		log.Fatalf("Error 1003: Server forced to shutdown: %v", err)
 	}
 
+	// Let a running cleanup finish and hand the cleanup lock to another replica
+	if cleanupRunner != nil {
+		if err := cleanupRunner.Stop(ctx); err != nil {
+			log.Printf("Error 1006: Cleanup job did not stop cleanly: %v", err)
+		}
+	}
+
 	log.Println("Server exited")
 }
 
 // setupRouter configures and returns the Gin router with all routes and middleware
 // Sets up health checks, API routes, and middleware stack with dependency injection
-func setupRouter(cfg *config.Config, redisClient *redis.Client) *gin.Engine {
+func setupRouter(cfg *config.Config, redisClient *redis.Client, mail mailer.Mailer) *gin.Engine {
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from known proxies, so clients cannot pick the IP they are rate limited under
+	if err := router.SetTrustedProxies(cfg.Security.TrustedProxies); err != nil {
+		log.Fatalf("Error 1010: Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Add middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
@@ -91,32 +165,112 @@ func setupRouter(cfg *config.Config, redisClient *redis.Client) *gin.Engine {
 	}
 
 	// Initialize repositories
-	userRepo := repositories.NewUserRepository(redisClient)
+	userRepo := repositories.NewUserRepositoryWithSessions(redisClient, repositories.SessionSettings{
+		RememberTTL: time.Duration(cfg.Session.Duration) * 24 * time.Hour,
+		ShortTTL:    time.Duration(cfg.Session.ShortDuration) * time.Hour,
+		Sliding:     cfg.Session.Sliding,
+	})
 	taskRepo := repositories.NewTaskRepository(redisClient)
 
+	// Session cookies and email verification tokens are signed with the session secret
+	signer := cookie.NewSigner(cfg.Session.SecretKey, cfg.Session.PreviousKeys...)
+
+	// Single sign-on is offered only when an identity provider is configured
+	userOptions := services.UserServiceOptions{
+		Tasks:            taskRepo,
+		Mailer:           mail,
+		Signer:           signer,
+		AppURL:           cfg.Email.AppURL,
+		PasswordResetTTL: time.Duration(cfg.Email.PasswordResetTTL) * time.Minute,
+		VerificationTTL:  time.Duration(cfg.Email.VerificationTTL) * time.Hour,
+		Logins:           userRepo,
+		Lockout: services.LockoutPolicy{
+			Threshold:   cfg.Security.LockoutThreshold,
+			Duration:    time.Duration(cfg.Security.LockoutDuration) * time.Minute,
+			MaxDuration: time.Duration(cfg.Security.LockoutMaxDuration) * time.Minute,
+		},
+		AdminEmails: cfg.Security.AdminEmails,
+		TwoFactor:   userRepo,
+		TOTPIssuer:  cfg.Security.TOTPIssuer,
+		APITokens:   userRepo,
+		OIDC:        userRepo,
+	}
+	if cfg.OIDC.Enabled() {
+		provider, err := discoverProvider(cfg.OIDC)
+		if err != nil {
+			log.Fatalf("Error 1012: Failed to configure identity provider: %v", err)
+		}
+		userOptions.OIDCProvider = provider
+	}
+
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserServiceWithOptions(userRepo, userOptions)
 	taskService := services.NewTaskService(taskRepo)
 
+	// Accounts that verified their email before it was listed in ADMIN_EMAILS become administrators now
+	promoted, err := userService.PromoteAdmins()
+	if err != nil {
+		log.Fatalf("Error 1011: Failed to promote administrators: %v", err)
+	}
+	if promoted > 0 {
+		log.Printf("Promoted %d accounts to administrator", promoted)
+	}
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userService)
+	authHandler := handlers.NewAuthHandlerWithCookies(userService, signer, handlers.CookieSettings{
+		MaxAge:   cfg.Session.Duration * 24 * 60 * 60,
+		Secure:   cfg.Session.Secure,
+		HTTPOnly: cfg.Session.HTTPOnly,
+	})
 	taskHandler := handlers.NewTaskHandler(taskService)
+	adminHandler := handlers.NewAdminHandler(userService)
 
 	// Initialize middleware
-	authMiddleware := middleware.AuthMiddleware(userRepo)
+	sessionCookies := middleware.CookieSettings{Secure: cfg.Session.Secure, HTTPOnly: cfg.Session.HTTPOnly}
+	authMiddleware := middleware.AuthMiddleware(userRepo, signer, sessionCookies)
+	tokenAuthMiddleware := middleware.AuthMiddlewareWithTokens(userRepo, userRepo, signer, sessionCookies)
+
+	// Rate limits are kept in Redis so they hold across replicas; a limit of 0 disables it
+	apiLimit := func(c *gin.Context) { c.Next() }
+	if cfg.Security.RateLimit > 0 {
+		apiLimit = middleware.RateLimit(ratelimit.NewLimiter(redisClient, "api", cfg.Security.RateLimit, time.Minute))
+	}
+	var authIPLimiter, authEmailLimiter middleware.RateLimiter
+	if cfg.Security.AuthRateLimit > 0 {
+		authIPLimiter = ratelimit.NewLimiter(redisClient, "auth-ip", cfg.Security.AuthRateLimit, time.Minute)
+	}
+	if cfg.Security.AuthEmailRateLimit > 0 {
+		authEmailLimiter = ratelimit.NewLimiter(redisClient, "auth-email", cfg.Security.AuthEmailRateLimit, time.Minute)
+	}
+	credentialLimit := middleware.CredentialRateLimit(authIPLimiter, authEmailLimiter)
+
+	// Task creation is held back until the email address is verified when REQUIRE_EMAIL_VERIFICATION is set
+	requireVerified := func(c *gin.Context) { c.Next() }
+	if cfg.Email.RequireVerified {
+		requireVerified = middleware.RequireVerifiedEmail()
+	}
 
 	// Health check endpoint
 	router.GET("/health", healthCheckHandler(redisClient))
 
 	// API version 1 routes group
 	v1 := router.Group("/api/v1")
+	v1.Use(apiLimit)
 	{
 		// Auth routes (no authentication required)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/register", credentialLimit, authHandler.Register)
+			auth.POST("/login", credentialLimit, authHandler.Login)
+			auth.POST("/login/2fa", credentialLimit, authHandler.LoginTwoFactor)
 			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/forgot-password", credentialLimit, authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			if cfg.OIDC.Enabled() {
+				auth.POST("/oidc/login", credentialLimit, authHandler.StartOIDCLogin)
+				auth.POST("/oidc/callback", credentialLimit, authHandler.CompleteOIDCLogin)
+			}
 		}
 
 		// Protected routes (authentication required)
@@ -125,24 +279,77 @@ func setupRouter(cfg *config.Config, redisClient *redis.Client) *gin.Engine {
 		{
 			// Auth routes that require authentication
 			protected.GET("/auth/me", authHandler.Me)
-			// Task routes
-			protected.GET("/tasks", taskHandler.ListTasks)
-			protected.POST("/tasks", taskHandler.CreateTask)
-			protected.GET("/tasks/:id", taskHandler.GetTask)
-			protected.PUT("/tasks/:id/complete", taskHandler.UpdateTaskCompletion)
-			protected.DELETE("/tasks/:id", taskHandler.DeleteTask)
-			protected.POST("/tasks/:id/restore", taskHandler.RestoreTask)
+			protected.PATCH("/auth/me", authHandler.UpdateProfile)
+			protected.DELETE("/auth/me", authHandler.DeleteAccount)
+			protected.PUT("/auth/password", authHandler.ChangePassword)
+			protected.POST("/auth/resend-verification", authHandler.ResendVerification)
+			protected.GET("/auth/sessions", authHandler.ListSessions)
+			protected.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+			protected.GET("/auth/login-activity", authHandler.LoginActivity)
+			protected.GET("/auth/2fa", authHandler.TwoFactorStatus)
+			protected.POST("/auth/2fa/setup", authHandler.SetupTwoFactor)
+			protected.POST("/auth/2fa/enable", authHandler.EnableTwoFactor)
+			protected.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+			protected.GET("/auth/tokens", authHandler.ListAPITokens)
+			protected.POST("/auth/tokens", authHandler.CreateAPIToken)
+			protected.DELETE("/auth/tokens/:id", authHandler.RevokeAPIToken)
+		}
+
+		// Task routes (a session, or an API token with the tasks:read scope to read and tasks:write to change)
+		tasks := v1.Group("/")
+		tasks.Use(tokenAuthMiddleware)
+		{
+			tasks.GET("/tasks", taskHandler.ListTasks)
+			tasks.POST("/tasks", requireVerified, taskHandler.CreateTask)
+			tasks.GET("/tasks/search", taskHandler.SearchTasks)
+			tasks.GET("/tasks/:id", taskHandler.GetTask)
+			tasks.PATCH("/tasks/:id", taskHandler.UpdateTask)
+			tasks.PUT("/tasks/:id/complete", taskHandler.UpdateTaskCompletion)
+			tasks.DELETE("/tasks/:id", taskHandler.DeleteTask)
+			tasks.POST("/tasks/:id/restore", taskHandler.RestoreTask)
+			tasks.GET("/tasks/:id/history", taskHandler.GetTaskHistory)
+			tasks.GET("/tasks/:id/subtasks", taskHandler.ListSubtasks)
 
 			// Category routes
-			protected.GET("/categories", taskHandler.GetCategories)
-			protected.PUT("/categories/:categoryName", taskHandler.RenameCategory)
-			protected.DELETE("/categories/:categoryName", taskHandler.DeleteCategory)
+			tasks.GET("/categories", taskHandler.GetCategories)
+			tasks.PUT("/categories/:categoryName", taskHandler.RenameCategory)
+			tasks.DELETE("/categories/:categoryName", taskHandler.DeleteCategory)
+
+			// Tag routes
+			tasks.GET("/tags", taskHandler.GetTags)
+			tasks.PUT("/tags/:tagName", taskHandler.RenameTag)
+			tasks.POST("/tags/:tagName/merge", taskHandler.MergeTags)
+		}
+
+		// Admin routes (administrator accounts only)
+		admin := v1.Group("/admin")
+		admin.Use(authMiddleware, middleware.RequireAdmin(), middleware.RequireVerifiedEmail())
+		{
+			admin.GET("/users/:id/login-activity", adminHandler.GetUserLoginActivity)
+			admin.POST("/users/:id/unlock", adminHandler.UnlockUser)
 		}
 	}
 
 	return router
 }
 
+// discoverProvider loads the identity provider's discovery document
+// Gives up after ten seconds so a provider outage cannot hang startup
+func discoverProvider(cfg config.OIDCConfig) (*oidc.Provider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return oidc.Discover(ctx, oidc.Config{
+		IssuerURL:    cfg.IssuerURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
+}
+
 // healthCheckHandler returns a handler for health check endpoints
 // Verifies that the application and its dependencies are running properly
 func healthCheckHandler(redisClient *redis.Client) gin.HandlerFunc {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
 	"strings"
@@ -16,6 +17,8 @@ type Config struct {
 	Session  SessionConfig `json:"session"`
 	Email    EmailConfig  `json:"email"`
 	Security SecurityConfig `json:"security"`
+	Cleanup  CleanupConfig  `json:"cleanup"`
+	OIDC     OIDCConfig     `json:"oidc"`
 }
 
 // ServerConfig contains HTTP server configuration
@@ -37,34 +40,79 @@ type RedisConfig struct {
 // SessionConfig contains session management configuration
 // Defines session duration and security settings
 type SessionConfig struct {
-	Duration int    `json:"duration"` // days
-	SecretKey string `json:"secret_key"`
-	Secure    bool   `json:"secure"`
-	HTTPOnly  bool   `json:"http_only"`
+	Duration      int      `json:"duration"`       // days, for sessions opened with remember me
+	ShortDuration int      `json:"short_duration"` // hours, for all other sessions
+	Sliding       bool     `json:"sliding"`        // renew the session lifetime on activity
+	SecretKey     string   `json:"secret_key"`
+	PreviousKeys  []string `json:"-"` // earlier secrets still accepted when verifying cookies
+	Secure        bool     `json:"secure"`
+	HTTPOnly      bool     `json:"http_only"`
 }
 
 // EmailConfig contains SMTP email configuration
 // Used for sending registration confirmations and notifications
 type EmailConfig struct {
-	SMTPHost     string `json:"smtp_host"`
-	SMTPPort     int    `json:"smtp_port"`
-	SMTPUsername string `json:"smtp_username"`
-	SMTPPassword string `json:"smtp_password"`
-	FromAddress  string `json:"from_address"`
-	FromName     string `json:"from_name"`
+	Transport        string `json:"transport"` // smtp, file or log
+	SMTPHost         string `json:"smtp_host"`
+	SMTPPort         int    `json:"smtp_port"`
+	SMTPUsername     string `json:"smtp_username"`
+	SMTPPassword     string `json:"smtp_password"`
+	FromAddress      string `json:"from_address"`
+	FromName         string `json:"from_name"`
+	FileDir          string `json:"file_dir"`           // where the file transport writes messages
+	AppURL           string `json:"app_url"`            // frontend base URL used for links in emails
+	PasswordResetTTL int    `json:"password_reset_ttl"` // minutes a password reset link stays valid
+	VerificationTTL  int    `json:"verification_ttl"`   // hours an email verification link stays valid
+	RequireVerified  bool   `json:"require_verified"`   // block task creation until the address is verified
 }
 
 // SecurityConfig contains security-related settings
 // Defines rate limiting and other security measures
 type SecurityConfig struct {
-	RateLimit    int  `json:"rate_limit"`     // requests per minute per IP
-	EnableCORS   bool `json:"enable_cors"`
-	AllowedOrigins []string `json:"allowed_origins"`
+	RateLimit          int      `json:"rate_limit"`            // requests per minute per IP; 0 disables
+	AuthRateLimit      int      `json:"auth_rate_limit"`       // login, registration and password reset requests per minute per IP; 0 disables
+	AuthEmailRateLimit int      `json:"auth_email_rate_limit"` // login, registration and password reset requests per minute per email address; 0 disables
+	EnableCORS         bool     `json:"enable_cors"`
+	AllowedOrigins     []string `json:"allowed_origins"`
+	TrustedProxies     []string `json:"trusted_proxies"`      // proxies whose X-Forwarded-For header is believed when finding the client IP
+	LockoutThreshold   int      `json:"lockout_threshold"`    // consecutive failed logins before an account is locked; 0 disables
+	LockoutDuration    int      `json:"lockout_duration"`     // minutes of the first lockout, doubling with each further failure
+	LockoutMaxDuration int      `json:"lockout_max_duration"` // minutes, the longest lockout
+	AdminEmails        []string `json:"admin_emails"`         // accounts with these emails are administrators
+	TOTPIssuer         string   `json:"totp_issuer"`          // service name shown in authenticator apps
 }
 
+// CleanupConfig contains settings for the background job that purges expired soft-deleted tasks
+// Only the replica holding the cleanup lock runs the job
+type CleanupConfig struct {
+	Enabled  bool `json:"enabled"`
+	Interval int  `json:"interval"` // minutes
+}
+
+// OIDCConfig contains the OpenID Connect identity provider users can sign in with
+// Sign-in with the provider is enabled when an issuer URL is set
+type OIDCConfig struct {
+	IssuerURL    string   `json:"issuer_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"-"`            // empty for public clients
+	RedirectURL  string   `json:"redirect_url"` // frontend page the provider sends the browser back to
+	Scopes       []string `json:"scopes"`
+}
+
+// Enabled reports whether sign-in with an identity provider is configured
+func (c *OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// DefaultSessionSecret is the placeholder secret used when SESSION_SECRET is not set
+// The server refuses to start in production while it is in use
+const DefaultSessionSecret = "your-secret-key-change-in-production"
+
 // Load creates a new configuration from environment variables
 // Uses sensible defaults when environment variables are not set
 func Load() *Config {
+	appURL := getEnv("APP_URL", "http://localhost:3000")
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnvAsInt("SERVER_PORT", 8080),
@@ -83,25 +131,69 @@ func Load() *Config {
 			},
 		},
 		Session: SessionConfig{
-			Duration:  getEnvAsInt("SESSION_DURATION", 7),
-			SecretKey: getEnv("SESSION_SECRET", "your-secret-key-change-in-production"),
-			Secure:    getEnvAsBool("SESSION_SECURE", false),
-			HTTPOnly:  getEnvAsBool("SESSION_HTTP_ONLY", true),
+			Duration:      getEnvAsInt("SESSION_DURATION", 7),
+			ShortDuration: getEnvAsInt("SESSION_SHORT_DURATION", 24),
+			Sliding:       getEnvAsBool("SESSION_SLIDING", true),
+			SecretKey:     getEnv("SESSION_SECRET", DefaultSessionSecret),
+			PreviousKeys:  getEnvAsSlice("SESSION_PREVIOUS_SECRETS", nil),
+			Secure:        getEnvAsBool("SESSION_SECURE", false),
+			HTTPOnly:      getEnvAsBool("SESSION_HTTP_ONLY", true),
 		},
 		Email: EmailConfig{
-			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
-			SMTPPort:     getEnvAsInt("SMTP_PORT", 1025), // MailHog default
-			SMTPUsername: getEnv("SMTP_USERNAME", ""),
-			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-			FromAddress:  getEnv("EMAIL_FROM_ADDRESS", "noreply@no.reply.com"),
-			FromName:     getEnv("EMAIL_FROM_NAME", "Task Tracker"),
+			Transport:        getEnv("EMAIL_TRANSPORT", "smtp"),
+			SMTPHost:         getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:         getEnvAsInt("SMTP_PORT", 1025), // MailHog default
+			SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+			FromAddress:      getEnv("EMAIL_FROM_ADDRESS", "noreply@no.reply.com"),
+			FromName:         getEnv("EMAIL_FROM_NAME", "Task Tracker"),
+			FileDir:          getEnv("EMAIL_FILE_DIR", "mail"),
+			AppURL:           appURL,
+			PasswordResetTTL: getEnvAsInt("PASSWORD_RESET_TTL", 60),
+			VerificationTTL:  getEnvAsInt("EMAIL_VERIFICATION_TTL", 48),
+			RequireVerified:  getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
 		},
 		Security: SecurityConfig{
-			RateLimit:      getEnvAsInt("RATE_LIMIT", 1000),
-			EnableCORS:     getEnvAsBool("ENABLE_CORS", true),
-			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:3001"}),
+			RateLimit:          getEnvAsInt("RATE_LIMIT", 1000),
+			AuthRateLimit:      getEnvAsInt("AUTH_RATE_LIMIT", 20),
+			AuthEmailRateLimit: getEnvAsInt("AUTH_EMAIL_RATE_LIMIT", 5),
+			EnableCORS:         getEnvAsBool("ENABLE_CORS", true),
+			AllowedOrigins:     getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:3001"}),
+			TrustedProxies:     getEnvAsSlice("TRUSTED_PROXIES", nil),
+			LockoutThreshold:   getEnvAsInt("LOCKOUT_THRESHOLD", 5),
+			LockoutDuration:    getEnvAsInt("LOCKOUT_DURATION", 5),
+			LockoutMaxDuration: getEnvAsInt("LOCKOUT_MAX_DURATION", 1440),
+			AdminEmails:        getEnvAsSlice("ADMIN_EMAILS", nil),
+			TOTPIssuer:         getEnv("TOTP_ISSUER", "Task Tracker"),
 		},
+		Cleanup: CleanupConfig{
+			Enabled:  getEnvAsBool("CLEANUP_ENABLED", true),
+			Interval: getEnvAsInt("CLEANUP_INTERVAL", 60),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", strings.TrimRight(appURL, "/")+"/auth/oidc/callback"),
+			Scopes:       getEnvAsSlice("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+		},
+	}
+}
+
+// Validate checks the configuration for settings that are unsafe to run with
+// Production deployments must set their own session secret and must not log emails; an identity provider needs a client ID
+func (c *Config) Validate() error {
+	if c.IsProduction() && (c.Session.SecretKey == "" || c.Session.SecretKey == DefaultSessionSecret) {
+		return errors.New("SESSION_SECRET must be set to a unique value in production")
+	}
+	// The log transport writes reset links and other secrets to the logs
+	if c.IsProduction() && c.Email.Transport == "log" {
+		return errors.New("EMAIL_TRANSPORT=log must not be used in production")
+	}
+	if c.OIDC.Enabled() && c.OIDC.ClientID == "" {
+		return errors.New("OIDC_CLIENT_ID must be set when OIDC_ISSUER_URL is set")
 	}
+	return nil
 }
 
 // IsDevelopment returns true if running in development mode
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		secret      string
+		transport   string
+		expectError bool
+	}{
+		{name: "default secret in production", environment: "production", secret: DefaultSessionSecret, expectError: true},
+		{name: "empty secret in production", environment: "production", secret: "", expectError: true},
+		{name: "custom secret in production", environment: "production", secret: "a-long-random-secret"},
+		{name: "default secret in development", environment: "development", secret: DefaultSessionSecret},
+		{name: "log mail transport in production", environment: "production", secret: "a-long-random-secret", transport: "log", expectError: true},
+		{name: "log mail transport in development", environment: "development", secret: DefaultSessionSecret, transport: "log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server:  ServerConfig{Environment: tt.environment},
+				Session: SessionConfig{SecretKey: tt.secret},
+				Email:   EmailConfig{Transport: tt.transport},
+			}
+
+			err := cfg.Validate()
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad_PreviousSessionSecrets(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "current-secret")
+	t.Setenv("SESSION_PREVIOUS_SECRETS", "old-secret,older-secret")
+
+	cfg := Load()
+	assert.Equal(t, "current-secret", cfg.Session.SecretKey)
+	assert.Equal(t, []string{"old-secret", "older-secret"}, cfg.Session.PreviousKeys)
+}
+
+func TestLoad_RateLimits(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "0")
+	t.Setenv("AUTH_RATE_LIMIT", "30")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.1")
+
+	cfg := Load()
+	assert.Equal(t, 0, cfg.Security.RateLimit)
+	assert.Equal(t, 30, cfg.Security.AuthRateLimit)
+	assert.Equal(t, 5, cfg.Security.AuthEmailRateLimit)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, cfg.Security.TrustedProxies)
+}
+
+func TestLoad_Lockout(t *testing.T) {
+	t.Setenv("LOCKOUT_THRESHOLD", "10")
+	t.Setenv("ADMIN_EMAILS", "admin@example.com,ops@example.com")
+
+	cfg := Load()
+	assert.Equal(t, 10, cfg.Security.LockoutThreshold)
+	assert.Equal(t, 5, cfg.Security.LockoutDuration)
+	assert.Equal(t, 1440, cfg.Security.LockoutMaxDuration)
+	assert.Equal(t, []string{"admin@example.com", "ops@example.com"}, cfg.Security.AdminEmails)
+	assert.Equal(t, "Task Tracker", cfg.Security.TOTPIssuer)
+}
+
+func TestLoad_OIDC(t *testing.T) {
+	cfg := Load()
+	assert.False(t, cfg.OIDC.Enabled())
+	assert.Equal(t, "http://localhost:3000/auth/oidc/callback", cfg.OIDC.RedirectURL)
+
+	t.Setenv("OIDC_ISSUER_URL", "https://idp.example.com")
+	t.Setenv("APP_URL", "https://tasks.example.com/")
+	t.Setenv("OIDC_SCOPES", "openid,email")
+
+	cfg = Load()
+	assert.True(t, cfg.OIDC.Enabled())
+	assert.Equal(t, "https://tasks.example.com/auth/oidc/callback", cfg.OIDC.RedirectURL)
+	assert.Equal(t, []string{"openid", "email"}, cfg.OIDC.Scopes)
+
+	// An issuer without a client ID cannot work
+	assert.Error(t, cfg.Validate())
+	cfg.OIDC.ClientID = "task-tracker"
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// API token scopes; a token can only reach the endpoints its scopes allow
+const (
+	ScopeTasksRead  = "tasks:read"  // read tasks, categories and tags
+	ScopeTasksWrite = "tasks:write" // create, change and delete tasks, categories and tags
+)
+
+// APITokenScopes lists every scope a token can be given
+var APITokenScopes = []string{ScopeTasksRead, ScopeTasksWrite}
+
+// APIToken is a personal access token that lets scripts call the API without a browser session
+// Only the hash of the token is stored; the token itself is shown once when it is created
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	TokenHash  string     `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // nil until the token is first used
+}
+
+// HasScope reports whether the token was given the scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Common API token errors
+var (
+	ErrAPITokenNotFound      = errors.New("API token not found")
+	ErrInvalidAPIToken       = errors.New("invalid, expired or revoked API token")
+	ErrInvalidAPITokenName   = errors.New("invalid API token name")
+	ErrInvalidAPITokenScopes = errors.New("invalid API token scopes")
+	ErrInvalidAPITokenExpiry = errors.New("invalid API token expiry")
+)
@@ -0,0 +1,18 @@
+package domain
+
+import "errors"
+
+// OIDCLoginState is a sign-in with the identity provider waiting for the browser to come back with a code
+// It is stored under the hash of the state parameter sent to the provider
+type OIDCLoginState struct {
+	Nonce        string `json:"nonce"`         // must come back in the ID token
+	CodeVerifier string `json:"code_verifier"` // PKCE verifier proving the code was requested by this server
+	RememberMe   bool   `json:"remember_me"`
+}
+
+// Common identity provider sign-in errors
+var (
+	ErrInvalidOIDCState     = errors.New("invalid or expired identity provider sign-in")
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not return a verified email address")
+	ErrOIDCExchangeFailed   = errors.New("identity provider sign-in failed")
+)
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// DefaultTaskPageSize is the number of tasks listed per page when no limit is given
+const DefaultTaskPageSize = 100
+
+// TaskPage represents one page of a task listing
+// Total counts every task matching the filters; NextCursor is empty on the last page
+type TaskPage struct {
+	Tasks      []*Task `json:"tasks"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// TaskCursor marks the position of the last task returned in a listing
+// Key holds the sort scores of that task, so pages stay stable when tasks are added before the cursor
+type TaskCursor struct {
+	SortBy string  `json:"s"`
+	Key    []int64 `json:"k"`
+	ID     string  `json:"id"`
+}
+
+// Encode serializes the cursor into an opaque URL-safe token
+func (c TaskCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTaskCursor parses a token produced by TaskCursor.Encode
+// Returns ErrInvalidCursor for malformed tokens
+func DecodeTaskCursor(token string) (*TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor TaskCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || len(cursor.Key) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskCursor_RoundTrip(t *testing.T) {
+	cursor := TaskCursor{SortBy: TaskSortPriority, Key: []int64{0, -3, -1700000000}, ID: "task-1"}
+
+	decoded, err := DecodeTaskCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+}
+
+func TestDecodeTaskCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "%%%"},
+		{name: "not json", token: "bm90LWpzb24"},
+		{name: "missing id", token: TaskCursor{SortBy: TaskSortCreated, Key: []int64{0}}.Encode()},
+		{name: "missing key", token: TaskCursor{SortBy: TaskSortCreated, ID: "task-1"}.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeTaskCursor(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported recurrence frequencies (iCalendar RRULE FREQ values)
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+)
+
+// recurrenceUntilLayout is the iCalendar UTC date-time format used for UNTIL
+const recurrenceUntilLayout = "20060102T150405Z"
+
+// recurrenceWeekdays maps iCalendar BYDAY codes to weekdays
+var recurrenceWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceRule represents a subset of an iCalendar RRULE
+// Supports FREQ, INTERVAL, BYDAY (weekly), BYMONTHDAY (monthly), COUNT and UNTIL
+type RecurrenceRule struct {
+	Frequency  string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay int
+	Count      int
+	Until      *time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE string such as "FREQ=WEEKLY;BYDAY=MO,FR"
+// Also accepts the shorthands daily, weekly, monthly and yearly
+func ParseRecurrenceRule(value string) (*RecurrenceRule, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.ToUpper(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: rule cannot be empty", ErrInvalidRecurrence)
+	}
+
+	// Shorthand frequencies
+	switch value {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		return &RecurrenceRule{Frequency: value, Interval: 1}, nil
+	}
+
+	rule := &RecurrenceRule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRecurrence, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch val {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rule.Frequency = val
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRecurrence, val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRecurrence)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				weekday, ok := recurrenceWeekdays[code]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY %q", ErrInvalidRecurrence, code)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(val)
+			if err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("%w: BYMONTHDAY must be between 1 and 31", ErrInvalidRecurrence)
+			}
+			rule.ByMonthDay = day
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRecurrence)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := time.Parse(recurrenceUntilLayout, val)
+			if err != nil {
+				until, err = time.Parse("20060102", val)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRecurrence)
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRecurrence, key)
+		}
+	}
+
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if len(rule.ByDay) > 0 && rule.Frequency != FrequencyWeekly {
+		return nil, fmt.Errorf("%w: BYDAY is only supported with FREQ=WEEKLY", ErrInvalidRecurrence)
+	}
+	if rule.ByMonthDay != 0 && rule.Frequency != FrequencyMonthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY is only supported with FREQ=MONTHLY", ErrInvalidRecurrence)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrence)
+	}
+
+	rule.ByDay = sortWeekdays(rule.ByDay)
+	return rule, nil
+}
+
+// String formats the rule as a canonical RRULE string
+// Parts are emitted in a fixed order so equal rules compare equal
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Frequency}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			codes = append(codes, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(recurrenceUntilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Anchor pins calendar fields the rule leaves implicit to the series start
+// Monthly rules without BYMONTHDAY keep the start day so short months do not shift later occurrences
+func (r *RecurrenceRule) Anchor(start time.Time) {
+	if r.Frequency == FrequencyMonthly && r.ByMonthDay == 0 {
+		r.ByMonthDay = start.Day()
+	}
+}
+
+// Next returns the first occurrence strictly after the given occurrence
+// Keeps the time of day and clamps month days that do not exist in shorter months
+func (r *RecurrenceRule) Next(from time.Time) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return from.AddDate(0, 0, interval)
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return from.AddDate(0, 0, 7*interval)
+		}
+		// Later day in the same week
+		current := mondayIndex(from.Weekday())
+		for _, weekday := range r.ByDay {
+			if mondayIndex(weekday) > current {
+				return from.AddDate(0, 0, mondayIndex(weekday)-current)
+			}
+		}
+		// First day of the next week in the interval
+		weekStart := from.AddDate(0, 0, -current)
+		return weekStart.AddDate(0, 0, 7*interval+mondayIndex(r.ByDay[0]))
+	case FrequencyMonthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = from.Day()
+		}
+		return dateInMonth(from, from.Year(), from.Month()+time.Month(interval), day)
+	case FrequencyYearly:
+		return dateInMonth(from, from.Year()+interval, from.Month(), from.Day())
+	}
+	return from
+}
+
+// HasEnded checks if an occurrence falls outside the rule's COUNT or UNTIL bounds
+// Occurrence numbers start at 1 for the first task in a series
+func (r *RecurrenceRule) HasEnded(occurrence int, at time.Time) bool {
+	if r.Count > 0 && occurrence > r.Count {
+		return true
+	}
+	if r.Until != nil && at.After(*r.Until) {
+		return true
+	}
+	return false
+}
+
+// mondayIndex returns the position of a weekday in a Monday-first week
+func mondayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// sortWeekdays orders weekdays Monday-first and removes duplicates
+func sortWeekdays(weekdays []time.Weekday) []time.Weekday {
+	if len(weekdays) == 0 {
+		return nil
+	}
+	seen := make(map[time.Weekday]bool, len(weekdays))
+	unique := make([]time.Weekday, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		if !seen[weekday] {
+			seen[weekday] = true
+			unique = append(unique, weekday)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return mondayIndex(unique[i]) < mondayIndex(unique[j])
+	})
+	return unique
+}
+
+// dateInMonth builds a date in the given month with the time of day of ref
+// Days past the end of the month are clamped to its last day
+func dateInMonth(ref time.Time, year int, month time.Month, day int) time.Time {
+	// Normalize month overflow (e.g. month 13) before clamping the day
+	first := time.Date(year, month, 1, ref.Hour(), ref.Minute(), ref.Second(), ref.Nanosecond(), ref.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
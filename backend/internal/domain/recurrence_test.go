@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		canonical string
+		wantErr   bool
+	}{
+		{name: "daily shorthand", value: "daily", canonical: "FREQ=DAILY"},
+		{name: "weekly shorthand", value: " Weekly ", canonical: "FREQ=WEEKLY"},
+		{name: "rrule prefix", value: "RRULE:FREQ=MONTHLY;BYMONTHDAY=15", canonical: "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{name: "weekdays sorted", value: "FREQ=WEEKLY;BYDAY=FR,MO,FR", canonical: "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{name: "interval and count", value: "FREQ=DAILY;INTERVAL=2;COUNT=5", canonical: "FREQ=DAILY;INTERVAL=2;COUNT=5"},
+		{name: "until date", value: "FREQ=YEARLY;UNTIL=20301231", canonical: "FREQ=YEARLY;UNTIL=20301231T000000Z"},
+		{name: "empty", value: "", wantErr: true},
+		{name: "missing frequency", value: "INTERVAL=2", wantErr: true},
+		{name: "unsupported frequency", value: "FREQ=HOURLY", wantErr: true},
+		{name: "invalid interval", value: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "invalid weekday", value: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "byday with monthly", value: "FREQ=MONTHLY;BYDAY=MO", wantErr: true},
+		{name: "bymonthday with weekly", value: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{name: "count with until", value: "FREQ=DAILY;COUNT=2;UNTIL=20301231", wantErr: true},
+		{name: "unsupported part", value: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		{name: "duplicate part", value: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidRecurrence))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.canonical, rule.String())
+		})
+	}
+}
+
+func TestRecurrenceRule_Next(t *testing.T) {
+	// Wednesday, 2024-01-31 09:30 UTC
+	from := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "daily",
+			rule:     "FREQ=DAILY",
+			from:     from,
+			expected: time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "every three days",
+			rule:     "FREQ=DAILY;INTERVAL=3",
+			from:     from,
+			expected: time.Date(2024, 2, 3, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly",
+			rule:     "FREQ=WEEKLY",
+			from:     from,
+			expected: time.Date(2024, 2, 7, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly later weekday in same week",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,FR",
+			from:     from,
+			expected: time.Date(2024, 2, 2, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly wraps to next week",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,TU",
+			from:     from,
+			expected: time.Date(2024, 2, 5, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "biweekly wraps two weeks ahead",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+			from:     from,
+			expected: time.Date(2024, 2, 12, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly clamps to end of short month",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31",
+			from:     from,
+			expected: time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly keeps anchored day after short month",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31",
+			from:     time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 31, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly across year end",
+			rule:     "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=15",
+			from:     time.Date(2024, 12, 15, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, 2, 15, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "yearly from leap day",
+			rule:     "FREQ=YEARLY",
+			from:     time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rule.Next(tt.from))
+		})
+	}
+}
+
+func TestRecurrenceRule_Anchor(t *testing.T) {
+	rule, err := ParseRecurrenceRule("monthly")
+	require.NoError(t, err)
+
+	rule.Anchor(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=31", rule.String())
+
+	weekly, err := ParseRecurrenceRule("weekly")
+	require.NoError(t, err)
+	weekly.Anchor(time.Now())
+	assert.Equal(t, "FREQ=WEEKLY", weekly.String())
+}
+
+func TestTask_NextOccurrence(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	newTask := func(recurrence string) *Task {
+		due := dueDate
+		return &Task{
+			ID:          uuid.New().String(),
+			UserID:      uuid.New().String(),
+			Description: "Weekly report",
+			Category:    "work",
+			Priority:    PriorityHigh,
+			Completed:   true,
+			DueDate:     &due,
+			Recurrence:  recurrence,
+			Occurrence:  1,
+			ParentID:    uuid.New().String(),
+		}
+	}
+
+	t.Run("non-recurring task has no next occurrence", func(t *testing.T) {
+		next, err := newTask("").NextOccurrence("next-id", now)
+		assert.NoError(t, err)
+		assert.Nil(t, next)
+	})
+
+	t.Run("next occurrence copies task attributes", func(t *testing.T) {
+		task := newTask("FREQ=WEEKLY")
+		next, err := task.NextOccurrence("next-id", now)
+		require.NoError(t, err)
+		require.NotNil(t, next)
+
+		assert.Equal(t, "next-id", next.ID)
+		assert.Equal(t, task.UserID, next.UserID)
+		assert.Equal(t, task.Description, next.Description)
+		assert.Equal(t, task.Category, next.Category)
+		assert.Equal(t, task.Priority, next.Priority)
+		assert.Equal(t, task.Recurrence, next.Recurrence)
+		assert.Equal(t, task.ID, next.SeriesID)
+		assert.Equal(t, 2, next.Occurrence)
+		assert.Equal(t, task.ParentID, next.ParentID)
+		assert.False(t, next.Completed)
+		assert.Equal(t, time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC), *next.DueDate)
+	})
+
+	t.Run("missed occurrences are skipped", func(t *testing.T) {
+		task := newTask("FREQ=DAILY")
+		past := dueDate.AddDate(0, 0, -10)
+		task.DueDate = &past
+
+		next, err := task.NextOccurrence("next-id", now)
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC), *next.DueDate)
+	})
+
+	t.Run("tasks without due date recur from completion time", func(t *testing.T) {
+		task := newTask("FREQ=DAILY")
+		task.DueDate = nil
+
+		next, err := task.NextOccurrence("next-id", now)
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, now.AddDate(0, 0, 1), *next.DueDate)
+	})
+
+	t.Run("count limits the series", func(t *testing.T) {
+		task := newTask("FREQ=DAILY;COUNT=2")
+		task.Occurrence = 2
+
+		next, err := task.NextOccurrence("next-id", now)
+		assert.NoError(t, err)
+		assert.Nil(t, next)
+	})
+
+	t.Run("until limits the series", func(t *testing.T) {
+		task := newTask("FREQ=WEEKLY;UNTIL=20240310")
+
+		next, err := task.NextOccurrence("next-id", now)
+		assert.NoError(t, err)
+		assert.Nil(t, next)
+	})
+
+	t.Run("series ID is kept for later occurrences", func(t *testing.T) {
+		task := newTask("FREQ=DAILY")
+		task.SeriesID = "series-1"
+		task.Occurrence = 4
+
+		next, err := task.NextOccurrence("next-id", now)
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, "series-1", next.SeriesID)
+		assert.Equal(t, 5, next.Occurrence)
+	})
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Limits on full-text task search
+const (
+	MaxSearchQueryTerms = 10
+	MaxSearchTermLength = 50
+	DefaultSearchLimit  = 20
+	MaxSearchLimit      = 100
+)
+
+// SearchTerms splits text into lowercase search terms and counts how often each term occurs
+// Terms are runs of letters and digits, truncated to MaxSearchTermLength characters
+func SearchTerms(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, term := range tokenizeSearchText(text) {
+		counts[term]++
+	}
+	return counts
+}
+
+// ParseSearchQuery splits a search query into its distinct terms in query order
+// Returns ErrInvalidSearchQuery when the query has no terms or more than MaxSearchQueryTerms
+func ParseSearchQuery(query string) ([]string, error) {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range tokenizeSearchText(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 || len(terms) > MaxSearchQueryTerms {
+		return nil, ErrInvalidSearchQuery
+	}
+	return terms, nil
+}
+
+// tokenizeSearchText lowercases text and splits it on every character that is not a letter or digit
+func tokenizeSearchText(text string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, token := range tokens {
+		if runes := []rune(token); len(runes) > MaxSearchTermLength {
+			tokens[i] = string(runes[:MaxSearchTermLength])
+		}
+	}
+	return tokens
+}
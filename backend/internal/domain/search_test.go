@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected map[string]int
+	}{
+		{
+			name:     "lowercases and counts words",
+			text:     "Deploy the API, then deploy the docs",
+			expected: map[string]int{"deploy": 2, "the": 2, "api": 1, "then": 1, "docs": 1},
+		},
+		{
+			name:     "splits on punctuation and keeps digits",
+			text:     "Fix bug #42 (v2.1-beta)",
+			expected: map[string]int{"fix": 1, "bug": 1, "42": 1, "v2": 1, "1": 1, "beta": 1},
+		},
+		{
+			name:     "keeps non-ASCII letters",
+			text:     "Café Überweisung",
+			expected: map[string]int{"café": 1, "überweisung": 1},
+		},
+		{
+			name:     "truncates long words",
+			text:     strings.Repeat("a", MaxSearchTermLength+10),
+			expected: map[string]int{strings.Repeat("a", MaxSearchTermLength): 1},
+		},
+		{
+			name:     "no words",
+			text:     " -- !! ",
+			expected: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SearchTerms(tt.text))
+		})
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	t.Run("dedupes terms in query order", func(t *testing.T) {
+		terms, err := ParseSearchQuery("  Report, quarterly REPORT ")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"report", "quarterly"}, terms)
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		_, err := ParseSearchQuery(" ?! ")
+		assert.ErrorIs(t, err, ErrInvalidSearchQuery)
+	})
+
+	t.Run("too many terms", func(t *testing.T) {
+		_, err := ParseSearchQuery("a b c d e f g h i j k")
+		assert.ErrorIs(t, err, ErrInvalidSearchQuery)
+	})
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// LoginAttempt describes one login attempt on an account
+type LoginAttempt struct {
+	At        time.Time `json:"at"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+}
+
+// LoginActivity summarizes the login history of an account
+// The last attempts are nil until one has been recorded; LockedUntil is zero unless the account is locked
+type LoginActivity struct {
+	LastSuccess    *LoginAttempt    `json:"last_success"`
+	LastFailure    *LoginAttempt    `json:"last_failure"`
+	FailedAttempts int              `json:"failed_attempts"` // consecutive failures since the last successful login or unlock
+	LockedUntil    time.Time        `json:"locked_until"`
+	Events         []*SecurityEvent `json:"events"` // most recent first
+}
+
+// SecurityEventType identifies what happened to an account in a security event
+type SecurityEventType string
+
+// Security event types recorded for an account
+const (
+	SecurityEventLoginSucceeded           SecurityEventType = "login_succeeded"
+	SecurityEventLoginFailed              SecurityEventType = "login_failed"
+	SecurityEventAccountLocked            SecurityEventType = "account_locked"
+	SecurityEventAccountUnlocked          SecurityEventType = "account_unlocked"
+	SecurityEventTwoFactorEnabled         SecurityEventType = "two_factor_enabled"
+	SecurityEventTwoFactorDisabled        SecurityEventType = "two_factor_disabled"
+	SecurityEventRecoveryCodesRegenerated SecurityEventType = "recovery_codes_regenerated"
+	SecurityEventRecoveryCodeUsed         SecurityEventType = "recovery_code_used"
+	SecurityEventAPITokenCreated          SecurityEventType = "api_token_created"
+	SecurityEventAPITokenRevoked          SecurityEventType = "api_token_revoked"
+	SecurityEventOIDCLinked               SecurityEventType = "oidc_linked"
+)
+
+// SecurityEvent records a security-relevant change or action on an account
+// IP and UserAgent describe the client that caused it, when there was one
+type SecurityEvent struct {
+	Type      SecurityEventType `json:"type"`
+	At        time.Time         `json:"at"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Detail    string            `json:"detail,omitempty"`
+}
+
+// Common security-related errors
+var (
+	ErrAccountLocked = errors.New("account is temporarily locked")
+)
+
+// AccountLockedError reports that an account is locked after too many failed logins
+// It matches ErrAccountLocked with errors.Is and carries when the lock ends
+type AccountLockedError struct {
+	Until time.Time
+}
+
+// Error returns the message of ErrAccountLocked
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+// Is reports whether target is ErrAccountLocked
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Session represents one signed-in device of a user
+// Current is set only when listing, for the session making the request
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	RememberMe bool      `json:"remember_me"`
+	Current    bool      `json:"current"`
+}
+
+// SessionClient describes the client a session was opened or last used from
+type SessionClient struct {
+	IP        string
+	UserAgent string
+}
+
+// Common session-related errors
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
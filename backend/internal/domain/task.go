@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -9,24 +10,119 @@ import (
 // Task represents a task in the task tracker system
 // Contains all information needed to track and manage individual tasks
 type Task struct {
-	ID          string     `json:"id" redis:"id"`
-	UserID      string     `json:"user_id" redis:"user_id"`
-	Description string     `json:"description" redis:"description"`
-	Category    string     `json:"category,omitempty" redis:"category"`
-	Completed   bool       `json:"completed" redis:"completed"`
-	CreatedAt   time.Time  `json:"created_at" redis:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" redis:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty" redis:"deleted_at"`
+	ID          string       `json:"id" redis:"id"`
+	UserID      string       `json:"user_id" redis:"user_id"`
+	Description string       `json:"description" redis:"description"`
+	Category    string       `json:"category,omitempty" redis:"category"`
+	Tags        []string     `json:"tags,omitempty" redis:"tags"`
+	Completed   bool         `json:"completed" redis:"completed"`
+	Priority    TaskPriority `json:"priority" redis:"priority"`
+	DueDate     *time.Time   `json:"due_date,omitempty" redis:"due_date"`
+	Recurrence  string       `json:"recurrence,omitempty" redis:"recurrence"`
+	SeriesID    string       `json:"series_id,omitempty" redis:"series_id"`
+	Occurrence  int          `json:"occurrence,omitempty" redis:"occurrence"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty" redis:"completed_at"`
+	ParentID    string       `json:"parent_id,omitempty" redis:"parent_id"`
+	Subtasks    []*Task      `json:"subtasks,omitempty" redis:"-"`
+	CreatedAt   time.Time    `json:"created_at" redis:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" redis:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty" redis:"deleted_at"`
 }
 
 // TaskFilters represents filtering options for task queries
 // Used to filter tasks by various criteria in list operations
 type TaskFilters struct {
-	Category       string `json:"category,omitempty"`
-	Completed      *bool  `json:"completed,omitempty"`
-	IncludeDeleted bool   `json:"include_deleted"`
-	Limit          int    `json:"limit"`
-	Offset         int    `json:"offset"`
+	Category       string       `json:"category,omitempty"`
+	Tags           []string     `json:"tags,omitempty"`
+	TagMatch       string       `json:"tag_match,omitempty"`
+	Completed      *bool        `json:"completed,omitempty"`
+	DueBefore      *time.Time   `json:"due_before,omitempty"`
+	DueAfter       *time.Time   `json:"due_after,omitempty"`
+	Overdue        bool         `json:"overdue"`
+	Priority       TaskPriority `json:"priority,omitempty"`
+	SortBy         string       `json:"sort_by,omitempty"`
+	IncludeDeleted bool         `json:"include_deleted"`
+	Limit          int          `json:"limit"`
+	Offset         int          `json:"offset"`
+	Cursor         string       `json:"cursor,omitempty"`
+}
+
+// Supported values for TaskFilters.SortBy
+// An empty SortBy is equivalent to TaskSortCreated
+const (
+	TaskSortCreated  = "created"
+	TaskSortDue      = "due"
+	TaskSortPriority = "priority"
+)
+
+// Supported values for TaskFilters.TagMatch
+// An empty TagMatch is equivalent to TagMatchAny
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Limits on the tags of a single task
+const (
+	MaxTagsPerTask = 20
+	MaxTagLength   = 50
+)
+
+// TagSummary represents a tag and the number of active tasks using it
+type TagSummary struct {
+	Name      string `json:"name"`
+	TaskCount int    `json:"task_count"`
+}
+
+// CleanupReport summarizes a purge of expired soft-deleted tasks
+// PerUser maps each user ID to the number of that user's tasks purged
+type CleanupReport struct {
+	Total   int            `json:"total"`
+	PerUser map[string]int `json:"per_user"`
+}
+
+// TaskPriority represents the urgency level of a task
+// An empty priority is treated as PriorityNone
+type TaskPriority string
+
+// Supported task priority levels, from least to most urgent
+const (
+	PriorityNone   TaskPriority = "none"
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+// taskPriorityRanks maps each priority level to its sort rank
+var taskPriorityRanks = map[TaskPriority]int{
+	PriorityNone:   0,
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+// TaskOptions represents optional attributes supplied when creating a task
+// Zero values leave the corresponding attribute unset
+type TaskOptions struct {
+	DueDate    *time.Time   `json:"due_date,omitempty"`
+	Priority   TaskPriority `json:"priority,omitempty"`
+	Recurrence string       `json:"recurrence,omitempty"`
+	ParentID   string       `json:"parent_id,omitempty"`
+	Tags       []string     `json:"tags,omitempty"`
+}
+
+// TaskUpdate represents a partial update to an existing task
+// Nil fields are left unchanged when the update is applied
+type TaskUpdate struct {
+	Description  *string       `json:"description,omitempty"`
+	Category     *string       `json:"category,omitempty"`
+	DueDate      *time.Time    `json:"due_date,omitempty"`
+	ClearDueDate bool          `json:"clear_due_date,omitempty"`
+	Priority     *TaskPriority `json:"priority,omitempty"`
+	Recurrence   *string       `json:"recurrence,omitempty"`
+	Tags         *[]string     `json:"tags,omitempty"`
 }
 
 // TaskRepository defines the interface for task data access operations
@@ -34,39 +130,69 @@ type TaskFilters struct {
 type TaskRepository interface {
 	CreateTask(task *Task) error
 	GetTaskByID(id string) (*Task, error)
-	ListTasks(userID string, filters TaskFilters) ([]*Task, error)
+	ListTasks(userID string, filters TaskFilters) (*TaskPage, error)
 	UpdateTaskCompletion(id string, completed bool) error
+	UpdateTask(task *Task) error
+	GetSeriesTasks(userID, seriesID string) ([]*Task, error)
+	CreateNextOccurrence(previousID string, next *Task) (bool, error)
+	GetSubtasks(parentID string) ([]*Task, error)
 	SoftDeleteTask(id string) error
 	RestoreTask(id string) error
 	GetUserCategories(userID string) ([]string, error)
 	RenameCategory(userID, oldName, newName string) error
 	DeleteCategory(userID, categoryName string) error
-	CleanupExpiredTasks() (int, error)
+	GetUserTags(userID string) ([]TagSummary, error)
+	RenameTag(userID, oldName, newName string) error
+	MergeTags(userID, sourceName, targetName string) error
+	SearchTasks(userID, query string, limit int) ([]*Task, error)
+	CleanupExpiredTasks(ctx context.Context) (*CleanupReport, error)
 }
 
 // TaskService defines the interface for task business logic operations
 // Contains all business rules and validation logic for task operations
 type TaskService interface {
-	CreateTask(userID, description, category string) (*Task, error)
+	CreateTask(userID, description, category string, opts TaskOptions) (*Task, error)
 	GetTaskByID(id string) (*Task, error)
-	ListTasks(userID string, filters TaskFilters) ([]*Task, error)
+	ListTasks(userID string, filters TaskFilters) (*TaskPage, error)
 	UpdateTaskCompletion(id string, completed bool) (*Task, error)
+	UpdateTask(id string, updates TaskUpdate) (*Task, error)
+	GetTaskHistory(id string) ([]*Task, error)
+	ListSubtasks(parentID string) ([]*Task, error)
 	SoftDeleteTask(id string) error
 	RestoreTask(id string) (*Task, error)
 	GetUserCategories(userID string) ([]string, error)
 	RenameCategory(userID, oldName, newName string) error
 	DeleteCategory(userID, categoryName string) error
+	GetUserTags(userID string) ([]TagSummary, error)
+	RenameTag(userID, oldName, newName string) error
+	MergeTags(userID, sourceName, targetName string) error
+	SearchTasks(userID, query string, limit int) ([]*Task, error)
 }
 
 // Common task-related errors
 var (
-	ErrTaskNotFound            = errors.New("task not found")
-	ErrTaskInvalidDescription  = errors.New("task description cannot be empty")
-	ErrTaskDescriptionTooLong  = errors.New("task description cannot exceed 10000 characters")
-	ErrTaskInvalidUser         = errors.New("invalid user for task")
-	ErrTaskInvalidID           = errors.New("task ID cannot be empty")
-	ErrCategoryNotFound        = errors.New("category not found")
-	ErrInvalidFilters          = errors.New("invalid task filters")
+	ErrTaskNotFound           = errors.New("task not found")
+	ErrTaskInvalidDescription = errors.New("task description cannot be empty")
+	ErrTaskDescriptionTooLong = errors.New("task description cannot exceed 10000 characters")
+	ErrTaskInvalidUser        = errors.New("invalid user for task")
+	ErrTaskInvalidID          = errors.New("task ID cannot be empty")
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrInvalidFilters         = errors.New("invalid task filters")
+	ErrEmptyTaskUpdate        = errors.New("task update must change at least one field")
+	ErrConflictingDueDate     = errors.New("due date cannot be both set and cleared")
+	ErrInvalidPriority        = errors.New("priority must be one of: none, low, medium, high, urgent")
+	ErrInvalidRecurrence      = errors.New("invalid recurrence rule")
+	ErrParentTaskNotFound     = errors.New("parent task not found")
+	ErrNestedSubtask          = errors.New("subtasks cannot have subtasks")
+	ErrParentTaskDeleted      = errors.New("parent task is deleted")
+	ErrInvalidTag             = errors.New("tags must be 1-50 characters and cannot contain commas")
+	ErrTooManyTags            = errors.New("a task cannot have more than 20 tags")
+	ErrInvalidTagMatch        = errors.New("tag match must be one of: any, all")
+	ErrTagNotFound            = errors.New("tag not found")
+	ErrTagExists              = errors.New("tag already exists")
+	ErrInvalidSearchQuery     = errors.New("search query must contain between 1 and 10 words")
+	ErrInvalidSearchLimit     = errors.New("search limit must be between 1 and 100")
+	ErrInvalidCursor          = errors.New("invalid pagination cursor")
 )
 
 // Validate checks if the task has valid data
@@ -84,20 +210,66 @@ func (t *Task) Validate() error {
 	if len(t.Description) > 10000 {
 		return ErrTaskDescriptionTooLong
 	}
+	if t.Priority != "" && !t.Priority.IsValid() {
+		return ErrInvalidPriority
+	}
+	if t.Recurrence != "" {
+		if _, err := ParseRecurrenceRule(t.Recurrence); err != nil {
+			return err
+		}
+	}
+	if len(t.Tags) > MaxTagsPerTask {
+		return ErrTooManyTags
+	}
+	for _, tag := range t.Tags {
+		if normalized, err := NormalizeTag(tag); err != nil || normalized != tag {
+			return ErrInvalidTag
+		}
+	}
 	return nil
 }
 
 // MarkCompleted marks the task as completed and updates the timestamp
 // Updates the task's completion status and modified time
 func (t *Task) MarkCompleted() {
+	now := time.Now()
 	t.Completed = true
-	t.UpdatedAt = time.Now()
+	t.CompletedAt = &now
+	t.UpdatedAt = now
 }
 
 // MarkIncomplete marks the task as incomplete and updates the timestamp
 // Updates the task's completion status and modified time
 func (t *Task) MarkIncomplete() {
 	t.Completed = false
+	t.CompletedAt = nil
+	t.UpdatedAt = time.Now()
+}
+
+// ApplyUpdate applies the non-nil fields of a partial update to the task
+// Trims string values and updates the modified time
+func (t *Task) ApplyUpdate(updates TaskUpdate) {
+	if updates.Description != nil {
+		t.Description = strings.TrimSpace(*updates.Description)
+	}
+	if updates.Category != nil {
+		t.Category = strings.TrimSpace(*updates.Category)
+	}
+	if updates.ClearDueDate {
+		t.DueDate = nil
+	} else if updates.DueDate != nil {
+		dueDate := *updates.DueDate
+		t.DueDate = &dueDate
+	}
+	if updates.Priority != nil {
+		t.Priority = *updates.Priority
+	}
+	if updates.Recurrence != nil {
+		t.Recurrence = strings.TrimSpace(*updates.Recurrence)
+	}
+	if updates.Tags != nil {
+		t.Tags = append([]string(nil), *updates.Tags...)
+	}
 	t.UpdatedAt = time.Now()
 }
 
@@ -116,12 +288,159 @@ func (t *Task) Restore() {
 	t.UpdatedAt = time.Now()
 }
 
+// IsOverdue checks if the task is past its due date and still incomplete
+// Returns false for tasks without a due date
+func (t *Task) IsOverdue() bool {
+	return t.DueDate != nil && !t.Completed && t.DueDate.Before(time.Now())
+}
+
+// IsRecurring checks if the task has a recurrence rule
+// Returns true for every task in a recurring series
+func (t *Task) IsRecurring() bool {
+	return t.Recurrence != ""
+}
+
+// NextOccurrence builds the task that follows this one in its recurring series
+// Returns nil when the task does not recur or its rule has no further occurrences
+func (t *Task) NextOccurrence(id string, now time.Time) (*Task, error) {
+	if !t.IsRecurring() {
+		return nil, nil
+	}
+
+	rule, err := ParseRecurrenceRule(t.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	// Schedule from the due date to keep the cadence, skipping missed occurrences
+	from := now
+	if t.DueDate != nil {
+		from = *t.DueDate
+	}
+	next := rule.Next(from)
+	for !next.After(now) {
+		next = rule.Next(next)
+	}
+
+	occurrence := t.Occurrence + 1
+	if rule.HasEnded(occurrence, next) {
+		return nil, nil
+	}
+
+	seriesID := t.SeriesID
+	if seriesID == "" {
+		seriesID = t.ID
+	}
+
+	return &Task{
+		ID:          id,
+		UserID:      t.UserID,
+		Description: t.Description,
+		Category:    t.Category,
+		Tags:        append([]string(nil), t.Tags...),
+		Priority:    t.Priority,
+		DueDate:     &next,
+		Recurrence:  t.Recurrence,
+		SeriesID:    seriesID,
+		Occurrence:  occurrence,
+		ParentID:    t.ParentID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// IsSubtask checks if the task belongs to a parent task
+// Subtasks are listed with their parent rather than on their own
+func (t *Task) IsSubtask() bool {
+	return t.ParentID != ""
+}
+
+// SubtaskProgress counts the completed subtasks and the total number of subtasks
+// Only subtasks loaded into Subtasks are counted
+func (t *Task) SubtaskProgress() (completed, total int) {
+	for _, subtask := range t.Subtasks {
+		if subtask.Completed {
+			completed++
+		}
+	}
+	return completed, len(t.Subtasks)
+}
+
+// HasTag checks if the task is labelled with the given tag
+// The tag is compared after normalization
+func (t *Task) HasTag(tag string) bool {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return false
+	}
+	for _, taskTag := range t.Tags {
+		if taskTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // IsDeleted checks if the task is soft-deleted
 // Returns true if the task has a DeletedAt timestamp
 func (t *Task) IsDeleted() bool {
 	return t.DeletedAt != nil
 }
 
+// IsEmpty checks if the update does not change any field
+// Returns true when every field of the update is nil
+func (u TaskUpdate) IsEmpty() bool {
+	return u.Description == nil && u.Category == nil && u.DueDate == nil && !u.ClearDueDate &&
+		u.Priority == nil && u.Recurrence == nil && u.Tags == nil
+}
+
+// NormalizeTag trims and lowercases a tag so tags compare case-insensitively
+// Returns ErrInvalidTag for empty or overly long tags and tags containing commas
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len(tag) > MaxTagLength || strings.Contains(tag, ",") {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes a list of tags and removes duplicates, keeping the first occurrence
+// Blank entries are skipped; returns nil when no tags remain
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTagsPerTask {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// IsValid checks if the priority is one of the supported levels
+// Returns false for empty or unknown values
+func (p TaskPriority) IsValid() bool {
+	_, ok := taskPriorityRanks[p]
+	return ok
+}
+
+// Rank returns the sort rank of the priority, higher meaning more urgent
+// Empty and unknown values rank the same as PriorityNone
+func (p TaskPriority) Rank() int {
+	return taskPriorityRanks[p]
+}
+
 // Validate checks if the task filters have valid values
 // Returns error if filter values are invalid
 func (f *TaskFilters) Validate() error {
@@ -131,5 +450,46 @@ func (f *TaskFilters) Validate() error {
 	if f.Offset < 0 {
 		return errors.New("offset must be non-negative")
 	}
+	if f.SortBy != "" && f.SortBy != TaskSortCreated && f.SortBy != TaskSortDue && f.SortBy != TaskSortPriority {
+		return errors.New("sort must be one of: created, due, priority")
+	}
+	if f.Priority != "" && !f.Priority.IsValid() {
+		return ErrInvalidPriority
+	}
+	if f.TagMatch != "" && f.TagMatch != TagMatchAny && f.TagMatch != TagMatchAll {
+		return ErrInvalidTagMatch
+	}
+	for _, tag := range f.Tags {
+		if _, err := NormalizeTag(tag); err != nil {
+			return err
+		}
+	}
+	if f.DueBefore != nil && f.DueAfter != nil && f.DueBefore.Before(*f.DueAfter) {
+		return errors.New("dueBefore must not be earlier than dueAfter")
+	}
+	if f.Cursor != "" {
+		if f.Offset > 0 {
+			return errors.New("cursor cannot be combined with offset")
+		}
+		cursor, err := DecodeTaskCursor(f.Cursor)
+		if err != nil {
+			return err
+		}
+		if cursor.SortBy != f.SortOrder() {
+			return ErrInvalidCursor
+		}
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// SortOrder returns the effective sort order of the filters
+// An empty SortBy sorts by due date when a due date filter is set, otherwise by creation time
+func (f *TaskFilters) SortOrder() string {
+	if f.SortBy != "" {
+		return f.SortBy
+	}
+	if f.DueBefore != nil || f.DueAfter != nil || f.Overdue {
+		return TaskSortDue
+	}
+	return TaskSortCreated
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -115,6 +118,42 @@ func TestTask_Validation(t *testing.T) {
 			},
 			expectedError: "task description cannot be empty",
 		},
+		{
+			name: "valid priority",
+			task: Task{
+				ID:          uuid.New().String(),
+				UserID:      uuid.New().String(),
+				Description: "Test task description",
+				Priority:    PriorityHigh,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedError: "",
+		},
+		{
+			name: "unknown priority",
+			task: Task{
+				ID:          uuid.New().String(),
+				UserID:      uuid.New().String(),
+				Description: "Test task description",
+				Priority:    TaskPriority("critical"),
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedError: "priority must be one of",
+		},
+		{
+			name: "invalid recurrence",
+			task: Task{
+				ID:          uuid.New().String(),
+				UserID:      uuid.New().String(),
+				Description: "Test task description",
+				Recurrence:  "FREQ=HOURLY",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedError: "invalid recurrence rule",
+		},
 	}
 
 	for _, tt := range tests {
@@ -145,6 +184,7 @@ func TestTask_MarkCompleted(t *testing.T) {
 	task.MarkCompleted()
 
 	assert.True(t, task.Completed)
+	assert.NotNil(t, task.CompletedAt)
 	assert.True(t, task.UpdatedAt.After(beforeUpdate) || task.UpdatedAt.Equal(beforeUpdate))
 }
 
@@ -155,6 +195,7 @@ func TestTask_MarkIncomplete(t *testing.T) {
 		Description: "Test task",
 		Category:    "Work",
 		Completed:   true,
+		CompletedAt: &[]time.Time{time.Now()}[0],
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -163,6 +204,7 @@ func TestTask_MarkIncomplete(t *testing.T) {
 	task.MarkIncomplete()
 
 	assert.False(t, task.Completed)
+	assert.Nil(t, task.CompletedAt)
 	assert.True(t, task.UpdatedAt.After(beforeUpdate) || task.UpdatedAt.Equal(beforeUpdate))
 }
 
@@ -205,6 +247,241 @@ func TestTask_Restore(t *testing.T) {
 	assert.True(t, task.UpdatedAt.After(beforeRestore) || task.UpdatedAt.Equal(beforeRestore))
 }
 
+func TestTask_ApplyUpdate(t *testing.T) {
+	description := "  Updated description  "
+	category := " Personal "
+
+	tests := []struct {
+		name                string
+		updates             TaskUpdate
+		expectedDescription string
+		expectedCategory    string
+		expectedEmpty       bool
+	}{
+		{
+			name:                "update description only",
+			updates:             TaskUpdate{Description: &description},
+			expectedDescription: "Updated description",
+			expectedCategory:    "Work",
+		},
+		{
+			name:                "update category only",
+			updates:             TaskUpdate{Category: &category},
+			expectedDescription: "Test task",
+			expectedCategory:    "Personal",
+		},
+		{
+			name:                "update both fields",
+			updates:             TaskUpdate{Description: &description, Category: &category},
+			expectedDescription: "Updated description",
+			expectedCategory:    "Personal",
+		},
+		{
+			name:                "empty update",
+			updates:             TaskUpdate{},
+			expectedDescription: "Test task",
+			expectedCategory:    "Work",
+			expectedEmpty:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{
+				ID:          uuid.New().String(),
+				UserID:      uuid.New().String(),
+				Description: "Test task",
+				Category:    "Work",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+
+			beforeUpdate := time.Now()
+			task.ApplyUpdate(tt.updates)
+
+			assert.Equal(t, tt.expectedEmpty, tt.updates.IsEmpty())
+			assert.Equal(t, tt.expectedDescription, task.Description)
+			assert.Equal(t, tt.expectedCategory, task.Category)
+			assert.True(t, task.UpdatedAt.After(beforeUpdate) || task.UpdatedAt.Equal(beforeUpdate))
+		})
+	}
+}
+
+func TestTask_ApplyUpdate_DueDate(t *testing.T) {
+	existingDue := time.Now().Add(24 * time.Hour)
+	newDue := time.Now().Add(72 * time.Hour)
+
+	t.Run("set due date", func(t *testing.T) {
+		task := &Task{Description: "Test task"}
+		task.ApplyUpdate(TaskUpdate{DueDate: &newDue})
+
+		assert.NotNil(t, task.DueDate)
+		assert.True(t, task.DueDate.Equal(newDue))
+	})
+
+	t.Run("clear due date", func(t *testing.T) {
+		task := &Task{Description: "Test task", DueDate: &existingDue}
+		update := TaskUpdate{ClearDueDate: true}
+		task.ApplyUpdate(update)
+
+		assert.False(t, update.IsEmpty())
+		assert.Nil(t, task.DueDate)
+	})
+}
+
+func TestTask_ApplyUpdate_Priority(t *testing.T) {
+	task := &Task{Description: "Test task", Priority: PriorityLow}
+	priority := PriorityUrgent
+	update := TaskUpdate{Priority: &priority}
+	task.ApplyUpdate(update)
+
+	assert.False(t, update.IsEmpty())
+	assert.Equal(t, PriorityUrgent, task.Priority)
+}
+
+func TestTask_ApplyUpdate_Tags(t *testing.T) {
+	task := &Task{Description: "Test task", Tags: []string{"home"}}
+	tags := []string{"work", "urgent"}
+	update := TaskUpdate{Tags: &tags}
+	task.ApplyUpdate(update)
+
+	assert.False(t, update.IsEmpty())
+	assert.Equal(t, []string{"work", "urgent"}, task.Tags)
+	assert.True(t, task.HasTag(" Work "))
+	assert.False(t, task.HasTag("home"))
+
+	cleared := []string{}
+	task.ApplyUpdate(TaskUpdate{Tags: &cleared})
+	assert.Empty(t, task.Tags)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+		wantErr  error
+	}{
+		{name: "trims, lowercases and removes duplicates", tags: []string{" Work", "urgent", "work", ""}, expected: []string{"work", "urgent"}},
+		{name: "no tags", tags: nil, expected: nil},
+		{name: "comma in tag", tags: []string{"a,b"}, wantErr: ErrInvalidTag},
+		{name: "tag too long", tags: []string{strings.Repeat("t", MaxTagLength+1)}, wantErr: ErrInvalidTag},
+		{name: "too many tags", tags: func() []string {
+			tags := make([]string, MaxTagsPerTask+1)
+			for i := range tags {
+				tags[i] = fmt.Sprintf("tag-%d", i)
+			}
+			return tags
+		}(), wantErr: ErrTooManyTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := NormalizeTags(tt.tags)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tags)
+		})
+	}
+
+	task := &Task{ID: "task-1", UserID: "user-1", Description: "Tagged", Tags: []string{"Work"}}
+	assert.Equal(t, ErrInvalidTag, task.Validate())
+}
+
+func TestTaskPriority_Rank(t *testing.T) {
+	tests := []struct {
+		priority TaskPriority
+		rank     int
+		valid    bool
+	}{
+		{priority: PriorityNone, rank: 0, valid: true},
+		{priority: PriorityLow, rank: 1, valid: true},
+		{priority: PriorityMedium, rank: 2, valid: true},
+		{priority: PriorityHigh, rank: 3, valid: true},
+		{priority: PriorityUrgent, rank: 4, valid: true},
+		{priority: "", rank: 0, valid: false},
+		{priority: "critical", rank: 0, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.priority), func(t *testing.T) {
+			assert.Equal(t, tt.rank, tt.priority.Rank())
+			assert.Equal(t, tt.valid, tt.priority.IsValid())
+		})
+	}
+}
+
+func TestTask_SubtaskProgress(t *testing.T) {
+	parent := &Task{ID: uuid.New().String(), Description: "Release checklist"}
+
+	completed, total := parent.SubtaskProgress()
+	assert.Equal(t, 0, completed)
+	assert.Equal(t, 0, total)
+
+	parent.Subtasks = []*Task{
+		{ID: uuid.New().String(), ParentID: parent.ID, Completed: true},
+		{ID: uuid.New().String(), ParentID: parent.ID, Completed: false},
+		{ID: uuid.New().String(), ParentID: parent.ID, Completed: true},
+	}
+
+	completed, total = parent.SubtaskProgress()
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 3, total)
+	assert.False(t, parent.IsSubtask())
+	assert.True(t, parent.Subtasks[0].IsSubtask())
+}
+
+func TestTask_IsOverdue(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		dueDate   *time.Time
+		completed bool
+		expected  bool
+	}{
+		{
+			name:     "no due date",
+			dueDate:  nil,
+			expected: false,
+		},
+		{
+			name:     "due in the future",
+			dueDate:  &future,
+			expected: false,
+		},
+		{
+			name:     "due in the past and incomplete",
+			dueDate:  &past,
+			expected: true,
+		},
+		{
+			name:      "due in the past but completed",
+			dueDate:   &past,
+			completed: true,
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{
+				ID:          uuid.New().String(),
+				UserID:      uuid.New().String(),
+				Description: "Test task",
+				Completed:   tt.completed,
+				DueDate:     tt.dueDate,
+			}
+
+			assert.Equal(t, tt.expected, task.IsOverdue())
+		})
+	}
+}
+
 func TestTask_IsDeleted(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -261,6 +538,20 @@ func TestErrorConstants(t *testing.T) {
 		ErrTaskInvalidID,
 		ErrCategoryNotFound,
 		ErrInvalidFilters,
+		ErrEmptyTaskUpdate,
+		ErrConflictingDueDate,
+		ErrInvalidPriority,
+		ErrInvalidRecurrence,
+		ErrParentTaskNotFound,
+		ErrNestedSubtask,
+		ErrParentTaskDeleted,
+		ErrInvalidTag,
+		ErrTooManyTags,
+		ErrInvalidTagMatch,
+		ErrTagNotFound,
+		ErrTagExists,
+		ErrInvalidSearchQuery,
+		ErrInvalidSearchLimit,
 	}
 
 	for _, err := range errors {
@@ -319,26 +610,41 @@ type mockTaskRepository struct{}
 
 func (m *mockTaskRepository) CreateTask(task *Task) error                          { return nil }
 func (m *mockTaskRepository) GetTaskByID(id string) (*Task, error)                { return nil, nil }
-func (m *mockTaskRepository) ListTasks(userID string, filters TaskFilters) ([]*Task, error) { return nil, nil }
+func (m *mockTaskRepository) ListTasks(userID string, filters TaskFilters) (*TaskPage, error) { return nil, nil }
 func (m *mockTaskRepository) UpdateTaskCompletion(id string, completed bool) error { return nil }
+func (m *mockTaskRepository) UpdateTask(task *Task) error                          { return nil }
+func (m *mockTaskRepository) GetSeriesTasks(userID, seriesID string) ([]*Task, error) { return nil, nil }
+func (m *mockTaskRepository) CreateNextOccurrence(previousID string, next *Task) (bool, error) { return false, nil }
+func (m *mockTaskRepository) GetSubtasks(parentID string) ([]*Task, error)            { return nil, nil }
 func (m *mockTaskRepository) SoftDeleteTask(id string) error                      { return nil }
 func (m *mockTaskRepository) RestoreTask(id string) error                         { return nil }
 func (m *mockTaskRepository) GetUserCategories(userID string) ([]string, error)   { return nil, nil }
 func (m *mockTaskRepository) RenameCategory(userID, oldName, newName string) error { return nil }
 func (m *mockTaskRepository) DeleteCategory(userID, categoryName string) error     { return nil }
-func (m *mockTaskRepository) CleanupExpiredTasks() (int, error)                    { return 0, nil }
+func (m *mockTaskRepository) GetUserTags(userID string) ([]TagSummary, error)        { return nil, nil }
+func (m *mockTaskRepository) RenameTag(userID, oldName, newName string) error      { return nil }
+func (m *mockTaskRepository) MergeTags(userID, sourceName, targetName string) error { return nil }
+func (m *mockTaskRepository) SearchTasks(userID, query string, limit int) ([]*Task, error) { return nil, nil }
+func (m *mockTaskRepository) CleanupExpiredTasks(ctx context.Context) (*CleanupReport, error) { return nil, nil }
 
 type mockTaskService struct{}
 
-func (m *mockTaskService) CreateTask(userID, description, category string) (*Task, error) { return nil, nil }
+func (m *mockTaskService) CreateTask(userID, description, category string, opts TaskOptions) (*Task, error) { return nil, nil }
 func (m *mockTaskService) GetTaskByID(id string) (*Task, error)                          { return nil, nil }
-func (m *mockTaskService) ListTasks(userID string, filters TaskFilters) ([]*Task, error) { return nil, nil }
+func (m *mockTaskService) ListTasks(userID string, filters TaskFilters) (*TaskPage, error) { return nil, nil }
 func (m *mockTaskService) UpdateTaskCompletion(id string, completed bool) (*Task, error) { return nil, nil }
+func (m *mockTaskService) UpdateTask(id string, updates TaskUpdate) (*Task, error)      { return nil, nil }
+func (m *mockTaskService) GetTaskHistory(id string) ([]*Task, error)                     { return nil, nil }
+func (m *mockTaskService) ListSubtasks(parentID string) ([]*Task, error)                 { return nil, nil }
 func (m *mockTaskService) SoftDeleteTask(id string) error                                { return nil }
 func (m *mockTaskService) RestoreTask(id string) (*Task, error)                          { return nil, nil }
 func (m *mockTaskService) GetUserCategories(userID string) ([]string, error)             { return nil, nil }
 func (m *mockTaskService) RenameCategory(userID, oldName, newName string) error           { return nil }
 func (m *mockTaskService) DeleteCategory(userID, categoryName string) error               { return nil }
+func (m *mockTaskService) GetUserTags(userID string) ([]TagSummary, error)                { return nil, nil }
+func (m *mockTaskService) RenameTag(userID, oldName, newName string) error              { return nil }
+func (m *mockTaskService) MergeTags(userID, sourceName, targetName string) error        { return nil }
+func (m *mockTaskService) SearchTasks(userID, query string, limit int) ([]*Task, error) { return nil, nil }
 
 // Test TaskFilters default values and edge cases
 func TestTaskFilters_EdgeCases(t *testing.T) {
@@ -445,6 +751,68 @@ func TestTaskFilters_Validation(t *testing.T) {
 			},
 			isValid: false,
 		},
+		{
+			name: "valid due date range and sort",
+			filters: TaskFilters{
+				DueAfter:  &[]time.Time{time.Now()}[0],
+				DueBefore: &[]time.Time{time.Now().Add(time.Hour)}[0],
+				SortBy:    TaskSortDue,
+				Limit:     10,
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid inverted due date range",
+			filters: TaskFilters{
+				DueAfter:  &[]time.Time{time.Now().Add(time.Hour)}[0],
+				DueBefore: &[]time.Time{time.Now()}[0],
+				Limit:     10,
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid sort value",
+			filters: TaskFilters{
+				SortBy: "random",
+				Limit:  10,
+			},
+			isValid: false,
+		},
+		{
+			name: "valid all-of tag filter",
+			filters: TaskFilters{
+				Tags:     []string{"work", "urgent"},
+				TagMatch: TagMatchAll,
+				Limit:    10,
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid tag match",
+			filters: TaskFilters{
+				Tags:     []string{"work"},
+				TagMatch: "none",
+				Limit:    10,
+			},
+			isValid: false,
+		},
+		{
+			name: "valid priority filter and sort",
+			filters: TaskFilters{
+				Priority: PriorityHigh,
+				SortBy:   TaskSortPriority,
+				Limit:    10,
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid priority filter",
+			filters: TaskFilters{
+				Priority: "critical",
+				Limit:    10,
+			},
+			isValid: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -457,4 +825,24 @@ func TestTaskFilters_Validation(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestTaskFilters_SortOrder(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		filters  TaskFilters
+		expected string
+	}{
+		{name: "defaults to created", filters: TaskFilters{}, expected: TaskSortCreated},
+		{name: "due filter sorts by due", filters: TaskFilters{DueBefore: &now}, expected: TaskSortDue},
+		{name: "overdue sorts by due", filters: TaskFilters{Overdue: true}, expected: TaskSortDue},
+		{name: "explicit sort wins", filters: TaskFilters{DueAfter: &now, SortBy: TaskSortPriority}, expected: TaskSortPriority},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filters.SortOrder())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// TwoFactor holds a user's two-factor authentication settings
+// A user without settings has two-factor authentication turned off
+type TwoFactor struct {
+	Secret                 string    `json:"-"` // base32 TOTP secret; never include in JSON responses
+	EnabledAt              time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int       `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup is an authenticator app enrollment waiting to be confirmed with a code
+type TwoFactorSetup struct {
+	Secret    string    `json:"secret"`
+	URI       string    `json:"uri"` // otpauth:// URI for QR codes
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LoginChallenge is a login whose password was correct and that waits for a second factor
+type LoginChallenge struct {
+	UserID     string `json:"user_id"`
+	RememberMe bool   `json:"remember_me"`
+	Attempts   int    `json:"attempts"` // wrong codes entered so far
+}
+
+// Common two-factor authentication errors
+var (
+	ErrTwoFactorRequired       = errors.New("two-factor authentication code required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor authentication code")
+	ErrInvalidLoginChallenge   = errors.New("invalid or expired login token")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorSetupNotFound  = errors.New("no two-factor authentication setup in progress")
+)
+
+// TwoFactorRequiredError reports that a login needs a second factor before a session is created
+// It matches ErrTwoFactorRequired with errors.Is and carries the token that completes the login
+type TwoFactorRequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Error returns the message of ErrTwoFactorRequired
+func (e *TwoFactorRequiredError) Error() string {
+	return ErrTwoFactorRequired.Error()
+}
+
+// Is reports whether target is ErrTwoFactorRequired
+func (e *TwoFactorRequiredError) Is(target error) bool {
+	return target == ErrTwoFactorRequired
+}
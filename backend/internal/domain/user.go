@@ -10,13 +10,14 @@ import (
 // User represents a user in the task tracker system
 // Stores user authentication and profile information
 type User struct {
-	ID          string    `json:"id" redis:"id"`
-	Email       string    `json:"email" redis:"email"`
-	DisplayName string    `json:"display_name" redis:"display_name"`
-	Password    string    `json:"-" redis:"password"` // Never include in JSON responses
-	IsAdmin     bool      `json:"is_admin" redis:"is_admin"`
-	CreatedAt   time.Time `json:"created_at" redis:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" redis:"updated_at"`
+	ID            string    `json:"id" redis:"id"`
+	Email         string    `json:"email" redis:"email"`
+	DisplayName   string    `json:"display_name" redis:"display_name"`
+	Password      string    `json:"-" redis:"password"` // Never include in JSON responses
+	IsAdmin       bool      `json:"is_admin" redis:"is_admin"`
+	EmailVerified bool      `json:"email_verified" redis:"email_verified"`
+	CreatedAt     time.Time `json:"created_at" redis:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" redis:"updated_at"`
 }
 
 // UserRepository defines the interface for user data access operations
@@ -37,8 +38,8 @@ type UserService interface {
 	Login(email, password string) (*User, error)
 	GetProfile(userID string) (*User, error)
 	UpdateProfile(userID, displayName string) (*User, error)
-	ChangePassword(userID, oldPassword, newPassword string) error
-	DeleteUser(userID string) error
+	ChangePassword(userID, currentSessionID, oldPassword, newPassword string, client SessionClient) (string, bool, error)
+	DeleteUser(userID, password string, client SessionClient) error
 	ListUsers(limit, offset int) ([]*User, error)
 	CreateAdmin(email, displayName, password string) (*User, error)
 }
@@ -50,6 +51,10 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrWeakPassword      = errors.New("password does not meet requirements")
 	ErrInvalidEmail      = errors.New("invalid email format")
+	ErrInvalidDisplayName = errors.New("invalid display name")
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
 )
 
 // HashPassword hashes a plain text password using bcrypt
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"backend/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminService defines the interface for administrator operations on user accounts
+// Contains methods needed for admin handlers
+type AdminService interface {
+	GetLoginActivity(userID string) (*domain.LoginActivity, error)
+	UnlockAccount(adminID, userID string) error
+}
+
+// AdminHandler handles administrator HTTP requests
+// Routes must be protected by the authentication and admin middleware
+type AdminHandler struct {
+	userService AdminService
+}
+
+// NewAdminHandler creates a new instance of AdminHandler
+// Initializes the handler with the provided user service
+func NewAdminHandler(userService AdminService) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+	}
+}
+
+// GetUserLoginActivity handles requests for another user's recent logins, lock state and security events
+// Used to investigate a lockout before unlocking the account
+func (h *AdminHandler) GetUserLoginActivity(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+			"code":  "4009",
+		})
+		return
+	}
+
+	activity, err := h.userService.GetLoginActivity(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+				"code":  "4044",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve login activity",
+				"code":  "4043",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginActivityResponse(activity))
+}
+
+// UnlockUser handles requests to lift a user's lockout after failed logins
+// Also resets the failed login count, so the user gets the full number of attempts again
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+			"code":  "4009",
+		})
+		return
+	}
+
+	if err := h.userService.UnlockAccount(adminID.(string), userID); err != nil {
+		if err == domain.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+				"code":  "4044",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to unlock account",
+				"code":  "4045",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account unlocked",
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"backend/internal/domain"
+	"backend/internal/mocks"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandler_GetUserLoginActivity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		activity       *domain.LoginActivity
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "success", activity: &domain.LoginActivity{FailedAttempts: 5, LockedUntil: time.Now().Add(time.Minute)}, expectedStatus: http.StatusOK, expectedBody: `"failedAttempts":5`},
+		{name: "unknown user", serviceErr: domain.ErrUserNotFound, expectedStatus: http.StatusNotFound, expectedBody: "4044"},
+		{name: "service error", serviceErr: errors.New("redis down"), expectedStatus: http.StatusInternalServerError, expectedBody: "4043"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockUserService)
+			mockService.On("GetLoginActivity", "user-456").Return(tt.activity, tt.serviceErr)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/admin/users/user-456/login-activity", nil)
+			c.Params = gin.Params{{Key: "id", Value: "user-456"}}
+			c.Set("userID", "admin-123")
+			NewAdminHandler(mockService).GetUserLoginActivity(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminHandler_UnlockUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "success", expectedStatus: http.StatusOK, expectedBody: "Account unlocked"},
+		{name: "unknown user", serviceErr: domain.ErrUserNotFound, expectedStatus: http.StatusNotFound, expectedBody: "4044"},
+		{name: "service error", serviceErr: errors.New("3004: failed to unlock account"), expectedStatus: http.StatusInternalServerError, expectedBody: "4045"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockUserService)
+			mockService.On("UnlockAccount", "admin-123", "user-456").Return(tt.serviceErr)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/admin/users/user-456/unlock", nil)
+			c.Params = gin.Params{{Key: "id", Value: "user-456"}}
+			c.Set("userID", "admin-123")
+			NewAdminHandler(mockService).UnlockUser(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("requires authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/admin/users/user-456/unlock", nil)
+		c.Params = gin.Params{{Key: "id", Value: "user-456"}}
+		NewAdminHandler(new(mocks.MockUserService)).UnlockUser(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -2,7 +2,13 @@ package handlers
 
 import (
 	"backend/internal/domain"
+	"backend/pkg/cookie"
+	"crypto/subtle"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,23 +17,79 @@ import (
 // UserService defines the interface for user business logic operations
 // Contains methods needed for authentication handlers
 type UserService interface {
-	Register(email, displayName, password string) (*domain.User, string, error)
-	Login(email, password string) (*domain.User, string, error)
+	Register(email, displayName, password string, client domain.SessionClient) (*domain.User, string, error)
+	Login(email, password string, rememberMe bool, client domain.SessionClient) (*domain.User, string, error)
 	Logout(sessionID string) error
 	GetCurrentUser(sessionID string) (*domain.User, error)
+	ListSessions(userID, currentSessionID string) ([]*domain.Session, error)
+	RevokeSession(userID, sessionID string) error
+	RevokeOtherSessions(userID, currentSessionID string) (int, error)
+	UpdateProfile(userID, displayName string) (*domain.User, error)
+	ChangePassword(userID, currentSessionID, oldPassword, newPassword string, client domain.SessionClient) (string, bool, error)
+	DeleteUser(userID, password string, client domain.SessionClient) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) error
+	SendVerificationEmail(userID string) error
+	VerifyEmail(token string) (*domain.User, error)
+	GetLoginActivity(userID string) (*domain.LoginActivity, error)
+	CompleteTwoFactorLogin(token, code string, client domain.SessionClient) (*domain.User, string, bool, error)
+	GetTwoFactorStatus(userID string) (*domain.TwoFactor, error)
+	BeginTwoFactorSetup(userID string) (*domain.TwoFactorSetup, error)
+	EnableTwoFactor(userID, code string, client domain.SessionClient) ([]string, error)
+	DisableTwoFactor(userID, password, code string, client domain.SessionClient) error
+	RegenerateRecoveryCodes(userID, password, code string, client domain.SessionClient) ([]string, error)
+	CreateAPIToken(userID, name string, scopes []string, ttl time.Duration, client domain.SessionClient) (*domain.APIToken, string, error)
+	ListAPITokens(userID string) ([]*domain.APIToken, error)
+	RevokeAPIToken(userID, tokenID string, client domain.SessionClient) error
+	BeginOIDCLogin(rememberMe bool) (string, string, error)
+	CompleteOIDCLogin(state, code string, client domain.SessionClient) (*domain.User, string, bool, error)
+}
+
+// oidcStateCookie names the cookie that ties an identity provider sign-in to the browser that started it
+const oidcStateCookie = "oidc_state"
+
+// oidcStateCookieMaxAge is how long the browser keeps the sign-in state, matching how long the server does
+const oidcStateCookieMaxAge = 600
+
+// CookieSettings controls the session cookie issued on registration and login
+// Remembered sessions get a persistent cookie; all others end when the browser closes
+type CookieSettings struct {
+	MaxAge   int // seconds, for sessions opened with remember me
+	Secure   bool
+	HTTPOnly bool
+}
+
+// DefaultCookieSettings returns the cookie settings used when none are configured
+// Remembered cookies last 7 days and are HTTP-only but not restricted to HTTPS
+func DefaultCookieSettings() CookieSettings {
+	return CookieSettings{
+		MaxAge:   604800,
+		Secure:   false,
+		HTTPOnly: true,
+	}
 }
 
 // AuthHandler handles authentication-related HTTP requests
 // Provides endpoints for user registration, login, logout, and profile retrieval
 type AuthHandler struct {
 	userService UserService
+	signer      *cookie.Signer
+	cookies     CookieSettings
 }
 
 // NewAuthHandler creates a new instance of AuthHandler
-// Initializes the handler with the provided user service
-func NewAuthHandler(userService UserService) *AuthHandler {
+// Initializes the handler with the provided user service, cookie signer and the default cookie settings
+func NewAuthHandler(userService UserService, signer *cookie.Signer) *AuthHandler {
+	return NewAuthHandlerWithCookies(userService, signer, DefaultCookieSettings())
+}
+
+// NewAuthHandlerWithCookies creates a new instance of AuthHandler with the given cookie settings
+// Used by the server to apply the session configuration
+func NewAuthHandlerWithCookies(userService UserService, signer *cookie.Signer, cookies CookieSettings) *AuthHandler {
 	return &AuthHandler{
 		userService: userService,
+		signer:      signer,
+		cookies:     cookies,
 	}
 }
 
@@ -45,13 +107,217 @@ type LoginRequest struct {
 	RememberMe bool   `json:"rememberMe"`
 }
 
+// UpdateProfileRequest represents the request payload for updating the user's profile
+type UpdateProfileRequest struct {
+	DisplayName string `json:"displayName"`
+}
+
+// ChangePasswordRequest represents the request payload for changing the user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// DeleteAccountRequest represents the request payload for deleting the user's account
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// ForgotPasswordRequest represents the request payload for requesting a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest represents the request payload for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+// LoginTwoFactorRequest represents the request payload for completing a login with a second factor
+type LoginTwoFactorRequest struct {
+	LoginToken string `json:"loginToken"`
+	Code       string `json:"code"` // TOTP code or recovery code
+}
+
+// EnableTwoFactorRequest represents the request payload for confirming a new authenticator app
+type EnableTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorChangeRequest represents the request payload for disabling two-factor authentication or regenerating recovery codes
+type TwoFactorChangeRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // TOTP code or recovery code
+}
+
+// CreateAPITokenRequest represents the request payload for creating a personal API token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"` // 30 when omitted
+}
+
+// OIDCLoginRequest represents the request payload for starting a sign-in with the identity provider
+type OIDCLoginRequest struct {
+	RememberMe bool `json:"rememberMe"`
+}
+
+// OIDCCallbackRequest represents the request payload for finishing a sign-in with the query parameters the identity provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
 // UserResponse represents the response payload for user data
 type UserResponse struct {
-	ID          string    `json:"id"`
-	Email       string    `json:"email"`
-	DisplayName string    `json:"displayName"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	DisplayName   string    `json:"displayName"`
+	EmailVerified bool      `json:"emailVerified"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// newUserResponse converts a domain user into its response payload
+func newUserResponse(user *domain.User) *UserResponse {
+	return &UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		DisplayName:   user.DisplayName,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// SessionResponse represents the response payload for a signed-in device
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	RememberMe bool      `json:"rememberMe"`
+	Current    bool      `json:"current"`
+}
+
+// LoginAttemptResponse represents the response payload for one login attempt
+type LoginAttemptResponse struct {
+	At        time.Time `json:"at"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+}
+
+// SecurityEventResponse represents the response payload for a security event on an account
+type SecurityEventResponse struct {
+	Type      string    `json:"type"`
+	At        time.Time `json:"at"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// LoginActivityResponse represents the response payload for an account's login activity
+// Last logins and lockedUntil are null when there is nothing to report
+type LoginActivityResponse struct {
+	LastSuccessfulLogin *LoginAttemptResponse    `json:"lastSuccessfulLogin"`
+	LastFailedLogin     *LoginAttemptResponse    `json:"lastFailedLogin"`
+	FailedAttempts      int                      `json:"failedAttempts"`
+	LockedUntil         *time.Time               `json:"lockedUntil"`
+	Events              []*SecurityEventResponse `json:"events"`
+}
+
+// TwoFactorStatusResponse represents the response payload for the user's two-factor authentication settings
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+// TwoFactorSetupResponse represents the response payload for a new authenticator app enrollment
+type TwoFactorSetupResponse struct {
+	Secret    string    `json:"secret"`
+	URI       string    `json:"uri"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// APITokenResponse represents the response payload for a personal API token, without the token itself
+type APITokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// CreatedAPITokenResponse represents the response payload for a new API token, the only one that includes the token
+type CreatedAPITokenResponse struct {
+	*APITokenResponse
+	Token string `json:"token"`
+}
+
+// newAPITokenResponse converts a domain API token into its response payload
+func newAPITokenResponse(token *domain.APIToken) *APITokenResponse {
+	return &APITokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+// newTwoFactorStatusResponse converts two-factor settings into their response payload; nil settings mean it is turned off
+func newTwoFactorStatusResponse(twoFactor *domain.TwoFactor) *TwoFactorStatusResponse {
+	if twoFactor == nil {
+		return &TwoFactorStatusResponse{}
+	}
+	enabledAt := twoFactor.EnabledAt
+	return &TwoFactorStatusResponse{
+		Enabled:                true,
+		EnabledAt:              &enabledAt,
+		RecoveryCodesRemaining: twoFactor.RecoveryCodesRemaining,
+	}
+}
+
+// newLoginAttemptResponse converts a login attempt into its response payload, keeping nil as nil
+func newLoginAttemptResponse(attempt *domain.LoginAttempt) *LoginAttemptResponse {
+	if attempt == nil {
+		return nil
+	}
+	return &LoginAttemptResponse{
+		At:        attempt.At,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+	}
+}
+
+// newLoginActivityResponse converts an account's login activity into its response payload
+func newLoginActivityResponse(activity *domain.LoginActivity) *LoginActivityResponse {
+	response := &LoginActivityResponse{
+		LastSuccessfulLogin: newLoginAttemptResponse(activity.LastSuccess),
+		LastFailedLogin:     newLoginAttemptResponse(activity.LastFailure),
+		FailedAttempts:      activity.FailedAttempts,
+		Events:              make([]*SecurityEventResponse, 0, len(activity.Events)),
+	}
+	if !activity.LockedUntil.IsZero() {
+		lockedUntil := activity.LockedUntil
+		response.LockedUntil = &lockedUntil
+	}
+	for _, event := range activity.Events {
+		response.Events = append(response.Events, &SecurityEventResponse{
+			Type:      string(event.Type),
+			At:        event.At,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Detail:    event.Detail,
+		})
+	}
+	return response
 }
 
 // Register handles user registration requests
@@ -78,7 +344,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Call service to register user
-	user, sessionID, err := h.userService.Register(req.Email, req.DisplayName, req.Password)
+	user, sessionID, err := h.userService.Register(req.Email, req.DisplayName, req.Password, sessionClient(c))
 	if err != nil {
 		if err == domain.ErrUserAlreadyExists {
 			c.JSON(http.StatusConflict, gin.H{
@@ -95,20 +361,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Set session cookie
-	h.setSessionCookie(c, sessionID)
+	h.setSessionCookie(c, sessionID, false)
 
 	// Return user data
-	c.JSON(http.StatusCreated, &UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		DisplayName: user.DisplayName,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-	})
+	c.JSON(http.StatusCreated, newUserResponse(user))
 }
 
 // Login handles user login requests
-// Authenticates user and creates a session
+// Authenticates user and creates a session; users with two-factor authentication get a login token for LoginTwoFactor instead
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 
@@ -131,13 +391,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Call service to authenticate user
-	user, sessionID, err := h.userService.Login(req.Email, req.Password)
+	user, sessionID, err := h.userService.Login(req.Email, req.Password, req.RememberMe, sessionClient(c))
 	if err != nil {
+		var locked *domain.AccountLockedError
+		var twoFactor *domain.TwoFactorRequiredError
 		if err == domain.ErrInvalidCredentials {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid credentials",
 				"code":  "4010",
 			})
+		} else if errors.As(err, &locked) {
+			h.accountLocked(c, locked)
+		} else if errors.As(err, &twoFactor) {
+			// The password was right; the client sends the token with a code to LoginTwoFactor
+			h.twoFactorRequired(c, twoFactor)
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Login failed",
@@ -148,24 +415,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Set session cookie
-	h.setSessionCookie(c, sessionID)
+	h.setSessionCookie(c, sessionID, req.RememberMe)
 
 	// Return user data
-	c.JSON(http.StatusOK, &UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		DisplayName: user.DisplayName,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-	})
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
 // Logout handles user logout requests
 // Invalidates the current session
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Get session cookie
-	sessionID, err := c.Cookie("session")
-	if err != nil || sessionID == "" {
+	sessionID, ok := h.sessionID(c)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "No active session found",
 			"code":  "4011",
@@ -183,7 +444,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	}
 
 	// Clear session cookie
-	c.SetCookie("session", "", -1, "/", "", false, true)
+	h.clearSessionCookie(c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
@@ -194,8 +455,8 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // Returns the profile of the authenticated user
 func (h *AuthHandler) Me(c *gin.Context) {
 	// Get session cookie
-	sessionID, err := c.Cookie("session")
-	if err != nil || sessionID == "" {
+	sessionID, ok := h.sessionID(c)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "No active session found",
 			"code":  "4013",
@@ -214,27 +475,987 @@ func (h *AuthHandler) Me(c *gin.Context) {
 	}
 
 	// Return user data
-	c.JSON(http.StatusOK, &UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		DisplayName: user.DisplayName,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ListSessions handles requests to list the user's signed-in devices
+// Returns every live session, most recently seen first, with the current one marked
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(userID.(string), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve sessions",
+			"code":  "4030",
+		})
+		return
+	}
+
+	response := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, &SessionResponse{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			RememberMe: session.RememberMe,
+			Current:    session.Current,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": response,
 	})
 }
 
-// setSessionCookie sets the session cookie with proper security settings
-// Configures cookie for 7-day expiration with security flags
-func (h *AuthHandler) setSessionCookie(c *gin.Context, sessionID string) {
-	// Set session cookie for 7 days (604800 seconds)
-	// Note: SameSite=Lax is the default for modern browsers
-	c.SetCookie(
-		"session",      // name
-		sessionID,      // value
-		604800,         // maxAge (7 days in seconds)
-		"/",            // path
-		"",             // domain (empty for current domain)
-		false,          // secure (set to true in production with HTTPS)
-		true,           // httpOnly
-	)
-}
\ No newline at end of file
+// RevokeSession handles requests to sign out one of the user's devices
+// Clears the session cookie when the current session is revoked
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	sessionID := c.Param("id")
+	if err := h.userService.RevokeSession(userID.(string), sessionID); err != nil {
+		if err == domain.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Session not found",
+				"code":  "4029",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to revoke session",
+				"code":  "4030",
+			})
+		}
+		return
+	}
+
+	if sessionID == c.GetString("sessionID") {
+		h.clearSessionCookie(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions handles requests to log out everywhere else
+// Signs out every device of the user except the one making the request
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	revoked, err := h.userService.RevokeOtherSessions(userID.(string), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke sessions",
+			"code":  "4030",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Other sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
+
+// UpdateProfile handles requests to change the user's display name
+// Returns the updated profile
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(userID.(string), req.DisplayName)
+	if err != nil {
+		if err == domain.ErrInvalidDisplayName {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Display name must be between 1 and 255 characters",
+				"code":  "4031",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update account",
+				"code":  "4034",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ChangePassword handles requests to change the user's password
+// Signs out every device and issues a new session cookie to the caller
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Current password and new password are required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	sessionID, rememberMe, err := h.userService.ChangePassword(userID.(string), c.GetString("sessionID"), req.CurrentPassword, req.NewPassword, sessionClient(c))
+	if err != nil {
+		var locked *domain.AccountLockedError
+		switch {
+		case err == domain.ErrInvalidCredentials:
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Current password is incorrect",
+				"code":  "4032",
+			})
+		case err == domain.ErrWeakPassword:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Password must be at least 6 characters with 1 number and 1 special character",
+				"code":  "4033",
+			})
+		case errors.As(err, &locked):
+			h.accountLocked(c, locked)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update account",
+				"code":  "4034",
+			})
+		}
+		return
+	}
+
+	// The old session was revoked with all others
+	h.setSessionCookie(c, sessionID, rememberMe)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
+}
+
+// DeleteAccount handles requests to permanently delete the user's account
+// Requires the password and removes all of the user's tasks and sessions
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Password is required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	if err := h.userService.DeleteUser(userID.(string), req.Password, sessionClient(c)); err != nil {
+		var locked *domain.AccountLockedError
+		if err == domain.ErrInvalidCredentials {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Current password is incorrect",
+				"code":  "4032",
+			})
+		} else if errors.As(err, &locked) {
+			h.accountLocked(c, locked)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update account",
+				"code":  "4034",
+			})
+		}
+		return
+	}
+
+	h.clearSessionCookie(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deleted successfully",
+	})
+}
+
+// ForgotPassword handles requests to email a password reset link
+// Always answers the same way so it does not reveal which emails have accounts
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Email is required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	// Delivery failures are logged rather than reported, since they only happen for existing accounts
+	if err := h.userService.RequestPasswordReset(req.Email); err != nil {
+		log.Printf("Failed to send password reset email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles requests to set a new password with a token from a reset email
+// The user has to log in again afterwards, since every session is revoked
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token and new password are required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	if err := h.userService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		switch err {
+		case domain.ErrInvalidResetToken:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Password reset link is invalid or has expired",
+				"code":  "4035",
+			})
+		case domain.ErrWeakPassword:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Password must be at least 6 characters with 1 number and 1 special character",
+				"code":  "4033",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update account",
+				"code":  "4034",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmailRequest represents the request payload for verifying an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// VerifyEmail handles requests to verify an email address with the token from a verification email
+// Does not need a session, so the link works in any browser
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token is required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(req.Token)
+	if err != nil {
+		if err == domain.ErrInvalidVerificationToken {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Verification link is invalid or has expired",
+				"code":  "4036",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update account",
+				"code":  "4034",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ResendVerification handles requests to send another verification email to the current user
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	if err := h.userService.SendVerificationEmail(userID.(string)); err != nil {
+		if err == domain.ErrEmailAlreadyVerified {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Email address is already verified",
+				"code":  "4037",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to send verification email",
+				"code":  "4038",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+// LoginActivity handles requests for the current user's recent logins and security events
+// Lets users spot sign-ins and failed attempts they do not recognise
+func (h *AuthHandler) LoginActivity(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	activity, err := h.userService.GetLoginActivity(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve login activity",
+			"code":  "4043",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginActivityResponse(activity))
+}
+
+// LoginTwoFactor handles the second step of a login for users with two-factor authentication
+// Exchanges the login token from the first step and a TOTP or recovery code for a session
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.LoginToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Login token and code are required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	user, sessionID, rememberMe, err := h.userService.CompleteTwoFactorLogin(req.LoginToken, req.Code, sessionClient(c))
+	if err != nil {
+		var locked *domain.AccountLockedError
+		if err == domain.ErrInvalidTwoFactorCode {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid two-factor authentication code",
+				"code":  "4047",
+			})
+		} else if err == domain.ErrInvalidLoginChallenge {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Login token is invalid or has expired, please log in again",
+				"code":  "4048",
+			})
+		} else if errors.As(err, &locked) {
+			h.accountLocked(c, locked)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Login failed",
+				"code":  "4009",
+			})
+		}
+		return
+	}
+
+	h.setSessionCookie(c, sessionID, rememberMe)
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// StartOIDCLogin handles requests to sign in with the identity provider
+// Responds with the authorization URL to send the browser to and sets a cookie tying the sign-in to this browser
+func (h *AuthHandler) StartOIDCLogin(c *gin.Context) {
+	var req OIDCLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	authURL, state, err := h.userService.BeginOIDCLogin(req.RememberMe)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start sign-in with the identity provider",
+			"code":  "4059",
+		})
+		return
+	}
+
+	c.SetCookie(oidcStateCookie, state, oidcStateCookieMaxAge, "/", "", h.cookies.Secure, true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorizationUrl": authURL,
+	})
+}
+
+// CompleteOIDCLogin handles the return from the identity provider
+// Exchanges the code for a session like Login; the state must match the cookie set by StartOIDCLogin, so a sign-in cannot be completed in another browser
+func (h *AuthHandler) CompleteOIDCLogin(c *gin.Context) {
+	var req OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Code == "" || req.State == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code and state are required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	// The state cookie is single-use like the state itself
+	expected, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", h.cookies.Secure, true)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(req.State)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Sign-in is invalid or has expired, please start again",
+			"code":  "4060",
+		})
+		return
+	}
+
+	user, sessionID, rememberMe, err := h.userService.CompleteOIDCLogin(req.State, req.Code, sessionClient(c))
+	if err != nil {
+		var twoFactor *domain.TwoFactorRequiredError
+		if err == domain.ErrInvalidOIDCState {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Sign-in is invalid or has expired, please start again",
+				"code":  "4060",
+			})
+		} else if err == domain.ErrOIDCEmailNotVerified || err == domain.ErrInvalidEmail {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "The identity provider did not confirm a verified email address",
+				"code":  "4061",
+			})
+		} else if errors.Is(err, domain.ErrOIDCExchangeFailed) {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": "Sign-in with the identity provider failed",
+				"code":  "4062",
+			})
+		} else if errors.As(err, &twoFactor) {
+			h.twoFactorRequired(c, twoFactor)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Login failed",
+				"code":  "4009",
+			})
+		}
+		return
+	}
+
+	h.setSessionCookie(c, sessionID, rememberMe)
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// TwoFactorStatus handles requests for whether the current user has two-factor authentication turned on
+func (h *AuthHandler) TwoFactorStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	twoFactor, err := h.userService.GetTwoFactorStatus(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update two-factor authentication",
+			"code":  "4052",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newTwoFactorStatusResponse(twoFactor))
+}
+
+// SetupTwoFactor handles requests to start adding an authenticator app
+// Returns the secret and otpauth URI; two-factor authentication stays off until EnableTwoFactor confirms a code
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	setup, err := h.userService.BeginTwoFactorSetup(userID.(string))
+	if err != nil {
+		if err == domain.ErrTwoFactorAlreadyEnabled {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Two-factor authentication is already enabled",
+				"code":  "4049",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update two-factor authentication",
+				"code":  "4052",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorSetupResponse{
+		Secret:    setup.Secret,
+		URI:       setup.URI,
+		ExpiresAt: setup.ExpiresAt,
+	})
+}
+
+// EnableTwoFactor handles requests to turn on two-factor authentication with a code from the new authenticator app
+// Responds with the recovery codes, which cannot be retrieved again
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	var req EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code is required",
+			"code":  "4007",
+		})
+		return
+	}
+
+	codes, err := h.userService.EnableTwoFactor(userID.(string), req.Code, sessionClient(c))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// DisableTwoFactor handles requests to turn off two-factor authentication
+// Requires the password and a TOTP or recovery code
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	req, ok := bindTwoFactorChange(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(userID.(string), req.Password, req.Code, sessionClient(c)); err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes handles requests to replace the current user's recovery codes
+// Requires the password and a TOTP or recovery code; the old codes stop working immediately
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	req, ok := bindTwoFactorChange(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(userID.(string), req.Password, req.Code, sessionClient(c))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recoveryCodes": codes,
+	})
+}
+
+// CreateAPIToken handles requests to issue a personal API token for scripts and integrations
+// Responds with the token itself, which cannot be retrieved again
+func (h *AuthHandler) CreateAPIToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, raw, err := h.userService.CreateAPIToken(userID.(string), req.Name, req.Scopes, ttl, sessionClient(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidAPITokenName:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Name is required and cannot exceed 100 characters",
+				"code":  "4056",
+			})
+		case domain.ErrInvalidAPITokenScopes:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Scopes must be one or more of: " + strings.Join(domain.APITokenScopes, ", "),
+				"code":  "4056",
+			})
+		case domain.ErrInvalidAPITokenExpiry:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "expiresInDays must be between 1 and 365",
+				"code":  "4056",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create API token",
+				"code":  "4058",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreatedAPITokenResponse{
+		APITokenResponse: newAPITokenResponse(token),
+		Token:            raw,
+	})
+}
+
+// ListAPITokens handles requests for the current user's API tokens
+// Tokens themselves are never returned, only their details
+func (h *AuthHandler) ListAPITokens(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	tokens, err := h.userService.ListAPITokens(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve API tokens",
+			"code":  "4058",
+		})
+		return
+	}
+
+	response := make([]*APITokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, newAPITokenResponse(token))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": response,
+	})
+}
+
+// RevokeAPIToken handles requests to revoke one of the current user's API tokens
+func (h *AuthHandler) RevokeAPIToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	if err := h.userService.RevokeAPIToken(userID.(string), c.Param("id"), sessionClient(c)); err != nil {
+		if err == domain.ErrAPITokenNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API token not found",
+				"code":  "4057",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to revoke API token",
+				"code":  "4058",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token revoked",
+	})
+}
+
+// bindTwoFactorChange parses and validates the request payload for disabling two-factor authentication or regenerating recovery codes
+// Writes the error response and returns false if the payload is unusable
+func bindTwoFactorChange(c *gin.Context) (TwoFactorChangeRequest, bool) {
+	var req TwoFactorChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return req, false
+	}
+
+	if req.Password == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Password and code are required",
+			"code":  "4007",
+		})
+		return req, false
+	}
+
+	return req, true
+}
+
+// twoFactorError writes the error response for a failed change to two-factor authentication
+func (h *AuthHandler) twoFactorError(c *gin.Context, err error) {
+	var locked *domain.AccountLockedError
+	if errors.As(err, &locked) {
+		h.accountLocked(c, locked)
+		return
+	}
+
+	switch err {
+	case domain.ErrInvalidCredentials:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Current password is incorrect",
+			"code":  "4032",
+		})
+	case domain.ErrInvalidTwoFactorCode:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid two-factor authentication code",
+			"code":  "4047",
+		})
+	case domain.ErrTwoFactorAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+			"code":  "4049",
+		})
+	case domain.ErrTwoFactorNotEnabled:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is not enabled",
+			"code":  "4050",
+		})
+	case domain.ErrTwoFactorSetupNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No two-factor authentication setup in progress, please start again",
+			"code":  "4051",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update two-factor authentication",
+			"code":  "4052",
+		})
+	}
+}
+
+// accountLocked writes the response for a login rejected because the account is locked
+func (h *AuthHandler) accountLocked(c *gin.Context, locked *domain.AccountLockedError) {
+	// Whole seconds, rounded up so retrying on time never hits the lock
+	retryAfter := max(1, int((time.Until(locked.Until)+time.Second-1)/time.Second))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusLocked, gin.H{
+		"error": "Account is temporarily locked after too many failed logins",
+		"code":  "4041",
+		"details": gin.H{
+			"lockedUntil": locked.Until,
+		},
+	})
+}
+
+// twoFactorRequired writes the response for a login that needs a second factor before LoginTwoFactor creates the session
+func (h *AuthHandler) twoFactorRequired(c *gin.Context, twoFactor *domain.TwoFactorRequiredError) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error": "Two-factor authentication code required",
+		"code":  "4046",
+		"details": gin.H{
+			"loginToken": twoFactor.Token,
+			"expiresAt":  twoFactor.ExpiresAt,
+		},
+	})
+}
+
+// sessionClient describes the client making the request for session tracking
+func sessionClient(c *gin.Context) domain.SessionClient {
+	return domain.SessionClient{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// setSessionCookie sets the session cookie with the configured security settings
+// Remembered sessions get a persistent cookie; others get a browser-session cookie
+func (h *AuthHandler) setSessionCookie(c *gin.Context, sessionID string, rememberMe bool) {
+	// A max age of 0 omits Max-Age, so the browser drops the cookie when it closes
+	// Note: SameSite=Lax is the default for modern browsers
+	maxAge := 0
+	if rememberMe {
+		maxAge = h.cookies.MaxAge
+	}
+
+	c.SetCookie(
+		"session",                // name
+		h.signer.Sign(sessionID), // value (signed so forged IDs are rejected)
+		maxAge,                   // maxAge
+		"/",                      // path
+		"",                       // domain (empty for current domain)
+		h.cookies.Secure,         // secure (enable in production with HTTPS)
+		h.cookies.HTTPOnly,       // httpOnly
+	)
+}
+
+// sessionID returns the session ID from a validly signed session cookie
+func (h *AuthHandler) sessionID(c *gin.Context) (string, bool) {
+	signed, err := c.Cookie("session")
+	if err != nil || signed == "" {
+		return "", false
+	}
+	return h.signer.Verify(signed)
+}
+
+// clearSessionCookie expires the session cookie in the browser
+func (h *AuthHandler) clearSessionCookie(c *gin.Context) {
+	c.SetCookie("session", "", -1, "/", "", h.cookies.Secure, h.cookies.HTTPOnly)
+}
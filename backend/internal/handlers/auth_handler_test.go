@@ -3,9 +3,11 @@ package handlers
 import (
 	"backend/internal/domain"
 	"backend/internal/mocks"
+	"backend/pkg/cookie"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,8 +15,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// testSigner signs the session cookies issued and accepted in these tests
+var testSigner = cookie.NewSigner("test-secret-key")
+
 func TestAuthHandler_Register(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -132,7 +138,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			mockService := new(mocks.MockUserService)
 
 			if tt.mockError == nil && tt.expectedStatus == http.StatusCreated {
-				mockService.On("Register", "test@example.com", "Test User", "Test123!").
+				mockService.On("Register", "test@example.com", "Test User", "Test123!", mock.Anything).
 					Return(tt.mockResponse, tt.mockSessionID, nil)
 			} else if tt.mockError != nil {
 				// Extract email from the request body for proper mock setup
@@ -140,7 +146,7 @@ func TestAuthHandler_Register(t *testing.T) {
 					email := reqMap["email"].(string)
 					displayName := reqMap["displayName"].(string)
 					password := reqMap["password"].(string)
-					mockService.On("Register", email, displayName, password).
+					mockService.On("Register", email, displayName, password, mock.Anything).
 						Return((*domain.User)(nil), "", tt.mockError)
 				}
 			} else if tt.expectedStatus == http.StatusBadRequest && tt.expectedCode == "4007" {
@@ -148,7 +154,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			}
 
 			// Create handler
-			handler := NewAuthHandler(mockService)
+			handler := NewAuthHandler(mockService, testSigner)
 
 			// Setup request
 			var body []byte
@@ -186,7 +192,7 @@ func TestAuthHandler_Register(t *testing.T) {
 					}
 				}
 				assert.NotNil(t, sessionCookie)
-				assert.Equal(t, tt.mockSessionID, sessionCookie.Value)
+				assert.Equal(t, testSigner.Sign(tt.mockSessionID), sessionCookie.Value)
 				assert.True(t, sessionCookie.HttpOnly)
 			}
 
@@ -270,20 +276,20 @@ func TestAuthHandler_Login(t *testing.T) {
 			mockService := new(mocks.MockUserService)
 
 			if tt.mockError == nil && tt.expectedStatus == http.StatusOK {
-				mockService.On("Login", "test@example.com", "Test123!").
+				mockService.On("Login", "test@example.com", "Test123!", mock.Anything, mock.Anything).
 					Return(tt.mockResponse, tt.mockSessionID, nil)
 			} else if tt.mockError != nil {
 				// Extract email and password from the request body for proper mock setup
 				if reqMap, ok := tt.requestBody.(map[string]interface{}); ok {
 					email := reqMap["email"].(string)
 					password := reqMap["password"].(string)
-					mockService.On("Login", email, password).
+					mockService.On("Login", email, password, mock.Anything, mock.Anything).
 						Return((*domain.User)(nil), "", tt.mockError)
 				}
 			}
 
 			// Create handler
-			handler := NewAuthHandler(mockService)
+			handler := NewAuthHandler(mockService, testSigner)
 
 			// Setup request
 			var body []byte
@@ -321,7 +327,7 @@ func TestAuthHandler_Login(t *testing.T) {
 					}
 				}
 				assert.NotNil(t, sessionCookie)
-				assert.Equal(t, tt.mockSessionID, sessionCookie.Value)
+				assert.Equal(t, testSigner.Sign(tt.mockSessionID), sessionCookie.Value)
 			}
 
 			// Verify mock expectations
@@ -372,7 +378,7 @@ func TestAuthHandler_Logout(t *testing.T) {
 			}
 
 			// Create handler
-			handler := NewAuthHandler(mockService)
+			handler := NewAuthHandler(mockService, testSigner)
 
 			// Setup request
 			w := httptest.NewRecorder()
@@ -381,7 +387,7 @@ func TestAuthHandler_Logout(t *testing.T) {
 			if tt.sessionCookie != "" {
 				req.AddCookie(&http.Cookie{
 					Name:  "session",
-					Value: tt.sessionCookie,
+					Value: testSigner.Sign(tt.sessionCookie),
 				})
 			}
 			c.Request = req
@@ -469,7 +475,7 @@ func TestAuthHandler_Me(t *testing.T) {
 			}
 
 			// Create handler
-			handler := NewAuthHandler(mockService)
+			handler := NewAuthHandler(mockService, testSigner)
 
 			// Setup request
 			w := httptest.NewRecorder()
@@ -478,7 +484,7 @@ func TestAuthHandler_Me(t *testing.T) {
 			if tt.sessionCookie != "" {
 				req.AddCookie(&http.Cookie{
 					Name:  "session",
-					Value: tt.sessionCookie,
+					Value: testSigner.Sign(tt.sessionCookie),
 				})
 			}
 			c.Request = req
@@ -506,4 +512,989 @@ func TestAuthHandler_Me(t *testing.T) {
 			mockService.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAuthHandler_Sessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newContext builds an authenticated request context for the current session
+	newContext := func(method, path string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, nil)
+		c.Set("userID", "user-123")
+		c.Set("sessionID", "current-session")
+		return c, w
+	}
+
+	t.Run("List sessions", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("ListSessions", "user-123", "current-session").Return([]*domain.Session{
+			{ID: "current-session", IP: "192.0.2.1", UserAgent: "Firefox", Current: true},
+			{ID: "other-session", IP: "198.51.100.7", UserAgent: "Safari"},
+		}, nil)
+
+		c, w := newContext("GET", "/auth/sessions")
+		NewAuthHandler(mockService, testSigner).ListSessions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Sessions []SessionResponse `json:"sessions"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Sessions, 2)
+		assert.True(t, response.Sessions[0].Current)
+		assert.Equal(t, "Safari", response.Sessions[1].UserAgent)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("List sessions service error", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("ListSessions", "user-123", "current-session").Return(nil, errors.New("redis down"))
+
+		c, w := newContext("GET", "/auth/sessions")
+		NewAuthHandler(mockService, testSigner).ListSessions(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "4030")
+	})
+
+	t.Run("Revoke another session keeps the cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("RevokeSession", "user-123", "other-session").Return(nil)
+
+		c, w := newContext("DELETE", "/auth/sessions/other-session")
+		c.Params = gin.Params{{Key: "id", Value: "other-session"}}
+		NewAuthHandler(mockService, testSigner).RevokeSession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Revoke current session clears the cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("RevokeSession", "user-123", "current-session").Return(nil)
+
+		c, w := newContext("DELETE", "/auth/sessions/current-session")
+		c.Params = gin.Params{{Key: "id", Value: "current-session"}}
+		NewAuthHandler(mockService, testSigner).RevokeSession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	})
+
+	t.Run("Revoke unknown session", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("RevokeSession", "user-123", "missing").Return(domain.ErrSessionNotFound)
+
+		c, w := newContext("DELETE", "/auth/sessions/missing")
+		c.Params = gin.Params{{Key: "id", Value: "missing"}}
+		NewAuthHandler(mockService, testSigner).RevokeSession(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "4029")
+	})
+
+	t.Run("Revoke other sessions", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("RevokeOtherSessions", "user-123", "current-session").Return(3, nil)
+
+		c, w := newContext("DELETE", "/auth/sessions")
+		NewAuthHandler(mockService, testSigner).RevokeOtherSessions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"revoked":3`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Unauthenticated request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/auth/sessions", nil)
+		NewAuthHandler(new(mocks.MockUserService), testSigner).ListSessions(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4001")
+	})
+}
+
+func TestAuthHandler_SessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &domain.User{ID: "user-123", Email: "test@example.com"}
+	cookies := CookieSettings{MaxAge: 2592000, Secure: true, HTTPOnly: true}
+
+	tests := []struct {
+		name           string
+		rememberMe     bool
+		expectedMaxAge int
+	}{
+		{name: "Remembered login gets a persistent cookie", rememberMe: true, expectedMaxAge: 2592000},
+		{name: "Other logins get a browser-session cookie", rememberMe: false, expectedMaxAge: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockUserService)
+			mockService.On("Login", "test@example.com", "Test123!", tt.rememberMe, mock.Anything).
+				Return(user, "session-123", nil)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"email":      "test@example.com",
+				"password":   "Test123!",
+				"rememberMe": tt.rememberMe,
+			})
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewAuthHandlerWithCookies(mockService, testSigner, cookies).Login(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			result := w.Result().Cookies()
+			assert.Len(t, result, 1)
+			assert.Equal(t, testSigner.Sign("session-123"), result[0].Value)
+			assert.Equal(t, tt.expectedMaxAge, result[0].MaxAge)
+			assert.True(t, result[0].Secure)
+			assert.True(t, result[0].HttpOnly)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_ForgedSessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	forged := cookie.NewSigner("attacker-secret").Sign("session-123")
+	tests := []struct {
+		name         string
+		call         func(h *AuthHandler, c *gin.Context)
+		expectedCode string
+	}{
+		{name: "logout", call: (*AuthHandler).Logout, expectedCode: "4011"},
+		{name: "me", call: (*AuthHandler).Me, expectedCode: "4013"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No expectations: a forged cookie must never reach the service
+			mockService := new(mocks.MockUserService)
+			handler := NewAuthHandler(mockService, testSigner)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/auth/"+tt.name, nil)
+			c.Request.AddCookie(&http.Cookie{Name: "session", Value: forged})
+
+			tt.call(handler, c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedCode)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_Account(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newContext builds an authenticated request context with a JSON body
+	newContext := func(method, path, body string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("userID", "user-123")
+		c.Set("sessionID", "current-session")
+		return c, w
+	}
+
+	t.Run("Update profile", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("UpdateProfile", "user-123", "New Name").Return(&domain.User{ID: "user-123", DisplayName: "New Name"}, nil)
+
+		c, w := newContext("PATCH", "/auth/me", `{"displayName":"New Name"}`)
+		NewAuthHandler(mockService, testSigner).UpdateProfile(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"displayName":"New Name"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Update profile with invalid display name", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("UpdateProfile", "user-123", "").Return(nil, domain.ErrInvalidDisplayName)
+
+		c, w := newContext("PATCH", "/auth/me", `{"displayName":""}`)
+		NewAuthHandler(mockService, testSigner).UpdateProfile(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "4031")
+	})
+
+	t.Run("Change password reissues the session cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("ChangePassword", "user-123", "current-session", "OldPass1!", "NewPass2@", mock.AnythingOfType("domain.SessionClient")).Return("new-session", true, nil)
+
+		c, w := newContext("PUT", "/auth/password", `{"currentPassword":"OldPass1!","newPassword":"NewPass2@"}`)
+		NewAuthHandler(mockService, testSigner).ChangePassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, testSigner.Sign("new-session"), cookies[0].Value)
+		assert.Equal(t, 604800, cookies[0].MaxAge)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Change password errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "missing fields", body: `{"currentPassword":"OldPass1!"}`, expectedStatus: http.StatusBadRequest, expectedCode: "4007"},
+			{name: "wrong current password", body: `{"currentPassword":"bad","newPassword":"NewPass2@"}`, serviceErr: domain.ErrInvalidCredentials, expectedStatus: http.StatusForbidden, expectedCode: "4032"},
+			{name: "weak new password", body: `{"currentPassword":"OldPass1!","newPassword":"weak"}`, serviceErr: domain.ErrWeakPassword, expectedStatus: http.StatusBadRequest, expectedCode: "4033"},
+			{name: "locked", body: `{"currentPassword":"bad","newPassword":"NewPass2@"}`, serviceErr: &domain.AccountLockedError{Until: time.Now().Add(time.Minute)}, expectedStatus: http.StatusLocked, expectedCode: "4041"},
+			{name: "service error", body: `{"currentPassword":"OldPass1!","newPassword":"NewPass2@"}`, serviceErr: errors.New("redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4034"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				if tt.serviceErr != nil {
+					mockService.On("ChangePassword", "user-123", "current-session", mock.Anything, mock.Anything, mock.Anything).Return("", false, tt.serviceErr)
+				}
+
+				c, w := newContext("PUT", "/auth/password", tt.body)
+				NewAuthHandler(mockService, testSigner).ChangePassword(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				assert.Empty(t, w.Result().Cookies())
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("Delete account clears the cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("DeleteUser", "user-123", "Pass123!", mock.AnythingOfType("domain.SessionClient")).Return(nil)
+
+		c, w := newContext("DELETE", "/auth/me", `{"password":"Pass123!"}`)
+		NewAuthHandler(mockService, testSigner).DeleteAccount(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Delete account with wrong password", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("DeleteUser", "user-123", "bad", mock.AnythingOfType("domain.SessionClient")).Return(domain.ErrInvalidCredentials)
+
+		c, w := newContext("DELETE", "/auth/me", `{"password":"bad"}`)
+		NewAuthHandler(mockService, testSigner).DeleteAccount(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "4032")
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("Delete account while locked", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("DeleteUser", "user-123", "bad", mock.AnythingOfType("domain.SessionClient")).Return(&domain.AccountLockedError{Until: time.Now().Add(time.Minute)})
+
+		c, w := newContext("DELETE", "/auth/me", `{"password":"bad"}`)
+		NewAuthHandler(mockService, testSigner).DeleteAccount(c)
+
+		assert.Equal(t, http.StatusLocked, w.Code)
+		assert.Contains(t, w.Body.String(), "4041")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestAuthHandler_PasswordReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(path, body string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return c, w
+	}
+
+	t.Run("Forgot password responds the same whether or not the email is known", func(t *testing.T) {
+		for _, serviceErr := range []error{nil, errors.New("3031: failed to send reset email")} {
+			mockService := new(mocks.MockUserService)
+			mockService.On("RequestPasswordReset", "test@example.com").Return(serviceErr)
+
+			c, w := newContext("/auth/forgot-password", `{"email":"test@example.com"}`)
+			NewAuthHandler(mockService, testSigner).ForgotPassword(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), "If an account exists")
+			mockService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("Forgot password requires an email", func(t *testing.T) {
+		c, w := newContext("/auth/forgot-password", `{}`)
+		NewAuthHandler(new(mocks.MockUserService), testSigner).ForgotPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "4007")
+	})
+
+	t.Run("Reset password", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			serviceErr     error
+			callsService   bool
+			expectedStatus int
+			expectedBody   string
+		}{
+			{name: "success", body: `{"token":"abc","newPassword":"NewPass2@"}`, callsService: true, expectedStatus: http.StatusOK, expectedBody: "Password reset successfully"},
+			{name: "invalid json", body: `{`, expectedStatus: http.StatusBadRequest, expectedBody: "4006"},
+			{name: "missing token", body: `{"newPassword":"NewPass2@"}`, expectedStatus: http.StatusBadRequest, expectedBody: "4007"},
+			{name: "invalid token", body: `{"token":"abc","newPassword":"NewPass2@"}`, serviceErr: domain.ErrInvalidResetToken, callsService: true, expectedStatus: http.StatusBadRequest, expectedBody: "4035"},
+			{name: "weak password", body: `{"token":"abc","newPassword":"weak"}`, serviceErr: domain.ErrWeakPassword, callsService: true, expectedStatus: http.StatusBadRequest, expectedBody: "4033"},
+			{name: "service error", body: `{"token":"abc","newPassword":"NewPass2@"}`, serviceErr: errors.New("redis down"), callsService: true, expectedStatus: http.StatusInternalServerError, expectedBody: "4034"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				if tt.callsService {
+					mockService.On("ResetPassword", "abc", mock.AnythingOfType("string")).Return(tt.serviceErr)
+				}
+
+				c, w := newContext("/auth/reset-password", tt.body)
+				NewAuthHandler(mockService, testSigner).ResetPassword(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestAuthHandler_EmailVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Verify email", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			serviceErr     error
+			callsService   bool
+			expectedStatus int
+			expectedBody   string
+		}{
+			{name: "success", body: `{"token":"abc"}`, callsService: true, expectedStatus: http.StatusOK, expectedBody: `"emailVerified":true`},
+			{name: "missing token", body: `{}`, expectedStatus: http.StatusBadRequest, expectedBody: "4007"},
+			{name: "invalid token", body: `{"token":"abc"}`, serviceErr: domain.ErrInvalidVerificationToken, callsService: true, expectedStatus: http.StatusBadRequest, expectedBody: "4036"},
+			{name: "service error", body: `{"token":"abc"}`, serviceErr: errors.New("redis down"), callsService: true, expectedStatus: http.StatusInternalServerError, expectedBody: "4034"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				if tt.callsService {
+					var user *domain.User
+					if tt.serviceErr == nil {
+						user = &domain.User{ID: "user-123", Email: "test@example.com", EmailVerified: true}
+					}
+					mockService.On("VerifyEmail", "abc").Return(user, tt.serviceErr)
+				}
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("POST", "/auth/verify-email", bytes.NewBufferString(tt.body))
+				c.Request.Header.Set("Content-Type", "application/json")
+				NewAuthHandler(mockService, testSigner).VerifyEmail(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("Resend verification", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			serviceErr     error
+			expectedStatus int
+			expectedBody   string
+		}{
+			{name: "success", expectedStatus: http.StatusOK, expectedBody: "Verification email sent"},
+			{name: "already verified", serviceErr: domain.ErrEmailAlreadyVerified, expectedStatus: http.StatusConflict, expectedBody: "4037"},
+			{name: "send failure", serviceErr: errors.New("3031: failed to send verification email"), expectedStatus: http.StatusInternalServerError, expectedBody: "4038"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				mockService.On("SendVerificationEmail", "user-123").Return(tt.serviceErr)
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("POST", "/auth/resend-verification", nil)
+				c.Set("userID", "user-123")
+				NewAuthHandler(mockService, testSigner).ResendVerification(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestAuthHandler_AccountLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("locked login returns 423 with Retry-After", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		until := time.Now().Add(90 * time.Second)
+		mockService.On("Login", "test@example.com", "Test123!", false, mock.Anything).
+			Return(nil, "", &domain.AccountLockedError{Until: until})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/auth/login", bytes.NewBufferString(`{"email":"test@example.com","password":"Test123!"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		NewAuthHandler(mockService, testSigner).Login(c)
+
+		assert.Equal(t, http.StatusLocked, w.Code)
+		assert.Contains(t, w.Body.String(), "4041")
+		assert.Contains(t, w.Body.String(), "lockedUntil")
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+		assert.Empty(t, w.Header().Get("Set-Cookie"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("login activity", func(t *testing.T) {
+		lastSuccess := &domain.LoginAttempt{At: time.Now().Add(-time.Hour), IP: "192.0.2.1", UserAgent: "Firefox"}
+		tests := []struct {
+			name           string
+			activity       *domain.LoginActivity
+			serviceErr     error
+			expectedStatus int
+			validate       func(*testing.T, *httptest.ResponseRecorder)
+		}{
+			{
+				name: "success",
+				activity: &domain.LoginActivity{
+					LastSuccess:    lastSuccess,
+					FailedAttempts: 2,
+					Events:         []*domain.SecurityEvent{{Type: domain.SecurityEventLoginFailed, At: time.Now(), IP: "198.51.100.7"}},
+				},
+				expectedStatus: http.StatusOK,
+				validate: func(t *testing.T, w *httptest.ResponseRecorder) {
+					var response LoginActivityResponse
+					assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+					assert.Equal(t, "192.0.2.1", response.LastSuccessfulLogin.IP)
+					assert.Nil(t, response.LastFailedLogin)
+					assert.Nil(t, response.LockedUntil)
+					assert.Equal(t, 2, response.FailedAttempts)
+					assert.Len(t, response.Events, 1)
+					assert.Equal(t, "login_failed", response.Events[0].Type)
+					assert.Contains(t, w.Body.String(), `"lastFailedLogin":null`)
+				},
+			},
+			{
+				name:           "service error",
+				serviceErr:     errors.New("3004: failed to get login activity"),
+				expectedStatus: http.StatusInternalServerError,
+				validate: func(t *testing.T, w *httptest.ResponseRecorder) {
+					assert.Contains(t, w.Body.String(), "4043")
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				mockService.On("GetLoginActivity", "user-123").Return(tt.activity, tt.serviceErr)
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("GET", "/auth/login-activity", nil)
+				c.Set("userID", "user-123")
+				NewAuthHandler(mockService, testSigner).LoginActivity(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				tt.validate(t, w)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestAuthHandler_TwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newContext builds a request context with a JSON body, authenticated when userID is set
+	newContext := func(method, path, body, userID string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			c.Set("userID", userID)
+		}
+		return c, w
+	}
+
+	t.Run("login asks for a second factor without setting a cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		expiresAt := time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+		mockService.On("Login", "test@example.com", "Test123!", true, mock.Anything).
+			Return(nil, "", &domain.TwoFactorRequiredError{Token: "login-token", ExpiresAt: expiresAt})
+
+		c, w := newContext("POST", "/auth/login", `{"email":"test@example.com","password":"Test123!","rememberMe":true}`, "")
+		NewAuthHandler(mockService, testSigner).Login(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4046")
+		assert.Contains(t, w.Body.String(), `"loginToken":"login-token"`)
+		assert.Contains(t, w.Body.String(), `"expiresAt":"2024-01-01T00:05:00Z"`)
+		assert.Empty(t, w.Header().Get("Set-Cookie"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("second step sets the session cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("CompleteTwoFactorLogin", "login-token", "123456", mock.AnythingOfType("domain.SessionClient")).
+			Return(&domain.User{ID: "user-123", Email: "test@example.com"}, "new-session", true, nil)
+
+		c, w := newContext("POST", "/auth/login/2fa", `{"loginToken":"login-token","code":"123456"}`, "")
+		NewAuthHandler(mockService, testSigner).LoginTwoFactor(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"email":"test@example.com"`)
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, testSigner.Sign("new-session"), cookies[0].Value)
+		assert.Equal(t, 604800, cookies[0].MaxAge)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("second step errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "missing code", body: `{"loginToken":"login-token"}`, expectedStatus: http.StatusBadRequest, expectedCode: "4007"},
+			{name: "wrong code", body: `{"loginToken":"login-token","code":"000000"}`, serviceErr: domain.ErrInvalidTwoFactorCode, expectedStatus: http.StatusUnauthorized, expectedCode: "4047"},
+			{name: "expired token", body: `{"loginToken":"login-token","code":"000000"}`, serviceErr: domain.ErrInvalidLoginChallenge, expectedStatus: http.StatusUnauthorized, expectedCode: "4048"},
+			{name: "locked", body: `{"loginToken":"login-token","code":"000000"}`, serviceErr: &domain.AccountLockedError{Until: time.Now().Add(time.Minute)}, expectedStatus: http.StatusLocked, expectedCode: "4041"},
+			{name: "service error", body: `{"loginToken":"login-token","code":"000000"}`, serviceErr: errors.New("3004: redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4009"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				if tt.serviceErr != nil {
+					mockService.On("CompleteTwoFactorLogin", "login-token", "000000", mock.AnythingOfType("domain.SessionClient")).
+						Return(nil, "", false, tt.serviceErr)
+				}
+
+				c, w := newContext("POST", "/auth/login/2fa", tt.body, "")
+				NewAuthHandler(mockService, testSigner).LoginTwoFactor(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				assert.Empty(t, w.Header().Get("Set-Cookie"))
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		enabledAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockService.On("GetTwoFactorStatus", "user-123").Return(&domain.TwoFactor{Secret: "SECRET", EnabledAt: enabledAt, RecoveryCodesRemaining: 7}, nil)
+		mockService.On("GetTwoFactorStatus", "user-456").Return(nil, nil)
+
+		c, w := newContext("GET", "/auth/2fa", "", "user-123")
+		NewAuthHandler(mockService, testSigner).TwoFactorStatus(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"enabled":true,"enabledAt":"2024-01-01T00:00:00Z","recoveryCodesRemaining":7}`, w.Body.String())
+
+		c, w = newContext("GET", "/auth/2fa", "", "user-456")
+		NewAuthHandler(mockService, testSigner).TwoFactorStatus(c)
+
+		assert.JSONEq(t, `{"enabled":false,"enabledAt":null,"recoveryCodesRemaining":0}`, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("setup", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("BeginTwoFactorSetup", "user-123").Return(&domain.TwoFactorSetup{Secret: "SECRET", URI: "otpauth://totp/x"}, nil)
+		mockService.On("BeginTwoFactorSetup", "user-456").Return(nil, domain.ErrTwoFactorAlreadyEnabled)
+
+		c, w := newContext("POST", "/auth/2fa/setup", "", "user-123")
+		NewAuthHandler(mockService, testSigner).SetupTwoFactor(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"secret":"SECRET"`)
+		assert.Contains(t, w.Body.String(), `"uri":"otpauth://totp/x"`)
+
+		c, w = newContext("POST", "/auth/2fa/setup", "", "user-456")
+		NewAuthHandler(mockService, testSigner).SetupTwoFactor(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "4049")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("enable returns the recovery codes", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("EnableTwoFactor", "user-123", "123456", mock.AnythingOfType("domain.SessionClient")).Return([]string{"aaaaa-bbbbb", "ccccc-ddddd"}, nil)
+
+		c, w := newContext("POST", "/auth/2fa/enable", `{"code":"123456"}`, "user-123")
+		NewAuthHandler(mockService, testSigner).EnableTwoFactor(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"recoveryCodes":["aaaaa-bbbbb","ccccc-ddddd"]`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("changes report errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "wrong password", serviceErr: domain.ErrInvalidCredentials, expectedStatus: http.StatusForbidden, expectedCode: "4032"},
+			{name: "wrong code", serviceErr: domain.ErrInvalidTwoFactorCode, expectedStatus: http.StatusBadRequest, expectedCode: "4047"},
+			{name: "not enabled", serviceErr: domain.ErrTwoFactorNotEnabled, expectedStatus: http.StatusConflict, expectedCode: "4050"},
+			{name: "locked", serviceErr: &domain.AccountLockedError{Until: time.Now().Add(time.Minute)}, expectedStatus: http.StatusLocked, expectedCode: "4041"},
+			{name: "service error", serviceErr: errors.New("3004: redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4052"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				mockService.On("DisableTwoFactor", "user-123", "Test123!", "123456", mock.AnythingOfType("domain.SessionClient")).Return(tt.serviceErr)
+				mockService.On("RegenerateRecoveryCodes", "user-123", "Test123!", "123456", mock.AnythingOfType("domain.SessionClient")).Return(nil, tt.serviceErr)
+				handler := NewAuthHandler(mockService, testSigner)
+
+				c, w := newContext("POST", "/auth/2fa/disable", `{"password":"Test123!","code":"123456"}`, "user-123")
+				handler.DisableTwoFactor(c)
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+
+				c, w = newContext("POST", "/auth/2fa/recovery-codes", `{"password":"Test123!","code":"123456"}`, "user-123")
+				handler.RegenerateRecoveryCodes(c)
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("enable without setup", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("EnableTwoFactor", "user-123", "123456", mock.AnythingOfType("domain.SessionClient")).Return(nil, domain.ErrTwoFactorSetupNotFound)
+
+		c, w := newContext("POST", "/auth/2fa/enable", `{"code":"123456"}`, "user-123")
+		NewAuthHandler(mockService, testSigner).EnableTwoFactor(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "4051")
+	})
+
+	t.Run("changes require password and code", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+
+		c, w := newContext("POST", "/auth/2fa/disable", `{"password":"Test123!"}`, "user-123")
+		NewAuthHandler(mockService, testSigner).DisableTwoFactor(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "4007")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		handler := NewAuthHandler(new(mocks.MockUserService), testSigner)
+		for _, handle := range []gin.HandlerFunc{handler.TwoFactorStatus, handler.SetupTwoFactor, handler.EnableTwoFactor, handler.DisableTwoFactor, handler.RegenerateRecoveryCodes} {
+			c, w := newContext("POST", "/auth/2fa", `{}`, "")
+			handle(c)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), "4001")
+		}
+	})
+}
+
+func TestAuthHandler_APITokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	token := &domain.APIToken{
+		ID:        "token-1",
+		UserID:    "user-123",
+		TokenHash: "stored-hash",
+		Name:      "CI",
+		Scopes:    []string{domain.ScopeTasksRead},
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(7 * 24 * time.Hour),
+	}
+
+	newContext := func(method, path, body string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("userID", "user-123")
+		return c, w
+	}
+
+	t.Run("create returns the token once", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("CreateAPIToken", "user-123", "CI", []string{"tasks:read"}, 7*24*time.Hour, mock.AnythingOfType("domain.SessionClient")).
+			Return(token, "tt_secret", nil)
+
+		c, w := newContext("POST", "/auth/tokens", `{"name":"CI","scopes":["tasks:read"],"expiresInDays":7}`)
+		NewAuthHandler(mockService, testSigner).CreateAPIToken(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"tt_secret"`)
+		assert.Contains(t, w.Body.String(), `"id":"token-1"`)
+		assert.Contains(t, w.Body.String(), `"scopes":["tasks:read"]`)
+		assert.Contains(t, w.Body.String(), `"expiresAt":"2024-01-08T00:00:00Z"`)
+		assert.Contains(t, w.Body.String(), `"lastUsedAt":null`)
+		assert.NotContains(t, w.Body.String(), "stored-hash")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("create errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "invalid name", serviceErr: domain.ErrInvalidAPITokenName, expectedStatus: http.StatusBadRequest, expectedCode: "4056"},
+			{name: "invalid scopes", serviceErr: domain.ErrInvalidAPITokenScopes, expectedStatus: http.StatusBadRequest, expectedCode: "4056"},
+			{name: "invalid expiry", serviceErr: domain.ErrInvalidAPITokenExpiry, expectedStatus: http.StatusBadRequest, expectedCode: "4056"},
+			{name: "service error", serviceErr: errors.New("3033: redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4058"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				mockService.On("CreateAPIToken", "user-123", "CI", []string(nil), time.Duration(0), mock.AnythingOfType("domain.SessionClient")).
+					Return(nil, "", tt.serviceErr)
+
+				c, w := newContext("POST", "/auth/tokens", `{"name":"CI"}`)
+				NewAuthHandler(mockService, testSigner).CreateAPIToken(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("list returns token details without secrets", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		lastUsed := createdAt.Add(time.Hour)
+		used := *token
+		used.LastUsedAt = &lastUsed
+		mockService.On("ListAPITokens", "user-123").Return([]*domain.APIToken{&used}, nil)
+
+		c, w := newContext("GET", "/auth/tokens", "")
+		NewAuthHandler(mockService, testSigner).ListAPITokens(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"tokens":[{"id":"token-1"`)
+		assert.Contains(t, w.Body.String(), `"lastUsedAt":"2024-01-01T01:00:00Z"`)
+		assert.NotContains(t, w.Body.String(), `"token":`)
+		assert.NotContains(t, w.Body.String(), "stored-hash")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "revoked", expectedStatus: http.StatusOK},
+			{name: "unknown token", serviceErr: domain.ErrAPITokenNotFound, expectedStatus: http.StatusNotFound, expectedCode: "4057"},
+			{name: "service error", serviceErr: errors.New("3004: redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4058"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				mockService.On("RevokeAPIToken", "user-123", "token-1", mock.AnythingOfType("domain.SessionClient")).Return(tt.serviceErr)
+
+				c, w := newContext("DELETE", "/auth/tokens/token-1", "")
+				c.Params = gin.Params{{Key: "id", Value: "token-1"}}
+				NewAuthHandler(mockService, testSigner).RevokeAPIToken(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestAuthHandler_OIDCLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newContext builds a request context with a JSON body and, when state is set, the sign-in state cookie
+	newContext := func(path, body, state string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if state != "" {
+			c.Request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: state})
+		}
+		return c, w
+	}
+
+	// sessionCookie returns the session cookie set by the response, if any
+	sessionCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session" {
+				return cookie
+			}
+		}
+		return nil
+	}
+
+	t.Run("start returns the authorization URL and sets the state cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("BeginOIDCLogin", true).Return("https://idp.example.com/authorize?state=the-state", "the-state", nil)
+
+		c, w := newContext("/auth/oidc/login", `{"rememberMe":true}`, "")
+		NewAuthHandler(mockService, testSigner).StartOIDCLogin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"authorizationUrl":"https://idp.example.com/authorize?state=the-state"`)
+		cookies := w.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, oidcStateCookie, cookies[0].Name)
+		assert.Equal(t, "the-state", cookies[0].Value)
+		assert.Equal(t, oidcStateCookieMaxAge, cookies[0].MaxAge)
+		assert.True(t, cookies[0].HttpOnly)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("start errors", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("BeginOIDCLogin", false).Return("", "", errors.New("3030: identity provider sign-in is not configured"))
+
+		c, w := newContext("/auth/oidc/login", `{}`, "")
+		NewAuthHandler(mockService, testSigner).StartOIDCLogin(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "4059")
+		assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+		c, w = newContext("/auth/oidc/login", `not json`, "")
+		NewAuthHandler(mockService, testSigner).StartOIDCLogin(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "4006")
+	})
+
+	t.Run("callback sets the session cookie and clears the state cookie", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("CompleteOIDCLogin", "the-state", "the-code", mock.AnythingOfType("domain.SessionClient")).
+			Return(&domain.User{ID: "user-123", Email: "test@example.com"}, "new-session", true, nil)
+
+		c, w := newContext("/auth/oidc/callback", `{"code":"the-code","state":"the-state"}`, "the-state")
+		NewAuthHandler(mockService, testSigner).CompleteOIDCLogin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"email":"test@example.com"`)
+		session := sessionCookie(w)
+		if assert.NotNil(t, session) {
+			assert.Equal(t, testSigner.Sign("new-session"), session.Value)
+			assert.Equal(t, 604800, session.MaxAge)
+		}
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == oidcStateCookie {
+				assert.Negative(t, cookie.MaxAge)
+			}
+		}
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("callback asks users with two-factor authentication for a code", func(t *testing.T) {
+		mockService := new(mocks.MockUserService)
+		mockService.On("CompleteOIDCLogin", "the-state", "the-code", mock.AnythingOfType("domain.SessionClient")).
+			Return(nil, "", false, &domain.TwoFactorRequiredError{Token: "login-token", ExpiresAt: time.Now().Add(5 * time.Minute)})
+
+		c, w := newContext("/auth/oidc/callback", `{"code":"the-code","state":"the-state"}`, "the-state")
+		NewAuthHandler(mockService, testSigner).CompleteOIDCLogin(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4046")
+		assert.Contains(t, w.Body.String(), `"loginToken":"login-token"`)
+		assert.Nil(t, sessionCookie(w))
+	})
+
+	t.Run("callback errors", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			cookie         string
+			serviceErr     error
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "missing code", body: `{"state":"the-state"}`, cookie: "the-state", expectedStatus: http.StatusBadRequest, expectedCode: "4007"},
+			{name: "missing cookie", body: `{"code":"the-code","state":"the-state"}`, expectedStatus: http.StatusUnauthorized, expectedCode: "4060"},
+			{name: "cookie from another sign-in", body: `{"code":"the-code","state":"the-state"}`, cookie: "other-state", expectedStatus: http.StatusUnauthorized, expectedCode: "4060"},
+			{name: "expired state", body: `{"code":"the-code","state":"the-state"}`, cookie: "the-state", serviceErr: domain.ErrInvalidOIDCState, expectedStatus: http.StatusUnauthorized, expectedCode: "4060"},
+			{name: "unverified email", body: `{"code":"the-code","state":"the-state"}`, cookie: "the-state", serviceErr: domain.ErrOIDCEmailNotVerified, expectedStatus: http.StatusForbidden, expectedCode: "4061"},
+			{name: "provider failure", body: `{"code":"the-code","state":"the-state"}`, cookie: "the-state", serviceErr: fmt.Errorf("3035: %w: invalid_grant", domain.ErrOIDCExchangeFailed), expectedStatus: http.StatusBadGateway, expectedCode: "4062"},
+			{name: "service error", body: `{"code":"the-code","state":"the-state"}`, cookie: "the-state", serviceErr: errors.New("3004: redis down"), expectedStatus: http.StatusInternalServerError, expectedCode: "4009"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := new(mocks.MockUserService)
+				if tt.serviceErr != nil {
+					mockService.On("CompleteOIDCLogin", "the-state", "the-code", mock.AnythingOfType("domain.SessionClient")).
+						Return(nil, "", false, tt.serviceErr)
+				}
+
+				c, w := newContext("/auth/oidc/callback", tt.body, tt.cookie)
+				NewAuthHandler(mockService, testSigner).CompleteOIDCLogin(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				assert.Nil(t, sessionCookie(w))
+				mockService.AssertExpectations(t)
+			})
+		}
+	})
+}
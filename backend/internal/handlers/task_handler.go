@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"backend/internal/domain"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,15 +14,22 @@ import (
 // TaskService defines the interface for task business logic operations
 // Contains methods needed for task and category handlers
 type TaskService interface {
-	CreateTask(userID, description, category string) (*domain.Task, error)
+	CreateTask(userID, description, category string, opts domain.TaskOptions) (*domain.Task, error)
 	GetTaskByID(id, userID string) (*domain.Task, error)
-	ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error)
+	ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error)
 	UpdateTaskCompletion(id, userID string, completed bool) (*domain.Task, error)
+	UpdateTask(id, userID string, updates domain.TaskUpdate) (*domain.Task, error)
+	GetTaskHistory(id, userID string) ([]*domain.Task, error)
+	ListSubtasks(parentID, userID string) ([]*domain.Task, error)
 	SoftDeleteTask(id, userID string) error
 	RestoreTask(id, userID string) (*domain.Task, error)
 	GetUserCategories(userID string) ([]string, error)
 	RenameCategory(userID, oldName, newName string) error
 	DeleteCategory(userID, categoryName string) error
+	GetUserTags(userID string) ([]domain.TagSummary, error)
+	RenameTag(userID, oldName, newName string) error
+	MergeTags(userID, sourceName, targetName string) error
+	SearchTasks(userID, query string, limit int) ([]*domain.Task, error)
 }
 
 // TaskHandler handles task and category-related HTTP requests
@@ -37,8 +48,13 @@ func NewTaskHandler(taskService TaskService) *TaskHandler {
 
 // CreateTaskRequest represents the request payload for creating a task
 type CreateTaskRequest struct {
-	Description string `json:"description"`
-	Category    string `json:"category"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	Tags        []string   `json:"tags"`
+	DueDate     *time.Time `json:"dueDate"`
+	Priority    string     `json:"priority"`
+	Recurrence  string     `json:"recurrence"`
+	ParentID    string     `json:"parentId"`
 }
 
 // UpdateTaskCompletionRequest represents the request payload for updating task completion
@@ -46,21 +62,60 @@ type UpdateTaskCompletionRequest struct {
 	Completed bool `json:"completed"`
 }
 
+// UpdateTaskRequest represents the request payload for partially updating a task
+// Omitted fields are left unchanged
+type UpdateTaskRequest struct {
+	Description  *string    `json:"description"`
+	Category     *string    `json:"category"`
+	Tags         *[]string  `json:"tags"`
+	DueDate      *time.Time `json:"dueDate"`
+	ClearDueDate bool       `json:"clearDueDate"`
+	Priority     *string    `json:"priority"`
+	Recurrence   *string    `json:"recurrence"`
+}
+
 // RenameCategoryRequest represents the request payload for renaming a category
 type RenameCategoryRequest struct {
 	NewName string `json:"newName"`
 }
 
+// RenameTagRequest represents the request payload for renaming a tag
+type RenameTagRequest struct {
+	NewName string `json:"newName"`
+}
+
+// MergeTagRequest represents the request payload for merging a tag into another tag
+type MergeTagRequest struct {
+	Into string `json:"into"`
+}
+
 // TaskResponse represents the response payload for task data
 type TaskResponse struct {
-	ID          string `json:"id"`
-	UserID      string `json:"userId"`
-	Description string `json:"description"`
-	Category    string `json:"category,omitempty"`
-	Completed   bool   `json:"completed"`
-	CreatedAt   string `json:"createdAt"`
-	UpdatedAt   string `json:"updatedAt"`
-	DeletedAt   string `json:"deletedAt,omitempty"`
+	ID          string         `json:"id"`
+	UserID      string         `json:"userId"`
+	Description string         `json:"description"`
+	Category    string         `json:"category,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Completed   bool           `json:"completed"`
+	Priority    string         `json:"priority"`
+	DueDate     string         `json:"dueDate,omitempty"`
+	Overdue     bool           `json:"overdue"`
+	Recurrence  string         `json:"recurrence,omitempty"`
+	SeriesID    string         `json:"seriesId,omitempty"`
+	Occurrence  int            `json:"occurrence,omitempty"`
+	ParentID    string         `json:"parentId,omitempty"`
+	Subtasks    []TaskResponse `json:"subtasks,omitempty"`
+	Progress    *TaskProgress  `json:"progress,omitempty"`
+	CompletedAt string         `json:"completedAt,omitempty"`
+	CreatedAt   string         `json:"createdAt"`
+	UpdatedAt   string         `json:"updatedAt"`
+	DeletedAt   string         `json:"deletedAt,omitempty"`
+}
+
+// TaskProgress represents how many of a task's subtasks are completed
+type TaskProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
 }
 
 // CategoryInfo represents category information with task count
@@ -69,6 +124,12 @@ type CategoryInfo struct {
 	TaskCount int    `json:"taskCount"`
 }
 
+// TagInfo represents tag information with task count
+type TagInfo struct {
+	Name      string `json:"name"`
+	TaskCount int    `json:"taskCount"`
+}
+
 // CreateTask handles task creation requests
 // Creates a new task for the authenticated user
 func (h *TaskHandler) CreateTask(c *gin.Context) {
@@ -98,14 +159,52 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		})
 		return
 	}
+	priority := domain.TaskPriority(req.Priority)
+	if priority != "" && !priority.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": domain.ErrInvalidPriority.Error(),
+			"code":  "4022",
+		})
+		return
+	}
+	if strings.TrimSpace(req.Recurrence) != "" {
+		if _, err := domain.ParseRecurrenceRule(req.Recurrence); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "4023",
+			})
+			return
+		}
+	}
+	tags, err := domain.NormalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "4025",
+		})
+		return
+	}
 
 	// Create task
-	task, err := h.taskService.CreateTask(userID.(string), req.Description, req.Category)
+	task, err := h.taskService.CreateTask(userID.(string), req.Description, req.Category, domain.TaskOptions{
+		DueDate:    req.DueDate,
+		Priority:   priority,
+		Recurrence: req.Recurrence,
+		ParentID:   req.ParentID,
+		Tags:       tags,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create task",
-			"code":  "4016",
-		})
+		if errors.Is(err, domain.ErrParentTaskNotFound) || errors.Is(err, domain.ErrNestedSubtask) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.Unwrap(err).Error(),
+				"code":  "4024",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create task",
+				"code":  "4016",
+			})
+		}
 		return
 	}
 
@@ -114,7 +213,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 }
 
 // GetTask handles requests to get a specific task by ID
-// Returns task details for the authenticated user
+// Returns task details for the authenticated user, including subtasks and their progress
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -136,7 +235,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	task, err := h.taskService.GetTaskByID(taskID, userID.(string))
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
+		if errors.Is(err, domain.ErrTaskNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Task not found",
 				"code":  "4017",
@@ -150,11 +249,125 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
+	// Subtasks cannot have subtasks of their own
+	if !task.IsSubtask() {
+		subtasks, err := h.taskService.ListSubtasks(taskID, userID.(string))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve task",
+				"code":  "4018",
+			})
+			return
+		}
+		task.Subtasks = subtasks
+	}
+
 	c.JSON(http.StatusOK, h.taskToResponse(task))
 }
 
+// ListSubtasks handles requests to list the subtasks of a task
+// Returns the active subtasks in creation order along with how many are completed
+func (h *TaskHandler) ListSubtasks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task ID is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	subtasks, err := h.taskService.ListSubtasks(taskID, userID.(string))
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Task not found",
+				"code":  "4017",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve subtasks",
+				"code":  "4019",
+			})
+		}
+		return
+	}
+
+	// Convert to response format
+	parent := &domain.Task{Subtasks: subtasks}
+	completed, total := parent.SubtaskProgress()
+	subtaskResponses := make([]TaskResponse, len(subtasks))
+	for i, subtask := range subtasks {
+		subtaskResponses[i] = h.taskToResponse(subtask)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subtasks":  subtaskResponses,
+		"total":     total,
+		"completed": completed,
+	})
+}
+
+// GetTaskHistory handles requests to list the occurrences of a recurring task
+// Returns every task in the series ordered by occurrence, or just the task if it does not recur
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task ID is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	tasks, err := h.taskService.GetTaskHistory(taskID, userID.(string))
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Task not found",
+				"code":  "4017",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve task history",
+				"code":  "4019",
+			})
+		}
+		return
+	}
+
+	// Convert to response format
+	history := make([]TaskResponse, len(tasks))
+	for i, task := range tasks {
+		history[i] = h.taskToResponse(task)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+		"total":   len(history),
+	})
+}
+
 // ListTasks handles requests to list tasks with optional filters
-// Returns paginated list of tasks for the authenticated user
+// Returns one page of the authenticated user's tasks with the total count and the cursor of the next page
 func (h *TaskHandler) ListTasks(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -169,8 +382,40 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 	filters := domain.TaskFilters{
 		Category:       c.Query("category"),
 		IncludeDeleted: c.Query("includeDeleted") == "true",
-		Limit:          100, // Default limit
-		Offset:         0,   // Default offset
+		Limit:          domain.DefaultTaskPageSize, // Default limit
+		Offset:         0,                          // Default offset
+		Cursor:         c.Query("cursor"),
+	}
+
+	// Parse pagination parameters
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit, expected a number between 1 and 1000",
+				"code":  "4028",
+			})
+			return
+		}
+		filters.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid offset, expected a non-negative number",
+				"code":  "4028",
+			})
+			return
+		}
+		filters.Offset = offset
+	}
+	if filters.Cursor != "" && filters.Offset > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cursor cannot be combined with offset",
+			"code":  "4028",
+		})
+		return
 	}
 
 	// Parse completed filter
@@ -182,8 +427,86 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		}
 	}
 
+	// Parse due date filters
+	for param, target := range map[string]**time.Time{
+		"dueBefore": &filters.DueBefore,
+		"dueAfter":  &filters.DueAfter,
+	} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid " + param + " date, expected RFC 3339 format",
+					"code":  "4021",
+				})
+				return
+			}
+			*target = &parsed
+		}
+	}
+	filters.Overdue = c.Query("overdue") == "true"
+
+	// Parse priority filter
+	if priority := c.Query("priority"); priority != "" {
+		filters.Priority = domain.TaskPriority(priority)
+		if !filters.Priority.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": domain.ErrInvalidPriority.Error(),
+				"code":  "4022",
+			})
+			return
+		}
+	}
+
+	// Parse tag filters (comma-separated, matching any tag unless tagMatch=all)
+	if tags := c.Query("tags"); tags != "" {
+		normalized, err := domain.NormalizeTags(strings.Split(tags, ","))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "4025",
+			})
+			return
+		}
+		filters.Tags = normalized
+	}
+	if tagMatch := c.Query("tagMatch"); tagMatch != "" {
+		if tagMatch != domain.TagMatchAny && tagMatch != domain.TagMatchAll {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": domain.ErrInvalidTagMatch.Error(),
+				"code":  "4025",
+			})
+			return
+		}
+		filters.TagMatch = tagMatch
+	}
+
+	// Parse sort order
+	if sortBy := c.Query("sort"); sortBy != "" {
+		if sortBy != domain.TaskSortCreated && sortBy != domain.TaskSortDue && sortBy != domain.TaskSortPriority {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid sort value",
+				"code":  "4021",
+			})
+			return
+		}
+		filters.SortBy = sortBy
+	}
+
+	if err := filters.Validate(); err != nil {
+		code := "4021"
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			code = "4028"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
 	// Get tasks from service
-	tasks, err := h.taskService.ListTasks(userID.(string), filters)
+	page, err := h.taskService.ListTasks(userID.(string), filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve tasks",
@@ -192,6 +515,77 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		return
 	}
 
+	// Convert to response format
+	taskResponses := make([]TaskResponse, len(page.Tasks))
+	for i, task := range page.Tasks {
+		taskResponses[i] = h.taskToResponse(task)
+	}
+
+	// nextCursor is null on the last page
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":      taskResponses,
+		"total":      page.Total,
+		"nextCursor": nextCursor,
+	})
+}
+
+// SearchTasks handles full-text search requests over task descriptions
+// Returns the user's active tasks matching every query word, most relevant first
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Search query is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	// Parse optional result limit
+	limit := domain.DefaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > domain.MaxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": domain.ErrInvalidSearchLimit.Error(),
+				"code":  "4027",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	// Search tasks through service
+	tasks, err := h.taskService.SearchTasks(userID.(string), query, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSearchQuery) || errors.Is(err, domain.ErrInvalidSearchLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "4027",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search tasks",
+			"code":  "4019",
+		})
+		return
+	}
+
 	// Convert to response format
 	taskResponses := make([]TaskResponse, len(tasks))
 	for i, task := range tasks {
@@ -237,11 +631,127 @@ func (h *TaskHandler) UpdateTaskCompletion(c *gin.Context) {
 	// Update task completion
 	task, err := h.taskService.UpdateTaskCompletion(taskID, userID.(string), req.Completed)
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Task not found",
+				"code":  "4017",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update task",
+				"code":  "4018",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.taskToResponse(task))
+}
+
+// UpdateTask handles requests to edit a task's description or category
+// Applies a partial update to a task owned by the authenticated user
+func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task ID is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	var req UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	updates := domain.TaskUpdate{
+		Description:  req.Description,
+		Category:     req.Category,
+		DueDate:      req.DueDate,
+		ClearDueDate: req.ClearDueDate,
+		Recurrence:   req.Recurrence,
+		Tags:         req.Tags,
+	}
+	if req.Priority != nil {
+		priority := domain.TaskPriority(*req.Priority)
+		updates.Priority = &priority
+	}
+
+	// Validate fields explicitly
+	if updates.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "At least one field must be provided",
+			"code":  "4015",
+		})
+		return
+	}
+	if req.Description != nil && strings.TrimSpace(*req.Description) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Task description cannot be empty",
+			"code":  "4015",
+		})
+		return
+	}
+	if req.DueDate != nil && req.ClearDueDate {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Due date cannot be both set and cleared",
+			"code":  "4021",
+		})
+		return
+	}
+	if updates.Priority != nil && !updates.Priority.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": domain.ErrInvalidPriority.Error(),
+			"code":  "4022",
+		})
+		return
+	}
+	if req.Recurrence != nil && strings.TrimSpace(*req.Recurrence) != "" {
+		if _, err := domain.ParseRecurrenceRule(*req.Recurrence); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "4023",
+			})
+			return
+		}
+	}
+	if req.Tags != nil {
+		if _, err := domain.NormalizeTags(*req.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "4025",
+			})
+			return
+		}
+	}
+
+	// Update task
+	task, err := h.taskService.UpdateTask(taskID, userID.(string), updates)
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Task not found",
 				"code":  "4017",
 			})
+		} else if isTaskValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.Unwrap(err).Error(),
+				"code":  "4015",
+			})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to update task",
@@ -277,7 +787,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 	err := h.taskService.SoftDeleteTask(taskID, userID.(string))
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
+		if errors.Is(err, domain.ErrTaskNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Task not found",
 				"code":  "4017",
@@ -319,11 +829,16 @@ func (h *TaskHandler) RestoreTask(c *gin.Context) {
 
 	task, err := h.taskService.RestoreTask(taskID, userID.(string))
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
+		if errors.Is(err, domain.ErrTaskNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Task not found",
 				"code":  "4017",
 			})
+		} else if errors.Is(err, domain.ErrParentTaskDeleted) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Restore the parent task first",
+				"code":  "4024",
+			})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to restore task",
@@ -474,6 +989,179 @@ func (h *TaskHandler) DeleteCategory(c *gin.Context) {
 	})
 }
 
+// GetTags handles requests to get user tags
+// Returns the tags used by the authenticated user with their active task counts
+func (h *TaskHandler) GetTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	tags, err := h.taskService.GetUserTags(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve tags",
+			"code":  "4019",
+		})
+		return
+	}
+
+	tagInfos := make([]TagInfo, len(tags))
+	for i, tag := range tags {
+		tagInfos[i] = TagInfo{
+			Name:      tag.Name,
+			TaskCount: tag.TaskCount,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tagInfos,
+	})
+}
+
+// RenameTag handles requests to rename a tag
+// Updates the tag on all tasks of the authenticated user; the new name must not be in use
+func (h *TaskHandler) RenameTag(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	tagName := c.Param("tagName")
+	if tagName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tag name is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.NewName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "New tag name is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	if err := h.taskService.RenameTag(userID.(string), tagName, req.NewName); err != nil {
+		h.respondTagError(c, err, "Failed to rename tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag renamed successfully",
+	})
+}
+
+// MergeTags handles requests to merge a tag into another tag
+// Moves every task of the authenticated user from the source tag to the target tag
+func (h *TaskHandler) MergeTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+			"code":  "4001",
+		})
+		return
+	}
+
+	tagName := c.Param("tagName")
+	if tagName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tag name is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	var req MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON format",
+			"code":  "4006",
+		})
+		return
+	}
+
+	if req.Into == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Target tag name is required",
+			"code":  "4015",
+		})
+		return
+	}
+
+	if err := h.taskService.MergeTags(userID.(string), tagName, req.Into); err != nil {
+		h.respondTagError(c, err, "Failed to merge tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tags merged successfully",
+	})
+}
+
+// isTaskValidationError reports whether a service error rejects the task's fields rather than failing to save them
+func isTaskValidationError(err error) bool {
+	for _, target := range []error{
+		domain.ErrTaskInvalidDescription,
+		domain.ErrTaskDescriptionTooLong,
+		domain.ErrInvalidPriority,
+		domain.ErrInvalidRecurrence,
+		domain.ErrTooManyTags,
+		domain.ErrInvalidTag,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// respondTagError writes the error response for a failed tag rename or merge
+func (h *TaskHandler) respondTagError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tag not found",
+			"code":  "4017",
+		})
+	case errors.Is(err, domain.ErrTagExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tag already exists",
+			"code":  "4026",
+		})
+	case errors.Is(err, domain.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": domain.ErrInvalidTag.Error(),
+			"code":  "4025",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+			"code":  "4019",
+		})
+	}
+}
+
 // taskToResponse converts a domain Task to TaskResponse
 // Handles proper formatting of timestamps and optional fields
 func (h *TaskHandler) taskToResponse(task *domain.Task) TaskResponse {
@@ -482,14 +1170,42 @@ func (h *TaskHandler) taskToResponse(task *domain.Task) TaskResponse {
 		UserID:      task.UserID,
 		Description: task.Description,
 		Category:    task.Category,
+		Tags:        task.Tags,
 		Completed:   task.Completed,
+		Priority:    string(task.Priority),
+		Recurrence:  task.Recurrence,
+		SeriesID:    task.SeriesID,
+		Occurrence:  task.Occurrence,
+		ParentID:    task.ParentID,
 		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
+	if response.Priority == "" {
+		response.Priority = string(domain.PriorityNone)
+	}
+
+	if task.DueDate != nil {
+		response.DueDate = task.DueDate.UTC().Format(time.RFC3339)
+		response.Overdue = task.IsOverdue()
+	}
+
+	if task.CompletedAt != nil {
+		response.CompletedAt = task.CompletedAt.UTC().Format(time.RFC3339)
+	}
+
 	if task.DeletedAt != nil {
 		response.DeletedAt = task.DeletedAt.Format("2006-01-02T15:04:05Z")
 	}
 
+	if len(task.Subtasks) > 0 {
+		completed, total := task.SubtaskProgress()
+		response.Progress = &TaskProgress{Completed: completed, Total: total}
+		response.Subtasks = make([]TaskResponse, len(task.Subtasks))
+		for i, subtask := range task.Subtasks {
+			response.Subtasks[i] = h.taskToResponse(subtask)
+		}
+	}
+
 	return response
 }
\ No newline at end of file
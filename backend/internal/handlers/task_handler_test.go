@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,13 +20,14 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name           string
-		userID         string
-		requestBody    interface{}
-		mockResponse   *domain.Task
-		mockError      error
-		expectedStatus int
-		expectedCode   string
+		name            string
+		userID          string
+		requestBody     interface{}
+		expectedOptions domain.TaskOptions
+		mockResponse    *domain.Task
+		mockError       error
+		expectedStatus  int
+		expectedCode    string
 	}{
 		{
 			name:   "Successful task creation",
@@ -46,6 +48,77 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name:   "Successful task creation with priority",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"category":    "work",
+				"priority":    "high",
+			},
+			expectedOptions: domain.TaskOptions{Priority: domain.PriorityHigh},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Complete project documentation",
+				Category:    "work",
+				Priority:    domain.PriorityHigh,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			mockError:      nil,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "Invalid priority",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"priority":    "critical",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4022",
+		},
+		{
+			name:   "Successful task creation with tags",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"category":    "work",
+				"tags":        []string{"Docs", "q3", "docs"},
+			},
+			expectedOptions: domain.TaskOptions{Tags: []string{"docs", "q3"}},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Complete project documentation",
+				Category:    "work",
+				Tags:        []string{"docs", "q3"},
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "Invalid tags",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"tags":        []string{"a,b"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4025",
+		},
+		{
+			name:   "Invalid recurrence",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"recurrence":  "FREQ=FORTNIGHTLY",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4023",
+		},
 		{
 			name:   "Missing description",
 			userID: "user-123",
@@ -78,6 +151,32 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedCode:   "4016",
 		},
+		{
+			name:   "Invalid parent task",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"category":    "work",
+				"parentId":    "task-999",
+			},
+			expectedOptions: domain.TaskOptions{ParentID: "task-999"},
+			mockError:       fmt.Errorf("3026: %w", domain.ErrParentTaskNotFound),
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "4024",
+		},
+		{
+			name:   "Nested subtask",
+			userID: "user-123",
+			requestBody: map[string]interface{}{
+				"description": "Complete project documentation",
+				"category":    "work",
+				"parentId":    "subtask-1",
+			},
+			expectedOptions: domain.TaskOptions{ParentID: "subtask-1"},
+			mockError:       fmt.Errorf("3026: %w", domain.ErrNestedSubtask),
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "4024",
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,10 +185,10 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 			mockService := new(mocks.MockTaskService)
 
 			if tt.mockError == nil && tt.expectedStatus == http.StatusCreated {
-				mockService.On("CreateTask", tt.userID, "Complete project documentation", "work").
+				mockService.On("CreateTask", tt.userID, "Complete project documentation", "work", tt.expectedOptions).
 					Return(tt.mockResponse, nil)
 			} else if tt.mockError != nil {
-				mockService.On("CreateTask", tt.userID, "Complete project documentation", "work").
+				mockService.On("CreateTask", tt.userID, "Complete project documentation", "work", tt.expectedOptions).
 					Return((*domain.Task)(nil), tt.mockError)
 			}
 
@@ -135,13 +234,15 @@ func TestTaskHandler_GetTask(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name           string
-		userID         string
-		taskID         string
-		mockResponse   *domain.Task
-		mockError      error
-		expectedStatus int
-		expectedCode   string
+		name             string
+		userID           string
+		taskID           string
+		mockResponse     *domain.Task
+		mockError        error
+		mockSubtasks     []*domain.Task
+		expectedStatus   int
+		expectedCode     string
+		expectedProgress *TaskProgress
 	}{
 		{
 			name:   "Successful get task",
@@ -159,6 +260,24 @@ func TestTaskHandler_GetTask(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:   "Get task with subtask progress",
+			userID: "user-123",
+			taskID: "task-123",
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Release checklist",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			mockSubtasks: []*domain.Task{
+				{ID: "subtask-1", UserID: "user-123", ParentID: "task-123", Description: "Write changelog", Completed: true},
+				{ID: "subtask-2", UserID: "user-123", ParentID: "task-123", Description: "Tag release"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedProgress: &TaskProgress{Completed: 1, Total: 2},
+		},
 		{
 			name:           "Task not found",
 			userID:         "user-123",
@@ -184,6 +303,9 @@ func TestTaskHandler_GetTask(t *testing.T) {
 			// Setup mock service
 			mockService := new(mocks.MockTaskService)
 			mockService.On("GetTaskByID", tt.taskID, tt.userID).Return(tt.mockResponse, tt.mockError)
+			if tt.mockError == nil {
+				mockService.On("ListSubtasks", tt.taskID, tt.userID).Return(tt.mockSubtasks, nil)
+			}
 
 			// Create handler
 			handler := NewTaskHandler(mockService)
@@ -208,25 +330,275 @@ func TestTaskHandler_GetTask(t *testing.T) {
 				assert.Contains(t, w.Body.String(), tt.expectedCode)
 			}
 
+			if tt.expectedStatus == http.StatusOK {
+				var response TaskResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedProgress, response.Progress)
+				assert.Len(t, response.Subtasks, len(tt.mockSubtasks))
+			}
+
 			// Verify mock expectations
 			mockService.AssertExpectations(t)
 		})
 	}
 }
 
+func TestTaskHandler_TaskToResponseFormatsTimesInUTC(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	dueDate := time.Date(2024, 3, 10, 9, 30, 0, 0, zone)
+	completedAt := time.Date(2024, 3, 9, 1, 15, 0, 0, zone)
+
+	handler := NewTaskHandler(new(mocks.MockTaskService))
+	response := handler.taskToResponse(&domain.Task{
+		ID:          "task-123",
+		UserID:      "user-123",
+		Description: "Zoned task",
+		Completed:   true,
+		DueDate:     &dueDate,
+		CompletedAt: &completedAt,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	})
+
+	assert.Equal(t, "2024-03-10T07:30:00Z", response.DueDate)
+	assert.Equal(t, "2024-03-08T23:15:00Z", response.CompletedAt)
+}
+
+func TestTaskHandler_GetTaskHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	completedAt := time.Now()
+	series := []*domain.Task{
+		{
+			ID:          "task-1",
+			UserID:      "user-123",
+			Description: "Weekly report",
+			Completed:   true,
+			CompletedAt: &completedAt,
+			Recurrence:  "FREQ=WEEKLY",
+			SeriesID:    "task-1",
+			Occurrence:  1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		},
+		{
+			ID:          "task-2",
+			UserID:      "user-123",
+			Description: "Weekly report",
+			Recurrence:  "FREQ=WEEKLY",
+			SeriesID:    "task-1",
+			Occurrence:  2,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		taskID         string
+		mockResponse   []*domain.Task
+		mockError      error
+		expectedStatus int
+		expectedCode   string
+		expectedTotal  int
+	}{
+		{
+			name:           "Successful history retrieval",
+			taskID:         "task-2",
+			mockResponse:   series,
+			expectedStatus: http.StatusOK,
+			expectedTotal:  2,
+		},
+		{
+			name:           "Task not found",
+			taskID:         "nonexistent-task",
+			mockError:      fmt.Errorf("3017: %w", domain.ErrTaskNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "4017",
+		},
+		{
+			name:           "Service error",
+			taskID:         "task-2",
+			mockError:      errors.New("service error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "4019",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockTaskService)
+			mockService.On("GetTaskHistory", tt.taskID, "user-123").Return(tt.mockResponse, tt.mockError)
+
+			handler := NewTaskHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/tasks/"+tt.taskID+"/history", nil)
+			c.Params = []gin.Param{{Key: "id", Value: tt.taskID}}
+			c.Set("userID", "user-123")
+
+			handler.GetTaskHistory(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					History []TaskResponse `json:"history"`
+					Total   int            `json:"total"`
+				}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedTotal, response.Total)
+				assert.Equal(t, 1, response.History[0].Occurrence)
+				assert.NotEmpty(t, response.History[0].CompletedAt)
+				assert.Equal(t, "FREQ=WEEKLY", response.History[1].Recurrence)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_ListSubtasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	subtasks := []*domain.Task{
+		{ID: "subtask-1", UserID: "user-123", ParentID: "task-123", Description: "Write changelog", Completed: true},
+		{ID: "subtask-2", UserID: "user-123", ParentID: "task-123", Description: "Tag release"},
+	}
+
+	tests := []struct {
+		name           string
+		mockResponse   []*domain.Task
+		mockError      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Successful subtask listing",
+			mockResponse:   subtasks,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Task not found",
+			mockError:      fmt.Errorf("3017: %w", domain.ErrTaskNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "4017",
+		},
+		{
+			name:           "Service error",
+			mockError:      errors.New("service error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "4019",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockTaskService)
+			mockService.On("ListSubtasks", "task-123", "user-123").Return(tt.mockResponse, tt.mockError)
+
+			handler := NewTaskHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/tasks/task-123/subtasks", nil)
+			c.Params = []gin.Param{{Key: "id", Value: "task-123"}}
+			c.Set("userID", "user-123")
+
+			handler.ListSubtasks(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Subtasks  []TaskResponse `json:"subtasks"`
+					Total     int            `json:"total"`
+					Completed int            `json:"completed"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Len(t, response.Subtasks, 2)
+				assert.Equal(t, "task-123", response.Subtasks[0].ParentID)
+				assert.Equal(t, 2, response.Total)
+				assert.Equal(t, 1, response.Completed)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTaskHandler_ListTasks(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	cursor := domain.TaskCursor{SortBy: domain.TaskSortCreated, Key: []int64{0, -1700000000}, ID: "task-0"}.Encode()
+
 	tests := []struct {
 		name           string
 		userID         string
 		queryParams    map[string]string
 		expectedFilters domain.TaskFilters
 		mockResponse   []*domain.Task
+		mockTotal      int
+		mockNextCursor string
 		mockError      error
 		expectedStatus int
 		expectedCode   string
 	}{
+		{
+			name:   "List tasks with limit and cursor",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"limit":  "1",
+				"cursor": cursor,
+			},
+			expectedFilters: domain.TaskFilters{
+				Limit:  1,
+				Cursor: cursor,
+			},
+			mockResponse: []*domain.Task{
+				{ID: "task-1", UserID: "user-123", Description: "Task 1"},
+			},
+			mockTotal:      3,
+			mockNextCursor: "next-page",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Invalid limit",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"limit": "0",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4028",
+		},
+		{
+			name:   "Invalid cursor",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"cursor": "not-a-cursor",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4028",
+		},
+		{
+			name:   "Cursor with offset",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"cursor": cursor,
+				"offset": "10",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4028",
+		},
 		{
 			name:   "Successful list tasks with no filters",
 			userID: "user-123",
@@ -249,6 +621,33 @@ func TestTaskHandler_ListTasks(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:   "List tasks with all-of tag filter",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"tags":     "Work, urgent",
+				"tagMatch": "all",
+			},
+			expectedFilters: domain.TaskFilters{
+				Tags:     []string{"work", "urgent"},
+				TagMatch: domain.TagMatchAll,
+				Limit:    100,
+				Offset:   0,
+			},
+			mockResponse:   []*domain.Task{},
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "List tasks with invalid tag match",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"tags":     "work",
+				"tagMatch": "some",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4025",
+		},
 		{
 			name:   "List tasks with category filter",
 			userID: "user-123",
@@ -294,6 +693,94 @@ func TestTaskHandler_ListTasks(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:   "List tasks with due date range and due sort",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"dueAfter":  "2024-01-01T00:00:00Z",
+				"dueBefore": "2024-02-01T00:00:00Z",
+				"sort":      "due",
+			},
+			expectedFilters: domain.TaskFilters{
+				DueAfter:  func() *time.Time { t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+				DueBefore: func() *time.Time { t := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+				SortBy:    domain.TaskSortDue,
+				Limit:     100,
+				Offset:    0,
+			},
+			mockResponse:   []*domain.Task{},
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "List overdue tasks",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"overdue": "true",
+			},
+			expectedFilters: domain.TaskFilters{
+				Overdue: true,
+				Limit:   100,
+				Offset:  0,
+			},
+			mockResponse:   []*domain.Task{},
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "List tasks with priority filter and priority sort",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"priority": "urgent",
+				"sort":     "priority",
+			},
+			expectedFilters: domain.TaskFilters{
+				Priority: domain.PriorityUrgent,
+				SortBy:   domain.TaskSortPriority,
+				Limit:    100,
+				Offset:   0,
+			},
+			mockResponse:   []*domain.Task{},
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Invalid priority filter",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"priority": "critical",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4022",
+		},
+		{
+			name:   "Invalid due date filter",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"dueBefore": "next tuesday",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4021",
+		},
+		{
+			name:   "Inverted due date range",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"dueAfter":  "2024-02-01T00:00:00Z",
+				"dueBefore": "2024-01-01T00:00:00Z",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4021",
+		},
+		{
+			name:   "Invalid sort value",
+			userID: "user-123",
+			queryParams: map[string]string{
+				"sort": "alphabetical",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4021",
+		},
 		{
 			name:   "Service error",
 			userID: "user-123",
@@ -313,7 +800,16 @@ func TestTaskHandler_ListTasks(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mock service
 			mockService := new(mocks.MockTaskService)
-			mockService.On("ListTasks", tt.userID, tt.expectedFilters).Return(tt.mockResponse, tt.mockError)
+			if tt.expectedStatus != http.StatusBadRequest {
+				var page *domain.TaskPage
+				if tt.mockError == nil {
+					page = &domain.TaskPage{Tasks: tt.mockResponse, Total: len(tt.mockResponse), NextCursor: tt.mockNextCursor}
+					if tt.mockTotal > 0 {
+						page.Total = tt.mockTotal
+					}
+				}
+				mockService.On("ListTasks", tt.userID, tt.expectedFilters).Return(page, tt.mockError)
+			}
 
 			// Create handler
 			handler := NewTaskHandler(mockService)
@@ -338,23 +834,130 @@ func TestTaskHandler_ListTasks(t *testing.T) {
 			// Execute
 			handler.ListTasks(c)
 
-			// Assert
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+
+			// For successful responses, check the response structure
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response, "tasks")
+				assert.Contains(t, response, "total")
+				assert.Contains(t, response, "nextCursor")
+
+				if tt.mockTotal > 0 {
+					assert.Equal(t, float64(tt.mockTotal), response["total"])
+				}
+				if tt.mockNextCursor != "" {
+					assert.Equal(t, tt.mockNextCursor, response["nextCursor"])
+				} else {
+					assert.Nil(t, response["nextCursor"])
+				}
+			}
+
+			// Verify mock expectations
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_SearchTasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	results := []*domain.Task{
+		{ID: "task-1", UserID: "user-123", Description: "Deploy the website"},
+		{ID: "task-2", UserID: "user-123", Description: "Deployment checklist"},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockLimit      int
+		mockResponse   []*domain.Task
+		mockError      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Successful search",
+			query:          "q=deploy",
+			mockLimit:      domain.DefaultSearchLimit,
+			mockResponse:   results,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Search with limit",
+			query:          "q=deploy&limit=5",
+			mockLimit:      5,
+			mockResponse:   results,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing query",
+			query:          "q=%20",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4015",
+		},
+		{
+			name:           "Invalid limit",
+			query:          "q=deploy&limit=500",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4027",
+		},
+		{
+			name:           "Query without words",
+			query:          "q=%3F%21",
+			mockLimit:      domain.DefaultSearchLimit,
+			mockError:      fmt.Errorf("3029: %w", domain.ErrInvalidSearchQuery),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4027",
+		},
+		{
+			name:           "Service error",
+			query:          "q=deploy",
+			mockLimit:      domain.DefaultSearchLimit,
+			mockError:      errors.New("service error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "4019",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/tasks/search?"+tt.query, nil)
+			c.Set("userID", "user-123")
+
+			mockService := new(mocks.MockTaskService)
+			if tt.mockLimit != 0 {
+				mockService.On("SearchTasks", "user-123", c.Query("q"), tt.mockLimit).Return(tt.mockResponse, tt.mockError)
+			}
+
+			handler := NewTaskHandler(mockService)
+
+			handler.SearchTasks(c)
+
 			assert.Equal(t, tt.expectedStatus, w.Code)
-
 			if tt.expectedCode != "" {
 				assert.Contains(t, w.Body.String(), tt.expectedCode)
 			}
 
-			// For successful responses, check the response structure
 			if tt.expectedStatus == http.StatusOK {
-				var response map[string]interface{}
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Contains(t, response, "tasks")
-				assert.Contains(t, response, "total")
+				var response struct {
+					Tasks []TaskResponse `json:"tasks"`
+					Total int            `json:"total"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 2, response.Total)
+				assert.Equal(t, "task-1", response.Tasks[0].ID)
 			}
 
-			// Verify mock expectations
 			mockService.AssertExpectations(t)
 		})
 	}
@@ -468,6 +1071,251 @@ func TestTaskHandler_UpdateTaskCompletion(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_UpdateTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	description := "Fixed description"
+	category := "personal"
+	lowPriority := domain.PriorityLow
+
+	tests := []struct {
+		name            string
+		userID          string
+		taskID          string
+		requestBody     interface{}
+		expectedUpdates *domain.TaskUpdate
+		mockResponse    *domain.Task
+		mockError       error
+		expectedStatus  int
+		expectedCode    string
+	}{
+		{
+			name:   "Successful description and category update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"description": description,
+				"category":    category,
+			},
+			expectedUpdates: &domain.TaskUpdate{Description: &description, Category: &category},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: description,
+				Category:    category,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Successful category-only update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"category": category,
+			},
+			expectedUpdates: &domain.TaskUpdate{Category: &category},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Existing description",
+				Category:    category,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid JSON",
+			userID:         "user-123",
+			taskID:         "task-123",
+			requestBody:    `{"description": invalid-json}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4006",
+		},
+		{
+			name:   "Successful due date clear",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"clearDueDate": true,
+			},
+			expectedUpdates: &domain.TaskUpdate{ClearDueDate: true},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Existing description",
+				Category:    "work",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Successful priority update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"priority": "low",
+			},
+			expectedUpdates: &domain.TaskUpdate{Priority: &lowPriority},
+			mockResponse: &domain.Task{
+				ID:          "task-123",
+				UserID:      "user-123",
+				Description: "Existing description",
+				Priority:    domain.PriorityLow,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Invalid priority update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"priority": "critical",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4022",
+		},
+		{
+			name:   "Invalid recurrence update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"recurrence": "FREQ=DAILY;INTERVAL=-1",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4023",
+		},
+		{
+			name:   "Invalid tags update",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"tags": []string{"bad,tag"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4025",
+		},
+		{
+			name:   "Due date set and cleared together",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"dueDate":      "2024-01-01T00:00:00Z",
+				"clearDueDate": true,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4021",
+		},
+		{
+			name:           "Empty update",
+			userID:         "user-123",
+			taskID:         "task-123",
+			requestBody:    map[string]interface{}{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4015",
+		},
+		{
+			name:   "Blank description",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"description": "   ",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4015",
+		},
+		{
+			name:   "Task not found",
+			userID: "user-123",
+			taskID: "nonexistent-task",
+			requestBody: map[string]interface{}{
+				"description": description,
+			},
+			expectedUpdates: &domain.TaskUpdate{Description: &description},
+			mockError:       fmt.Errorf("3017: %w", domain.ErrTaskNotFound),
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    "4017",
+		},
+		{
+			name:   "Merged task fails validation",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"description": description,
+			},
+			expectedUpdates: &domain.TaskUpdate{Description: &description},
+			mockError:       fmt.Errorf("3014: %w", domain.ErrInvalidRecurrence),
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "4015",
+		},
+		{
+			name:   "Service error",
+			userID: "user-123",
+			taskID: "task-123",
+			requestBody: map[string]interface{}{
+				"description": description,
+			},
+			expectedUpdates: &domain.TaskUpdate{Description: &description},
+			mockError:       errors.New("service error"),
+			expectedStatus:  http.StatusInternalServerError,
+			expectedCode:    "4018",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service
+			mockService := new(mocks.MockTaskService)
+
+			if tt.expectedUpdates != nil {
+				mockService.On("UpdateTask", tt.taskID, tt.userID, *tt.expectedUpdates).
+					Return(tt.mockResponse, tt.mockError)
+			}
+
+			// Create handler
+			handler := NewTaskHandler(mockService)
+
+			// Setup request
+			var body []byte
+			var err error
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, err = json.Marshal(tt.requestBody)
+				assert.NoError(t, err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req := httptest.NewRequest("PATCH", "/tasks/"+tt.taskID, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			c.Request = req
+			c.Params = []gin.Param{{Key: "id", Value: tt.taskID}}
+
+			// Set user context (simulating auth middleware)
+			c.Set("userID", tt.userID)
+
+			// Execute
+			handler.UpdateTask(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+
+			// Verify mock expectations
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTaskHandler_DeleteTask(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -577,6 +1425,15 @@ func TestTaskHandler_RestoreTask(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedCode:   "4017",
 		},
+		{
+			name:           "Parent task still deleted",
+			userID:         "user-123",
+			taskID:         "subtask-1",
+			mockResponse:   nil,
+			mockError:      fmt.Errorf("3027: %w", domain.ErrParentTaskDeleted),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "4024",
+		},
 	}
 
 	for _, tt := range tests {
@@ -796,6 +1653,149 @@ func TestTaskHandler_RenameCategory(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_GetTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(mocks.MockTaskService)
+	mockService.On("GetUserTags", "user-123").Return([]domain.TagSummary{
+		{Name: "home", TaskCount: 1},
+		{Name: "work", TaskCount: 3},
+	}, nil)
+
+	handler := NewTaskHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/tags", nil)
+	c.Set("userID", "user-123")
+
+	handler.GetTags(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Tags []TagInfo `json:"tags"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []TagInfo{{Name: "home", TaskCount: 1}, {Name: "work", TaskCount: 3}}, response.Tags)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_RenameAndMergeTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		merge          bool
+		tagName        string
+		requestBody    interface{}
+		mockTarget     string
+		mockError      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Successful rename",
+			tagName:        "work",
+			requestBody:    map[string]string{"newName": "office"},
+			mockTarget:     "office",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Rename to existing tag",
+			tagName:        "work",
+			requestBody:    map[string]string{"newName": "home"},
+			mockTarget:     "home",
+			mockError:      fmt.Errorf("3020: failed to rename tag: 2008: %w", domain.ErrTagExists),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "4026",
+		},
+		{
+			name:           "Rename missing tag",
+			tagName:        "missing",
+			requestBody:    map[string]string{"newName": "office"},
+			mockTarget:     "office",
+			mockError:      fmt.Errorf("3020: failed to rename tag: 2006: %w", domain.ErrTagNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "4017",
+		},
+		{
+			name:           "Rename without new name",
+			tagName:        "work",
+			requestBody:    map[string]string{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4015",
+		},
+		{
+			name:           "Successful merge",
+			merge:          true,
+			tagName:        "urgent",
+			requestBody:    map[string]string{"into": "work"},
+			mockTarget:     "work",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Merge with invalid target",
+			merge:          true,
+			tagName:        "urgent",
+			requestBody:    map[string]string{"into": "a,b"},
+			mockTarget:     "a,b",
+			mockError:      fmt.Errorf("3028: %w", domain.ErrInvalidTag),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "4025",
+		},
+		{
+			name:           "Merge service error",
+			merge:          true,
+			tagName:        "urgent",
+			requestBody:    map[string]string{"into": "work"},
+			mockTarget:     "work",
+			mockError:      errors.New("service error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "4019",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockTaskService)
+			method := "RenameTag"
+			if tt.merge {
+				method = "MergeTags"
+			}
+			if tt.mockTarget != "" {
+				mockService.On(method, "user-123", tt.tagName, tt.mockTarget).Return(tt.mockError)
+			}
+
+			handler := NewTaskHandler(mockService)
+
+			body, err := json.Marshal(tt.requestBody)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PUT", "/tags/"+tt.tagName, bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = []gin.Param{{Key: "tagName", Value: tt.tagName}}
+			c.Set("userID", "user-123")
+
+			if tt.merge {
+				handler.MergeTags(c)
+			} else {
+				handler.RenameTag(c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTaskHandler_DeleteCategory(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"backend/pkg/redis"
+
+	"github.com/google/uuid"
+)
+
+// Runner runs a background job at a fixed interval on a single replica
+// Replicas compete for a Redis leader lock; the holder renews it on every run and releases it when stopped
+type Runner struct {
+	client   *redis.Client
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+	token    string
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewRunner creates a runner for the named job
+// The name identifies the leader lock shared by every replica running the same job
+func NewRunner(client *redis.Client, name string, interval time.Duration, job func(ctx context.Context) error) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		client:   client,
+		name:     name,
+		interval: interval,
+		job:      job,
+		token:    uuid.New().String(),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the job immediately and then once per interval until Stop is called
+// Calling Start more than once, or after Stop, has no effect
+func (r *Runner) Start() {
+	r.startOnce.Do(r.loop)
+}
+
+// loop starts the goroutine that runs the job on every tick
+func (r *Runner) loop() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.RunOnce(r.ctx); err != nil && r.ctx.Err() == nil {
+				log.Printf("Job %s failed: %v", r.name, err)
+			}
+
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the running job, waits for it to finish and releases the leader lock
+// Returns the context error if the job does not finish before ctx is done
+func (r *Runner) Stop(ctx context.Context) error {
+	r.stopOnce.Do(r.cancel)
+	r.startOnce.Do(func() { close(r.done) }) // Never started, nothing to wait for
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return r.client.ReleaseLock(ctx, r.lockKey(), r.token)
+}
+
+// RunOnce runs the job if this runner holds or can take the leader lock
+// Returns false without running the job when another replica holds the lock
+func (r *Runner) RunOnce(ctx context.Context) (bool, error) {
+	// The lock outlives two intervals so a stopped leader is replaced within a few runs
+	leader, err := r.client.AcquireLock(ctx, r.lockKey(), r.token, 2*r.interval)
+	if err != nil || !leader {
+		return false, err
+	}
+
+	return true, r.job(ctx)
+}
+
+// lockKey returns the key of the leader lock shared by all runners of the job
+func (r *Runner) lockKey() string {
+	return redis.GenerateKey(redis.LockKeyPrefix, "job:"+r.name)
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	redislib "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRunnerRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+	rdb := redislib.NewClient(&redislib.Options{Addr: s.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return &redis.Client{Client: rdb}, s
+}
+
+func countingJob(runs *int32) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		atomic.AddInt32(runs, 1)
+		return nil
+	}
+}
+
+func TestRunner_RunOnce(t *testing.T) {
+	client, s := setupTestRunnerRedis(t)
+	ctx := context.Background()
+
+	var leaderRuns, followerRuns int32
+	leader := NewRunner(client, "cleanup", time.Minute, countingJob(&leaderRuns))
+	follower := NewRunner(client, "cleanup", time.Minute, countingJob(&followerRuns))
+
+	t.Run("should run the job and take the lock", func(t *testing.T) {
+		ran, err := leader.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&leaderRuns))
+		assert.True(t, s.Exists("lock:job:cleanup"))
+	})
+
+	t.Run("should skip the job while another runner holds the lock", func(t *testing.T) {
+		ran, err := follower.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.False(t, ran)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&followerRuns))
+	})
+
+	t.Run("should renew the lock for the holder", func(t *testing.T) {
+		s.FastForward(90 * time.Second)
+		ran, err := leader.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, 2*time.Minute, s.TTL("lock:job:cleanup"))
+	})
+
+	t.Run("should hand over the lock once it expires", func(t *testing.T) {
+		s.FastForward(3 * time.Minute)
+		ran, err := follower.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.True(t, ran)
+
+		ran, err = leader.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("should return job errors", func(t *testing.T) {
+		failing := NewRunner(client, "failing", time.Minute, func(ctx context.Context) error {
+			return errors.New("cleanup failed")
+		})
+		ran, err := failing.RunOnce(ctx)
+		assert.True(t, ran)
+		assert.EqualError(t, err, "cleanup failed")
+	})
+}
+
+func TestRunner_StartStop(t *testing.T) {
+	client, s := setupTestRunnerRedis(t)
+
+	t.Run("should run immediately and release the lock on stop", func(t *testing.T) {
+		var runs int32
+		runner := NewRunner(client, "cleanup", time.Hour, countingJob(&runs))
+		runner.Start()
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, time.Second, 5*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, runner.Stop(ctx))
+		assert.False(t, s.Exists("lock:job:cleanup"))
+	})
+
+	t.Run("should run on every tick", func(t *testing.T) {
+		var runs int32
+		runner := NewRunner(client, "ticking", 10*time.Millisecond, countingJob(&runs))
+		runner.Start()
+		defer runner.Stop(context.Background())
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 3 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should cancel the job context and wait for it on stop", func(t *testing.T) {
+		started := make(chan struct{})
+		var cancelled int32
+		runner := NewRunner(client, "slow", time.Hour, func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			atomic.StoreInt32(&cancelled, 1)
+			return ctx.Err()
+		})
+		runner.Start()
+		<-started
+
+		require.NoError(t, runner.Stop(context.Background()))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+	})
+
+	t.Run("should stop a runner that was never started", func(t *testing.T) {
+		runner := NewRunner(client, "idle", time.Hour, countingJob(new(int32)))
+		assert.NoError(t, runner.Stop(context.Background()))
+	})
+}
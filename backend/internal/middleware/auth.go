@@ -2,7 +2,11 @@ package middleware
 
 import (
 	"backend/internal/domain"
+	"backend/pkg/apitoken"
+	"backend/pkg/cookie"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,15 +17,37 @@ type UserRepository interface {
 	ValidateSession(sessionID string) (bool, error)
 	GetSessionUserID(sessionID string) (string, error)
 	GetByID(id string) (*domain.User, error)
+	TouchSession(sessionID string, client domain.SessionClient) (time.Time, error)
+}
+
+// APITokenRepository defines the interface needed to authenticate requests with personal API tokens
+type APITokenRepository interface {
+	GetAPIToken(tokenHash string) (*domain.APIToken, error)
+	TouchAPIToken(tokenHash string, at time.Time) error
+}
+
+// CookieSettings controls the session cookie re-issued when a sliding session is renewed
+type CookieSettings struct {
+	Secure   bool
+	HTTPOnly bool
 }
 
 // AuthMiddleware returns a middleware function that validates user sessions
-// Checks for valid session cookie and adds user context to the request
-func AuthMiddleware(userRepo UserRepository) gin.HandlerFunc {
+// Checks for a validly signed session cookie and adds user context to the request
+// Renewed remembered sessions get their cookie re-issued, so the browser keeps it as long as the server keeps the session
+func AuthMiddleware(userRepo UserRepository, signer *cookie.Signer, cookies CookieSettings) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get session cookie
-		sessionCookie, err := c.Cookie("session")
-		if err != nil || sessionCookie == "" {
+		signedCookie, err := c.Cookie("session")
+		if err != nil || signedCookie == "" {
+			if _, ok := bearerToken(c); ok {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "API tokens cannot be used for this endpoint",
+					"code":  "4055",
+				})
+				c.Abort()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Authentication required",
 				"code":  "4001",
@@ -30,6 +56,17 @@ func AuthMiddleware(userRepo UserRepository) gin.HandlerFunc {
 			return
 		}
 
+		// Reject forged or truncated cookies before touching Redis
+		sessionCookie, ok := signer.Verify(signedCookie)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid session",
+				"code":  "4002",
+			})
+			c.Abort()
+			return
+		}
+
 		// Validate session
 		isValid, err := userRepo.ValidateSession(sessionCookie)
 		if err != nil {
@@ -79,11 +116,179 @@ func AuthMiddleware(userRepo UserRepository) gin.HandlerFunc {
 			return
 		}
 
+		// Record the device activity; a failure here must not block the request
+		expiresAt, err := userRepo.TouchSession(sessionCookie, domain.SessionClient{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		if err == nil && !expiresAt.IsZero() {
+			maxAge := int(time.Until(expiresAt).Seconds())
+			c.SetCookie("session", signer.Sign(sessionCookie), maxAge, "/", "", cookies.Secure, cookies.HTTPOnly)
+		}
+
 		// Add user context to the request
 		c.Set("userID", userID)
 		c.Set("user", user)
+		c.Set("sessionID", sessionCookie)
 
 		// Continue to next handler
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// AuthMiddlewareWithTokens returns a middleware function that also accepts personal API tokens
+// Requests with an Authorization: Bearer header are authenticated by the token, which needs the tasks:read scope for reads and tasks:write for anything else; all other requests need a session cookie
+func AuthMiddlewareWithTokens(userRepo UserRepository, tokens APITokenRepository, signer *cookie.Signer, cookies CookieSettings) gin.HandlerFunc {
+	sessionAuth := AuthMiddleware(userRepo, signer, cookies)
+
+	return func(c *gin.Context) {
+		raw, ok := bearerToken(c)
+		if !ok {
+			sessionAuth(c)
+			return
+		}
+
+		// Reject values that cannot be tokens before touching Redis
+		tokenHash, ok := apitoken.Hash(raw)
+		if !ok {
+			invalidAPIToken(c)
+			return
+		}
+
+		token, err := tokens.GetAPIToken(tokenHash)
+		if err != nil {
+			if err == domain.ErrAPITokenNotFound {
+				invalidAPIToken(c)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "API token validation failed",
+					"code":  "4003",
+				})
+				c.Abort()
+			}
+			return
+		}
+
+		now := time.Now()
+		if !now.Before(token.ExpiresAt) {
+			invalidAPIToken(c)
+			return
+		}
+
+		scope := domain.ScopeTasksWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = domain.ScopeTasksRead
+		}
+		if !token.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API token does not have the required scope",
+				"code":  "4054",
+				"details": gin.H{
+					"requiredScope": scope,
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		user, err := userRepo.GetByID(token.UserID)
+		if err != nil {
+			if err == domain.ErrUserNotFound {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "User not found",
+					"code":  "4004",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to retrieve user details",
+					"code":  "4005",
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		// Record the use; a failure here must not block the request
+		_ = tokens.TouchAPIToken(tokenHash, now)
+
+		// Add user context to the request; there is no session to revoke or report
+		c.Set("userID", token.UserID)
+		c.Set("user", user)
+		c.Set("apiTokenID", token.ID)
+
+		c.Next()
+	}
+}
+
+// bearerToken returns the credentials of an Authorization: Bearer header, if the request has one
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, credentials, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	credentials = strings.TrimSpace(credentials)
+	return credentials, credentials != ""
+}
+
+// invalidAPIToken rejects a request whose API token is unknown, revoked or expired
+func invalidAPIToken(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error": "Invalid, expired or revoked API token",
+		"code":  "4053",
+	})
+	c.Abort()
+}
+
+// RequireVerifiedEmail returns a middleware function that rejects users whose email address is not verified
+// Must run after AuthMiddleware, which puts the user in the request context
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.Get("user")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+				"code":  "4001",
+			})
+			c.Abort()
+			return
+		}
+
+		if u, ok := user.(*domain.User); !ok || !u.EmailVerified {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Email address must be verified first",
+				"code":  "4039",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin returns a middleware function that rejects users who are not administrators
+// Must run after AuthMiddleware, which puts the user in the request context
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.Get("user")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+				"code":  "4001",
+			})
+			c.Abort()
+			return
+		}
+
+		if u, ok := user.(*domain.User); !ok || !u.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+				"code":  "4042",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"backend/internal/domain"
 	"backend/internal/mocks"
+	"backend/pkg/apitoken"
+	"backend/pkg/cookie"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -11,8 +13,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+// testSigner signs the session cookies sent in these tests
+var testSigner = cookie.NewSigner("test-secret-key")
+
+// testCookies are the attributes of session cookies re-issued in these tests
+var testCookies = CookieSettings{HTTPOnly: true}
+
 func TestAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -118,11 +128,14 @@ func TestAuthMiddleware(t *testing.T) {
 				if tt.mockSessionValid && tt.mockSessionError == nil {
 					mockRepo.On("GetSessionUserID", tt.sessionCookie).Return(tt.mockUserID, nil)
 					mockRepo.On("GetByID", tt.mockUserID).Return(tt.mockUser, tt.mockGetUserError)
+					if tt.mockGetUserError == nil {
+						mockRepo.On("TouchSession", tt.sessionCookie, mock.AnythingOfType("domain.SessionClient")).Return(time.Time{}, nil)
+					}
 				}
 			}
 
 			// Create middleware
-			authMiddleware := AuthMiddleware(mockRepo)
+			authMiddleware := AuthMiddleware(mockRepo, testSigner, testCookies)
 
 			// Setup test request
 			w := httptest.NewRecorder()
@@ -154,7 +167,7 @@ func TestAuthMiddleware(t *testing.T) {
 			if tt.sessionCookie != "" {
 				req.AddCookie(&http.Cookie{
 					Name:  "session",
-					Value: tt.sessionCookie,
+					Value: testSigner.Sign(tt.sessionCookie),
 				})
 			}
 
@@ -195,9 +208,10 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 	mockRepo.On("ValidateSession", sessionID).Return(true, nil)
 	mockRepo.On("GetSessionUserID", sessionID).Return(userID, nil)
 	mockRepo.On("GetByID", userID).Return(user, nil)
+	mockRepo.On("TouchSession", sessionID, domain.SessionClient{IP: "192.0.2.1", UserAgent: "integration-test"}).Return(time.Time{}, nil)
 
 	// Create middleware and router
-	authMiddleware := AuthMiddleware(mockRepo)
+	authMiddleware := AuthMiddleware(mockRepo, testSigner, testCookies)
 	router := gin.New()
 	router.Use(authMiddleware)
 
@@ -210,6 +224,7 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 		userObj, exists := c.Get("user")
 		assert.True(t, exists)
 		assert.Equal(t, user, userObj)
+		assert.Equal(t, sessionID, c.GetString("sessionID"))
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Access granted",
@@ -220,9 +235,11 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 	// Create request with valid session
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/protected", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "integration-test")
 	req.AddCookie(&http.Cookie{
 		Name:  "session",
-		Value: sessionID,
+		Value: testSigner.Sign(sessionID),
 	})
 
 	// Execute request
@@ -235,4 +252,361 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 
 	// Verify all expectations
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestAuthMiddleware_TouchSessionFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(mocks.MockUserRepository)
+	user := &domain.User{ID: "user-123", Email: "test@example.com"}
+	mockRepo.On("ValidateSession", "valid-session").Return(true, nil)
+	mockRepo.On("GetSessionUserID", "valid-session").Return(user.ID, nil)
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("TouchSession", "valid-session", mock.AnythingOfType("domain.SessionClient")).Return(time.Time{}, errors.New("redis unavailable"))
+
+	router := gin.New()
+	router.Use(AuthMiddleware(mockRepo, testSigner, testCookies))
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: testSigner.Sign("valid-session")})
+	router.ServeHTTP(w, req)
+
+	// Failing to record activity must not block an authenticated request
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthMiddleware_SlidingSessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &domain.User{ID: "user-123", Email: "test@example.com"}
+	newRouter := func(expiresAt time.Time) *gin.Engine {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("ValidateSession", "session-123").Return(true, nil)
+		mockRepo.On("GetSessionUserID", "session-123").Return(user.ID, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockRepo.On("TouchSession", "session-123", mock.AnythingOfType("domain.SessionClient")).Return(expiresAt, nil)
+
+		router := gin.New()
+		router.Use(AuthMiddleware(mockRepo, testSigner, CookieSettings{Secure: true, HTTPOnly: true}))
+		router.GET("/protected", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+		})
+		return router
+	}
+
+	request := func(router *gin.Engine) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: testSigner.Sign("session-123")})
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("renewed session re-issues the cookie with a fresh max age", func(t *testing.T) {
+		w := request(newRouter(time.Now().Add(30 * 24 * time.Hour)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "session", cookies[0].Name)
+		assert.Equal(t, testSigner.Sign("session-123"), cookies[0].Value)
+		assert.InDelta(t, 30*24*60*60, cookies[0].MaxAge, 5)
+		assert.True(t, cookies[0].Secure)
+		assert.True(t, cookies[0].HttpOnly)
+	})
+
+	t.Run("session that was not renewed keeps its cookie", func(t *testing.T) {
+		w := request(newRouter(time.Time{}))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestAuthMiddleware_SignedCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	signed := testSigner.Sign("session-123")
+	tests := []struct {
+		name   string
+		cookie string
+	}{
+		{name: "unsigned session ID", cookie: "session-123"},
+		{name: "forged signature", cookie: cookie.NewSigner("attacker-secret").Sign("session-123")},
+		{name: "truncated signature", cookie: signed[:len(signed)-6]},
+		{name: "tampered session ID", cookie: "session-124" + signed[len("session-123"):]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No expectations: Redis must not be consulted for a bad signature
+			mockRepo := new(mocks.MockUserRepository)
+
+			router := gin.New()
+			router.Use(AuthMiddleware(mockRepo, testSigner, testCookies))
+			router.GET("/protected", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: tt.cookie})
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), "4002")
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "ValidateSession", mock.Anything)
+		})
+	}
+
+	t.Run("accepts cookies signed with a previous secret", func(t *testing.T) {
+		user := &domain.User{ID: "user-123", Email: "test@example.com"}
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("ValidateSession", "session-123").Return(true, nil)
+		mockRepo.On("GetSessionUserID", "session-123").Return(user.ID, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockRepo.On("TouchSession", "session-123", mock.AnythingOfType("domain.SessionClient")).Return(time.Time{}, nil)
+
+		rotated := cookie.NewSigner("new-secret-key", "test-secret-key")
+		router := gin.New()
+		router.Use(AuthMiddleware(mockRepo, rotated, testCookies))
+		router.GET("/protected", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: testSigner.Sign("session-123")})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthMiddlewareWithTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &domain.User{ID: "user-123", Email: "test@example.com"}
+	raw, err := apitoken.Generate()
+	require.NoError(t, err)
+	tokenHash, _ := apitoken.Hash(raw)
+
+	newToken := func(scopes ...string) *domain.APIToken {
+		return &domain.APIToken{
+			ID:        "token-1",
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			Scopes:    scopes,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	serve := func(mockRepo *mocks.MockUserRepository, mockTokens *mocks.MockAPITokenRepository, method, authorization string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(AuthMiddlewareWithTokens(mockRepo, mockTokens, testSigner, testCookies))
+		router.Handle(method, "/tasks", func(c *gin.Context) {
+			assert.Equal(t, user.ID, c.GetString("userID"))
+			assert.Equal(t, "token-1", c.GetString("apiTokenID"))
+			assert.Empty(t, c.GetString("sessionID"))
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/tasks", nil)
+		req.Header.Set("Authorization", authorization)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("accepts a token with the scope for the method", func(t *testing.T) {
+		tests := []struct {
+			method string
+			scope  string
+		}{
+			{method: "GET", scope: domain.ScopeTasksRead},
+			{method: "HEAD", scope: domain.ScopeTasksRead},
+			{method: "POST", scope: domain.ScopeTasksWrite},
+			{method: "PATCH", scope: domain.ScopeTasksWrite},
+			{method: "DELETE", scope: domain.ScopeTasksWrite},
+		}
+		for _, tt := range tests {
+			mockRepo := mocks.NewMockUserRepository(t)
+			mockTokens := mocks.NewMockAPITokenRepository(t)
+			mockTokens.On("GetAPIToken", tokenHash).Return(newToken(tt.scope), nil)
+			mockTokens.On("TouchAPIToken", tokenHash, mock.AnythingOfType("time.Time")).Return(nil)
+			mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+			w := serve(mockRepo, mockTokens, tt.method, "Bearer "+raw)
+			assert.Equal(t, http.StatusOK, w.Code, tt.method)
+		}
+	})
+
+	t.Run("rejects a token without the scope for the method", func(t *testing.T) {
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockTokens.On("GetAPIToken", tokenHash).Return(newToken(domain.ScopeTasksRead), nil)
+
+		w := serve(mocks.NewMockUserRepository(t), mockTokens, "POST", "Bearer "+raw)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "4054")
+		assert.Contains(t, w.Body.String(), domain.ScopeTasksWrite)
+	})
+
+	t.Run("rejects unknown, malformed and expired tokens", func(t *testing.T) {
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockTokens.On("GetAPIToken", tokenHash).Return(nil, domain.ErrAPITokenNotFound).Once()
+
+		w := serve(mocks.NewMockUserRepository(t), mockTokens, "GET", "Bearer "+raw)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4053")
+
+		// Malformed tokens are rejected without a lookup
+		w = serve(mocks.NewMockUserRepository(t), mockTokens, "GET", "Bearer not-a-token")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4053")
+
+		expired := newToken(domain.ScopeTasksRead)
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		mockTokens.On("GetAPIToken", tokenHash).Return(expired, nil).Once()
+		w = serve(mocks.NewMockUserRepository(t), mockTokens, "GET", "Bearer "+raw)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "4053")
+	})
+
+	t.Run("reports lookup failures", func(t *testing.T) {
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockTokens.On("GetAPIToken", tokenHash).Return(nil, errors.New("redis unavailable"))
+
+		w := serve(mocks.NewMockUserRepository(t), mockTokens, "GET", "Bearer "+raw)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "4003")
+	})
+
+	t.Run("failing to record use does not block the request", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockTokens.On("GetAPIToken", tokenHash).Return(newToken(domain.ScopeTasksRead), nil)
+		mockTokens.On("TouchAPIToken", tokenHash, mock.AnythingOfType("time.Time")).Return(errors.New("redis unavailable"))
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+		w := serve(mockRepo, mockTokens, "GET", "bearer "+raw)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("falls back to the session cookie", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("ValidateSession", "session-123").Return(true, nil)
+		mockRepo.On("GetSessionUserID", "session-123").Return(user.ID, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockRepo.On("TouchSession", "session-123", mock.AnythingOfType("domain.SessionClient")).Return(time.Time{}, nil)
+
+		router := gin.New()
+		router.Use(AuthMiddlewareWithTokens(mockRepo, mocks.NewMockAPITokenRepository(t), testSigner, testCookies))
+		router.GET("/tasks", func(c *gin.Context) {
+			assert.Equal(t, "session-123", c.GetString("sessionID"))
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/tasks", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: testSigner.Sign("session-123")})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("session-only endpoints refuse tokens", func(t *testing.T) {
+		router := gin.New()
+		router.Use(AuthMiddleware(mocks.NewMockUserRepository(t), testSigner, testCookies))
+		router.GET("/auth/me", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+raw)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "4055")
+	})
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		user           interface{}
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "verified user", user: &domain.User{ID: "user-123", EmailVerified: true}, expectedStatus: http.StatusOK},
+		{name: "unverified user", user: &domain.User{ID: "user-123"}, expectedStatus: http.StatusForbidden, expectedCode: "4039"},
+		{name: "no user in context", expectedStatus: http.StatusUnauthorized, expectedCode: "4001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/tasks", func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set("user", tt.user)
+				}
+				c.Next()
+			}, RequireVerifiedEmail(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/tasks", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		user           interface{}
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "administrator", user: &domain.User{ID: "admin-123", IsAdmin: true}, expectedStatus: http.StatusOK},
+		{name: "regular user", user: &domain.User{ID: "user-123"}, expectedStatus: http.StatusForbidden, expectedCode: "4042"},
+		{name: "no user in context", expectedStatus: http.StatusUnauthorized, expectedCode: "4001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/admin/users/:id/unlock", func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set("user", tt.user)
+				}
+				c.Next()
+			}, RequireAdmin(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/admin/users/user-456/unlock", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"backend/pkg/ratelimit"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCredentialBody bounds how much of a request body is read to find the email address
+const maxCredentialBody = 64 << 10
+
+// RateLimiter defines the interface needed for rate limiting middleware
+// Counts a request for a key and reports whether it is within the limit
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (ratelimit.Result, error)
+}
+
+// limitCheck is one limiter applied to one key
+type limitCheck struct {
+	limiter RateLimiter
+	key     string
+}
+
+// RateLimit returns a middleware function that limits requests per client IP
+// Sets the RateLimit-* headers on every response and rejects requests over the limit with 429
+func RateLimit(limiter RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enforceLimits(c, limitCheck{limiter, "ip:" + c.ClientIP()}) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// CredentialRateLimit returns a middleware function for endpoints that take an email address, such as login
+// Limits requests per client IP and per email address, so guessing is slowed whether it is spread over accounts or over IPs
+// Either limiter may be nil to leave that dimension unlimited
+func CredentialRateLimit(ipLimiter, emailLimiter RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var checks []limitCheck
+		if ipLimiter != nil {
+			checks = append(checks, limitCheck{ipLimiter, "ip:" + c.ClientIP()})
+		}
+		if emailLimiter != nil {
+			if email := requestEmail(c); email != "" {
+				checks = append(checks, limitCheck{emailLimiter, "email:" + email})
+			}
+		}
+
+		if !enforceLimits(c, checks...) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// enforceLimits runs every check, reports the most restrictive result in the headers and rejects the request if any limit is exceeded
+// Checks that fail because Redis is unavailable are skipped, so an outage does not take the API down with it
+func enforceLimits(c *gin.Context, checks ...limitCheck) bool {
+	var strictest *ratelimit.Result
+	for _, check := range checks {
+		result, err := check.limiter.Allow(c.Request.Context(), check.key)
+		if err != nil {
+			log.Printf("Rate limit check failed, allowing request: %v", err)
+			continue
+		}
+		if strictest == nil || stricter(result, *strictest) {
+			strictest = &result
+		}
+	}
+
+	if strictest == nil {
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(strictest.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(strictest.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(int(strictest.Reset.Seconds())))
+
+	if !strictest.Allowed {
+		c.Header("Retry-After", strconv.Itoa(int(strictest.RetryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many requests, please try again later",
+			"code":  "4040",
+		})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// stricter reports whether result a should be reported instead of b
+// Denials win over allowances; among denials the longest wait wins, otherwise the fewest remaining requests
+func stricter(a, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// requestEmail returns a hash of the normalized email address in a JSON request body, or "" if there is none
+// The body is restored so the handler can still read it; hashing keeps addresses out of Redis key names
+func requestEmail(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCredentialBody))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	if email == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"backend/pkg/ratelimit"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLimiter allows a fixed number of requests per key and records the keys it saw
+type fakeLimiter struct {
+	limit int
+	err   error
+	seen  map[string]int
+}
+
+func newFakeLimiter(limit int) *fakeLimiter {
+	return &fakeLimiter{limit: limit, seen: map[string]int{}}
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, key string) (ratelimit.Result, error) {
+	if l.err != nil {
+		return ratelimit.Result{}, l.err
+	}
+	if l.seen[key] >= l.limit {
+		return ratelimit.Result{Limit: l.limit, Reset: 30 * time.Second, RetryAfter: 30 * time.Second}, nil
+	}
+	l.seen[key]++
+	return ratelimit.Result{Allowed: true, Limit: l.limit, Remaining: l.limit - l.seen[key], Reset: time.Minute}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("sets headers and rejects requests over the limit", func(t *testing.T) {
+		router := gin.New()
+		router.Use(RateLimit(newFakeLimiter(2)))
+		router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/tasks", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, []string{"1", "0"}[i], w.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+			assert.Empty(t, w.Header().Get("Retry-After"))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/tasks", nil))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "4040")
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("allows requests when the limiter fails", func(t *testing.T) {
+		limiter := newFakeLimiter(1)
+		limiter.err = errors.New("redis down")
+
+		router := gin.New()
+		router.Use(RateLimit(limiter))
+		router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/tasks", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestCredentialRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(ipLimit, emailLimit int) (*gin.Engine, *fakeLimiter) {
+		emailLimiter := newFakeLimiter(emailLimit)
+		router := gin.New()
+		router.POST("/login", CredentialRateLimit(newFakeLimiter(ipLimit), emailLimiter), func(c *gin.Context) {
+			// The handler must still see the whole body
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		})
+		return router, emailLimiter
+	}
+	login := func(router *gin.Engine, body, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", bytes.NewBufferString(body))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("limits attempts per email across IPs", func(t *testing.T) {
+		router, emailLimiter := setup(10, 2)
+		body := `{"email":"victim@example.com","password":"guess"}`
+
+		assert.Equal(t, http.StatusOK, login(router, body, "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, login(router, `{"email":" Victim@Example.com ","password":"guess"}`, "10.0.0.2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login(router, body, "10.0.0.3").Code)
+
+		// Other accounts are unaffected, and addresses never appear in keys
+		assert.Equal(t, http.StatusOK, login(router, `{"email":"other@example.com"}`, "10.0.0.3").Code)
+		for key := range emailLimiter.seen {
+			assert.NotContains(t, key, "example.com")
+		}
+	})
+
+	t.Run("limits attempts per IP across emails", func(t *testing.T) {
+		router, _ := setup(2, 10)
+
+		assert.Equal(t, http.StatusOK, login(router, `{"email":"a@example.com"}`, "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, login(router, `{"email":"b@example.com"}`, "10.0.0.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login(router, `{"email":"c@example.com"}`, "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, login(router, `{"email":"c@example.com"}`, "10.0.0.2").Code)
+	})
+
+	t.Run("passes the body through to the handler", func(t *testing.T) {
+		router, _ := setup(10, 10)
+		body := `{"email":"a@example.com","password":"Pass123!"}`
+
+		w := login(router, body, "10.0.0.1")
+		assert.Equal(t, body, w.Body.String())
+
+		w = login(router, `not json`, "10.0.0.1")
+		assert.Equal(t, "not json", w.Body.String())
+	})
+
+	t.Run("nil limiters are skipped", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/login", CredentialRateLimit(nil, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := login(router, `{"email":"a@example.com"}`, "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	"context"
+
 	"backend/internal/domain"
 	
 	"github.com/stretchr/testify/mock"
@@ -13,23 +15,49 @@ type MockTaskRepository struct {
 	mock.Mock
 }
 
-// CleanupExpiredTasks provides a mock function with no fields
-func (_m *MockTaskRepository) CleanupExpiredTasks() (int, error) {
-	ret := _m.Called()
+// CleanupExpiredTasks provides a mock function with given fields: ctx
+func (_m *MockTaskRepository) CleanupExpiredTasks(ctx context.Context) (*domain.CleanupReport, error) {
+	ret := _m.Called(ctx)
 
-	var r0 int
+	var r0 *domain.CleanupReport
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (int, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) (*domain.CleanupReport, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() int); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.CleanupReport); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Get(0).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.CleanupReport)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateNextOccurrence provides a mock function with given fields: previousID, next
+func (_m *MockTaskRepository) CreateNextOccurrence(previousID string, next *domain.Task) (bool, error) {
+	ret := _m.Called(previousID, next)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *domain.Task) (bool, error)); ok {
+		return rf(previousID, next)
+	}
+	if rf, ok := ret.Get(0).(func(string, *domain.Task) bool); ok {
+		r0 = rf(previousID, next)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *domain.Task) error); ok {
+		r1 = rf(previousID, next)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,6 +93,82 @@ func (_m *MockTaskRepository) DeleteCategory(userID string, categoryName string)
 	return r0
 }
 
+// DeleteUserTasks provides a mock function with given fields: userID
+func (_m *MockTaskRepository) DeleteUserTasks(userID string) (int, error) {
+	ret := _m.Called(userID)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSeriesTasks provides a mock function with given fields: userID, seriesID
+func (_m *MockTaskRepository) GetSeriesTasks(userID string, seriesID string) ([]*domain.Task, error) {
+	ret := _m.Called(userID, seriesID)
+
+	var r0 []*domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]*domain.Task, error)); ok {
+		return rf(userID, seriesID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []*domain.Task); ok {
+		r0 = rf(userID, seriesID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, seriesID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubtasks provides a mock function with given fields: parentID
+func (_m *MockTaskRepository) GetSubtasks(parentID string) ([]*domain.Task, error) {
+	ret := _m.Called(parentID)
+
+	var r0 []*domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*domain.Task, error)); ok {
+		return rf(parentID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*domain.Task); ok {
+		r0 = rf(parentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(parentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTaskByID provides a mock function with given fields: id
 func (_m *MockTaskRepository) GetTaskByID(id string) (*domain.Task, error) {
 	ret := _m.Called(id)
@@ -117,20 +221,74 @@ func (_m *MockTaskRepository) GetUserCategories(userID string) ([]string, error)
 	return r0, r1
 }
 
+// GetUserTags provides a mock function with given fields: userID
+func (_m *MockTaskRepository) GetUserTags(userID string) ([]domain.TagSummary, error) {
+	ret := _m.Called(userID)
+
+	var r0 []domain.TagSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]domain.TagSummary, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []domain.TagSummary); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TagSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeTags provides a mock function with given fields: userID, sourceName, targetName
+func (_m *MockTaskRepository) MergeTags(userID string, sourceName string, targetName string) error {
+	ret := _m.Called(userID, sourceName, targetName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(userID, sourceName, targetName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RenameTag provides a mock function with given fields: userID, oldName, newName
+func (_m *MockTaskRepository) RenameTag(userID string, oldName string, newName string) error {
+	ret := _m.Called(userID, oldName, newName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(userID, oldName, newName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ListTasks provides a mock function with given fields: userID, filters
-func (_m *MockTaskRepository) ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error) {
+func (_m *MockTaskRepository) ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error) {
 	ret := _m.Called(userID, filters)
 
-	var r0 []*domain.Task
+	var r0 *domain.TaskPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) ([]*domain.Task, error)); ok {
+	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) (*domain.TaskPage, error)); ok {
 		return rf(userID, filters)
 	}
-	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) []*domain.Task); ok {
+	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) *domain.TaskPage); ok {
 		r0 = rf(userID, filters)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.Task)
+			r0 = ret.Get(0).(*domain.TaskPage)
 		}
 	}
 
@@ -171,6 +329,32 @@ func (_m *MockTaskRepository) RestoreTask(id string) error {
 	return r0
 }
 
+// SearchTasks provides a mock function with given fields: userID, query, limit
+func (_m *MockTaskRepository) SearchTasks(userID string, query string, limit int) ([]*domain.Task, error) {
+	ret := _m.Called(userID, query, limit)
+
+	var r0 []*domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, int) ([]*domain.Task, error)); ok {
+		return rf(userID, query, limit)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, int) []*domain.Task); ok {
+		r0 = rf(userID, query, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, int) error); ok {
+		r1 = rf(userID, query, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SoftDeleteTask provides a mock function with given fields: id
 func (_m *MockTaskRepository) SoftDeleteTask(id string) error {
 	ret := _m.Called(id)
@@ -199,6 +383,20 @@ func (_m *MockTaskRepository) UpdateTaskCompletion(id string, completed bool) er
 	return r0
 }
 
+// UpdateTask provides a mock function with given fields: task
+func (_m *MockTaskRepository) UpdateTask(task *domain.Task) error {
+	ret := _m.Called(task)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*domain.Task) error); ok {
+		r0 = rf(task)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMockTaskRepository creates a new instance of MockTaskRepository. It also registers a testing interface on the mock and a cleanup function to assert the mock's expectations.
 func NewMockTaskRepository(t interface {
 	mock.TestingT
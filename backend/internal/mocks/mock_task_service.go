@@ -13,26 +13,26 @@ type MockTaskService struct {
 	mock.Mock
 }
 
-// CreateTask provides a mock function with given fields: userID, description, category
-func (_m *MockTaskService) CreateTask(userID string, description string, category string) (*domain.Task, error) {
-	ret := _m.Called(userID, description, category)
+// CreateTask provides a mock function with given fields: userID, description, category, opts
+func (_m *MockTaskService) CreateTask(userID string, description string, category string, opts domain.TaskOptions) (*domain.Task, error) {
+	ret := _m.Called(userID, description, category, opts)
 
 	var r0 *domain.Task
 	var r1 error
 
-	if rf, ok := ret.Get(0).(func(string, string, string) (*domain.Task, error)); ok {
-		return rf(userID, description, category)
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.TaskOptions) (*domain.Task, error)); ok {
+		return rf(userID, description, category, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string) *domain.Task); ok {
-		r0 = rf(userID, description, category)
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.TaskOptions) *domain.Task); ok {
+		r0 = rf(userID, description, category, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
-		r1 = rf(userID, description, category)
+	if rf, ok := ret.Get(1).(func(string, string, string, domain.TaskOptions) error); ok {
+		r1 = rf(userID, description, category, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -68,20 +68,20 @@ func (_m *MockTaskService) GetTaskByID(id string, userID string) (*domain.Task,
 }
 
 // ListTasks provides a mock function with given fields: userID, filters
-func (_m *MockTaskService) ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error) {
+func (_m *MockTaskService) ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error) {
 	ret := _m.Called(userID, filters)
 
-	var r0 []*domain.Task
+	var r0 *domain.TaskPage
 	var r1 error
 
-	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) ([]*domain.Task, error)); ok {
+	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) (*domain.TaskPage, error)); ok {
 		return rf(userID, filters)
 	}
-	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) []*domain.Task); ok {
+	if rf, ok := ret.Get(0).(func(string, domain.TaskFilters) *domain.TaskPage); ok {
 		r0 = rf(userID, filters)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.Task)
+			r0 = ret.Get(0).(*domain.TaskPage)
 		}
 	}
 
@@ -121,6 +121,87 @@ func (_m *MockTaskService) UpdateTaskCompletion(id string, userID string, comple
 	return r0, r1
 }
 
+// UpdateTask provides a mock function with given fields: id, userID, updates
+func (_m *MockTaskService) UpdateTask(id string, userID string, updates domain.TaskUpdate) (*domain.Task, error) {
+	ret := _m.Called(id, userID, updates)
+
+	var r0 *domain.Task
+	var r1 error
+
+	if rf, ok := ret.Get(0).(func(string, string, domain.TaskUpdate) (*domain.Task, error)); ok {
+		return rf(id, userID, updates)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, domain.TaskUpdate) *domain.Task); ok {
+		r0 = rf(id, userID, updates)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, domain.TaskUpdate) error); ok {
+		r1 = rf(id, userID, updates)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTaskHistory provides a mock function with given fields: id, userID
+func (_m *MockTaskService) GetTaskHistory(id string, userID string) ([]*domain.Task, error) {
+	ret := _m.Called(id, userID)
+
+	var r0 []*domain.Task
+	var r1 error
+
+	if rf, ok := ret.Get(0).(func(string, string) ([]*domain.Task, error)); ok {
+		return rf(id, userID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []*domain.Task); ok {
+		r0 = rf(id, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSubtasks provides a mock function with given fields: parentID, userID
+func (_m *MockTaskService) ListSubtasks(parentID string, userID string) ([]*domain.Task, error) {
+	ret := _m.Called(parentID, userID)
+
+	var r0 []*domain.Task
+	var r1 error
+
+	if rf, ok := ret.Get(0).(func(string, string) ([]*domain.Task, error)); ok {
+		return rf(parentID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []*domain.Task); ok {
+		r0 = rf(parentID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(parentID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SoftDeleteTask provides a mock function with given fields: id, userID
 func (_m *MockTaskService) SoftDeleteTask(id string, userID string) error {
 	ret := _m.Called(id, userID)
@@ -215,4 +296,85 @@ func (_m *MockTaskService) DeleteCategory(userID string, categoryName string) er
 	}
 
 	return r0
+}
+
+// GetUserTags provides a mock function with given fields: userID
+func (_m *MockTaskService) GetUserTags(userID string) ([]domain.TagSummary, error) {
+	ret := _m.Called(userID)
+
+	var r0 []domain.TagSummary
+	var r1 error
+
+	if rf, ok := ret.Get(0).(func(string) ([]domain.TagSummary, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []domain.TagSummary); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TagSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeTags provides a mock function with given fields: userID, sourceName, targetName
+func (_m *MockTaskService) MergeTags(userID string, sourceName string, targetName string) error {
+	ret := _m.Called(userID, sourceName, targetName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(userID, sourceName, targetName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RenameTag provides a mock function with given fields: userID, oldName, newName
+func (_m *MockTaskService) RenameTag(userID string, oldName string, newName string) error {
+	ret := _m.Called(userID, oldName, newName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(userID, oldName, newName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchTasks provides a mock function with given fields: userID, query, limit
+func (_m *MockTaskService) SearchTasks(userID string, query string, limit int) ([]*domain.Task, error) {
+	ret := _m.Called(userID, query, limit)
+
+	var r0 []*domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, int) ([]*domain.Task, error)); ok {
+		return rf(userID, query, limit)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, int) []*domain.Task); ok {
+		r0 = rf(userID, query, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, int) error); ok {
+		r1 = rf(userID, query, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
\ No newline at end of file
@@ -4,7 +4,8 @@ package mocks
 
 import (
 	"backend/internal/domain"
-	
+	"time"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -133,13 +134,13 @@ func (_m *MockUserRepository) Update(user *domain.User) error {
 	return r0
 }
 
-// CreateSession provides a mock function with given fields: userID, sessionID
-func (_m *MockUserRepository) CreateSession(userID string, sessionID string) error {
-	ret := _m.Called(userID, sessionID)
+// CreateSession provides a mock function with given fields: userID, sessionID, rememberMe, client
+func (_m *MockUserRepository) CreateSession(userID string, sessionID string, rememberMe bool, client domain.SessionClient) error {
+	ret := _m.Called(userID, sessionID, rememberMe, client)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(userID, sessionID)
+	if rf, ok := ret.Get(0).(func(string, string, bool, domain.SessionClient) error); ok {
+		r0 = rf(userID, sessionID, rememberMe, client)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -223,6 +224,144 @@ func (_m *MockUserRepository) DeleteAllUserSessions(userID string) error {
 	return r0
 }
 
+// GetSession provides a mock function with given fields: sessionID
+func (_m *MockUserRepository) GetSession(sessionID string) (*domain.Session, error) {
+	ret := _m.Called(sessionID)
+
+	var r0 *domain.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*domain.Session, error)); ok {
+		return rf(sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *domain.Session); ok {
+		r0 = rf(sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserSessions provides a mock function with given fields: userID
+func (_m *MockUserRepository) ListUserSessions(userID string) ([]*domain.Session, error) {
+	ret := _m.Called(userID)
+
+	var r0 []*domain.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*domain.Session, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*domain.Session); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchSession provides a mock function with given fields: sessionID, client
+func (_m *MockUserRepository) TouchSession(sessionID string, client domain.SessionClient) (time.Time, error) {
+	ret := _m.Called(sessionID, client)
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, domain.SessionClient) (time.Time, error)); ok {
+		return rf(sessionID, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, domain.SessionClient) time.Time); ok {
+		r0 = rf(sessionID, client)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, domain.SessionClient) error); ok {
+		r1 = rf(sessionID, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteOtherUserSessions provides a mock function with given fields: userID, keepSessionID
+func (_m *MockUserRepository) DeleteOtherUserSessions(userID string, keepSessionID string) (int, error) {
+	ret := _m.Called(userID, keepSessionID)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (int, error)); ok {
+		return rf(userID, keepSessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) int); ok {
+		r0 = rf(userID, keepSessionID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, keepSessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SavePasswordResetToken provides a mock function with given fields: userID, tokenHash, ttl
+func (_m *MockUserRepository) SavePasswordResetToken(userID string, tokenHash string, ttl time.Duration) error {
+	ret := _m.Called(userID, tokenHash, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) error); ok {
+		r0 = rf(userID, tokenHash, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumePasswordResetToken provides a mock function with given fields: tokenHash
+func (_m *MockUserRepository) ConsumePasswordResetToken(tokenHash string) (string, error) {
+	ret := _m.Called(tokenHash)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(tokenHash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMockUserRepository creates a new instance of MockUserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mock's expectations.
 func NewMockUserRepository(t interface {
 	mock.TestingT
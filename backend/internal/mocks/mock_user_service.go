@@ -4,6 +4,7 @@ package mocks
 
 import (
 	"backend/internal/domain"
+	"time"
 	
 	"github.com/stretchr/testify/mock"
 )
@@ -13,33 +14,33 @@ type MockUserService struct {
 	mock.Mock
 }
 
-// Register provides a mock function with given fields: email, displayName, password
-func (_m *MockUserService) Register(email string, displayName string, password string) (*domain.User, string, error) {
-	ret := _m.Called(email, displayName, password)
+// Register provides a mock function with given fields: email, displayName, password, client
+func (_m *MockUserService) Register(email string, displayName string, password string, client domain.SessionClient) (*domain.User, string, error) {
+	ret := _m.Called(email, displayName, password, client)
 
 	var r0 *domain.User
 	var r1 string
 	var r2 error
 
-	if rf, ok := ret.Get(0).(func(string, string, string) (*domain.User, string, error)); ok {
-		return rf(email, displayName, password)
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.SessionClient) (*domain.User, string, error)); ok {
+		return rf(email, displayName, password, client)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string) *domain.User); ok {
-		r0 = rf(email, displayName, password)
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.SessionClient) *domain.User); ok {
+		r0 = rf(email, displayName, password, client)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string) string); ok {
-		r1 = rf(email, displayName, password)
+	if rf, ok := ret.Get(1).(func(string, string, string, domain.SessionClient) string); ok {
+		r1 = rf(email, displayName, password, client)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string, string) error); ok {
-		r2 = rf(email, displayName, password)
+	if rf, ok := ret.Get(2).(func(string, string, string, domain.SessionClient) error); ok {
+		r2 = rf(email, displayName, password, client)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -47,33 +48,33 @@ func (_m *MockUserService) Register(email string, displayName string, password s
 	return r0, r1, r2
 }
 
-// Login provides a mock function with given fields: email, password
-func (_m *MockUserService) Login(email string, password string) (*domain.User, string, error) {
-	ret := _m.Called(email, password)
+// Login provides a mock function with given fields: email, password, rememberMe, client
+func (_m *MockUserService) Login(email string, password string, rememberMe bool, client domain.SessionClient) (*domain.User, string, error) {
+	ret := _m.Called(email, password, rememberMe, client)
 
 	var r0 *domain.User
 	var r1 string
 	var r2 error
 
-	if rf, ok := ret.Get(0).(func(string, string) (*domain.User, string, error)); ok {
-		return rf(email, password)
+	if rf, ok := ret.Get(0).(func(string, string, bool, domain.SessionClient) (*domain.User, string, error)); ok {
+		return rf(email, password, rememberMe, client)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *domain.User); ok {
-		r0 = rf(email, password)
+	if rf, ok := ret.Get(0).(func(string, string, bool, domain.SessionClient) *domain.User); ok {
+		r0 = rf(email, password, rememberMe, client)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) string); ok {
-		r1 = rf(email, password)
+	if rf, ok := ret.Get(1).(func(string, string, bool, domain.SessionClient) string); ok {
+		r1 = rf(email, password, rememberMe, client)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(email, password)
+	if rf, ok := ret.Get(2).(func(string, string, bool, domain.SessionClient) error); ok {
+		r2 = rf(email, password, rememberMe, client)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -120,4 +121,549 @@ func (_m *MockUserService) GetCurrentUser(sessionID string) (*domain.User, error
 	}
 
 	return r0, r1
-}
\ No newline at end of file
+}
+
+// ListSessions provides a mock function with given fields: userID, currentSessionID
+func (_m *MockUserService) ListSessions(userID string, currentSessionID string) ([]*domain.Session, error) {
+	ret := _m.Called(userID, currentSessionID)
+
+	var r0 []*domain.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]*domain.Session, error)); ok {
+		return rf(userID, currentSessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []*domain.Session); ok {
+		r0 = rf(userID, currentSessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, currentSessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeSession provides a mock function with given fields: userID, sessionID
+func (_m *MockUserService) RevokeSession(userID string, sessionID string) error {
+	ret := _m.Called(userID, sessionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeOtherSessions provides a mock function with given fields: userID, currentSessionID
+func (_m *MockUserService) RevokeOtherSessions(userID string, currentSessionID string) (int, error) {
+	ret := _m.Called(userID, currentSessionID)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (int, error)); ok {
+		return rf(userID, currentSessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) int); ok {
+		r0 = rf(userID, currentSessionID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, currentSessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProfile provides a mock function with given fields: userID, displayName
+func (_m *MockUserService) UpdateProfile(userID string, displayName string) (*domain.User, error) {
+	ret := _m.Called(userID, displayName)
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*domain.User, error)); ok {
+		return rf(userID, displayName)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *domain.User); ok {
+		r0 = rf(userID, displayName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, displayName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: userID, currentSessionID, oldPassword, newPassword, client
+func (_m *MockUserService) ChangePassword(userID string, currentSessionID string, oldPassword string, newPassword string, client domain.SessionClient) (string, bool, error) {
+	ret := _m.Called(userID, currentSessionID, oldPassword, newPassword, client)
+
+	var r0 string
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, domain.SessionClient) (string, bool, error)); ok {
+		return rf(userID, currentSessionID, oldPassword, newPassword, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, string, domain.SessionClient) string); ok {
+		r0 = rf(userID, currentSessionID, oldPassword, newPassword, client)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, string, domain.SessionClient) bool); ok {
+		r1 = rf(userID, currentSessionID, oldPassword, newPassword, client)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, string, string, string, domain.SessionClient) error); ok {
+		r2 = rf(userID, currentSessionID, oldPassword, newPassword, client)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteUser provides a mock function with given fields: userID, password, client
+func (_m *MockUserService) DeleteUser(userID string, password string, client domain.SessionClient) error {
+	ret := _m.Called(userID, password, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) error); ok {
+		r0 = rf(userID, password, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequestPasswordReset provides a mock function with given fields: email
+func (_m *MockUserService) RequestPasswordReset(email string) error {
+	ret := _m.Called(email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: token, newPassword
+func (_m *MockUserService) ResetPassword(token string, newPassword string) error {
+	ret := _m.Called(token, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(token, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendVerificationEmail provides a mock function with given fields: userID
+func (_m *MockUserService) SendVerificationEmail(userID string) error {
+	ret := _m.Called(userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyEmail provides a mock function with given fields: token
+func (_m *MockUserService) VerifyEmail(token string) (*domain.User, error) {
+	ret := _m.Called(token)
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLoginActivity provides a mock function with given fields: userID
+func (_m *MockUserService) GetLoginActivity(userID string) (*domain.LoginActivity, error) {
+	ret := _m.Called(userID)
+
+	var r0 *domain.LoginActivity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*domain.LoginActivity, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *domain.LoginActivity); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginActivity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlockAccount provides a mock function with given fields: adminID, userID
+func (_m *MockUserService) UnlockAccount(adminID string, userID string) error {
+	ret := _m.Called(adminID, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(adminID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BeginTwoFactorSetup provides a mock function with given fields: userID
+func (_m *MockUserService) BeginTwoFactorSetup(userID string) (*domain.TwoFactorSetup, error) {
+	ret := _m.Called(userID)
+
+	var r0 *domain.TwoFactorSetup
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*domain.TwoFactorSetup, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *domain.TwoFactorSetup); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactorSetup)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteTwoFactorLogin provides a mock function with given fields: token, code, client
+func (_m *MockUserService) CompleteTwoFactorLogin(token string, code string, client domain.SessionClient) (*domain.User, string, bool, error) {
+	ret := _m.Called(token, code, client)
+
+	var r0 *domain.User
+	var r1 string
+	var r2 bool
+	var r3 error
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) (*domain.User, string, bool, error)); ok {
+		return rf(token, code, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) *domain.User); ok {
+		r0 = rf(token, code, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, domain.SessionClient) string); ok {
+		r1 = rf(token, code, client)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, string, domain.SessionClient) bool); ok {
+		r2 = rf(token, code, client)
+	} else {
+		r2 = ret.Get(2).(bool)
+	}
+
+	if rf, ok := ret.Get(3).(func(string, string, domain.SessionClient) error); ok {
+		r3 = rf(token, code, client)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// DisableTwoFactor provides a mock function with given fields: userID, password, code, client
+func (_m *MockUserService) DisableTwoFactor(userID string, password string, code string, client domain.SessionClient) error {
+	ret := _m.Called(userID, password, code, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.SessionClient) error); ok {
+		r0 = rf(userID, password, code, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableTwoFactor provides a mock function with given fields: userID, code, client
+func (_m *MockUserService) EnableTwoFactor(userID string, code string, client domain.SessionClient) ([]string, error) {
+	ret := _m.Called(userID, code, client)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) ([]string, error)); ok {
+		return rf(userID, code, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) []string); ok {
+		r0 = rf(userID, code, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, domain.SessionClient) error); ok {
+		r1 = rf(userID, code, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTwoFactorStatus provides a mock function with given fields: userID
+func (_m *MockUserService) GetTwoFactorStatus(userID string) (*domain.TwoFactor, error) {
+	ret := _m.Called(userID)
+
+	var r0 *domain.TwoFactor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*domain.TwoFactor, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *domain.TwoFactor); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactor)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegenerateRecoveryCodes provides a mock function with given fields: userID, password, code, client
+func (_m *MockUserService) RegenerateRecoveryCodes(userID string, password string, code string, client domain.SessionClient) ([]string, error) {
+	ret := _m.Called(userID, password, code, client)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.SessionClient) ([]string, error)); ok {
+		return rf(userID, password, code, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, domain.SessionClient) []string); ok {
+		r0 = rf(userID, password, code, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, domain.SessionClient) error); ok {
+		r1 = rf(userID, password, code, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIToken provides a mock function with given fields: userID, name, scopes, ttl, client
+func (_m *MockUserService) CreateAPIToken(userID string, name string, scopes []string, ttl time.Duration, client domain.SessionClient) (*domain.APIToken, string, error) {
+	ret := _m.Called(userID, name, scopes, ttl, client)
+
+	var r0 *domain.APIToken
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, string, []string, time.Duration, domain.SessionClient) (*domain.APIToken, string, error)); ok {
+		return rf(userID, name, scopes, ttl, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, []string, time.Duration, domain.SessionClient) *domain.APIToken); ok {
+		r0 = rf(userID, name, scopes, ttl, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, []string, time.Duration, domain.SessionClient) string); ok {
+		r1 = rf(userID, name, scopes, ttl, client)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, string, []string, time.Duration, domain.SessionClient) error); ok {
+		r2 = rf(userID, name, scopes, ttl, client)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListAPITokens provides a mock function with given fields: userID
+func (_m *MockUserService) ListAPITokens(userID string) ([]*domain.APIToken, error) {
+	ret := _m.Called(userID)
+
+	var r0 []*domain.APIToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*domain.APIToken, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*domain.APIToken); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.APIToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIToken provides a mock function with given fields: userID, tokenID, client
+func (_m *MockUserService) RevokeAPIToken(userID string, tokenID string, client domain.SessionClient) error {
+	ret := _m.Called(userID, tokenID, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) error); ok {
+		r0 = rf(userID, tokenID, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BeginOIDCLogin provides a mock function with given fields: rememberMe
+func (_m *MockUserService) BeginOIDCLogin(rememberMe bool) (string, string, error) {
+	ret := _m.Called(rememberMe)
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(bool) (string, string, error)); ok {
+		return rf(rememberMe)
+	}
+	if rf, ok := ret.Get(0).(func(bool) string); ok {
+		r0 = rf(rememberMe)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(bool) string); ok {
+		r1 = rf(rememberMe)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(bool) error); ok {
+		r2 = rf(rememberMe)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CompleteOIDCLogin provides a mock function with given fields: state, code, client
+func (_m *MockUserService) CompleteOIDCLogin(state string, code string, client domain.SessionClient) (*domain.User, string, bool, error) {
+	ret := _m.Called(state, code, client)
+
+	var r0 *domain.User
+	var r1 string
+	var r2 bool
+	var r3 error
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) (*domain.User, string, bool, error)); ok {
+		return rf(state, code, client)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, domain.SessionClient) *domain.User); ok {
+		r0 = rf(state, code, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, domain.SessionClient) string); ok {
+		r1 = rf(state, code, client)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, string, domain.SessionClient) bool); ok {
+		r2 = rf(state, code, client)
+	} else {
+		r2 = ret.Get(2).(bool)
+	}
+
+	if rf, ok := ret.Get(3).(func(string, string, domain.SessionClient) error); ok {
+		r3 = rf(state, code, client)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
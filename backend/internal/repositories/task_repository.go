@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"backend/internal/domain"
 	"backend/pkg/redis"
 
+	"github.com/google/uuid"
 	redislib "github.com/redis/go-redis/v9"
 )
 
@@ -48,10 +51,36 @@ func (r *TaskRepository) CreateTask(task *domain.Task) error {
 		"description": task.Description,
 		"category":    task.Category,
 		"completed":   task.Completed,
+		"priority":    string(task.Priority),
 		"created_at":  task.CreatedAt.Unix(),
 		"updated_at":  task.UpdatedAt.Unix(),
 	}
 
+	if task.DueDate != nil {
+		taskData["due_date"] = task.DueDate.Unix()
+	}
+
+	if task.CompletedAt != nil {
+		taskData["completed_at"] = task.CompletedAt.Unix()
+	}
+
+	if task.Recurrence != "" {
+		taskData["recurrence"] = task.Recurrence
+	}
+
+	if task.SeriesID != "" {
+		taskData["series_id"] = task.SeriesID
+		taskData["occurrence"] = task.Occurrence
+	}
+
+	if task.ParentID != "" {
+		taskData["parent_id"] = task.ParentID
+	}
+
+	if len(task.Tags) > 0 {
+		taskData["tags"] = strings.Join(task.Tags, ",")
+	}
+
 	if task.DeletedAt != nil {
 		taskData["deleted_at"] = task.DeletedAt.Unix()
 	}
@@ -74,17 +103,74 @@ func (r *TaskRepository) CreateTask(task *domain.Task) error {
 		Member: task.ID,
 	})
 
+	// Add top-level tasks to user's listing index (scored by negated created timestamp, newest first)
+	if task.ParentID == "" {
+		pipe.ZAdd(ctx, topLevelIndexKey(task.UserID, false), redislib.Z{
+			Score:  -float64(task.CreatedAt.Unix()),
+			Member: task.ID,
+		})
+	}
+
+	// Add to user's priority index (scored by priority rank)
+	userTasksPriorityKey := redis.GenerateKey("user", task.UserID) + ":tasks:priority"
+	pipe.ZAdd(ctx, userTasksPriorityKey, redislib.Z{
+		Score:  float64(task.Priority.Rank()),
+		Member: task.ID,
+	})
+
+	// Add to user's completed or active index (sorted by created timestamp)
+	r.queueCompletionIndex(ctx, pipe, task)
+
+	// Add to user's due date index (sorted by due timestamp)
+	if task.DueDate != nil {
+		userTasksDueKey := redis.GenerateKey("user", task.UserID) + ":tasks:due"
+		pipe.ZAdd(ctx, userTasksDueKey, redislib.Z{
+			Score:  float64(task.DueDate.Unix()),
+			Member: task.ID,
+		})
+	}
+
+	// Add to the recurring series index (sorted by occurrence number)
+	if task.SeriesID != "" {
+		seriesKey := redis.GenerateKey("user", task.UserID) + ":series:" + task.SeriesID
+		pipe.ZAdd(ctx, seriesKey, redislib.Z{
+			Score:  float64(task.Occurrence),
+			Member: task.ID,
+		})
+	}
+
+	// Add to the parent's subtask index (sorted by created timestamp)
+	if task.ParentID != "" {
+		subtasksKey := redis.GenerateKey(redis.TaskKeyPrefix, task.ParentID) + ":subtasks"
+		pipe.ZAdd(ctx, subtasksKey, redislib.Z{
+			Score:  float64(task.CreatedAt.Unix()),
+			Member: task.ID,
+		})
+
+		userSubtasksKey := redis.GenerateKey("user", task.UserID) + ":subtasks"
+		pipe.SAdd(ctx, userSubtasksKey, task.ID)
+	}
+
 	// Handle category management if category is not empty
 	if strings.TrimSpace(task.Category) != "" {
 		// Add category to user's categories set
 		userCategoriesKey := redis.GenerateKey("user", task.UserID) + ":categories"
 		pipe.SAdd(ctx, userCategoriesKey, task.Category)
 
-		// Add task to category set
+		// Add task to category sorted set (sorted by created timestamp)
 		categoryTasksKey := redis.GenerateKey("user", task.UserID) + ":category:" + task.Category
-		pipe.SAdd(ctx, categoryTasksKey, task.ID)
+		pipe.ZAdd(ctx, categoryTasksKey, redislib.Z{
+			Score:  float64(task.CreatedAt.Unix()),
+			Member: task.ID,
+		})
 	}
 
+	// Add task to each of its tag sets
+	r.queueTagIndex(ctx, pipe, task.UserID, task.ID, task.Tags)
+
+	// Add task to the search index for each word of its description
+	r.queueSearchIndex(ctx, pipe, task.UserID, task.ID, domain.SearchTerms(task.Description))
+
 	// Execute pipeline
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -112,7 +198,7 @@ func (r *TaskRepository) GetTaskByID(taskID string) (*domain.Task, error) {
 	}
 
 	if len(taskData) == 0 {
-		return nil, fmt.Errorf("2003: task not found")
+		return nil, fmt.Errorf("2003: %w", domain.ErrTaskNotFound)
 	}
 
 	// Note: Access control should be handled at service layer
@@ -127,71 +213,129 @@ func (r *TaskRepository) GetTaskByID(taskID string) (*domain.Task, error) {
 	return task, nil
 }
 
-// ListTasks retrieves tasks for a user with filtering and pagination support
-// Supports filtering by category, completion status, deleted status, and pagination
+// ListTasks retrieves a page of a user's tasks with filtering and cursor pagination
+// Supports filtering by category, tags, completion status, due date, priority and deleted status
+// Unfiltered listings in creation order page the maintained top-level indexes directly; filters and other
+// sort orders are intersected into temporary sorted sets first. Either way pages are cut in Redis and only
+// the tasks of the page are loaded; the page cursor resumes right after the last task returned, breaking ties by task ID
 // Error codes: 2005 (invalid filters)
-func (r *TaskRepository) ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error) {
+func (r *TaskRepository) ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error) {
 	ctx := context.Background()
 	// Validate filters
 	if err := filters.Validate(); err != nil {
 		return nil, fmt.Errorf("2005: %w", err)
 	}
 
-	var taskIDs []string
-	var err error
+	sortBy := filters.SortOrder()
+	var cursor *domain.TaskCursor
+	if filters.Cursor != "" {
+		var err error
+		if cursor, err = domain.DecodeTaskCursor(filters.Cursor); err != nil {
+			return nil, fmt.Errorf("2005: %w", err)
+		}
+	}
 
-	// Get task IDs based on filters
-	if filters.IncludeDeleted {
-		// Get all tasks (active + deleted)
-		taskIDs, err = r.getAllTaskIDs(ctx, userID, filters)
-	} else {
-		// Get only active tasks
-		taskIDs, err = r.getActiveTaskIDs(ctx, userID, filters)
+	if err := r.ensureTopLevelIndex(ctx, userID); err != nil {
+		return nil, fmt.Errorf("2005: failed to get task IDs: %w", err)
+	}
+	if filters.Completed != nil {
+		if err := r.ensureCompletionIndex(ctx, userID); err != nil {
+			return nil, fmt.Errorf("2005: failed to get task IDs: %w", err)
+		}
 	}
 
-	if err != nil {
+	// Build the ordered segments of matching tasks and count them in one transaction
+	listing := r.newTaskListing(ctx, userID)
+	segments := listing.queueSegments(userID, filters, sortBy)
+	if cursor != nil {
+		if err := listing.queueCursorPosition(segments, cursor); err != nil {
+			return nil, fmt.Errorf("2005: %w", err)
+		}
+	}
+	listing.queueExpiry()
+	if _, err := listing.pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("2005: failed to get task IDs: %w", err)
 	}
 
-	// Apply pagination
+	total := 0
+	for _, segment := range segments {
+		total += int(segment.count.Val())
+	}
+
+	// Resume after the cursor position, or skip the offset
 	start := filters.Offset
-	end := len(taskIDs)
-	if filters.Limit > 0 && start + filters.Limit < end {
-		end = start + filters.Limit
+	if cursor != nil {
+		start = cursorPosition(segments, cursor)
+	}
+	if start > total {
+		start = total
 	}
 
-	if start >= len(taskIDs) {
-		return []*domain.Task{}, nil
+	end := total
+	if filters.Limit > 0 && start+filters.Limit < end {
+		end = start + filters.Limit
 	}
 
-	if start < 0 {
-		start = 0
+	// Read the page IDs from the segments it spans and drop any temporary keys
+	pipe := r.client.Pipeline()
+	var rangeCmds []*redislib.StringSliceCmd
+	offset := 0
+	for _, segment := range segments {
+		count := int(segment.count.Val())
+		from, to := start-offset, end-offset
+		if from < 0 {
+			from = 0
+		}
+		if to > count {
+			to = count
+		}
+		if from < to {
+			rangeCmds = append(rangeCmds, pipe.ZRange(ctx, segment.key, int64(from), int64(to-1)))
+		}
+		offset += count
+	}
+	if len(listing.keys) > 0 {
+		pipe.Del(ctx, listing.keys...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("2005: failed to get task IDs: %w", err)
 	}
 
-	taskIDs = taskIDs[start:end]
+	var pageIDs []string
+	for _, cmd := range rangeCmds {
+		pageIDs = append(pageIDs, cmd.Val()...)
+	}
 
-	// Fetch task details
-	tasks := make([]*domain.Task, 0, len(taskIDs))
-	for _, taskID := range taskIDs {
-		task, err := r.GetTaskByID(taskID)
-		if err != nil {
-			continue // Skip tasks that can't be retrieved
-		}
+	// Fetch task details in a single round trip
+	pageTasks, err := r.getTasksByIDs(ctx, pageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("2005: failed to get tasks: %w", err)
+	}
 
+	tasks := make([]*domain.Task, 0, len(pageTasks))
+	for _, task := range pageTasks {
 		// Verify task belongs to user
-		if task.UserID != userID {
-			continue
+		if task.UserID == userID {
+			tasks = append(tasks, task)
 		}
+	}
 
-		// Apply completion filter
-		if filters.Completed != nil && task.Completed != *filters.Completed {
-			continue
-		}
+	page := &domain.TaskPage{
+		Tasks: tasks,
+		Total: total,
+	}
 
-		tasks = append(tasks, task)
+	if end < total && len(tasks) > 0 {
+		last := tasks[len(tasks)-1]
+		page.NextCursor = domain.TaskCursor{SortBy: sortBy, Key: taskSortKey(last, sortBy), ID: last.ID}.Encode()
 	}
 
-	return tasks, nil
+	// Attach subtasks so parents can report their progress
+	if err := r.attachSubtasks(ctx, page.Tasks); err != nil {
+		return nil, fmt.Errorf("2005: failed to get subtasks: %w", err)
+	}
+
+	return page, nil
 }
 
 // UpdateTaskCompletion updates the completion status of a task
@@ -210,15 +354,30 @@ func (r *TaskRepository) UpdateTaskCompletion(taskID string, completed bool) err
 	}
 
 	// Update task
-	task.Completed = completed
-	task.UpdatedAt = time.Now()
+	if completed {
+		task.MarkCompleted()
+	} else {
+		task.MarkIncomplete()
+	}
 
-	// Update task hash
+	// Update task hash, recording when the task was completed
 	taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
-	_, err = r.client.HMSet(ctx, taskKey, map[string]interface{}{
+	pipe := r.client.TxPipeline()
+	pipe.HMSet(ctx, taskKey, map[string]interface{}{
 		"completed":  completed,
 		"updated_at": task.UpdatedAt.Unix(),
-	}).Result()
+	})
+	if task.CompletedAt != nil {
+		pipe.HSet(ctx, taskKey, "completed_at", task.CompletedAt.Unix())
+	} else {
+		pipe.HDel(ctx, taskKey, "completed_at")
+	}
+
+	// Move task between the completed and active indexes (deleted tasks are re-indexed on restore)
+	if !task.IsDeleted() {
+		r.queueCompletionIndex(ctx, pipe, task)
+	}
+	_, err = pipe.Exec(ctx)
 
 	if err != nil {
 		return fmt.Errorf("2003: failed to update task completion: %w", err)
@@ -227,8 +386,237 @@ func (r *TaskRepository) UpdateTaskCompletion(taskID string, completed bool) err
 	return nil
 }
 
+// UpdateTask persists changes to a task's editable fields
+// Updates the task hash and moves the task between category indexes when its category changes
+// Error codes: 2001 (nil task), 2002 (validation error), 2003 (not found)
+func (r *TaskRepository) UpdateTask(task *domain.Task) error {
+	ctx := context.Background()
+	if task == nil {
+		return fmt.Errorf("2001: task cannot be nil")
+	}
+
+	// Validate task
+	if err := task.Validate(); err != nil {
+		return fmt.Errorf("2002: %w", err)
+	}
+
+	// Load stored task to compare indexed fields
+	existing, err := r.GetTaskByID(task.ID)
+	if err != nil {
+		return err // Error code already included
+	}
+
+	if existing.UserID != task.UserID {
+		return fmt.Errorf("2003: %w", domain.ErrTaskNotFound)
+	}
+
+	userID := existing.UserID
+	userCategoriesKey := redis.GenerateKey("user", userID) + ":categories"
+	categoryChanged := existing.Category != task.Category
+	addedTags, removedTags := diffTags(existing.Tags, task.Tags)
+	oldTerms := domain.SearchTerms(existing.Description)
+	newTerms := domain.SearchTerms(task.Description)
+	removedTerms := diffSearchTerms(oldTerms, newTerms)
+
+	// Use pipeline for atomic operations
+	pipe := r.client.TxPipeline()
+
+	// Update task hash
+	taskKey := redis.GenerateKey(redis.TaskKeyPrefix, task.ID)
+	pipe.HMSet(ctx, taskKey, map[string]interface{}{
+		"description": task.Description,
+		"category":    task.Category,
+		"priority":    string(task.Priority),
+		"recurrence":  task.Recurrence,
+		"updated_at":  task.UpdatedAt.Unix(),
+	})
+
+	// Add to the recurring series index once the task has a series
+	if task.SeriesID != "" {
+		pipe.HMSet(ctx, taskKey, map[string]interface{}{
+			"series_id":  task.SeriesID,
+			"occurrence": task.Occurrence,
+		})
+		seriesKey := redis.GenerateKey("user", userID) + ":series:" + task.SeriesID
+		pipe.ZAdd(ctx, seriesKey, redislib.Z{
+			Score:  float64(task.Occurrence),
+			Member: task.ID,
+		})
+	}
+
+	// Update priority index (deleted tasks are re-indexed on restore)
+	if !existing.IsDeleted() {
+		userTasksPriorityKey := redis.GenerateKey("user", userID) + ":tasks:priority"
+		pipe.ZAdd(ctx, userTasksPriorityKey, redislib.Z{
+			Score:  float64(task.Priority.Rank()),
+			Member: task.ID,
+		})
+	}
+
+	// Update due date field and index (deleted tasks are re-indexed on restore)
+	userTasksDueKey := redis.GenerateKey("user", userID) + ":tasks:due"
+	if task.DueDate != nil {
+		pipe.HSet(ctx, taskKey, "due_date", task.DueDate.Unix())
+		if !existing.IsDeleted() {
+			pipe.ZAdd(ctx, userTasksDueKey, redislib.Z{
+				Score:  float64(task.DueDate.Unix()),
+				Member: task.ID,
+			})
+		}
+	} else {
+		pipe.HDel(ctx, taskKey, "due_date")
+		pipe.ZRem(ctx, userTasksDueKey, task.ID)
+	}
+
+	// Move task between category sets (deleted tasks are re-indexed on restore)
+	if categoryChanged && !existing.IsDeleted() {
+		if strings.TrimSpace(existing.Category) != "" {
+			oldCategoryKey := redis.GenerateKey("user", userID) + ":category:" + existing.Category
+			pipe.ZRem(ctx, oldCategoryKey, task.ID)
+		}
+
+		if strings.TrimSpace(task.Category) != "" {
+			pipe.SAdd(ctx, userCategoriesKey, task.Category)
+			newCategoryKey := redis.GenerateKey("user", userID) + ":category:" + task.Category
+			pipe.ZAdd(ctx, newCategoryKey, redislib.Z{
+				Score:  float64(existing.CreatedAt.Unix()),
+				Member: task.ID,
+			})
+		}
+	}
+
+	// Update tags field and move task between tag sets (deleted tasks are re-indexed on restore)
+	if len(task.Tags) > 0 {
+		pipe.HSet(ctx, taskKey, "tags", strings.Join(task.Tags, ","))
+	} else {
+		pipe.HDel(ctx, taskKey, "tags")
+	}
+	if !existing.IsDeleted() {
+		r.queueTagIndex(ctx, pipe, userID, task.ID, addedTags)
+		r.queueTagUnindex(ctx, pipe, userID, task.ID, removedTags)
+	}
+
+	// Re-index the description words when the description changes (deleted tasks are re-indexed on restore)
+	if existing.Description != task.Description && !existing.IsDeleted() {
+		r.queueSearchUnindex(ctx, pipe, userID, task.ID, removedTerms)
+		r.queueSearchIndex(ctx, pipe, userID, task.ID, newTerms)
+	}
+
+	// Execute pipeline
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("2003: failed to update task: %w", err)
+	}
+
+	// Drop removed tags from the user's tags once no task uses them
+	r.dropUnusedTags(ctx, userID, removedTags)
+
+	// Drop removed words from the search index once no task uses them
+	r.dropUnusedSearchTerms(ctx, userID, removedTerms)
+
+	// Drop the old category from the user's categories once no task uses it
+	if categoryChanged && strings.TrimSpace(existing.Category) != "" {
+		r.dropUnusedCategories(ctx, userID, []string{existing.Category})
+	}
+
+	return nil
+}
+
+// GetSeriesTasks retrieves every stored task in a recurring series ordered by occurrence
+// Includes completed and soft-deleted occurrences so the series history stays visible
+func (r *TaskRepository) GetSeriesTasks(userID, seriesID string) ([]*domain.Task, error) {
+	ctx := context.Background()
+	if strings.TrimSpace(seriesID) == "" {
+		return nil, fmt.Errorf("2004: series ID cannot be empty")
+	}
+
+	seriesKey := redis.GenerateKey("user", userID) + ":series:" + seriesID
+	taskIDs, err := r.client.ZRange(ctx, seriesKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("2003: failed to get series tasks: %w", err)
+	}
+
+	// Occurrences that have been purged are skipped
+	stored, err := r.getTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("2003: failed to get series tasks: %w", err)
+	}
+
+	tasks := make([]*domain.Task, 0, len(stored))
+	for _, task := range stored {
+		if task.UserID == userID {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// claimNextOccurrenceScript records the successor of a stored task unless it already has one
+// Checking the hash exists first keeps a purged task from being recreated with only this field
+var claimNextOccurrenceScript = redislib.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HSETNX", KEYS[1], "next_occurrence_id", ARGV[1])
+`)
+
+// CreateNextOccurrence creates the task following a completed occurrence of a recurring series
+// The previous task claims its successor atomically before it is created, so completing it again or twice at once creates one task
+// Returns false without creating anything if the previous task already has a successor or no longer exists
+// Error codes: 2001 (nil task), 2002 (validation error), 2003 (failure)
+func (r *TaskRepository) CreateNextOccurrence(previousID string, next *domain.Task) (bool, error) {
+	ctx := context.Background()
+	if next == nil {
+		return false, fmt.Errorf("2001: task cannot be nil")
+	}
+
+	previousKey := redis.GenerateKey(redis.TaskKeyPrefix, previousID)
+	claimed, err := claimNextOccurrenceScript.Run(ctx, r.client, []string{previousKey}, next.ID).Int()
+	if err != nil {
+		return false, fmt.Errorf("2003: failed to claim next occurrence: %w", err)
+	}
+	if claimed == 0 {
+		return false, nil
+	}
+
+	// Release the claim so completing the task again can retry
+	if err := r.CreateTask(next); err != nil {
+		if releaseErr := r.client.HDel(ctx, previousKey, "next_occurrence_id").Err(); releaseErr != nil {
+			return false, errors.Join(err, fmt.Errorf("2003: failed to release next occurrence: %w", releaseErr))
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetSubtasks retrieves the active subtasks of a parent task ordered by creation time
+// Soft-deleted subtasks are omitted
+// Error codes: 2003 (failure), 2004 (invalid ID)
+func (r *TaskRepository) GetSubtasks(parentID string) ([]*domain.Task, error) {
+	ctx := context.Background()
+	if strings.TrimSpace(parentID) == "" {
+		return nil, fmt.Errorf("2004: task ID cannot be empty")
+	}
+
+	subtasks, err := r.getSubtasks(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("2003: failed to get subtasks: %w", err)
+	}
+
+	activeSubtasks := make([]*domain.Task, 0, len(subtasks))
+	for _, subtask := range subtasks {
+		if !subtask.IsDeleted() {
+			activeSubtasks = append(activeSubtasks, subtask)
+		}
+	}
+
+	return activeSubtasks, nil
+}
+
 // SoftDeleteTask marks a task as deleted by moving it to deleted sorted set
-// Removes from active sets and adds to deleted set with expiry tracking
+// Removes from active sets and adds to deleted set with expiry tracking; active subtasks are deleted with their parent
 // Error codes: 2003 (not found), 2004 (invalid ID)
 func (r *TaskRepository) SoftDeleteTask(taskID string) error {
 	ctx := context.Background()
@@ -242,12 +630,49 @@ func (r *TaskRepository) SoftDeleteTask(taskID string) error {
 		return err // Error code already included
 	}
 
-	userID := task.UserID
+	subtasks, err := r.getSubtasks(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("2003: failed to get subtasks: %w", err)
+	}
+
 	now := time.Now()
 
 	// Use pipeline for atomic operations
 	pipe := r.client.TxPipeline()
 
+	r.queueSoftDelete(ctx, pipe, task, now)
+	unindexedTerms := domain.SearchTerms(task.Description)
+
+	// Cascade to subtasks that are still active, sharing the parent's deletion timestamp
+	for _, subtask := range subtasks {
+		if !subtask.IsDeleted() {
+			r.queueSoftDelete(ctx, pipe, subtask, now)
+			for term, count := range domain.SearchTerms(subtask.Description) {
+				unindexedTerms[term] = count
+			}
+		}
+	}
+
+	// Note: Redis sorted sets don't have individual expiry, so we rely on CleanupExpiredTasks
+	// to periodically clean up expired deleted tasks
+
+	// Execute pipeline
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("2003: failed to soft delete task: %w", err)
+	}
+
+	// Drop the deleted tasks' words from the search index once no task uses them
+	r.dropUnusedSearchTerms(ctx, task.UserID, searchTermList(unindexedTerms))
+
+	return nil
+}
+
+// queueSoftDelete adds the commands that soft-delete a single task to a pipeline
+func (r *TaskRepository) queueSoftDelete(ctx context.Context, pipe redislib.Pipeliner, task *domain.Task, now time.Time) {
+	taskID := task.ID
+	userID := task.UserID
+
 	// Update task hash with deleted timestamp
 	taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
 	pipe.HMSet(ctx, taskKey, map[string]interface{}{
@@ -261,13 +686,32 @@ func (r *TaskRepository) SoftDeleteTask(taskID string) error {
 
 	userTasksSortedKey := redis.GenerateKey("user", userID) + ":tasks:sorted"
 	pipe.ZRem(ctx, userTasksSortedKey, taskID)
+	pipe.ZRem(ctx, topLevelIndexKey(userID, false), taskID)
+
+	userTasksDueKey := redis.GenerateKey("user", userID) + ":tasks:due"
+	pipe.ZRem(ctx, userTasksDueKey, taskID)
+
+	userTasksPriorityKey := redis.GenerateKey("user", userID) + ":tasks:priority"
+	pipe.ZRem(ctx, userTasksPriorityKey, taskID)
+
+	userCompletedKey := redis.GenerateKey("user", userID) + ":tasks:completed"
+	pipe.ZRem(ctx, userCompletedKey, taskID)
+
+	userActiveKey := redis.GenerateKey("user", userID) + ":tasks:active"
+	pipe.ZRem(ctx, userActiveKey, taskID)
 
 	// Remove from category set if task has category
 	if strings.TrimSpace(task.Category) != "" {
 		categoryTasksKey := redis.GenerateKey("user", userID) + ":category:" + task.Category
-		pipe.SRem(ctx, categoryTasksKey, taskID)
+		pipe.ZRem(ctx, categoryTasksKey, taskID)
 	}
 
+	// Remove from tag sets
+	r.queueTagUnindex(ctx, pipe, userID, taskID, task.Tags)
+
+	// Remove from the search index
+	r.queueSearchUnindex(ctx, pipe, userID, taskID, searchTermList(domain.SearchTerms(task.Description)))
+
 	// Add to deleted sorted set with deletion timestamp as score
 	userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
 	pipe.ZAdd(ctx, userDeletedKey, redislib.Z{
@@ -275,20 +719,21 @@ func (r *TaskRepository) SoftDeleteTask(taskID string) error {
 		Member: taskID,
 	})
 
-	// Note: Redis sorted sets don't have individual expiry, so we rely on CleanupExpiredTasks
-	// to periodically clean up expired deleted tasks
-
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("2003: failed to soft delete task: %w", err)
+	// Add top-level tasks to the deleted listing index (scored by negated deletion timestamp, most recent first)
+	if task.ParentID == "" {
+		pipe.ZAdd(ctx, topLevelIndexKey(userID, true), redislib.Z{
+			Score:  -float64(now.Unix()),
+			Member: taskID,
+		})
 	}
 
-	return nil
+	// Record the user in the global index used by cleanup
+	pipe.SAdd(ctx, deletedTaskUsersKey, userID)
 }
 
 // RestoreTask restores a soft-deleted task to active status
 // Moves task from deleted set back to active sets and clears deletion timestamp
+// Subtasks deleted together with the parent are restored with it; subtasks deleted on their own stay deleted
 // Error codes: 2003 (not found), 2004 (invalid ID)
 func (r *TaskRepository) RestoreTask(taskID string) error {
 	ctx := context.Background()
@@ -302,12 +747,41 @@ func (r *TaskRepository) RestoreTask(taskID string) error {
 		return err // Error code already included
 	}
 
-	userID := task.UserID
+	subtasks, err := r.getSubtasks(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("2003: failed to get subtasks: %w", err)
+	}
+
 	now := time.Now()
 
 	// Use pipeline for atomic operations
 	pipe := r.client.TxPipeline()
 
+	r.queueRestore(ctx, pipe, task, now)
+
+	// Cascade to subtasks removed by the parent's deletion
+	if task.DeletedAt != nil {
+		for _, subtask := range subtasks {
+			if subtask.DeletedAt != nil && subtask.DeletedAt.Unix() == task.DeletedAt.Unix() {
+				r.queueRestore(ctx, pipe, subtask, now)
+			}
+		}
+	}
+
+	// Execute pipeline
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("2003: failed to restore task: %w", err)
+	}
+
+	return nil
+}
+
+// queueRestore adds the commands that restore a single soft-deleted task to a pipeline
+func (r *TaskRepository) queueRestore(ctx context.Context, pipe redislib.Pipeliner, task *domain.Task, now time.Time) {
+	taskID := task.ID
+	userID := task.UserID
+
 	// Update task hash to remove deleted timestamp
 	taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
 	pipe.HDel(ctx, taskKey, "deleted_at")
@@ -325,23 +799,51 @@ func (r *TaskRepository) RestoreTask(taskID string) error {
 		Member: taskID,
 	})
 
+	if task.ParentID == "" {
+		pipe.ZAdd(ctx, topLevelIndexKey(userID, false), redislib.Z{
+			Score:  -float64(task.CreatedAt.Unix()),
+			Member: taskID,
+		})
+	}
+
+	userTasksPriorityKey := redis.GenerateKey("user", userID) + ":tasks:priority"
+	pipe.ZAdd(ctx, userTasksPriorityKey, redislib.Z{
+		Score:  float64(task.Priority.Rank()),
+		Member: taskID,
+	})
+
+	r.queueCompletionIndex(ctx, pipe, task)
+
+	if task.DueDate != nil {
+		userTasksDueKey := redis.GenerateKey("user", userID) + ":tasks:due"
+		pipe.ZAdd(ctx, userTasksDueKey, redislib.Z{
+			Score:  float64(task.DueDate.Unix()),
+			Member: taskID,
+		})
+	}
+
 	// Add back to category set if task has category
 	if strings.TrimSpace(task.Category) != "" {
+		userCategoriesKey := redis.GenerateKey("user", userID) + ":categories"
+		pipe.SAdd(ctx, userCategoriesKey, task.Category)
+
 		categoryTasksKey := redis.GenerateKey("user", userID) + ":category:" + task.Category
-		pipe.SAdd(ctx, categoryTasksKey, taskID)
+		pipe.ZAdd(ctx, categoryTasksKey, redislib.Z{
+			Score:  float64(task.CreatedAt.Unix()),
+			Member: taskID,
+		})
 	}
 
-	// Remove from deleted set
-	userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
-	pipe.ZRem(ctx, userDeletedKey, taskID)
+	// Add back to tag sets
+	r.queueTagIndex(ctx, pipe, userID, taskID, task.Tags)
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("2003: failed to restore task: %w", err)
-	}
+	// Add back to the search index
+	r.queueSearchIndex(ctx, pipe, userID, taskID, domain.SearchTerms(task.Description))
 
-	return nil
+	// Remove from deleted sets
+	userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
+	pipe.ZRem(ctx, userDeletedKey, taskID)
+	pipe.ZRem(ctx, topLevelIndexKey(userID, true), taskID)
 }
 
 // GetUserCategories retrieves all unique categories for a user
@@ -385,9 +887,9 @@ func (r *TaskRepository) RenameCategory(userID, oldName, newName string) error {
 		return fmt.Errorf("2006: category not found")
 	}
 
-	// Get all tasks in the old category
+	// Get all tasks in the old category with their creation timestamps
 	oldCategoryKey := redis.GenerateKey("user", userID) + ":category:" + oldName
-	taskIDs, err := r.client.SMembers(ctx, oldCategoryKey).Result()
+	entries, err := r.client.ZRangeWithScores(ctx, oldCategoryKey, 0, -1).Result()
 	if err != nil {
 		return fmt.Errorf("2006: failed to get tasks in category: %w", err)
 	}
@@ -400,16 +902,16 @@ func (r *TaskRepository) RenameCategory(userID, oldName, newName string) error {
 	pipe.SAdd(ctx, userCategoriesKey, newName)
 
 	// Update each task's category in hash
-	for _, taskID := range taskIDs {
-		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
+	for _, entry := range entries {
+		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, entry.Member.(string))
 		pipe.HSet(ctx, taskKey, "category", newName)
 		pipe.HSet(ctx, taskKey, "updated_at", time.Now().Unix())
 	}
 
-	// Move tasks to new category set
+	// Move tasks to new category sorted set, keeping their creation timestamps
 	newCategoryKey := redis.GenerateKey("user", userID) + ":category:" + newName
-	for _, taskID := range taskIDs {
-		pipe.SAdd(ctx, newCategoryKey, taskID)
+	if len(entries) > 0 {
+		pipe.ZAdd(ctx, newCategoryKey, entries...)
 	}
 
 	// Remove old category set
@@ -445,7 +947,7 @@ func (r *TaskRepository) DeleteCategory(userID, categoryName string) error {
 
 	// Get all tasks in the category
 	categoryKey := redis.GenerateKey("user", userID) + ":category:" + categoryName
-	taskIDs, err := r.client.SMembers(ctx, categoryKey).Result()
+	taskIDs, err := r.client.ZRange(ctx, categoryKey, 0, -1).Result()
 	if err != nil {
 		return fmt.Errorf("2006: failed to get tasks in category: %w", err)
 	}
@@ -475,58 +977,502 @@ func (r *TaskRepository) DeleteCategory(userID, categoryName string) error {
 	return nil
 }
 
-// CleanupExpiredTasks removes tasks that have been soft-deleted for more than 7 days
-// Completely removes task hashes and cleans up any remaining references
-// Returns the number of tasks cleaned up
-func (r *TaskRepository) CleanupExpiredTasks() (int, error) {
+// categoryIndexesMigration names the marker recording that category indexes are sorted sets
+const categoryIndexesMigration = "category_indexes"
+
+// deletedTaskUsersMigration names the marker recording that the deleted task users index was backfilled
+const deletedTaskUsersMigration = "deleted_task_users"
+
+// migrationKey returns the marker key recording that a one-off data migration has completed
+func migrationKey(name string) string {
+	return redis.GenerateKey("migration", name)
+}
+
+// MigrateCategoryIndexes converts category indexes stored as plain sets into sorted sets
+// Tasks are scored by their creation timestamp; indexes that are already sorted sets are left untouched
+// Once a scan completes a marker key is set, so later startups skip the scan entirely
+// Returns the number of category indexes converted
+func (r *TaskRepository) MigrateCategoryIndexes() (int, error) {
 	ctx := context.Background()
-	// Find all users with deleted tasks
-	pattern := "user:*:tasks:deleted"
-	keys, err := r.client.Keys(ctx, pattern).Result()
+	migrated := 0
+
+	done, err := r.client.Exists(ctx, migrationKey(categoryIndexesMigration)).Result()
 	if err != nil {
-		return 0, fmt.Errorf("failed to find deleted task sets: %w", err)
+		return migrated, fmt.Errorf("failed to check category index migration: %w", err)
+	}
+	if done > 0 {
+		return migrated, nil
 	}
 
-	totalCleaned := 0
-	expiredTime := time.Now().AddDate(0, 0, -7).Unix() // 7 days ago
+	iter := r.client.Scan(ctx, 0, "user:*:category:*", 100).Iterator()
+	for iter.Next(ctx) {
+		categoryKey := iter.Val()
+		keyType, err := r.client.Type(ctx, categoryKey).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to inspect category index: %w", err)
+		}
+		if keyType != "set" {
+			continue
+		}
+
+		taskIDs, err := r.client.SMembers(ctx, categoryKey).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to read category index: %w", err)
+		}
+
+		// Look up each task's creation timestamp
+		pipe := r.client.Pipeline()
+		createdCmds := make([]*redislib.StringCmd, len(taskIDs))
+		for i, taskID := range taskIDs {
+			createdCmds[i] = pipe.HGet(ctx, redis.GenerateKey(redis.TaskKeyPrefix, taskID), "created_at")
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redislib.Nil {
+			return migrated, fmt.Errorf("failed to read task timestamps: %w", err)
+		}
+
+		entries := make([]redislib.Z, 0, len(taskIDs))
+		for i, taskID := range taskIDs {
+			createdAt, err := createdCmds[i].Int64()
+			if err != nil {
+				continue // Skip tasks whose hash no longer exists
+			}
+			entries = append(entries, redislib.Z{Score: float64(createdAt), Member: taskID})
+		}
+
+		// Replace the set with a sorted set in a single transaction
+		pipe = r.client.TxPipeline()
+		pipe.Del(ctx, categoryKey)
+		if len(entries) > 0 {
+			pipe.ZAdd(ctx, categoryKey, entries...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return migrated, fmt.Errorf("failed to write category index: %w", err)
+		}
+
+		migrated++
+	}
+
+	if err := iter.Err(); err != nil {
+		return migrated, fmt.Errorf("failed to scan category indexes: %w", err)
+	}
+
+	if err := r.client.Set(ctx, migrationKey(categoryIndexesMigration), time.Now().Unix(), 0).Err(); err != nil {
+		return migrated, fmt.Errorf("failed to record category index migration: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// GetUserTags retrieves all tags used by a user's active tasks with their task counts
+// Returns tags sorted by name
+func (r *TaskRepository) GetUserTags(userID string) ([]domain.TagSummary, error) {
+	ctx := context.Background()
+	userTagsKey := redis.GenerateKey("user", userID) + ":tags"
+
+	tags, err := r.client.SMembers(ctx, userTagsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("2003: failed to get tags: %w", err)
+	}
+	sort.Strings(tags)
+
+	// Count tasks per tag in a single round trip
+	pipe := r.client.Pipeline()
+	counts := make([]*redislib.IntCmd, len(tags))
+	for i, tag := range tags {
+		counts[i] = pipe.SCard(ctx, redis.GenerateKey("user", userID)+":tag:"+tag)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redislib.Nil {
+		return nil, fmt.Errorf("2003: failed to count tag tasks: %w", err)
+	}
+
+	summaries := make([]domain.TagSummary, 0, len(tags))
+	for i, tag := range tags {
+		summaries = append(summaries, domain.TagSummary{
+			Name:      tag,
+			TaskCount: int(counts[i].Val()),
+		})
+	}
+
+	return summaries, nil
+}
+
+// RenameTag renames a tag across all of a user's tasks, including soft-deleted ones
+// The new name must not already be in use
+// Error codes: 2006 (tag not found), 2007 (invalid names), 2008 (tag already exists)
+func (r *TaskRepository) RenameTag(userID, oldName, newName string) error {
+	ctx := context.Background()
+	if strings.TrimSpace(oldName) == "" || strings.TrimSpace(newName) == "" {
+		return fmt.Errorf("2007: tag names cannot be empty")
+	}
+
+	userTagsKey := redis.GenerateKey("user", userID) + ":tags"
+	exists, err := r.client.SIsMember(ctx, userTagsKey, newName).Result()
+	if err != nil {
+		return fmt.Errorf("2006: failed to check tag existence: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("2008: %w", domain.ErrTagExists)
+	}
+
+	return r.moveTag(ctx, userID, oldName, newName)
+}
 
-	for _, deletedSetKey := range keys {
-		// Get expired tasks (deleted more than 7 days ago)
-		expiredTasks, err := r.client.ZRangeByScore(ctx, deletedSetKey, &redislib.ZRangeBy{
-			Min: "-inf",
-			Max: fmt.Sprintf("%d", expiredTime),
-		}).Result()
+// MergeTags moves every task tagged with the source tag to the target tag and removes the source tag
+// The target tag is created if it does not exist yet
+// Error codes: 2006 (tag not found), 2007 (invalid names)
+func (r *TaskRepository) MergeTags(userID, sourceName, targetName string) error {
+	ctx := context.Background()
+	if strings.TrimSpace(sourceName) == "" || strings.TrimSpace(targetName) == "" {
+		return fmt.Errorf("2007: tag names cannot be empty")
+	}
+
+	return r.moveTag(ctx, userID, sourceName, targetName)
+}
+
+// SearchTasks finds a user's active tasks whose description contains every query word as a word or word prefix
+// Results are ranked by relevance: whole-word matches outweigh prefix matches, repeated words add weight, and ties list newer tasks first
+// Error codes: 2003 (failure), 2004 (invalid user ID), 2005 (invalid query)
+func (r *TaskRepository) SearchTasks(userID, query string, limit int) ([]*domain.Task, error) {
+	ctx := context.Background()
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("2004: user ID cannot be empty")
+	}
 
+	terms, err := domain.ParseSearchQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("2005: %w", err)
+	}
+
+	if limit <= 0 || limit > domain.MaxSearchLimit {
+		limit = domain.DefaultSearchLimit
+	}
+
+	// Score tasks per query word, keeping only tasks that match every word
+	var scores map[string]float64
+	for i, term := range terms {
+		termScores, err := r.scoreSearchTerm(ctx, userID, term)
 		if err != nil {
-			continue // Skip this set on error
+			return nil, fmt.Errorf("2003: failed to search tasks: %w", err)
 		}
 
-		if len(expiredTasks) == 0 {
+		if i == 0 {
+			scores = termScores
 			continue
 		}
 
-		// Use pipeline for cleanup operations
-		pipe := r.client.TxPipeline()
+		for taskID, score := range scores {
+			if termScore, ok := termScores[taskID]; ok {
+				scores[taskID] = score + termScore
+			} else {
+				delete(scores, taskID)
+			}
+		}
+	}
+
+	// Fetch task details in a single round trip
+	matchedIDs := make([]string, 0, len(scores))
+	for taskID := range scores {
+		matchedIDs = append(matchedIDs, taskID)
+	}
+	matched, err := r.getTasksByIDs(ctx, matchedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("2003: failed to get tasks: %w", err)
+	}
 
-		for _, taskID := range expiredTasks {
-			// Remove task hash completely
-			taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
-			pipe.Del(ctx, taskKey)
+	tasks := make([]*domain.Task, 0, len(matched))
+	for _, task := range matched {
+		if task.UserID == userID && !task.IsDeleted() {
+			tasks = append(tasks, task)
+		}
+	}
 
-			// Remove from deleted set
-			pipe.ZRem(ctx, deletedSetKey, taskID)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if scores[tasks[i].ID] != scores[tasks[j].ID] {
+			return scores[tasks[i].ID] > scores[tasks[j].ID]
 		}
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	return tasks, nil
+}
 
-		// Execute cleanup pipeline
-		_, err = pipe.Exec(ctx)
+// cleanupBatchSize caps how many expired tasks are purged in a single transaction
+const cleanupBatchSize = 100
+
+// deletedTaskUsersKey is the global set of users that have soft-deleted tasks awaiting cleanup
+const deletedTaskUsersKey = "users:with_deleted_tasks"
+
+// untrackEmptyDeletedScript removes a user from the deleted task users index once their deleted set is empty
+// Checking and removing in one script keeps a concurrent soft delete from being missed
+var untrackEmptyDeletedScript = redislib.NewScript(`
+if redis.call("ZCARD", KEYS[1]) == 0 then
+	return redis.call("SREM", KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// CleanupExpiredTasks removes tasks that have been soft-deleted for more than 7 days
+// Completely removes task hashes and every index entry, category, tag and search term left behind by them
+// Expired tasks are purged in batches of cleanupBatchSize; returns the number of tasks purged per user
+// A failure skips the rest of that user's tasks; failures are joined and returned with the tasks purged so far
+// Stops between batches once ctx is done, returning the tasks purged so far with the context error
+func (r *TaskRepository) CleanupExpiredTasks(ctx context.Context) (*domain.CleanupReport, error) {
+	// Find all users with deleted tasks
+	userIDs, err := r.client.SMembers(ctx, deletedTaskUsersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users with deleted tasks: %w", err)
+	}
+
+	report := &domain.CleanupReport{PerUser: make(map[string]int)}
+	expiredTime := time.Now().AddDate(0, 0, -7).Unix() // 7 days ago
+	var errs []error
+
+	for _, userID := range userIDs {
+		deletedSetKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return report, errors.Join(append(errs, err)...)
+			}
+
+			// Get the next batch of expired tasks (deleted more than 7 days ago)
+			expiredTasks, err := r.client.ZRangeByScore(ctx, deletedSetKey, &redislib.ZRangeBy{
+				Min:   "-inf",
+				Max:   fmt.Sprintf("%d", expiredTime),
+				Count: cleanupBatchSize,
+			}).Result()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to find expired tasks of user %s: %w", userID, err))
+				break
+			}
+			if len(expiredTasks) == 0 {
+				break
+			}
+
+			if err := r.purgeTasks(ctx, userID, deletedSetKey, expiredTasks); err != nil {
+				errs = append(errs, fmt.Errorf("failed to purge expired tasks of user %s: %w", userID, err))
+				break
+			}
+
+			report.PerUser[userID] += len(expiredTasks)
+			report.Total += len(expiredTasks)
+
+			if len(expiredTasks) < cleanupBatchSize {
+				break
+			}
+		}
+
+		// Stop tracking users without any deleted tasks left
+		if err := untrackEmptyDeletedScript.Run(ctx, r.client, []string{deletedSetKey, deletedTaskUsersKey}, userID).Err(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to untrack user %s: %w", userID, err))
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// BackfillDeletedTaskUsers adds users whose tasks were soft-deleted before the deleted task users index existed
+// Scans the per-user deleted sets incrementally; returns the number of users added
+// Once a scan completes a marker key is set, so later startups skip the scan entirely
+func (r *TaskRepository) BackfillDeletedTaskUsers() (int, error) {
+	ctx := context.Background()
+	added := 0
+
+	done, err := r.client.Exists(ctx, migrationKey(deletedTaskUsersMigration)).Result()
+	if err != nil {
+		return added, fmt.Errorf("failed to check deleted task users backfill: %w", err)
+	}
+	if done > 0 {
+		return added, nil
+	}
+
+	iter := r.client.Scan(ctx, 0, "user:*:tasks:deleted", 100).Iterator()
+	for iter.Next(ctx) {
+		deletedSetKey := iter.Val()
+		if r.client.ZCard(ctx, deletedSetKey).Val() == 0 {
+			continue
+		}
+
+		userID := strings.TrimSuffix(strings.TrimPrefix(deletedSetKey, "user:"), ":tasks:deleted")
+		count, err := r.client.SAdd(ctx, deletedTaskUsersKey, userID).Result()
 		if err != nil {
-			continue // Skip this batch on error
+			return added, fmt.Errorf("failed to index user with deleted tasks: %w", err)
 		}
+		added += int(count)
+	}
+	if err := iter.Err(); err != nil {
+		return added, fmt.Errorf("failed to scan deleted task sets: %w", err)
+	}
 
-		totalCleaned += len(expiredTasks)
+	if err := r.client.Set(ctx, migrationKey(deletedTaskUsersMigration), time.Now().Unix(), 0).Err(); err != nil {
+		return added, fmt.Errorf("failed to record deleted task users backfill: %w", err)
 	}
 
-	return totalCleaned, nil
+	return added, nil
+}
+
+// DeleteUserTasks permanently removes every task of a user together with all of the user's task indexes
+// Covers active, completed and soft-deleted tasks, categories, tags, search terms and series; returns the number of tasks removed
+func (r *TaskRepository) DeleteUserTasks(userID string) (int, error) {
+	if strings.TrimSpace(userID) == "" {
+		return 0, fmt.Errorf("2004: user ID cannot be empty")
+	}
+
+	ctx := context.Background()
+	userKey := redis.GenerateKey("user", userID)
+
+	// Collect task IDs and index members from every index that may reference them
+	pipe := r.client.Pipeline()
+	activeCmd := pipe.SMembers(ctx, userKey+":tasks")
+	sortedCmd := pipe.ZRange(ctx, userKey+":tasks:sorted", 0, -1)
+	deletedCmd := pipe.ZRange(ctx, userKey+":tasks:deleted", 0, -1)
+	subtasksCmd := pipe.SMembers(ctx, userKey+":subtasks")
+	categoriesCmd := pipe.SMembers(ctx, userKey+":categories")
+	tagsCmd := pipe.SMembers(ctx, userKey+":tags")
+	termsCmd := pipe.ZRange(ctx, userKey+":search:terms", 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil && err != redislib.Nil {
+		return 0, fmt.Errorf("failed to get user task indexes: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var taskIDs []string
+	for _, ids := range [][]string{activeCmd.Val(), sortedCmd.Val(), deletedCmd.Val(), subtasksCmd.Val()} {
+		for _, taskID := range ids {
+			if !seen[taskID] {
+				seen[taskID] = true
+				taskIDs = append(taskIDs, taskID)
+			}
+		}
+	}
+
+	tasks, err := r.getTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user tasks: %w", err)
+	}
+
+	keys := []string{
+		userKey + ":tasks",
+		userKey + ":tasks:sorted",
+		userKey + ":tasks:due",
+		userKey + ":tasks:priority",
+		userKey + ":tasks:deleted",
+		topLevelIndexKey(userID, false),
+		topLevelIndexKey(userID, true),
+		topLevelIndexedKey(userID),
+		completionIndexKey(userID, true),
+		completionIndexKey(userID, false),
+		userKey + ":subtasks",
+		userKey + ":categories",
+		userKey + ":tags",
+		userKey + ":search:terms",
+	}
+	for _, taskID := range taskIDs {
+		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
+		keys = append(keys, taskKey, taskKey+":subtasks")
+	}
+	categories := categoriesCmd.Val()
+	for _, task := range tasks {
+		if task.SeriesID != "" {
+			keys = append(keys, userKey+":series:"+task.SeriesID)
+		}
+		// Deleted tasks may still name a category that is no longer in the categories set
+		if strings.TrimSpace(task.Category) != "" {
+			categories = append(categories, task.Category)
+		}
+	}
+	for _, category := range categories {
+		keys = append(keys, userKey+":category:"+category)
+	}
+	for _, tag := range tagsCmd.Val() {
+		keys = append(keys, userKey+":tag:"+tag)
+	}
+	for _, term := range termsCmd.Val() {
+		keys = append(keys, userKey+":search:term:"+term)
+	}
+
+	tx := r.client.TxPipeline()
+	tx.Del(ctx, keys...)
+	tx.SRem(ctx, deletedTaskUsersKey, userID)
+	if _, err := tx.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete user tasks: %w", err)
+	}
+
+	return len(tasks), nil
+}
+
+// purgeTasks permanently removes a batch of a user's soft-deleted tasks and every reference to them
+// Categories, tags and search terms are dropped from the user's indexes once no task uses them
+func (r *TaskRepository) purgeTasks(ctx context.Context, userID, deletedSetKey string, taskIDs []string) error {
+	tasks, err := r.getTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	userKey := redis.GenerateKey("user", userID)
+	var categories, tags, terms []string
+
+	// Use pipeline for cleanup operations
+	pipe := r.client.TxPipeline()
+
+	for _, task := range tasks {
+		// Remove any index entries left behind by the task
+		pipe.SRem(ctx, userKey+":tasks", task.ID)
+		pipe.ZRem(ctx, userKey+":tasks:sorted", task.ID)
+		pipe.ZRem(ctx, topLevelIndexKey(userID, false), task.ID)
+		pipe.ZRem(ctx, topLevelIndexKey(userID, true), task.ID)
+		pipe.ZRem(ctx, userKey+":tasks:due", task.ID)
+		pipe.ZRem(ctx, userKey+":tasks:priority", task.ID)
+		pipe.ZRem(ctx, completionIndexKey(userID, true), task.ID)
+		pipe.ZRem(ctx, completionIndexKey(userID, false), task.ID)
+
+		if strings.TrimSpace(task.Category) != "" {
+			pipe.ZRem(ctx, userKey+":category:"+task.Category, task.ID)
+			categories = append(categories, task.Category)
+		}
+
+		r.queueTagUnindex(ctx, pipe, userID, task.ID, task.Tags)
+		tags = append(tags, task.Tags...)
+
+		taskTerms := searchTermList(domain.SearchTerms(task.Description))
+		r.queueSearchUnindex(ctx, pipe, userID, task.ID, taskTerms)
+		terms = append(terms, taskTerms...)
+
+		// Remove the task from its series history and its parent's subtasks
+		if task.SeriesID != "" {
+			pipe.ZRem(ctx, userKey+":series:"+task.SeriesID, task.ID)
+		}
+		if task.ParentID != "" {
+			pipe.ZRem(ctx, redis.GenerateKey(redis.TaskKeyPrefix, task.ParentID)+":subtasks", task.ID)
+			pipe.SRem(ctx, userKey+":subtasks", task.ID)
+		}
+	}
+
+	for _, taskID := range taskIDs {
+		// Remove task hash and subtask index completely
+		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
+		pipe.Del(ctx, taskKey, taskKey+":subtasks")
+
+		// Remove from deleted set
+		pipe.ZRem(ctx, deletedSetKey, taskID)
+	}
+
+	// Execute cleanup pipeline
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	r.dropUnusedCategories(ctx, userID, categories)
+	r.dropUnusedTags(ctx, userID, tags)
+	r.dropUnusedSearchTerms(ctx, userID, terms)
+
+	return nil
 }
 
 // Helper methods
@@ -545,6 +1491,12 @@ func (r *TaskRepository) parseTaskFromHash(data map[string]string) (*domain.Task
 		task.Completed = true
 	}
 
+	// Tasks stored before priorities existed have no priority field
+	task.Priority = domain.TaskPriority(data["priority"])
+	if task.Priority == "" {
+		task.Priority = domain.PriorityNone
+	}
+
 	// Parse timestamps
 	if createdAtStr := data["created_at"]; createdAtStr != "" {
 		if createdAt, err := parseUnixTimestamp(createdAtStr); err == nil {
@@ -558,6 +1510,33 @@ func (r *TaskRepository) parseTaskFromHash(data map[string]string) (*domain.Task
 		}
 	}
 
+	if completedAtStr := data["completed_at"]; completedAtStr != "" {
+		if completedAt, err := parseUnixTimestamp(completedAtStr); err == nil {
+			task.CompletedAt = &completedAt
+		}
+	}
+
+	// Parse recurrence fields
+	task.Recurrence = data["recurrence"]
+	task.SeriesID = data["series_id"]
+	if occurrenceStr := data["occurrence"]; occurrenceStr != "" {
+		if occurrence, err := strconv.Atoi(occurrenceStr); err == nil {
+			task.Occurrence = occurrence
+		}
+	}
+
+	task.ParentID = data["parent_id"]
+
+	if tagsStr := data["tags"]; tagsStr != "" {
+		task.Tags = strings.Split(tagsStr, ",")
+	}
+
+	if dueDateStr := data["due_date"]; dueDateStr != "" {
+		if dueDate, err := parseUnixTimestamp(dueDateStr); err == nil {
+			task.DueDate = &dueDate
+		}
+	}
+
 	if deletedAtStr := data["deleted_at"]; deletedAtStr != "" {
 		if deletedAt, err := parseUnixTimestamp(deletedAtStr); err == nil {
 			task.DeletedAt = &deletedAt
@@ -567,45 +1546,836 @@ func (r *TaskRepository) parseTaskFromHash(data map[string]string) (*domain.Task
 	return task, nil
 }
 
-// getActiveTaskIDs retrieves task IDs from active sets with optional category filtering
-func (r *TaskRepository) getActiveTaskIDs(ctx context.Context, userID string, filters domain.TaskFilters) ([]string, error) {
-	var taskIDs []string
-	var err error
+// listingKeyTTL bounds how long the temporary keys of an interrupted task listing are kept
+const listingKeyTTL = time.Minute
+
+// listingPriorityWeight spreads priority ranks far enough apart that creation times never overlap them
+const listingPriorityWeight = 1e10
+
+// taskListing queues the commands that build the temporary sorted sets of one task listing
+// Temporary keys are scoped to the listing so concurrent listings never share them
+type taskListing struct {
+	ctx    context.Context
+	pipe   redislib.Pipeliner
+	prefix string
+	keys   []string
+
+	members string // matching active top-level tasks scored by negated creation time (temporary only when filtered)
+	dated   string // members with a due date in range, scored by due date
+	ranks   string // members scored by priority rank
+}
+
+// listingSegment is a temporary sorted set holding the tasks that share the leading scores of their sort key
+// Members are scored so that ZRANGE returns them in listing order, which breaks ties by task ID like cursors do
+type listingSegment struct {
+	key     string
+	prefix  []int64   // sort key scores shared by every task of the segment
+	weights []float64 // combine the remaining sort key scores into the member score
+
+	count  *redislib.IntCmd
+	before *redislib.IntCmd         // members scored before the cursor
+	ties   *redislib.StringSliceCmd // members scored the same as the cursor
+}
+
+// newTaskListing starts a listing of a user's tasks in a new transaction
+func (r *TaskRepository) newTaskListing(ctx context.Context, userID string) *taskListing {
+	return &taskListing{
+		ctx:    ctx,
+		pipe:   r.client.TxPipeline(),
+		prefix: redis.GenerateKey("user", userID) + ":tasks:listing:" + uuid.New().String() + ":",
+	}
+}
+
+// key returns the name of a temporary key of the listing
+func (l *taskListing) key(name string) string {
+	key := l.prefix + name
+	l.keys = append(l.keys, key)
+	return key
+}
+
+// queueExpiry queues an expiry on every temporary key of the listing
+func (l *taskListing) queueExpiry() {
+	for _, key := range l.keys {
+		l.pipe.Expire(l.ctx, key, listingKeyTTL)
+	}
+}
+
+// queueSegments queues the commands that split the matching tasks into sorted segments and count them
+// Segments are returned in listing order
+func (l *taskListing) queueSegments(userID string, filters domain.TaskFilters, sortBy string) []*listingSegment {
+	ctx := l.ctx
+	userKey := redis.GenerateKey("user", userID)
+	l.queueMembers(userID, filters)
+
+	var segments []*listingSegment
+	switch sortBy {
+	case domain.TaskSortDue:
+		if l.dated == "" {
+			l.dated = l.key("due")
+			l.pipe.ZInterStore(ctx, l.dated, &redislib.ZStore{
+				Keys:    []string{userKey + ":tasks:due", l.members},
+				Weights: []float64{1, 0},
+			})
+		}
+		segments = append(segments, &listingSegment{key: l.dated, prefix: []int64{0, 0}, weights: []float64{1}})
+
+		// Tasks without a due date come last, newest first, unless due filters leave them out
+		if !hasDueFilters(filters) {
+			// Creation times are positive, so dated tasks are the ones scored 0 by the union
+			undatedKey := l.key("undated")
+			l.pipe.ZUnionStore(ctx, undatedKey, &redislib.ZStore{
+				Keys:      []string{l.members, l.dated},
+				Weights:   []float64{1, 0},
+				Aggregate: "MAX",
+			})
+			l.pipe.ZRemRangeByScore(ctx, undatedKey, "0", "+inf")
+			segments = append(segments, &listingSegment{key: undatedKey, prefix: []int64{0, 1}, weights: []float64{1}})
+		}
+	case domain.TaskSortPriority:
+		l.queueRanks(userKey)
+		priorityKey := l.key("priority")
+		l.pipe.ZInterStore(ctx, priorityKey, &redislib.ZStore{
+			Keys:    []string{l.members, l.ranks},
+			Weights: []float64{1, -listingPriorityWeight},
+		})
+		segments = append(segments, &listingSegment{key: priorityKey, prefix: []int64{0}, weights: []float64{listingPriorityWeight, 1}})
+	default:
+		segments = append(segments, &listingSegment{key: l.members, prefix: []int64{0}, weights: []float64{1}})
+	}
+
+	// Deleted tasks follow the active ones, most recently deleted first
+	if filters.IncludeDeleted {
+		segments = append(segments, &listingSegment{key: l.queueDeleted(userID, filters), prefix: []int64{1}, weights: []float64{1}})
+	}
+
+	for _, segment := range segments {
+		segment.count = l.pipe.ZCard(ctx, segment.key)
+	}
+
+	return segments
+}
+
+// queueCursorPosition queues the commands that locate the cursor within the segment sharing its leading scores
+// Returns ErrInvalidCursor when the cursor's sort key doesn't fit that segment
+func (l *taskListing) queueCursorPosition(segments []*listingSegment, cursor *domain.TaskCursor) error {
+	for _, segment := range segments {
+		if len(cursor.Key) < len(segment.prefix) ||
+			compareTaskSortKeys(cursor.Key[:len(segment.prefix)], "", segment.prefix, "") != 0 {
+			continue
+		}
+
+		suffix := cursor.Key[len(segment.prefix):]
+		if len(suffix) != len(segment.weights) {
+			return domain.ErrInvalidCursor
+		}
+
+		score := 0.0
+		for i, weight := range segment.weights {
+			score += weight * float64(suffix[i])
+		}
+		scoreStr := strconv.FormatFloat(score, 'f', -1, 64)
+		segment.before = l.pipe.ZCount(l.ctx, segment.key, "-inf", "("+scoreStr)
+		segment.ties = l.pipe.ZRangeByScore(l.ctx, segment.key, &redislib.ZRangeBy{Min: scoreStr, Max: scoreStr})
+	}
+	return nil
+}
+
+// cursorPosition returns the number of listed tasks up to and including the cursor position
+// Segments ahead of the cursor are skipped whole
+func cursorPosition(segments []*listingSegment, cursor *domain.TaskCursor) int {
+	position := 0
+	for _, segment := range segments {
+		if segment.before != nil {
+			position += int(segment.before.Val())
+			for _, taskID := range segment.ties.Val() {
+				if taskID <= cursor.ID {
+					position++
+				}
+			}
+			return position
+		}
+		if compareTaskSortKeys(cursor.Key, cursor.ID, segment.prefix, "") < 0 {
+			return position
+		}
+		position += int(segment.count.Val())
+	}
+	return position
+}
+
+// queueRanks queues the command that scores the members by priority rank
+// Tasks missing from the priority index rank as having no priority
+func (l *taskListing) queueRanks(userKey string) {
+	if l.ranks != "" {
+		return
+	}
+	l.ranks = l.key("ranks")
+	l.pipe.ZUnionStore(l.ctx, l.ranks, &redislib.ZStore{
+		Keys:    []string{l.members, userKey + ":tasks:priority"},
+		Weights: []float64{0, 1},
+	})
+}
+
+// queueMembers queues the commands that intersect the filter indexes into the members sorted set
+// Members are the matching active top-level tasks scored by negated creation time, so the newest comes first
+// Without filters the members are the maintained top-level index itself and nothing is written
+func (l *taskListing) queueMembers(userID string, filters domain.TaskFilters) {
+	ctx := l.ctx
+	userKey := redis.GenerateKey("user", userID)
+
+	// Subtasks are listed under their parent rather than as top-level tasks
+	keys := []string{topLevelIndexKey(userID, false)}
+	if !hasTaskFilters(filters) {
+		l.members = keys[0]
+		return
+	}
 
 	if strings.TrimSpace(filters.Category) != "" {
-		// Get tasks from specific category
-		categoryKey := redis.GenerateKey("user", userID) + ":category:" + filters.Category
-		taskIDs, err = r.client.SMembers(ctx, categoryKey).Result()
-	} else {
-		// Get all active tasks sorted by creation time (descending)
-		userTasksSortedKey := redis.GenerateKey("user", userID) + ":tasks:sorted"
-		taskIDs, err = r.client.ZRevRange(ctx, userTasksSortedKey, 0, -1).Result()
+		keys = append(keys, userKey+":category:"+filters.Category)
+	}
+	if filters.Completed != nil {
+		keys = append(keys, completionIndexKey(userID, *filters.Completed))
+	}
+	if len(filters.Tags) > 0 {
+		tagKeys := make([]string, len(filters.Tags))
+		for i, tag := range filters.Tags {
+			tagKeys[i] = userKey + ":tag:" + tag
+		}
+		if filters.TagMatch == domain.TagMatchAll {
+			keys = append(keys, tagKeys...)
+		} else {
+			anyTagKey := l.key("tags")
+			l.pipe.SUnionStore(ctx, anyTagKey, tagKeys...)
+			keys = append(keys, anyTagKey)
+		}
+	}
+
+	// Only the creation time contributes to the score
+	weights := make([]float64, len(keys))
+	weights[0] = 1
+	l.members = l.key("members")
+	l.pipe.ZInterStore(ctx, l.members, &redislib.ZStore{Keys: keys, Weights: weights})
+
+	if filters.Priority != "" {
+		l.queueRanks(userKey)
+		rank := strconv.Itoa(filters.Priority.Rank())
+		l.pipe.ZRemRangeByScore(ctx, l.ranks, "-inf", "("+rank)
+		l.pipe.ZRemRangeByScore(ctx, l.ranks, "("+rank, "+inf")
+		l.pipe.ZInterStore(ctx, l.members, &redislib.ZStore{
+			Keys:    []string{l.members, l.ranks},
+			Weights: []float64{1, 0},
+		})
+	}
+
+	// Due filters go last so the dated set holds exactly the members
+	if hasDueFilters(filters) {
+		l.dated = l.key("due")
+		l.pipe.ZInterStore(ctx, l.dated, &redislib.ZStore{
+			Keys:    []string{userKey + ":tasks:due", l.members},
+			Weights: []float64{1, 0},
+		})
+		queueDueRange(ctx, l.pipe, l.dated, filters)
+		l.pipe.ZInterStore(ctx, l.members, &redislib.ZStore{
+			Keys:    []string{l.members, l.dated},
+			Weights: []float64{1, 0},
+		})
+	}
+}
+
+// queueDueRange adds the commands that drop tasks due outside the due date filters from a due-scored sorted set
+func queueDueRange(ctx context.Context, pipe redislib.Pipeliner, key string, filters domain.TaskFilters) {
+	if filters.DueAfter != nil {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(filters.DueAfter.Unix(), 10))
 	}
+	if filters.DueBefore != nil {
+		pipe.ZRemRangeByScore(ctx, key, "("+strconv.FormatInt(filters.DueBefore.Unix(), 10), "+inf")
+	}
+	if filters.Overdue {
+		// Tasks due right now are not overdue yet
+		pipe.ZRemRangeByScore(ctx, key, strconv.FormatInt(time.Now().Unix(), 10), "+inf")
+	}
+}
 
-	return taskIDs, err
+// queueCompletionIndex adds the commands that file a task under the completed or active index to a pipeline
+// Both indexes are scored by creation time like the sorted tasks index
+func (r *TaskRepository) queueCompletionIndex(ctx context.Context, pipe redislib.Pipeliner, task *domain.Task) {
+	pipe.ZAdd(ctx, completionIndexKey(task.UserID, task.Completed), redislib.Z{
+		Score:  float64(task.CreatedAt.Unix()),
+		Member: task.ID,
+	})
+	pipe.ZRem(ctx, completionIndexKey(task.UserID, !task.Completed), task.ID)
 }
 
-// getAllTaskIDs retrieves task IDs from both active and deleted sets
-func (r *TaskRepository) getAllTaskIDs(ctx context.Context, userID string, filters domain.TaskFilters) ([]string, error) {
-	// Get active tasks
-	activeIDs, err := r.getActiveTaskIDs(ctx, userID, filters)
+// ensureCompletionIndex rebuilds a user's completed and active indexes when they don't cover every active task
+// Tasks created before the indexes existed are filed from their stored completion status
+func (r *TaskRepository) ensureCompletionIndex(ctx context.Context, userID string) error {
+	userTasksSortedKey := redis.GenerateKey("user", userID) + ":tasks:sorted"
+
+	pipe := r.client.Pipeline()
+	sortedCount := pipe.ZCard(ctx, userTasksSortedKey)
+	completedCount := pipe.ZCard(ctx, completionIndexKey(userID, true))
+	activeCount := pipe.ZCard(ctx, completionIndexKey(userID, false))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if sortedCount.Val() == completedCount.Val()+activeCount.Val() {
+		return nil
+	}
+
+	entries, err := r.client.ZRangeWithScores(ctx, userTasksSortedKey, 0, -1).Result()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	pipe = r.client.Pipeline()
+	completedCmds := make([]*redislib.StringCmd, len(entries))
+	for i, entry := range entries {
+		completedCmds[i] = pipe.HGet(ctx, redis.GenerateKey(redis.TaskKeyPrefix, entry.Member.(string)), "completed")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redislib.Nil {
+		return err
+	}
+
+	pipe = r.client.TxPipeline()
+	pipe.Del(ctx, completionIndexKey(userID, true), completionIndexKey(userID, false))
+	for i, entry := range entries {
+		completed := completedCmds[i].Val() == "1" || completedCmds[i].Val() == "true"
+		pipe.ZAdd(ctx, completionIndexKey(userID, completed), entry)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// filterDeletedTasksScript copies the soft-deleted tasks matching the listing filters into a temporary sorted set
+// Deleted tasks are left out of the filter indexes, so their stored fields are checked one by one; the retention window keeps them few
+// ARGV: task key prefix, category, completed ("1" or "0"), priority, due after, due before, overdue before, tag match, tags...
+var filterDeletedTasksScript = redislib.NewScript(`
+local entries = redis.call("ZRANGE", KEYS[1], 0, -1, "WITHSCORES")
+for i = 1, #entries, 2 do
+	local task = redis.call("HMGET", ARGV[1] .. entries[i], "category", "completed", "priority", "due_date", "tags")
+	local completed = task[2] == "1" or task[2] == "true"
+	local match = (ARGV[2] == "" or task[1] == ARGV[2])
+		and (ARGV[3] == "" or (ARGV[3] == "1") == completed)
+		and (ARGV[4] == "" or (task[3] or "") == ARGV[4])
+	if match and (ARGV[5] ~= "" or ARGV[6] ~= "" or ARGV[7] ~= "") then
+		local due = tonumber(task[4] or "")
+		match = due ~= nil
+			and (ARGV[5] == "" or due >= tonumber(ARGV[5]))
+			and (ARGV[6] == "" or due <= tonumber(ARGV[6]))
+			and (ARGV[7] == "" or due < tonumber(ARGV[7]))
+	end
+	if match and #ARGV > 8 then
+		local tags = {}
+		for tag in string.gmatch(task[5] or "", "[^,]+") do
+			tags[tag] = true
+		end
+		local matched = 0
+		for j = 9, #ARGV do
+			if tags[ARGV[j]] then
+				matched = matched + 1
+			end
+		end
+		if ARGV[8] == "all" then
+			match = matched == #ARGV - 8
+		else
+			match = matched > 0
+		end
+	end
+	if match then
+		redis.call("ZADD", KEYS[2], entries[i + 1], entries[i])
+	end
+end
+return redis.call("ZCARD", KEYS[2])
+`)
+
+// queueDeleted queues the commands that collect the soft-deleted top-level tasks matching the filters
+// Returns the key of a sorted set scored by negated deletion time; without filters it is the maintained index itself
+func (l *taskListing) queueDeleted(userID string, filters domain.TaskFilters) string {
+	deletedKey := topLevelIndexKey(userID, true)
+	if !hasTaskFilters(filters) {
+		return deletedKey
+	}
+
+	completed := ""
+	if filters.Completed != nil {
+		completed = "0"
+		if *filters.Completed {
+			completed = "1"
+		}
+	}
+	unixArg := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	overdueBefore := ""
+	if filters.Overdue {
+		overdueBefore = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	category := ""
+	if strings.TrimSpace(filters.Category) != "" {
+		category = filters.Category
+	}
+
+	args := []interface{}{
+		redis.GenerateKey(redis.TaskKeyPrefix, ""),
+		category,
+		completed,
+		string(filters.Priority),
+		unixArg(filters.DueAfter),
+		unixArg(filters.DueBefore),
+		overdueBefore,
+		filters.TagMatch,
+	}
+	for _, tag := range filters.Tags {
+		args = append(args, tag)
+	}
+
+	filteredKey := l.key("deleted")
+	filterDeletedTasksScript.Eval(l.ctx, l.pipe, []string{deletedKey, filteredKey}, args...)
+	return filteredKey
+}
+
+// buildTopLevelIndexScript files the tasks a user stored before the top-level indexes existed and marks the indexes built
+// Subtasks are left out; doing it in one script keeps concurrent creates, deletes and restores from being missed
+var buildTopLevelIndexScript = redislib.NewScript(`
+if redis.call("EXISTS", KEYS[6]) == 1 then
+	return 0
+end
+for i = 1, 2 do
+	local entries = redis.call("ZRANGE", KEYS[i], 0, -1, "WITHSCORES")
+	for j = 1, #entries, 2 do
+		if redis.call("SISMEMBER", KEYS[3], entries[j]) == 0 then
+			redis.call("ZADD", KEYS[i + 3], -tonumber(entries[j + 1]), entries[j])
+		end
+	end
+end
+redis.call("SET", KEYS[6], ARGV[1])
+return 1
+`)
+
+// ensureTopLevelIndex builds a user's active and deleted top-level indexes once from the sorted and deleted task indexes
+// Later writes keep them up to date, so only the marker is checked afterwards
+func (r *TaskRepository) ensureTopLevelIndex(ctx context.Context, userID string) error {
+	indexed, err := r.client.Exists(ctx, topLevelIndexedKey(userID)).Result()
+	if err != nil || indexed > 0 {
+		return err
+	}
+
+	userKey := redis.GenerateKey("user", userID)
+	keys := []string{
+		userKey + ":tasks:sorted",
+		userKey + ":tasks:deleted",
+		userKey + ":subtasks",
+		topLevelIndexKey(userID, false),
+		topLevelIndexKey(userID, true),
+		topLevelIndexedKey(userID),
+	}
+	return buildTopLevelIndexScript.Run(ctx, r.client, keys, time.Now().Unix()).Err()
+}
+
+// moveTag replaces one tag with another on every task carrying it and updates the tag indexes
+// Tasks that already carry the target tag keep a single copy of it
+func (r *TaskRepository) moveTag(ctx context.Context, userID, oldName, newName string) error {
+	userTagsKey := redis.GenerateKey("user", userID) + ":tags"
+	exists, err := r.client.SIsMember(ctx, userTagsKey, oldName).Result()
+	if err != nil {
+		return fmt.Errorf("2006: failed to check tag existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("2006: %w", domain.ErrTagNotFound)
+	}
+
+	// Active tasks come from the tag set; deleted tasks keep their tags in the hash only
+	oldTagKey := redis.GenerateKey("user", userID) + ":tag:" + oldName
+	taskIDs, err := r.client.SMembers(ctx, oldTagKey).Result()
+	if err != nil {
+		return fmt.Errorf("2006: failed to get tasks with tag: %w", err)
 	}
 
-	// Get deleted tasks
 	userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
-	deletedIDs, err := r.client.ZRevRange(ctx, userDeletedKey, 0, -1).Result()
+	deletedIDs, err := r.client.ZRange(ctx, userDeletedKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("2006: failed to get deleted tasks: %w", err)
+	}
+	taskIDs = append(taskIDs, deletedIDs...)
+
+	now := time.Now().Unix()
+	newTagKey := redis.GenerateKey("user", userID) + ":tag:" + newName
+
+	tasks, err := r.getTasksByIDs(ctx, taskIDs)
 	if err != nil {
-		return activeIDs, nil // Return active tasks if deleted query fails
+		return fmt.Errorf("2006: failed to get tasks with tag: %w", err)
+	}
+
+	// Use pipeline for atomic operations
+	pipe := r.client.TxPipeline()
+
+	for _, task := range tasks {
+		taskID := task.ID
+		if task.UserID != userID || !task.HasTag(oldName) {
+			continue
+		}
+
+		tags := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			if tag == oldName {
+				tag = newName
+			}
+			if !containsTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+
+		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, taskID)
+		pipe.HMSet(ctx, taskKey, map[string]interface{}{
+			"tags":       strings.Join(tags, ","),
+			"updated_at": now,
+		})
+		if !task.IsDeleted() {
+			pipe.SAdd(ctx, newTagKey, taskID)
+		}
 	}
 
-	// Combine and maintain order (active first, then deleted)
-	allIDs := make([]string, 0, len(activeIDs)+len(deletedIDs))
-	allIDs = append(allIDs, activeIDs...)
-	allIDs = append(allIDs, deletedIDs...)
+	// Replace the tag in the user's tags set and remove the old tag set
+	pipe.SRem(ctx, userTagsKey, oldName)
+	pipe.SAdd(ctx, userTagsKey, newName)
+	pipe.Del(ctx, oldTagKey)
+
+	// Execute pipeline
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("2006: failed to move tag: %w", err)
+	}
+
+	return nil
+}
+
+// queueTagIndex adds the commands that add a task to its tag sets to a pipeline
+func (r *TaskRepository) queueTagIndex(ctx context.Context, pipe redislib.Pipeliner, userID, taskID string, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	userTagsKey := redis.GenerateKey("user", userID) + ":tags"
+	for _, tag := range tags {
+		pipe.SAdd(ctx, userTagsKey, tag)
+		pipe.SAdd(ctx, redis.GenerateKey("user", userID)+":tag:"+tag, taskID)
+	}
+}
+
+// queueTagUnindex adds the commands that remove a task from its tag sets to a pipeline
+func (r *TaskRepository) queueTagUnindex(ctx context.Context, pipe redislib.Pipeliner, userID, taskID string, tags []string) {
+	for _, tag := range tags {
+		pipe.SRem(ctx, redis.GenerateKey("user", userID)+":tag:"+tag, taskID)
+	}
+}
+
+// dropUnusedTags removes tags without any remaining active task from the user's tags set
+func (r *TaskRepository) dropUnusedTags(ctx context.Context, userID string, tags []string) {
+	userTagsKey := redis.GenerateKey("user", userID) + ":tags"
+	for _, tag := range tags {
+		if r.client.SCard(ctx, redis.GenerateKey("user", userID)+":tag:"+tag).Val() == 0 {
+			r.client.SRem(ctx, userTagsKey, tag)
+		}
+	}
+}
+
+// dropUnusedCategories removes categories without any remaining active task from the user's categories set
+func (r *TaskRepository) dropUnusedCategories(ctx context.Context, userID string, categories []string) {
+	userCategoriesKey := redis.GenerateKey("user", userID) + ":categories"
+	for _, category := range categories {
+		if r.client.ZCard(ctx, redis.GenerateKey("user", userID)+":category:"+category).Val() == 0 {
+			r.client.SRem(ctx, userCategoriesKey, category)
+		}
+	}
+}
+
+// maxSearchPrefixMatches caps how many indexed words a single query word can match as a prefix
+const maxSearchPrefixMatches = 100
+
+// scoreSearchTerm scores the tasks containing a query word as a whole word or as a word prefix
+// Scores are the number of times the word occurs, doubled for whole-word matches; the best matching word counts
+func (r *TaskRepository) scoreSearchTerm(ctx context.Context, userID, term string) (map[string]float64, error) {
+	searchTermsKey := redis.GenerateKey("user", userID) + ":search:terms"
+	words, err := r.client.ZRangeByLex(ctx, searchTermsKey, &redislib.ZRangeBy{
+		Min:   "[" + term,
+		Max:   "[" + term + "\xff",
+		Count: maxSearchPrefixMatches,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redislib.ZSliceCmd, len(words))
+	for i, word := range words {
+		cmds[i] = pipe.ZRangeWithScores(ctx, redis.GenerateKey("user", userID)+":search:term:"+word, 0, -1)
+	}
+	if len(words) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	scores := make(map[string]float64)
+	for i, word := range words {
+		weight := 1.0
+		if word == term {
+			weight = 2.0
+		}
+
+		for _, match := range cmds[i].Val() {
+			taskID := match.Member.(string)
+			if score := match.Score * weight; score > scores[taskID] {
+				scores[taskID] = score
+			}
+		}
+	}
+
+	return scores, nil
+}
+
+// queueSearchIndex adds the commands that add a task to the search index of each of its words to a pipeline
+// Each entry is scored by the number of times the word occurs in the description
+func (r *TaskRepository) queueSearchIndex(ctx context.Context, pipe redislib.Pipeliner, userID, taskID string, terms map[string]int) {
+	if len(terms) == 0 {
+		return
+	}
+
+	searchTermsKey := redis.GenerateKey("user", userID) + ":search:terms"
+	for term, count := range terms {
+		pipe.ZAdd(ctx, searchTermsKey, redislib.Z{Score: 0, Member: term})
+		pipe.ZAdd(ctx, redis.GenerateKey("user", userID)+":search:term:"+term, redislib.Z{
+			Score:  float64(count),
+			Member: taskID,
+		})
+	}
+}
+
+// queueSearchUnindex adds the commands that remove a task from the search index of the given words to a pipeline
+func (r *TaskRepository) queueSearchUnindex(ctx context.Context, pipe redislib.Pipeliner, userID, taskID string, terms []string) {
+	for _, term := range terms {
+		pipe.ZRem(ctx, redis.GenerateKey("user", userID)+":search:term:"+term, taskID)
+	}
+}
+
+// dropUnusedSearchTerms removes words without any remaining task from the user's search terms
+func (r *TaskRepository) dropUnusedSearchTerms(ctx context.Context, userID string, terms []string) {
+	searchTermsKey := redis.GenerateKey("user", userID) + ":search:terms"
+	for _, term := range terms {
+		if r.client.ZCard(ctx, redis.GenerateKey("user", userID)+":search:term:"+term).Val() == 0 {
+			r.client.ZRem(ctx, searchTermsKey, term)
+		}
+	}
+}
+
+// getSubtasks loads every stored subtask of a parent, including soft-deleted ones
+// Subtasks that have been purged are skipped
+func (r *TaskRepository) getSubtasks(ctx context.Context, parentID string) ([]*domain.Task, error) {
+	subtasksKey := redis.GenerateKey(redis.TaskKeyPrefix, parentID) + ":subtasks"
+	subtaskIDs, err := r.client.ZRange(ctx, subtasksKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getTasksByIDs(ctx, subtaskIDs)
+}
+
+// attachSubtasks sets the active subtasks of each parent task, using one pipeline for the subtask indexes
+// and one for the subtask hashes
+func (r *TaskRepository) attachSubtasks(ctx context.Context, parents []*domain.Task) error {
+	if len(parents) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	indexCmds := make([]*redislib.StringSliceCmd, len(parents))
+	for i, parent := range parents {
+		indexCmds[i] = pipe.ZRange(ctx, redis.GenerateKey(redis.TaskKeyPrefix, parent.ID)+":subtasks", 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	var subtaskIDs []string
+	for _, cmd := range indexCmds {
+		subtaskIDs = append(subtaskIDs, cmd.Val()...)
+	}
+	if len(subtaskIDs) == 0 {
+		return nil
+	}
+
+	subtasks, err := r.getTasksByIDs(ctx, subtaskIDs)
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[string][]*domain.Task, len(parents))
+	for _, subtask := range subtasks {
+		if !subtask.IsDeleted() {
+			byParent[subtask.ParentID] = append(byParent[subtask.ParentID], subtask)
+		}
+	}
+	for _, parent := range parents {
+		if children := byParent[parent.ID]; len(children) > 0 {
+			parent.Subtasks = children
+		}
+	}
+
+	return nil
+}
+
+// getTasksByIDs loads tasks with a single pipelined round trip, keeping the order of the IDs
+// Tasks that no longer exist or can't be parsed are skipped
+func (r *TaskRepository) getTasksByIDs(ctx context.Context, taskIDs []string) ([]*domain.Task, error) {
+	if len(taskIDs) == 0 {
+		return []*domain.Task{}, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redislib.MapStringStringCmd, len(taskIDs))
+	for i, taskID := range taskIDs {
+		cmds[i] = pipe.HGetAll(ctx, redis.GenerateKey(redis.TaskKeyPrefix, taskID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*domain.Task, 0, len(taskIDs))
+	for _, cmd := range cmds {
+		data := cmd.Val()
+		if len(data) == 0 {
+			continue
+		}
+
+		task, err := r.parseTaskFromHash(data)
+		if err != nil {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// diffTags returns the tags only present in the new list and the tags only present in the old list
+func diffTags(oldTags, newTags []string) (added, removed []string) {
+	for _, tag := range newTags {
+		if !containsTag(oldTags, tag) {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range oldTags {
+		if !containsTag(newTags, tag) {
+			removed = append(removed, tag)
+		}
+	}
+	return added, removed
+}
+
+// diffSearchTerms returns the words of the old description that the new description no longer contains
+func diffSearchTerms(oldTerms, newTerms map[string]int) []string {
+	var removed []string
+	for term := range oldTerms {
+		if _, ok := newTerms[term]; !ok {
+			removed = append(removed, term)
+		}
+	}
+	return removed
+}
+
+// searchTermList returns the words of a search term count map
+func searchTermList(terms map[string]int) []string {
+	list := make([]string, 0, len(terms))
+	for term := range terms {
+		list = append(list, term)
+	}
+	return list
+}
+
+// containsTag reports whether a tag is in a list of tags
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// topLevelIndexKey returns the key of a user's active or soft-deleted top-level tasks index
+// Tasks are scored by negated creation or deletion time, so ascending ranges list the newest first
+func topLevelIndexKey(userID string, deleted bool) string {
+	if deleted {
+		return redis.GenerateKey("user", userID) + ":tasks:top:deleted"
+	}
+	return redis.GenerateKey("user", userID) + ":tasks:top"
+}
+
+// topLevelIndexedKey returns the key marking that a user's top-level indexes cover the tasks stored before them
+func topLevelIndexedKey(userID string) string {
+	return redis.GenerateKey("user", userID) + ":tasks:top:indexed"
+}
+
+// completionIndexKey returns the key of a user's completed or active (not yet completed) tasks index
+func completionIndexKey(userID string, completed bool) string {
+	if completed {
+		return redis.GenerateKey("user", userID) + ":tasks:completed"
+	}
+	return redis.GenerateKey("user", userID) + ":tasks:active"
+}
+
+// taskSortKey returns the scores that position a task in a listing, compared in ascending order
+// Scores mirror the sorted-set indexes (creation time, due date, priority rank); deleted tasks follow active ones,
+// most recently deleted first whatever the sort order
+func taskSortKey(task *domain.Task, sortBy string) []int64 {
+	if task.IsDeleted() {
+		return []int64{1, -task.DeletedAt.Unix()}
+	}
+	newestFirst := -task.CreatedAt.Unix()
+
+	switch sortBy {
+	case domain.TaskSortDue:
+		// Tasks without a due date come last, newest first
+		if task.DueDate == nil {
+			return []int64{0, 1, newestFirst}
+		}
+		return []int64{0, 0, task.DueDate.Unix()}
+	case domain.TaskSortPriority:
+		return []int64{0, -int64(task.Priority.Rank()), newestFirst}
+	default:
+		return []int64{0, newestFirst}
+	}
+}
+
+// compareTaskSortKeys orders two listing positions by their sort keys, breaking ties by task ID
+// Returns a negative number when a comes first, zero when equal and a positive number otherwise
+func compareTaskSortKeys(a []int64, aID string, b []int64, bID string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(aID, bID)
+}
+
+// hasTaskFilters reports whether the filters restrict which tasks are listed
+func hasTaskFilters(filters domain.TaskFilters) bool {
+	return strings.TrimSpace(filters.Category) != "" || filters.Completed != nil || filters.Priority != "" ||
+		len(filters.Tags) > 0 || hasDueFilters(filters)
+}
 
-	return allIDs, nil
+// hasDueFilters reports whether the filters restrict results by due date
+func hasDueFilters(filters domain.TaskFilters) bool {
+	return filters.DueBefore != nil || filters.DueAfter != nil || filters.Overdue
 }
 
 // parseUnixTimestamp converts unix timestamp string to time.Time
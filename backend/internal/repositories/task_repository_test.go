@@ -2,6 +2,13 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -179,7 +186,7 @@ func TestTaskRepository_CreateTask(t *testing.T) {
 				assert.True(t, isMember)
 
 				categoryTasksKey := redis.GenerateKey("user", tt.task.UserID) + ":category:" + tt.task.Category
-				isMember = repo.client.SIsMember(ctx, categoryTasksKey, tt.task.ID).Val()
+				isMember = repo.client.ZScore(ctx, categoryTasksKey, tt.task.ID).Err() == nil
 				assert.True(t, isMember)
 			}
 		})
@@ -284,6 +291,7 @@ func TestTaskRepository_ListTasks(t *testing.T) {
 		userID      string
 		filters     domain.TaskFilters
 		wantCount   int
+		wantTotal   int
 		wantTaskIDs []string
 		wantErr     bool
 		errCode     string
@@ -333,6 +341,7 @@ func TestTaskRepository_ListTasks(t *testing.T) {
 				Limit: 1,
 			},
 			wantCount: 1,
+			wantTotal: 2,
 			wantErr:   false,
 		},
 		{
@@ -342,6 +351,7 @@ func TestTaskRepository_ListTasks(t *testing.T) {
 				Offset: 1,
 			},
 			wantCount: 1,
+			wantTotal: 2,
 			wantErr:   false,
 		},
 		{
@@ -376,12 +386,20 @@ func TestTaskRepository_ListTasks(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
-			assert.NotNil(t, result)
-			assert.Equal(t, tt.wantCount, len(result))
+			require.NotNil(t, result)
+			tasks := result.Tasks
+			assert.Equal(t, tt.wantCount, len(tasks))
+
+			// Total counts every matching task, not just the page
+			wantTotal := tt.wantTotal
+			if wantTotal == 0 {
+				wantTotal = tt.wantCount
+			}
+			assert.Equal(t, wantTotal, result.Total)
 
 			if len(tt.wantTaskIDs) > 0 {
-				resultIDs := make([]string, len(result))
-				for i, task := range result {
+				resultIDs := make([]string, len(tasks))
+				for i, task := range tasks {
 					resultIDs[i] = task.ID
 				}
 				for _, expectedID := range tt.wantTaskIDs {
@@ -390,16 +408,209 @@ func TestTaskRepository_ListTasks(t *testing.T) {
 			}
 
 			// Verify tasks are sorted by CreatedAt desc
-			if len(result) > 1 {
-				for i := 1; i < len(result); i++ {
-					assert.True(t, result[i-1].CreatedAt.After(result[i].CreatedAt) || 
-						result[i-1].CreatedAt.Equal(result[i].CreatedAt))
+			if len(tasks) > 1 {
+				for i := 1; i < len(tasks); i++ {
+					assert.True(t, tasks[i-1].CreatedAt.After(tasks[i].CreatedAt) || 
+						tasks[i-1].CreatedAt.Equal(tasks[i].CreatedAt))
 				}
 			}
 		})
 	}
 }
 
+func TestTaskRepository_ListTasksCursor(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	userID := uuid.New().String()
+	base := time.Now().Add(-time.Hour)
+
+	newTask := func(description string, createdAt time.Time) *domain.Task {
+		task := createTestTask(userID, description, "")
+		task.CreatedAt = createdAt
+		require.NoError(t, repo.CreateTask(task))
+		return task
+	}
+
+	// Tasks created a minute apart, listed newest first
+	var created []*domain.Task
+	for i := 0; i < 5; i++ {
+		created = append(created, newTask(fmt.Sprintf("Task %d", i), base.Add(time.Duration(i)*time.Minute)))
+	}
+
+	t.Run("should page through tasks with cursors and report the full total", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created[4].ID, created[3].ID}, taskIDs(page.Tasks))
+		assert.Equal(t, 5, page.Total)
+		require.NotEmpty(t, page.NextCursor)
+
+		page, err = repo.ListTasks(userID, domain.TaskFilters{Limit: 2, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created[2].ID, created[1].ID}, taskIDs(page.Tasks))
+		require.NotEmpty(t, page.NextCursor)
+
+		page, err = repo.ListTasks(userID, domain.TaskFilters{Limit: 2, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created[0].ID}, taskIDs(page.Tasks))
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("should stay stable when tasks are added before the cursor", func(t *testing.T) {
+		first, err := repo.ListTasks(userID, domain.TaskFilters{Limit: 2})
+		require.NoError(t, err)
+
+		newTask("Newest task", base.Add(time.Hour))
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Limit: 2, Cursor: first.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created[2].ID, created[1].ID}, taskIDs(page.Tasks))
+		assert.Equal(t, 6, page.Total)
+	})
+
+	t.Run("should page through priority order", func(t *testing.T) {
+		created[1].Priority = domain.PriorityUrgent
+		require.NoError(t, repo.UpdateTask(created[1]))
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortPriority, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{created[1].ID}, taskIDs(page.Tasks))
+
+		page, err = repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortPriority, Limit: 1, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		assert.NotEqual(t, created[1].ID, page.Tasks[0].ID)
+	})
+
+	t.Run("should break creation time ties by task ID across pages", func(t *testing.T) {
+		tiedUserID := uuid.New().String()
+		createdAt := base.Add(-time.Hour)
+		var tied []*domain.Task
+		for i := 0; i < 4; i++ {
+			task := createTestTask(tiedUserID, fmt.Sprintf("Tied task %d", i), "")
+			task.CreatedAt = createdAt
+			require.NoError(t, repo.CreateTask(task))
+			tied = append(tied, task)
+		}
+		sort.Slice(tied, func(i, j int) bool { return tied[i].ID < tied[j].ID })
+
+		var listed []string
+		cursor := ""
+		for {
+			page, err := repo.ListTasks(tiedUserID, domain.TaskFilters{Limit: 3, Cursor: cursor})
+			require.NoError(t, err)
+			assert.Equal(t, 4, page.Total)
+			listed = append(listed, taskIDs(page.Tasks)...)
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, taskIDs(tied), listed)
+	})
+
+	t.Run("should page from dated to undated to deleted tasks when sorting by due date", func(t *testing.T) {
+		dueUserID := uuid.New().String()
+		newDueTask := func(description string, due *time.Time, offset int) *domain.Task {
+			task := createTestTask(dueUserID, description, "")
+			task.DueDate = due
+			task.CreatedAt = base.Add(time.Duration(offset) * time.Minute)
+			require.NoError(t, repo.CreateTask(task))
+			return task
+		}
+
+		soon := base.Add(24 * time.Hour).Truncate(time.Second)
+		later := soon.Add(24 * time.Hour)
+		laterTask := newDueTask("Due later", &later, 0)
+		soonTask := newDueTask("Due soon", &soon, 1)
+		olderUndated := newDueTask("Older undated", nil, 2)
+		newerUndated := newDueTask("Newer undated", nil, 3)
+		deletedTask := newDueTask("Deleted", &soon, 4)
+		require.NoError(t, repo.SoftDeleteTask(deletedTask.ID))
+
+		want := []string{soonTask.ID, laterTask.ID, newerUndated.ID, olderUndated.ID, deletedTask.ID}
+		filters := domain.TaskFilters{SortBy: domain.TaskSortDue, IncludeDeleted: true, Limit: 2}
+
+		var listed []string
+		for {
+			page, err := repo.ListTasks(dueUserID, filters)
+			require.NoError(t, err)
+			assert.Equal(t, len(want), page.Total)
+			listed = append(listed, taskIDs(page.Tasks)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filters.Cursor = page.NextCursor
+		}
+		assert.Equal(t, want, listed)
+
+		page, err := repo.ListTasks(dueUserID, domain.TaskFilters{SortBy: domain.TaskSortDue, IncludeDeleted: true, Limit: 2, Offset: 3})
+		require.NoError(t, err)
+		assert.Equal(t, want[3:], taskIDs(page.Tasks))
+	})
+
+	t.Run("should page deleted tasks after active ones, most recently deleted first", func(t *testing.T) {
+		deletedUserID := uuid.New().String()
+		var deleted []*domain.Task
+		for i := 0; i < 3; i++ {
+			task := createTestTask(deletedUserID, fmt.Sprintf("Deleted %d", i), "work")
+			require.NoError(t, repo.CreateTask(task))
+			require.NoError(t, repo.SoftDeleteTask(task.ID))
+
+			// Backdate the deletion so the tasks were deleted a minute apart
+			deletedAt := base.Add(time.Duration(i) * time.Minute).Unix()
+			s.HSet("task:"+task.ID, "deleted_at", strconv.FormatInt(deletedAt, 10))
+			s.ZAdd("user:"+deletedUserID+":tasks:deleted", float64(deletedAt), task.ID)
+			s.ZAdd(topLevelIndexKey(deletedUserID, true), -float64(deletedAt), task.ID)
+			deleted = append(deleted, task)
+		}
+		other := createTestTask(deletedUserID, "Deleted elsewhere", "home")
+		require.NoError(t, repo.CreateTask(other))
+		require.NoError(t, repo.SoftDeleteTask(other.ID))
+		active := createTestTask(deletedUserID, "Active", "work")
+		require.NoError(t, repo.CreateTask(active))
+
+		want := []string{active.ID, deleted[2].ID, deleted[1].ID, deleted[0].ID}
+		filters := domain.TaskFilters{Category: "work", IncludeDeleted: true, Limit: 2}
+
+		var listed []string
+		for {
+			page, err := repo.ListTasks(deletedUserID, filters)
+			require.NoError(t, err)
+			assert.Equal(t, len(want), page.Total)
+			listed = append(listed, taskIDs(page.Tasks)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filters.Cursor = page.NextCursor
+		}
+		assert.Equal(t, want, listed)
+
+		page, err := repo.ListTasks(deletedUserID, domain.TaskFilters{IncludeDeleted: true, Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		assert.Equal(t, []string{deleted[2].ID, deleted[1].ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should leave no temporary keys behind", func(t *testing.T) {
+		_, err := repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortPriority, Priority: domain.PriorityNone, Tags: []string{"a", "b"}, Limit: 2})
+		require.NoError(t, err)
+		for _, key := range s.Keys() {
+			assert.NotContains(t, key, ":tasks:listing:")
+		}
+	})
+
+	t.Run("should reject invalid cursors", func(t *testing.T) {
+		_, err := repo.ListTasks(userID, domain.TaskFilters{Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		assert.Contains(t, err.Error(), "2005")
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Limit: 1})
+		require.NoError(t, err)
+		_, err = repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortDue, Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+}
+
 func TestTaskRepository_UpdateTaskCompletion(t *testing.T) {
 	repo, s := setupTestTaskRepository(t)
 	defer s.Close()
@@ -471,6 +682,913 @@ func TestTaskRepository_UpdateTaskCompletion(t *testing.T) {
 	}
 }
 
+func TestTaskRepository_UpdateTask(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	userCategoriesKey := redis.GenerateKey("user", userID) + ":categories"
+
+	t.Run("should update description without touching category indexes", func(t *testing.T) {
+		task := createTestTask(userID, "Original description", "work")
+		require.NoError(t, repo.CreateTask(task))
+
+		task.Description = "Fixed description"
+		err := repo.UpdateTask(task)
+		assert.NoError(t, err)
+
+		updatedTask, err := repo.GetTaskByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Fixed description", updatedTask.Description)
+		assert.Equal(t, "work", updatedTask.Category)
+
+		categoryKey := redis.GenerateKey("user", userID) + ":category:work"
+		assert.NoError(t, repo.client.ZScore(ctx, categoryKey, task.ID).Err())
+	})
+
+	t.Run("should move task to new category and prune empty old category", func(t *testing.T) {
+		task := createTestTask(userID, "Movable task", "errands")
+		require.NoError(t, repo.CreateTask(task))
+
+		task.Category = "home"
+		err := repo.UpdateTask(task)
+		assert.NoError(t, err)
+
+		oldCategoryKey := redis.GenerateKey("user", userID) + ":category:errands"
+		newCategoryKey := redis.GenerateKey("user", userID) + ":category:home"
+		assert.Error(t, repo.client.ZScore(ctx, oldCategoryKey, task.ID).Err())
+		assert.NoError(t, repo.client.ZScore(ctx, newCategoryKey, task.ID).Err())
+		assert.False(t, repo.client.SIsMember(ctx, userCategoriesKey, "errands").Val())
+		assert.True(t, repo.client.SIsMember(ctx, userCategoriesKey, "home").Val())
+	})
+
+	t.Run("should keep old category while other tasks still use it", func(t *testing.T) {
+		task := createTestTask(userID, "First shared task", "shared")
+		other := createTestTask(userID, "Second shared task", "shared")
+		require.NoError(t, repo.CreateTask(task))
+		require.NoError(t, repo.CreateTask(other))
+
+		task.Category = ""
+		err := repo.UpdateTask(task)
+		assert.NoError(t, err)
+
+		sharedKey := redis.GenerateKey("user", userID) + ":category:shared"
+		assert.Error(t, repo.client.ZScore(ctx, sharedKey, task.ID).Err())
+		assert.NoError(t, repo.client.ZScore(ctx, sharedKey, other.ID).Err())
+		assert.True(t, repo.client.SIsMember(ctx, userCategoriesKey, "shared").Val())
+	})
+
+	t.Run("should fail with nil task", func(t *testing.T) {
+		err := repo.UpdateTask(nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "2001")
+	})
+
+	t.Run("should fail with invalid task", func(t *testing.T) {
+		task := createTestTask(userID, "Valid task", "work")
+		require.NoError(t, repo.CreateTask(task))
+
+		task.Description = ""
+		err := repo.UpdateTask(task)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "2002")
+	})
+
+	t.Run("should fail with non-existent task", func(t *testing.T) {
+		task := createTestTask(userID, "Never stored", "work")
+		err := repo.UpdateTask(task)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.Contains(t, err.Error(), "2003")
+	})
+
+	t.Run("should fail with another user's task", func(t *testing.T) {
+		task := createTestTask(userID, "Owned task", "work")
+		require.NoError(t, repo.CreateTask(task))
+
+		task.UserID = uuid.New().String()
+		task.Description = "Taken over"
+		err := repo.UpdateTask(task)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+		assert.Contains(t, err.Error(), "2003")
+	})
+}
+
+func TestTaskRepository_DueDates(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	userTasksDueKey := redis.GenerateKey("user", userID) + ":tasks:due"
+
+	now := time.Now().UTC().Truncate(time.Second)
+	pastDue := now.Add(-48 * time.Hour)
+	soonDue := now.Add(24 * time.Hour)
+	laterDue := now.Add(7 * 24 * time.Hour)
+
+	overdueTask := createTestTask(userID, "Overdue task", "work")
+	overdueTask.DueDate = &pastDue
+	soonTask := createTestTask(userID, "Due soon", "home")
+	soonTask.DueDate = &soonDue
+	laterTask := createTestTask(userID, "Due later", "work")
+	laterTask.DueDate = &laterDue
+	undatedTask := createTestTask(userID, "No due date", "work")
+
+	for _, task := range []*domain.Task{laterTask, undatedTask, overdueTask, soonTask} {
+		require.NoError(t, repo.CreateTask(task))
+	}
+
+	taskIDs := func(tasks []*domain.Task) []string {
+		ids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("should persist due date and index it", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(soonTask.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.DueDate)
+		assert.True(t, soonDue.Equal(*stored.DueDate))
+
+		score, err := repo.client.ZScore(ctx, userTasksDueKey, soonTask.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(soonDue.Unix()), score)
+
+		_, err = repo.client.ZScore(ctx, userTasksDueKey, undatedTask.ID).Result()
+		assert.Error(t, err)
+	})
+
+	t.Run("should list overdue tasks only", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Overdue: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{overdueTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should list tasks due within a range in due order", func(t *testing.T) {
+		before := now.Add(30 * 24 * time.Hour)
+		page, err := repo.ListTasks(userID, domain.TaskFilters{DueAfter: &now, DueBefore: &before})
+		require.NoError(t, err)
+		assert.Equal(t, []string{soonTask.ID, laterTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should combine due range with category filter", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{DueAfter: &now, Category: "work"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{laterTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should sort by due date with undated tasks last", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortDue})
+		require.NoError(t, err)
+		assert.Equal(t, []string{overdueTask.ID, soonTask.ID, laterTask.ID, undatedTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should reindex and clear due date on update", func(t *testing.T) {
+		task := createTestTask(userID, "Rescheduled task", "work")
+		task.DueDate = &laterDue
+		require.NoError(t, repo.CreateTask(task))
+
+		task.DueDate = &soonDue
+		require.NoError(t, repo.UpdateTask(task))
+		score, err := repo.client.ZScore(ctx, userTasksDueKey, task.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(soonDue.Unix()), score)
+
+		task.DueDate = nil
+		require.NoError(t, repo.UpdateTask(task))
+		_, err = repo.client.ZScore(ctx, userTasksDueKey, task.ID).Result()
+		assert.Error(t, err)
+
+		stored, err := repo.GetTaskByID(task.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.DueDate)
+	})
+
+	t.Run("should drop soft deleted tasks from due index and restore them", func(t *testing.T) {
+		task := createTestTask(userID, "Deleted with due date", "work")
+		task.DueDate = &pastDue
+		require.NoError(t, repo.CreateTask(task))
+
+		require.NoError(t, repo.SoftDeleteTask(task.ID))
+		_, err := repo.client.ZScore(ctx, userTasksDueKey, task.ID).Result()
+		assert.Error(t, err)
+
+		require.NoError(t, repo.RestoreTask(task.ID))
+		score, err := repo.client.ZScore(ctx, userTasksDueKey, task.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(pastDue.Unix()), score)
+	})
+}
+
+func TestTaskRepository_Priorities(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	userTasksPriorityKey := redis.GenerateKey("user", userID) + ":tasks:priority"
+
+	// Creation timestamps are spaced so creation order is deterministic
+	base := time.Now().Add(-time.Hour)
+	newTask := func(description string, priority domain.TaskPriority, offset int) *domain.Task {
+		task := createTestTask(userID, description, "work")
+		task.Priority = priority
+		task.CreatedAt = base.Add(time.Duration(offset) * time.Minute)
+		require.NoError(t, repo.CreateTask(task))
+		return task
+	}
+
+	lowTask := newTask("Low priority", domain.PriorityLow, 0)
+	urgentTask := newTask("Urgent priority", domain.PriorityUrgent, 1)
+	plainTask := newTask("No priority", domain.PriorityNone, 2)
+	highTask := newTask("High priority", domain.PriorityHigh, 3)
+	otherHighTask := newTask("Another high priority", domain.PriorityHigh, 4)
+
+	taskIDs := func(tasks []*domain.Task) []string {
+		ids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("should persist priority and index it by rank", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(urgentTask.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityUrgent, stored.Priority)
+
+		score, err := repo.client.ZScore(ctx, userTasksPriorityKey, urgentTask.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(domain.PriorityUrgent.Rank()), score)
+	})
+
+	t.Run("should filter by priority", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Priority: domain.PriorityHigh})
+		require.NoError(t, err)
+		assert.Equal(t, []string{otherHighTask.ID, highTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should sort by priority with newest first within a level", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortPriority})
+		require.NoError(t, err)
+		assert.Equal(t, []string{urgentTask.ID, otherHighTask.ID, highTask.ID, lowTask.ID, plainTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should paginate after sorting by priority", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{SortBy: domain.TaskSortPriority, Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{otherHighTask.ID, highTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should reindex priority on update", func(t *testing.T) {
+		lowTask.Priority = domain.PriorityMedium
+		require.NoError(t, repo.UpdateTask(lowTask))
+
+		score, err := repo.client.ZScore(ctx, userTasksPriorityKey, lowTask.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(domain.PriorityMedium.Rank()), score)
+	})
+
+	t.Run("should treat tasks without stored priority as none", func(t *testing.T) {
+		taskKey := redis.GenerateKey(redis.TaskKeyPrefix, plainTask.ID)
+		repo.client.HDel(ctx, taskKey, "priority")
+		repo.client.ZRem(ctx, userTasksPriorityKey, plainTask.ID)
+
+		stored, err := repo.GetTaskByID(plainTask.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityNone, stored.Priority)
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Priority: domain.PriorityNone})
+		require.NoError(t, err)
+		assert.Equal(t, []string{plainTask.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should drop soft deleted tasks from priority index and restore them", func(t *testing.T) {
+		require.NoError(t, repo.SoftDeleteTask(urgentTask.ID))
+		_, err := repo.client.ZScore(ctx, userTasksPriorityKey, urgentTask.ID).Result()
+		assert.Error(t, err)
+
+		require.NoError(t, repo.RestoreTask(urgentTask.ID))
+		score, err := repo.client.ZScore(ctx, userTasksPriorityKey, urgentTask.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(domain.PriorityUrgent.Rank()), score)
+	})
+}
+
+func TestTaskRepository_CompletionIndexes(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	userCompletedKey := redis.GenerateKey("user", userID) + ":tasks:completed"
+	userActiveKey := redis.GenerateKey("user", userID) + ":tasks:active"
+
+	// Completed tasks are the newest, so filtering after a limit would return too few active tasks
+	base := time.Now().Add(-time.Hour)
+	var activeTasks, completedTasks []*domain.Task
+	for i := 0; i < 6; i++ {
+		task := createTestTask(userID, fmt.Sprintf("Task %d", i), "work")
+		task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.CreateTask(task))
+		if i >= 3 {
+			require.NoError(t, repo.UpdateTaskCompletion(task.ID, true))
+			completedTasks = append(completedTasks, task)
+		} else {
+			activeTasks = append(activeTasks, task)
+		}
+	}
+
+	t.Run("should file tasks under the completed or active index", func(t *testing.T) {
+		completedIDs, err := repo.client.ZRange(ctx, userCompletedKey, 0, -1).Result()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, taskIDs(completedTasks), completedIDs)
+
+		activeIDs, err := repo.client.ZRange(ctx, userActiveKey, 0, -1).Result()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, taskIDs(activeTasks), activeIDs)
+	})
+
+	t.Run("should filter completion before paginating", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Completed: boolPtr(false), Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{activeTasks[2].ID, activeTasks[1].ID}, taskIDs(page.Tasks))
+		assert.Equal(t, 3, page.Total)
+	})
+
+	t.Run("should combine completion with category and due filters", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Completed: boolPtr(true), Category: "work"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+
+		page, err = repo.ListTasks(userID, domain.TaskFilters{Completed: boolPtr(true), SortBy: domain.TaskSortDue})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, taskIDs(completedTasks), taskIDs(page.Tasks))
+	})
+
+	t.Run("should move tasks between indexes on completion change", func(t *testing.T) {
+		require.NoError(t, repo.UpdateTaskCompletion(completedTasks[0].ID, false))
+
+		_, err := repo.client.ZScore(ctx, userCompletedKey, completedTasks[0].ID).Result()
+		assert.Error(t, err)
+		score, err := repo.client.ZScore(ctx, userActiveKey, completedTasks[0].ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(completedTasks[0].CreatedAt.Unix()), score)
+
+		require.NoError(t, repo.UpdateTaskCompletion(completedTasks[0].ID, true))
+	})
+
+	t.Run("should drop soft deleted tasks from the indexes and restore them", func(t *testing.T) {
+		require.NoError(t, repo.SoftDeleteTask(completedTasks[1].ID))
+		_, err := repo.client.ZScore(ctx, userCompletedKey, completedTasks[1].ID).Result()
+		assert.Error(t, err)
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Completed: boolPtr(true), IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+
+		require.NoError(t, repo.RestoreTask(completedTasks[1].ID))
+		_, err = repo.client.ZScore(ctx, userCompletedKey, completedTasks[1].ID).Result()
+		assert.NoError(t, err)
+	})
+
+	t.Run("should rebuild missing indexes from stored tasks", func(t *testing.T) {
+		repo.client.Del(ctx, userCompletedKey, userActiveKey)
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Completed: boolPtr(true)})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, taskIDs(completedTasks), taskIDs(page.Tasks))
+
+		activeCount, err := repo.client.ZCard(ctx, userActiveKey).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(activeTasks)), activeCount)
+	})
+}
+
+func TestTaskRepository_RecurringSeries(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	userID := uuid.New().String()
+	dueDate := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	first := createTestTask(userID, "Weekly report", "work")
+	first.Recurrence = "FREQ=WEEKLY"
+	first.SeriesID = first.ID
+	first.Occurrence = 1
+	first.DueDate = &dueDate
+	require.NoError(t, repo.CreateTask(first))
+
+	t.Run("should persist recurrence fields", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY", stored.Recurrence)
+		assert.Equal(t, first.ID, stored.SeriesID)
+		assert.Equal(t, 1, stored.Occurrence)
+	})
+
+	t.Run("should record and clear completion time", func(t *testing.T) {
+		require.NoError(t, repo.UpdateTaskCompletion(first.ID, true))
+		stored, err := repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.CompletedAt)
+
+		require.NoError(t, repo.UpdateTaskCompletion(first.ID, false))
+		stored, err = repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.CompletedAt)
+	})
+
+	t.Run("should list series tasks by occurrence", func(t *testing.T) {
+		nextDue := dueDate.AddDate(0, 0, 7)
+		second := createTestTask(userID, "Weekly report", "work")
+		second.Recurrence = first.Recurrence
+		second.SeriesID = first.ID
+		second.Occurrence = 2
+		second.DueDate = &nextDue
+		require.NoError(t, repo.CreateTask(second))
+
+		// Deleted occurrences remain part of the history
+		require.NoError(t, repo.SoftDeleteTask(first.ID))
+
+		tasks, err := repo.GetSeriesTasks(userID, first.ID)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, first.ID, tasks[0].ID)
+		assert.Equal(t, second.ID, tasks[1].ID)
+	})
+
+	t.Run("should add existing task to a series on update", func(t *testing.T) {
+		task := createTestTask(userID, "Becomes recurring", "home")
+		require.NoError(t, repo.CreateTask(task))
+
+		task.Recurrence = "FREQ=DAILY"
+		task.SeriesID = task.ID
+		task.Occurrence = 1
+		require.NoError(t, repo.UpdateTask(task))
+
+		tasks, err := repo.GetSeriesTasks(userID, task.ID)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "FREQ=DAILY", tasks[0].Recurrence)
+	})
+
+	t.Run("should not return series of another user", func(t *testing.T) {
+		tasks, err := repo.GetSeriesTasks(uuid.New().String(), first.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("should fail with empty series ID", func(t *testing.T) {
+		_, err := repo.GetSeriesTasks(userID, "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "2004")
+	})
+
+	t.Run("should create the next occurrence once", func(t *testing.T) {
+		current := createTestTask(userID, "Daily standup", "work")
+		current.Recurrence = "FREQ=DAILY"
+		current.SeriesID = current.ID
+		current.Occurrence = 1
+		require.NoError(t, repo.CreateTask(current))
+
+		successor := func() *domain.Task {
+			next := createTestTask(userID, "Daily standup", "work")
+			next.Recurrence = current.Recurrence
+			next.SeriesID = current.ID
+			next.Occurrence = 2
+			return next
+		}
+
+		// Concurrent completions race for the same occurrence
+		var wg sync.WaitGroup
+		var created atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := repo.CreateNextOccurrence(current.ID, successor())
+				assert.NoError(t, err)
+				if ok {
+					created.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), created.Load())
+
+		tasks, err := repo.GetSeriesTasks(userID, current.ID)
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		// Completing the occurrence again later does not add another
+		ok, err := repo.CreateNextOccurrence(current.ID, successor())
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("should release the claim when the next occurrence is invalid", func(t *testing.T) {
+		current := createTestTask(userID, "Weekly review", "work")
+		require.NoError(t, repo.CreateTask(current))
+
+		invalid := createTestTask(userID, "", "work")
+		_, err := repo.CreateNextOccurrence(current.ID, invalid)
+		assert.Contains(t, err.Error(), "2002")
+
+		ok, err := repo.CreateNextOccurrence(current.ID, createTestTask(userID, "Weekly review", "work"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("should not create the next occurrence of a purged task", func(t *testing.T) {
+		ok, err := repo.CreateNextOccurrence(uuid.New().String(), createTestTask(userID, "Orphan", "work"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestTaskRepository_Subtasks(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	userID := uuid.New().String()
+
+	parent := createTestTask(userID, "Release checklist", "work")
+	require.NoError(t, repo.CreateTask(parent))
+
+	first := createTestTask(userID, "Write changelog", "work")
+	first.ParentID = parent.ID
+	require.NoError(t, repo.CreateTask(first))
+
+	second := createTestTask(userID, "Tag release", "work")
+	second.ParentID = parent.ID
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	require.NoError(t, repo.CreateTask(second))
+
+	t.Run("should persist parent ID and index subtasks", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, parent.ID, stored.ParentID)
+
+		members, err := s.ZMembers("task:" + parent.ID + ":subtasks")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{first.ID, second.ID}, members)
+	})
+
+	t.Run("should get subtasks in creation order", func(t *testing.T) {
+		subtasks, err := repo.GetSubtasks(parent.ID)
+		require.NoError(t, err)
+		require.Len(t, subtasks, 2)
+		assert.Equal(t, first.ID, subtasks[0].ID)
+		assert.Equal(t, second.ID, subtasks[1].ID)
+	})
+
+	t.Run("should reject empty parent ID", func(t *testing.T) {
+		_, err := repo.GetSubtasks("")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "2004")
+	})
+
+	t.Run("should list subtasks under their parent", func(t *testing.T) {
+		require.NoError(t, repo.UpdateTaskCompletion(first.ID, true))
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 1)
+		assert.Equal(t, parent.ID, page.Tasks[0].ID)
+		require.Len(t, page.Tasks[0].Subtasks, 2)
+
+		completed, total := page.Tasks[0].SubtaskProgress()
+		assert.Equal(t, 1, completed)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("should cascade soft delete and restore to subtasks", func(t *testing.T) {
+		// A subtask deleted on its own earlier stays deleted when the parent is restored
+		require.NoError(t, repo.SoftDeleteTask(second.ID))
+		s.HSet("task:"+second.ID, "deleted_at", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+
+		require.NoError(t, repo.SoftDeleteTask(parent.ID))
+
+		stored, err := repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.IsDeleted())
+
+		ctx := context.Background()
+		userTasksKey := redis.GenerateKey("user", userID) + ":tasks"
+		assert.False(t, repo.client.SIsMember(ctx, userTasksKey, first.ID).Val())
+
+		userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
+		assert.Greater(t, repo.client.ZScore(ctx, userDeletedKey, first.ID).Val(), float64(0))
+
+		require.NoError(t, repo.RestoreTask(parent.ID))
+
+		stored, err = repo.GetTaskByID(first.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.IsDeleted())
+
+		stored, err = repo.GetTaskByID(second.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.IsDeleted())
+
+		subtasks, err := repo.GetSubtasks(parent.ID)
+		require.NoError(t, err)
+		require.Len(t, subtasks, 1)
+		assert.Equal(t, first.ID, subtasks[0].ID)
+	})
+}
+
+func TestTaskRepository_TopLevelIndexes(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	topKey := topLevelIndexKey(userID, false)
+	deletedKey := topLevelIndexKey(userID, true)
+
+	parent := createTestTask(userID, "Release checklist", "work")
+	require.NoError(t, repo.CreateTask(parent))
+	subtask := createTestTask(userID, "Write changelog", "work")
+	subtask.ParentID = parent.ID
+	require.NoError(t, repo.CreateTask(subtask))
+
+	t.Run("should index top-level tasks only, newest first", func(t *testing.T) {
+		members := repo.client.ZRangeWithScores(ctx, topKey, 0, -1).Val()
+		require.Len(t, members, 1)
+		assert.Equal(t, parent.ID, members[0].Member)
+		assert.Equal(t, -float64(parent.CreatedAt.Unix()), members[0].Score)
+	})
+
+	t.Run("should move deleted tasks between the indexes and back", func(t *testing.T) {
+		require.NoError(t, repo.SoftDeleteTask(parent.ID))
+		assert.Zero(t, repo.client.ZCard(ctx, topKey).Val())
+		assert.Equal(t, []string{parent.ID}, repo.client.ZRange(ctx, deletedKey, 0, -1).Val())
+
+		require.NoError(t, repo.RestoreTask(parent.ID))
+		assert.Equal(t, []string{parent.ID}, repo.client.ZRange(ctx, topKey, 0, -1).Val())
+		assert.Zero(t, repo.client.ZCard(ctx, deletedKey).Val())
+	})
+
+	t.Run("should build the indexes of tasks stored before them", func(t *testing.T) {
+		deleted := createTestTask(userID, "Old idea", "someday")
+		require.NoError(t, repo.CreateTask(deleted))
+		require.NoError(t, repo.SoftDeleteTask(deleted.ID))
+		repo.client.Del(ctx, topKey, deletedKey, topLevelIndexedKey(userID))
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{parent.ID, deleted.ID}, taskIDs(page.Tasks))
+		assert.Equal(t, []string{parent.ID}, repo.client.ZRange(ctx, topKey, 0, -1).Val())
+		assert.Equal(t, []string{deleted.ID}, repo.client.ZRange(ctx, deletedKey, 0, -1).Val())
+
+		// Once built, the indexes are trusted as they are
+		repo.client.ZRem(ctx, deletedKey, deleted.ID)
+		page, err = repo.ListTasks(userID, domain.TaskFilters{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{parent.ID}, taskIDs(page.Tasks))
+	})
+}
+
+func TestTaskRepository_Tags(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	userID := uuid.New().String()
+	ctx := context.Background()
+
+	newTaggedTask := func(description string, tags ...string) *domain.Task {
+		task := createTestTask(userID, description, "")
+		task.Tags = tags
+		require.NoError(t, repo.CreateTask(task))
+		return task
+	}
+
+	both := newTaggedTask("Prepare slides", "work", "urgent")
+	work := newTaggedTask("Review budget", "work")
+	home := newTaggedTask("Fix sink", "home")
+
+	t.Run("should persist tags and index tasks by tag", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(both.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "urgent"}, stored.Tags)
+
+		workKey := redis.GenerateKey("user", userID) + ":tag:work"
+		assert.ElementsMatch(t, []string{both.ID, work.ID}, repo.client.SMembers(ctx, workKey).Val())
+	})
+
+	t.Run("should filter by any or all tags", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Tags: []string{"urgent", "home"}, Limit: 10})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{both.ID, home.ID}, taskIDs(page.Tasks))
+
+		page, err = repo.ListTasks(userID, domain.TaskFilters{Tags: []string{"work", "urgent"}, TagMatch: domain.TagMatchAll, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{both.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should combine tags with priority, completion and deleted filters", func(t *testing.T) {
+		work.Priority = domain.PriorityHigh
+		require.NoError(t, repo.UpdateTask(work))
+		deleted := newTaggedTask("Archive reports", "work")
+		deleted.Priority = domain.PriorityHigh
+		require.NoError(t, repo.UpdateTask(deleted))
+		require.NoError(t, repo.SoftDeleteTask(deleted.ID))
+
+		filters := domain.TaskFilters{Tags: []string{"work"}, Priority: domain.PriorityHigh, Completed: boolPtr(false)}
+		page, err := repo.ListTasks(userID, filters)
+		require.NoError(t, err)
+		assert.Equal(t, []string{work.ID}, taskIDs(page.Tasks))
+
+		filters.IncludeDeleted = true
+		page, err = repo.ListTasks(userID, filters)
+		require.NoError(t, err)
+		assert.Equal(t, []string{work.ID, deleted.ID}, taskIDs(page.Tasks))
+
+		filters.Category = "reports"
+		page, err = repo.ListTasks(userID, filters)
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+
+		work.Priority = domain.PriorityNone
+		require.NoError(t, repo.UpdateTask(work))
+	})
+
+	t.Run("should list tags with task counts", func(t *testing.T) {
+		tags, err := repo.GetUserTags(userID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TagSummary{
+			{Name: "home", TaskCount: 1},
+			{Name: "urgent", TaskCount: 1},
+			{Name: "work", TaskCount: 2},
+		}, tags)
+	})
+
+	t.Run("should move task between tag sets on update", func(t *testing.T) {
+		home.Tags = []string{"chores"}
+		require.NoError(t, repo.UpdateTask(home))
+
+		tags, err := repo.GetUserTags(userID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TagSummary{
+			{Name: "chores", TaskCount: 1},
+			{Name: "urgent", TaskCount: 1},
+			{Name: "work", TaskCount: 2},
+		}, tags)
+	})
+
+	t.Run("should rename tag on active and deleted tasks", func(t *testing.T) {
+		require.NoError(t, repo.SoftDeleteTask(work.ID))
+
+		err := repo.RenameTag(userID, "work", "urgent")
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, domain.ErrTagExists)
+
+		require.NoError(t, repo.RenameTag(userID, "work", "office"))
+
+		stored, err := repo.GetTaskByID(work.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"office"}, stored.Tags)
+
+		require.NoError(t, repo.RestoreTask(work.ID))
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Tags: []string{"office"}, Limit: 10})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{both.ID, work.ID}, taskIDs(page.Tasks))
+
+		err = repo.RenameTag(userID, "missing", "other")
+		assert.ErrorIs(t, err, domain.ErrTagNotFound)
+	})
+
+	t.Run("should merge tags without duplicating them", func(t *testing.T) {
+		require.NoError(t, repo.MergeTags(userID, "urgent", "office"))
+
+		stored, err := repo.GetTaskByID(both.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"office"}, stored.Tags)
+
+		tags, err := repo.GetUserTags(userID)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.TagSummary{
+			{Name: "chores", TaskCount: 1},
+			{Name: "office", TaskCount: 2},
+		}, tags)
+	})
+}
+
+func TestTaskRepository_SearchTasks(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	userID := uuid.New().String()
+	ctx := context.Background()
+	searchTermsKey := redis.GenerateKey("user", userID) + ":search:terms"
+
+	newTask := func(description string) *domain.Task {
+		task := createTestTask(userID, description, "")
+		require.NoError(t, repo.CreateTask(task))
+		return task
+	}
+
+	repeated := newTask("Deploy the website, then deploy the docs")
+	single := newTask("Deploy API")
+	prefix := newTask("Deployment checklist")
+	newTask("Water the plants")
+
+	t.Run("should rank whole-word and repeated matches first", func(t *testing.T) {
+		tasks, err := repo.SearchTasks(userID, "deploy", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{repeated.ID, single.ID, prefix.ID}, taskIDs(tasks))
+	})
+
+	t.Run("should match prefixes case-insensitively", func(t *testing.T) {
+		tasks, err := repo.SearchTasks(userID, "DEPL", 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{repeated.ID, single.ID, prefix.ID}, taskIDs(tasks))
+	})
+
+	t.Run("should require every query word to match", func(t *testing.T) {
+		tasks, err := repo.SearchTasks(userID, "deploy api", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{single.ID}, taskIDs(tasks))
+
+		tasks, err = repo.SearchTasks(userID, "deploy plants", 10)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("should apply the limit", func(t *testing.T) {
+		tasks, err := repo.SearchTasks(userID, "deploy", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{repeated.ID, single.ID}, taskIDs(tasks))
+	})
+
+	t.Run("should re-index on description update", func(t *testing.T) {
+		single.Description = "Publish API"
+		require.NoError(t, repo.UpdateTask(single))
+
+		tasks, err := repo.SearchTasks(userID, "publish", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{single.ID}, taskIDs(tasks))
+
+		tasks, err = repo.SearchTasks(userID, "deploy api", 10)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+
+		_, err = repo.client.ZScore(ctx, searchTermsKey, "api").Result()
+		assert.NoError(t, err)
+	})
+
+	t.Run("should drop deleted tasks and restore them", func(t *testing.T) {
+		require.NoError(t, repo.SoftDeleteTask(prefix.ID))
+
+		tasks, err := repo.SearchTasks(userID, "checklist", 10)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+		assert.Error(t, repo.client.ZScore(ctx, searchTermsKey, "checklist").Err())
+
+		require.NoError(t, repo.RestoreTask(prefix.ID))
+
+		tasks, err = repo.SearchTasks(userID, "checklist", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{prefix.ID}, taskIDs(tasks))
+	})
+
+	t.Run("should remove index entries of purged tasks", func(t *testing.T) {
+		purged := newTask("Archive invoices")
+		require.NoError(t, repo.SoftDeleteTask(purged.ID))
+
+		// Simulate an index entry left behind by an older deletion
+		repo.client.ZAdd(ctx, searchTermsKey, redislib.Z{Member: "invoices"})
+		invoicesKey := redis.GenerateKey("user", userID) + ":search:term:invoices"
+		repo.client.ZAdd(ctx, invoicesKey, redislib.Z{Score: 1, Member: purged.ID})
+
+		userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
+		repo.client.ZAdd(ctx, userDeletedKey, redislib.Z{
+			Score:  float64(time.Now().AddDate(0, 0, -8).Unix()),
+			Member: purged.ID,
+		})
+
+		report, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Total)
+		assert.Equal(t, int64(0), repo.client.Exists(ctx, invoicesKey).Val())
+		assert.Error(t, repo.client.ZScore(ctx, searchTermsKey, "invoices").Err())
+	})
+
+	t.Run("should reject invalid queries", func(t *testing.T) {
+		_, err := repo.SearchTasks(userID, "  !? ", 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2005")
+
+		_, err = repo.SearchTasks("", "deploy", 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2004")
+	})
+}
+
 func TestTaskRepository_SoftDeleteTask(t *testing.T) {
 	repo, s := setupTestTaskRepository(t)
 	defer s.Close()
@@ -774,18 +1892,18 @@ func TestTaskRepository_RenameCategory(t *testing.T) {
 			assert.NotContains(t, categories, tt.oldName)
 
 			// Verify tasks with the old category now have the new category
-			tasks, err := repo.ListTasks(tt.userID, domain.TaskFilters{Category: tt.newName})
+			page, err := repo.ListTasks(tt.userID, domain.TaskFilters{Category: tt.newName})
 			assert.NoError(t, err)
-			assert.Greater(t, len(tasks), 0)
+			assert.Greater(t, len(page.Tasks), 0)
 
-			for _, task := range tasks {
+			for _, task := range page.Tasks {
 				assert.Equal(t, tt.newName, task.Category)
 			}
 
 			// Verify old category set is empty/removed
 			ctx := context.Background()
 			oldCategoryKey := redis.GenerateKey("user", tt.userID) + ":category:" + tt.oldName
-			count := repo.client.SCard(ctx, oldCategoryKey).Val()
+			count := repo.client.ZCard(ctx, oldCategoryKey).Val()
 			assert.Equal(t, int64(0), count)
 		})
 	}
@@ -855,10 +1973,10 @@ func TestTaskRepository_DeleteCategory(t *testing.T) {
 			assert.NotContains(t, categories, tt.categoryName)
 
 			// Verify tasks with the deleted category now have empty category
-			tasks, err := repo.ListTasks(tt.userID, domain.TaskFilters{})
+			page, err := repo.ListTasks(tt.userID, domain.TaskFilters{})
 			assert.NoError(t, err)
 
-			for _, task := range tasks {
+			for _, task := range page.Tasks {
 				if task.ID == task1.ID || task.ID == task2.ID {
 					assert.Equal(t, "", task.Category)
 				}
@@ -867,12 +1985,155 @@ func TestTaskRepository_DeleteCategory(t *testing.T) {
 			// Verify category set is removed
 			ctx := context.Background()
 			categoryKey := redis.GenerateKey("user", tt.userID) + ":category:" + tt.categoryName
-			count := repo.client.SCard(ctx, categoryKey).Val()
+			count := repo.client.ZCard(ctx, categoryKey).Val()
 			assert.Equal(t, int64(0), count)
 		})
 	}
 }
 
+func TestTaskRepository_CategoryOrder(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	workKey := redis.GenerateKey("user", userID) + ":category:work"
+
+	// Creation timestamps are spaced so creation order is deterministic
+	base := time.Now().Add(-time.Hour)
+	newTask := func(description string, priority domain.TaskPriority, offset int) *domain.Task {
+		task := createTestTask(userID, description, "work")
+		task.Priority = priority
+		task.CreatedAt = base.Add(time.Duration(offset) * time.Minute)
+		require.NoError(t, repo.CreateTask(task))
+		return task
+	}
+
+	first := newTask("First", domain.PriorityHigh, 0)
+	second := newTask("Second", domain.PriorityLow, 1)
+	third := newTask("Third", domain.PriorityUrgent, 2)
+	require.NoError(t, repo.CreateTask(createTestTask(userID, "Elsewhere", "home")))
+
+	t.Run("should index category tasks by creation time", func(t *testing.T) {
+		entries, err := repo.client.ZRangeWithScores(ctx, workKey, 0, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, first.ID, entries[0].Member)
+		assert.Equal(t, float64(first.CreatedAt.Unix()), entries[0].Score)
+	})
+
+	t.Run("should list a category newest first", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Category: "work"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{third.ID, second.ID, first.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should apply sort options to a category", func(t *testing.T) {
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Category: "work", SortBy: domain.TaskSortPriority})
+		require.NoError(t, err)
+		assert.Equal(t, []string{third.ID, first.ID, second.ID}, taskIDs(page.Tasks))
+	})
+
+	t.Run("should keep creation order after a rename", func(t *testing.T) {
+		require.NoError(t, repo.RenameCategory(userID, "work", "office"))
+
+		page, err := repo.ListTasks(userID, domain.TaskFilters{Category: "office"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{third.ID, second.ID, first.ID}, taskIDs(page.Tasks))
+
+		score, err := repo.client.ZScore(ctx, redis.GenerateKey("user", userID)+":category:office", first.ID).Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(first.CreatedAt.Unix()), score)
+	})
+}
+
+func TestTaskRepository_MigrateCategoryIndexes(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	workKey := redis.GenerateKey("user", userID) + ":category:work"
+	homeKey := redis.GenerateKey("user", userID) + ":category:home"
+
+	older := createTestTask(userID, "Older", "work")
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	newer := createTestTask(userID, "Newer", "work")
+	require.NoError(t, repo.CreateTask(older))
+	require.NoError(t, repo.CreateTask(newer))
+	require.NoError(t, repo.CreateTask(createTestTask(userID, "Chores", "home")))
+
+	// Rewrite the work index the way earlier versions stored it
+	repo.client.Del(ctx, workKey)
+	repo.client.SAdd(ctx, workKey, older.ID, newer.ID, "missing-task")
+
+	migrated, err := repo.MigrateCategoryIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	keyType, err := repo.client.Type(ctx, workKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, "zset", keyType)
+	assert.Equal(t, []string{older.ID, newer.ID}, repo.client.ZRange(ctx, workKey, 0, -1).Val())
+	assert.Equal(t, int64(1), repo.client.ZCard(ctx, homeKey).Val())
+
+	page, err := repo.ListTasks(userID, domain.TaskFilters{Category: "work"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{newer.ID, older.ID}, taskIDs(page.Tasks))
+
+	// Running again skips the scan once the marker is recorded
+	assert.Equal(t, int64(1), repo.client.Exists(ctx, migrationKey(categoryIndexesMigration)).Val())
+	repo.client.Del(ctx, homeKey)
+	repo.client.SAdd(ctx, homeKey, "legacy-task")
+
+	migrated, err = repo.MigrateCategoryIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+	assert.Equal(t, "set", repo.client.Type(ctx, homeKey).Val())
+}
+
+func TestTaskRepository_GetTasksByIDs(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+
+	first := createTestTask(userID, "First", "work")
+	second := createTestTask(userID, "Second", "")
+	require.NoError(t, repo.CreateTask(first))
+	require.NoError(t, repo.CreateTask(second))
+
+	t.Run("should keep the order of the IDs and skip missing tasks", func(t *testing.T) {
+		tasks, err := repo.getTasksByIDs(ctx, []string{second.ID, "missing-task", first.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{second.ID, first.ID}, taskIDs(tasks))
+		assert.Equal(t, "Second", tasks[0].Description)
+		assert.Equal(t, "work", tasks[1].Category)
+	})
+
+	t.Run("should return an empty slice for no IDs", func(t *testing.T) {
+		tasks, err := repo.getTasksByIDs(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("should attach active subtasks to their parents", func(t *testing.T) {
+		child := createTestTask(userID, "Child", "")
+		child.ParentID = first.ID
+		removed := createTestTask(userID, "Removed child", "")
+		removed.ParentID = first.ID
+		require.NoError(t, repo.CreateTask(child))
+		require.NoError(t, repo.CreateTask(removed))
+		require.NoError(t, repo.SoftDeleteTask(removed.ID))
+
+		parents := []*domain.Task{first, second}
+		require.NoError(t, repo.attachSubtasks(ctx, parents))
+		assert.Equal(t, []string{child.ID}, taskIDs(first.Subtasks))
+		assert.Empty(t, second.Subtasks)
+	})
+}
+
 func TestTaskRepository_CleanupExpiredTasks(t *testing.T) {
 	repo, s := setupTestTaskRepository(t)
 	defer s.Close()
@@ -915,7 +2176,7 @@ func TestTaskRepository_CleanupExpiredTasks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cleanedCount, err := repo.CleanupExpiredTasks()
+			report, err := repo.CleanupExpiredTasks(ctx)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -926,7 +2187,8 @@ func TestTaskRepository_CleanupExpiredTasks(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
-			assert.Equal(t, tt.wantCleaned, cleanedCount)
+			assert.Equal(t, tt.wantCleaned, report.Total)
+			assert.Equal(t, map[string]int{userID: tt.wantCleaned}, report.PerUser)
 
 			// Verify old task was completely removed
 			ctx := context.Background()
@@ -946,7 +2208,395 @@ func TestTaskRepository_CleanupExpiredTasks(t *testing.T) {
 	}
 }
 
+func TestTaskRepository_CleanupExpiredTasksPurge(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	expiredScore := float64(time.Now().AddDate(0, 0, -8).Unix())
+
+	// expire backdates a soft-deleted task past the 7-day recovery window
+	expire := func(task *domain.Task) {
+		userDeletedKey := redis.GenerateKey("user", task.UserID) + ":tasks:deleted"
+		repo.client.ZAdd(ctx, userDeletedKey, redislib.Z{Score: expiredScore, Member: task.ID})
+	}
+
+	t.Run("should remove every trace of a purged task", func(t *testing.T) {
+		userID := uuid.New().String()
+		userKey := redis.GenerateKey("user", userID)
+
+		parent := createTestTask(userID, "Plan garden", "home")
+		parent.Tags = []string{"outdoor"}
+		parent.SeriesID = uuid.New().String()
+		parent.Occurrence = 1
+		child := createTestTask(userID, "Buy seeds", "")
+		child.ParentID = parent.ID
+		require.NoError(t, repo.CreateTask(parent))
+		require.NoError(t, repo.CreateTask(child))
+		require.NoError(t, repo.SoftDeleteTask(parent.ID))
+
+		// Simulate index entries left behind by an older version of soft delete
+		repo.client.ZAdd(ctx, userKey+":category:home", redislib.Z{Score: 1, Member: parent.ID})
+		repo.client.SAdd(ctx, userKey+":tag:outdoor", parent.ID)
+
+		expire(parent)
+		expire(child)
+
+		report, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Total)
+		assert.Equal(t, map[string]int{userID: 2}, report.PerUser)
+
+		for _, key := range []string{
+			redis.GenerateKey(redis.TaskKeyPrefix, parent.ID),
+			redis.GenerateKey(redis.TaskKeyPrefix, child.ID),
+			redis.GenerateKey(redis.TaskKeyPrefix, parent.ID) + ":subtasks",
+			userKey + ":category:home",
+			userKey + ":tag:outdoor",
+			userKey + ":series:" + parent.SeriesID,
+			userKey + ":subtasks",
+			userKey + ":tasks:deleted",
+			userKey + ":search:term:garden",
+		} {
+			assert.Equal(t, int64(0), repo.client.Exists(ctx, key).Val(), key)
+		}
+
+		categories, err := repo.GetUserCategories(userID)
+		require.NoError(t, err)
+		assert.Empty(t, categories)
+
+		tags, err := repo.GetUserTags(userID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("should keep categories still used by other tasks", func(t *testing.T) {
+		userID := uuid.New().String()
+		purged := createTestTask(userID, "Old report", "work")
+		kept := createTestTask(userID, "New report", "work")
+		require.NoError(t, repo.CreateTask(purged))
+		require.NoError(t, repo.CreateTask(kept))
+		require.NoError(t, repo.SoftDeleteTask(purged.ID))
+		expire(purged)
+
+		_, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+
+		categories, err := repo.GetUserCategories(userID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, categories)
+	})
+
+	t.Run("should purge in batches and count per user", func(t *testing.T) {
+		firstUser := uuid.New().String()
+		secondUser := uuid.New().String()
+
+		for i := 0; i < cleanupBatchSize+5; i++ {
+			task := createTestTask(firstUser, fmt.Sprintf("Task %d", i), "")
+			require.NoError(t, repo.CreateTask(task))
+			require.NoError(t, repo.SoftDeleteTask(task.ID))
+			expire(task)
+		}
+		other := createTestTask(secondUser, "Other task", "")
+		require.NoError(t, repo.CreateTask(other))
+		require.NoError(t, repo.SoftDeleteTask(other.ID))
+		expire(other)
+
+		report, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, cleanupBatchSize+6, report.Total)
+		assert.Equal(t, map[string]int{firstUser: cleanupBatchSize + 5, secondUser: 1}, report.PerUser)
+	})
+
+	t.Run("should report failures and keep purging other users", func(t *testing.T) {
+		brokenUser := uuid.New().String()
+		repo.client.Set(ctx, redis.GenerateKey("user", brokenUser)+":tasks:deleted", "not a sorted set", 0)
+		repo.client.SAdd(ctx, deletedTaskUsersKey, brokenUser)
+
+		userID := uuid.New().String()
+		task := createTestTask(userID, "Old report", "")
+		require.NoError(t, repo.CreateTask(task))
+		require.NoError(t, repo.SoftDeleteTask(task.ID))
+		expire(task)
+
+		report, err := repo.CleanupExpiredTasks(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), brokenUser)
+		require.NotNil(t, report)
+		assert.Equal(t, map[string]int{userID: 1}, report.PerUser)
+
+		repo.client.Del(ctx, redis.GenerateKey("user", brokenUser)+":tasks:deleted")
+		repo.client.SRem(ctx, deletedTaskUsersKey, brokenUser)
+	})
+
+	t.Run("should stop between batches once the context is done", func(t *testing.T) {
+		userID := uuid.New().String()
+		task := createTestTask(userID, "Old report", "")
+		require.NoError(t, repo.CreateTask(task))
+		require.NoError(t, repo.SoftDeleteTask(task.ID))
+		expire(task)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := repo.CleanupExpiredTasks(canceled)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, int64(1), repo.client.Exists(ctx, redis.GenerateKey(redis.TaskKeyPrefix, task.ID)).Val())
+	})
+}
+
 // Helper function to create bool pointer
+func taskIDs(tasks []*domain.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
 func boolPtr(b bool) *bool {
 	return &b
-}
\ No newline at end of file
+}
+
+// forEachBenchmarkBackend runs a benchmark against miniredis and, when REDIS_BENCH_ADDR is set
+// (for example to the redis-test container), against a real Redis server
+func forEachBenchmarkBackend(b *testing.B, run func(b *testing.B, repo *TaskRepository)) {
+	b.Run("miniredis", func(b *testing.B) {
+		s := miniredis.RunT(b)
+		rdb := redislib.NewClient(&redislib.Options{Addr: s.Addr()})
+		defer rdb.Close()
+
+		run(b, NewTaskRepository(&redis.Client{Client: rdb}))
+	})
+
+	b.Run("redis", func(b *testing.B) {
+		addr := os.Getenv("REDIS_BENCH_ADDR")
+		if addr == "" {
+			b.Skip("set REDIS_BENCH_ADDR to benchmark against a real Redis server")
+		}
+
+		rdb := redislib.NewClient(&redislib.Options{Addr: addr})
+		defer rdb.Close()
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			b.Skipf("Redis server at %s is not reachable: %v", addr, err)
+		}
+
+		run(b, NewTaskRepository(&redis.Client{Client: rdb}))
+	})
+}
+
+// seedBenchmarkTasks creates tasks for a new user and removes every key they touched when the benchmark ends
+func seedBenchmarkTasks(b *testing.B, repo *TaskRepository, count int) (string, []string) {
+	ctx := context.Background()
+	userID := uuid.New().String()
+	ids := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		task := createTestTask(userID, fmt.Sprintf("Benchmark task %d", i), fmt.Sprintf("category%d", i%5))
+		task.Tags = []string{"bench"}
+		if err := repo.CreateTask(task); err != nil {
+			b.Fatalf("failed to seed task: %v", err)
+		}
+		ids[i] = task.ID
+	}
+
+	b.Cleanup(func() {
+		var keys []string
+		iter := repo.client.Scan(ctx, 0, redis.GenerateKey("user", userID)+":*", 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		for _, id := range ids {
+			keys = append(keys, redis.GenerateKey(redis.TaskKeyPrefix, id))
+		}
+		repo.client.Del(ctx, keys...)
+	})
+
+	return userID, ids
+}
+
+// BenchmarkTaskRepository_FetchTasks compares one round trip per task with a single pipelined fetch
+func BenchmarkTaskRepository_FetchTasks(b *testing.B) {
+	forEachBenchmarkBackend(b, func(b *testing.B, repo *TaskRepository) {
+		for _, count := range []int{100, 1000} {
+			_, ids := seedBenchmarkTasks(b, repo, count)
+
+			b.Run(fmt.Sprintf("sequential/%d", count), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					for _, id := range ids {
+						if _, err := repo.GetTaskByID(id); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			})
+
+			b.Run(fmt.Sprintf("pipelined/%d", count), func(b *testing.B) {
+				ctx := context.Background()
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.getTasksByIDs(ctx, ids); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+}
+
+// BenchmarkTaskRepository_ListTasks measures listing pages of a large task set, where only the page's tasks are loaded
+func BenchmarkTaskRepository_ListTasks(b *testing.B) {
+	forEachBenchmarkBackend(b, func(b *testing.B, repo *TaskRepository) {
+		userID, _ := seedBenchmarkTasks(b, repo, 10000)
+
+		// A cursor half way through the listing
+		deep, err := repo.ListTasks(userID, domain.TaskFilters{Offset: 4999, Limit: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		cases := []struct {
+			name    string
+			filters domain.TaskFilters
+		}{
+			{"all/limit-1000", domain.TaskFilters{Limit: 1000}},
+			{"first-page/limit-20", domain.TaskFilters{Limit: 20}},
+			{"cursor-page/limit-20", domain.TaskFilters{Limit: 20, Cursor: deep.NextCursor}},
+			{"filtered/limit-20", domain.TaskFilters{Category: "category1", Tags: []string{"bench"}, Completed: boolPtr(false), Limit: 20}},
+			{"priority-order/limit-20", domain.TaskFilters{SortBy: domain.TaskSortPriority, Limit: 20}},
+			{"due-order/limit-20", domain.TaskFilters{SortBy: domain.TaskSortDue, Limit: 20}},
+		}
+
+		for _, tc := range cases {
+			b.Run(tc.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.ListTasks(userID, tc.filters); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestTaskRepository_DeletedTaskUsersIndex(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	userDeletedKey := redis.GenerateKey("user", userID) + ":tasks:deleted"
+
+	task := createTestTask(userID, "Old task", "")
+	require.NoError(t, repo.CreateTask(task))
+	require.NoError(t, repo.SoftDeleteTask(task.ID))
+
+	t.Run("should track users when tasks are soft-deleted", func(t *testing.T) {
+		assert.True(t, repo.client.SIsMember(ctx, deletedTaskUsersKey, userID).Val())
+	})
+
+	t.Run("should keep users with unexpired deleted tasks", func(t *testing.T) {
+		_, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+		assert.True(t, repo.client.SIsMember(ctx, deletedTaskUsersKey, userID).Val())
+	})
+
+	t.Run("should untrack users once their deleted tasks are purged", func(t *testing.T) {
+		repo.client.ZAdd(ctx, userDeletedKey, redislib.Z{Score: float64(time.Now().AddDate(0, 0, -8).Unix()), Member: task.ID})
+
+		report, err := repo.CleanupExpiredTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Total)
+		assert.False(t, repo.client.SIsMember(ctx, deletedTaskUsersKey, userID).Val())
+	})
+
+	t.Run("should backfill users with deleted tasks", func(t *testing.T) {
+		other := uuid.New().String()
+		otherDeletedKey := redis.GenerateKey("user", other) + ":tasks:deleted"
+		repo.client.ZAdd(ctx, otherDeletedKey, redislib.Z{Score: float64(time.Now().Unix()), Member: uuid.New().String()})
+
+		added, err := repo.BackfillDeletedTaskUsers()
+		require.NoError(t, err)
+		assert.Equal(t, 1, added)
+		assert.Equal(t, []string{other}, repo.client.SMembers(ctx, deletedTaskUsersKey).Val())
+	})
+
+	t.Run("should skip the backfill once it has completed", func(t *testing.T) {
+		repo.client.Del(ctx, deletedTaskUsersKey)
+
+		added, err := repo.BackfillDeletedTaskUsers()
+		require.NoError(t, err)
+		assert.Equal(t, 0, added)
+		assert.Equal(t, int64(0), repo.client.Exists(ctx, deletedTaskUsersKey).Val())
+	})
+}
+
+func TestTaskRepository_DeleteUserTasks(t *testing.T) {
+	repo, s := setupTestTaskRepository(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	userID := uuid.New().String()
+	otherUserID := uuid.New().String()
+	dueDate := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	recurring := createTestTask(userID, "Weekly report", "work")
+	recurring.Recurrence = "FREQ=WEEKLY"
+	recurring.SeriesID = recurring.ID
+	recurring.Occurrence = 1
+	recurring.DueDate = &dueDate
+	recurring.Tags = []string{"urgent"}
+	require.NoError(t, repo.CreateTask(recurring))
+
+	subtask := createTestTask(userID, "Collect numbers", "work")
+	subtask.ParentID = recurring.ID
+	require.NoError(t, repo.CreateTask(subtask))
+
+	completed := createTestTask(userID, "File taxes", "home")
+	require.NoError(t, repo.CreateTask(completed))
+	require.NoError(t, repo.UpdateTaskCompletion(completed.ID, true))
+
+	deleted := createTestTask(userID, "Old idea", "someday")
+	require.NoError(t, repo.CreateTask(deleted))
+	require.NoError(t, repo.SoftDeleteTask(deleted.ID))
+
+	otherTask := createTestTask(otherUserID, "Weekly report", "work")
+	require.NoError(t, repo.CreateTask(otherTask))
+
+	t.Run("should delete every task and index of the user", func(t *testing.T) {
+		count, err := repo.DeleteUserTasks(userID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+
+		for _, key := range s.Keys() {
+			assert.False(t, strings.HasPrefix(key, "user:"+userID), "left behind %s", key)
+			for _, task := range []*domain.Task{recurring, subtask, completed, deleted} {
+				assert.False(t, strings.HasPrefix(key, "task:"+task.ID), "left behind %s", key)
+			}
+		}
+		assert.False(t, repo.client.SIsMember(ctx, deletedTaskUsersKey, userID).Val())
+	})
+
+	t.Run("should keep other users' tasks", func(t *testing.T) {
+		stored, err := repo.GetTaskByID(otherTask.ID)
+		require.NoError(t, err)
+		assert.Equal(t, otherTask.Description, stored.Description)
+
+		categories, err := repo.GetUserCategories(otherUserID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, categories)
+
+		results, err := repo.SearchTasks(otherUserID, "weekly", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("should succeed for a user without tasks", func(t *testing.T) {
+		count, err := repo.DeleteUserTasks(uuid.New().String())
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("should require a user ID", func(t *testing.T) {
+		_, err := repo.DeleteUserTasks(" ")
+		assert.Error(t, err)
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,17 +16,163 @@ import (
 	redislib "github.com/redis/go-redis/v9"
 )
 
+// usersIndexKey is the global sorted set of user IDs scored by creation time
+const usersIndexKey = "users:sorted"
+
+// userIndexesMigration names the marker recording that the users and sessions indexes were backfilled
+const userIndexesMigration = "user_indexes"
+
+// sessionTouchInterval limits how often a session's last seen time is rewritten
+const sessionTouchInterval = time.Minute
+
+// loginFailureTTL is how long consecutive failed logins are remembered after the latest one
+const loginFailureTTL = 24 * time.Hour
+
+// maxSecurityEvents is how many security events are kept per user; older ones are dropped
+const maxSecurityEvents = 50
+
+// totpStepTTL is how long the last accepted TOTP time step is remembered, comfortably longer than any code stays valid
+const totpStepTTL = 10 * time.Minute
+
+// useTOTPStepScript records a TOTP time step as used unless it is not newer than the last one used
+// KEYS: last step. ARGV: step, TTL in ms. Returns 1 if the step was accepted
+var useTOTPStepScript = redislib.NewScript(`
+local last = tonumber(redis.call("GET", KEYS[1]) or "-1")
+if tonumber(ARGV[1]) <= last then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// recordChallengeFailureScript counts a wrong code against a login challenge that still exists
+// KEYS: challenge. Returns the new number of attempts, or 0 if the challenge has expired
+var recordChallengeFailureScript = redislib.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// storedSession is the JSON layout of a session key
+// Sessions written before metadata was tracked only carry user_id and created_at
+type storedSession struct {
+	UserID     string `json:"user_id"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	RememberMe bool   `json:"remember_me,omitempty"`
+}
+
+// storedUser is the JSON layout of a user key
+// Unlike domain.User it includes the password hash
+type storedUser struct {
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	DisplayName   string    `json:"display_name"`
+	Password      string    `json:"password"`
+	IsAdmin       bool      `json:"is_admin"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// newStoredUser converts a domain user into its stored form
+func newStoredUser(user *domain.User) storedUser {
+	return storedUser{
+		ID:            user.ID,
+		Email:         user.Email,
+		DisplayName:   user.DisplayName,
+		Password:      user.Password,
+		IsAdmin:       user.IsAdmin,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// toDomain converts the stored user back into a domain user
+func (u *storedUser) toDomain() *domain.User {
+	return &domain.User{
+		ID:            u.ID,
+		Email:         u.Email,
+		DisplayName:   u.DisplayName,
+		Password:      u.Password,
+		IsAdmin:       u.IsAdmin,
+		EmailVerified: u.EmailVerified,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
+
+// toDomain converts the stored session into a domain session expiring after ttl
+func (s *storedSession) toDomain(sessionID string, ttl time.Duration) *domain.Session {
+	lastSeen := s.LastSeenAt
+	if lastSeen == 0 {
+		lastSeen = s.CreatedAt
+	}
+	return &domain.Session{
+		ID:         sessionID,
+		UserID:     s.UserID,
+		CreatedAt:  time.Unix(s.CreatedAt, 0),
+		LastSeenAt: time.Unix(lastSeen, 0),
+		ExpiresAt:  time.Now().Add(ttl).Truncate(time.Second),
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		RememberMe: s.RememberMe,
+	}
+}
+
+// sessionTTL returns the lifetime of a session opened with or without remember me
+func (r *UserRepository) sessionTTL(rememberMe bool) time.Duration {
+	if rememberMe {
+		return r.sessions.RememberTTL
+	}
+	return r.sessions.ShortTTL
+}
+
+// userSessionsKey returns the key of a user's sessions index
+func userSessionsKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":sessions"
+}
+
+// SessionSettings controls how long sessions live in Redis
+// Sliding sessions have their lifetime renewed whenever they are used
+type SessionSettings struct {
+	RememberTTL time.Duration // sessions opened with remember me
+	ShortTTL    time.Duration // all other sessions
+	Sliding     bool
+}
+
+// DefaultSessionSettings returns the session lifetimes used when none are configured
+// Remembered sessions last 7 days and others 24 hours, without sliding expiry
+func DefaultSessionSettings() SessionSettings {
+	return SessionSettings{
+		RememberTTL: 7 * 24 * time.Hour,
+		ShortTTL:    24 * time.Hour,
+	}
+}
+
 // UserRepository implements the domain.UserRepository interface
 // Provides Redis-based storage for user data and session management
 type UserRepository struct {
-	client *redis.Client
+	client   *redis.Client
+	sessions SessionSettings
 }
 
 // NewUserRepository creates a new UserRepository instance
-// Takes a Redis client and returns a configured user repository
+// Takes a Redis client and returns a user repository with the default session lifetimes
 func NewUserRepository(client *redis.Client) *UserRepository {
+	return NewUserRepositoryWithSessions(client, DefaultSessionSettings())
+}
+
+// NewUserRepositoryWithSessions creates a new UserRepository instance with the given session lifetimes
+// Used by the server to apply the session configuration
+func NewUserRepositoryWithSessions(client *redis.Client, sessions SessionSettings) *UserRepository {
 	return &UserRepository{
-		client: client,
+		client:   client,
+		sessions: sessions,
 	}
 }
 
@@ -55,27 +203,8 @@ func (r *UserRepository) Create(user *domain.User) error {
 		return domain.ErrUserAlreadyExists
 	}
 
-	// Create storage representation that includes password
-	storageUser := struct {
-		ID          string    `json:"id"`
-		Email       string    `json:"email"`
-		DisplayName string    `json:"display_name"`
-		Password    string    `json:"password"` // Include password for storage
-		IsAdmin     bool      `json:"is_admin"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-	}{
-		ID:          user.ID,
-		Email:       user.Email,
-		DisplayName: user.DisplayName,
-		Password:    user.Password,
-		IsAdmin:     user.IsAdmin,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-	}
-
-	// Serialize user data
-	userData, err := json.Marshal(storageUser)
+	// Serialize user data, including the password hash
+	userData, err := json.Marshal(newStoredUser(user))
 	if err != nil {
 		return fmt.Errorf("failed to marshal user data: %w", err)
 	}
@@ -90,6 +219,12 @@ func (r *UserRepository) Create(user *domain.User) error {
 	// Create email index
 	pipe.Set(ctx, emailKey, user.ID, 0)
 
+	// Add to the global users index (sorted by created timestamp)
+	pipe.ZAdd(ctx, usersIndexKey, redislib.Z{
+		Score:  float64(user.CreatedAt.Unix()),
+		Member: user.ID,
+	})
+
 	// Execute transaction
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -117,34 +252,12 @@ func (r *UserRepository) GetByID(id string) (*domain.User, error) {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Create storage representation for deserialization
-	var storageUser struct {
-		ID          string    `json:"id"`
-		Email       string    `json:"email"`
-		DisplayName string    `json:"display_name"`
-		Password    string    `json:"password"`
-		IsAdmin     bool      `json:"is_admin"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-	}
-	
-	err = json.Unmarshal([]byte(userData), &storageUser)
-	if err != nil {
+	var stored storedUser
+	if err := json.Unmarshal([]byte(userData), &stored); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal user data: %w", err)
 	}
 
-	// Convert back to domain user
-	user := &domain.User{
-		ID:          storageUser.ID,
-		Email:       storageUser.Email,
-		DisplayName: storageUser.DisplayName,
-		Password:    storageUser.Password,
-		IsAdmin:     storageUser.IsAdmin,
-		CreatedAt:   storageUser.CreatedAt,
-		UpdatedAt:   storageUser.UpdatedAt,
-	}
-
-	return user, nil
+	return stored.toDomain(), nil
 }
 
 // GetByEmail retrieves a user by their email address
@@ -181,27 +294,8 @@ func (r *UserRepository) Update(user *domain.User) error {
 		return err
 	}
 
-	// Create storage representation that includes password
-	storageUser := struct {
-		ID          string    `json:"id"`
-		Email       string    `json:"email"`
-		DisplayName string    `json:"display_name"`
-		Password    string    `json:"password"` // Include password for storage
-		IsAdmin     bool      `json:"is_admin"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-	}{
-		ID:          user.ID,
-		Email:       user.Email,
-		DisplayName: user.DisplayName,
-		Password:    user.Password,
-		IsAdmin:     user.IsAdmin,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-	}
-
-	// Serialize updated user data
-	userData, err := json.Marshal(storageUser)
+	// Serialize updated user data, including the password hash
+	userData, err := json.Marshal(newStoredUser(user))
 	if err != nil {
 		return fmt.Errorf("failed to marshal user data: %w", err)
 	}
@@ -218,7 +312,7 @@ func (r *UserRepository) Update(user *domain.User) error {
 }
 
 // Delete removes a user and their associated data
-// Cleans up user data and email index
+// Cleans up user data, email index, API tokens, linked identity provider accounts and any outstanding password reset token
 func (r *UserRepository) Delete(id string) error {
 	if strings.TrimSpace(id) == "" {
 		return errors.New("user ID is required")
@@ -232,6 +326,24 @@ func (r *UserRepository) Delete(id string) error {
 
 	ctx := context.Background()
 
+	// Find an outstanding password reset token so it cannot outlive the user
+	resetToken, err := r.client.Get(ctx, userPasswordResetKey(id)).Result()
+	if err != nil && err != redislib.Nil {
+		return fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	// Find the user's API tokens so they stop working with the account
+	apiTokenHashes, err := r.client.ZRange(ctx, userAPITokensKey(id), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get API tokens: %w", err)
+	}
+
+	// Find linked identity provider accounts so a later sign-in with them does not reach a deleted user
+	oidcIdentities, err := r.client.HGetAll(ctx, userOIDCIdentitiesKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get identity provider accounts: %w", err)
+	}
+
 	// Use transaction to ensure atomicity
 	pipe := r.client.TxPipeline()
 
@@ -239,10 +351,36 @@ func (r *UserRepository) Delete(id string) error {
 	userKey := redis.GenerateKey(redis.UserKeyPrefix, id)
 	pipe.Del(ctx, userKey)
 
+	// Delete login tracking and security events
+	pipe.Del(ctx, userLoginKey(id), userLoginFailuresKey(id), userLockKey(id), userSecurityEventsKey(id))
+
+	// Delete two-factor authentication settings
+	pipe.Del(ctx, userTwoFactorKey(id), userTwoFactorSetupKey(id), userRecoveryCodesKey(id), userTOTPStepKey(id))
+
+	// Delete API tokens and their index
+	for _, tokenHash := range apiTokenHashes {
+		pipe.Del(ctx, apiTokenKey(tokenHash))
+	}
+	pipe.Del(ctx, userAPITokensKey(id))
+
+	// Unlink identity provider accounts
+	for issuer, subject := range oidcIdentities {
+		pipe.Del(ctx, oidcIdentityKey(issuer, subject))
+	}
+	pipe.Del(ctx, userOIDCIdentitiesKey(id))
+
+	// Delete any password reset token
+	if resetToken != "" {
+		pipe.Del(ctx, passwordResetKey(resetToken), userPasswordResetKey(id))
+	}
+
 	// Delete email index
 	emailKey := redis.GenerateKey("user:email", user.Email)
 	pipe.Del(ctx, emailKey)
 
+	// Remove from the global users index
+	pipe.ZRem(ctx, usersIndexKey, id)
+
 	// Execute transaction
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -253,51 +391,35 @@ func (r *UserRepository) Delete(id string) error {
 }
 
 // List retrieves a paginated list of users
-// Returns users with limit and offset for pagination
+// Returns users in creation order, read from the global users index with limit and offset
 func (r *UserRepository) List(limit, offset int) ([]*domain.User, error) {
-	// This is a simplified implementation
-	// In a real application, you might use Redis sets or sorted sets for better pagination
 	ctx := context.Background()
-
-	// Get all user keys
-	pattern := redis.GenerateKey(redis.UserKeyPrefix, "*")
-	keys, err := r.client.Keys(ctx, pattern).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user keys: %w", err)
-	}
-
-	// Apply pagination
-	start := offset
-	end := offset + limit
-	if start >= len(keys) {
+	if limit <= 0 || offset < 0 {
 		return []*domain.User{}, nil
 	}
-	if end > len(keys) {
-		end = len(keys)
-	}
 
-	users := make([]*domain.User, 0, end-start)
-	for i := start; i < end; i++ {
-		userData, err := r.client.Get(ctx, keys[i]).Result()
-		if err != nil {
-			continue // Skip invalid entries
-		}
+	// Get the page of user IDs
+	userIDs, err := r.client.ZRange(ctx, usersIndexKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user IDs: %w", err)
+	}
 
-		var user domain.User
-		err = json.Unmarshal([]byte(userData), &user)
+	users := make([]*domain.User, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, err := r.GetByID(userID)
 		if err != nil {
 			continue // Skip invalid entries
 		}
 
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	return users, nil
 }
 
-// CreateSession creates a new user session with 7-day TTL
-// Stores session data in Redis with automatic expiration
-func (r *UserRepository) CreateSession(userID, sessionID string) error {
+// CreateSession creates a new user session, long-lived when remember me is set
+// Stores session data and the client it was opened from in Redis with automatic expiration
+func (r *UserRepository) CreateSession(userID, sessionID string, rememberMe bool, client domain.SessionClient) error {
 	if strings.TrimSpace(userID) == "" {
 		return errors.New("user ID is required")
 	}
@@ -308,10 +430,16 @@ func (r *UserRepository) CreateSession(userID, sessionID string) error {
 	ctx := context.Background()
 	sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
 
+	now := time.Now()
+
 	// Create session data
-	sessionData := map[string]interface{}{
-		"user_id":    userID,
-		"created_at": time.Now().Unix(),
+	sessionData := storedSession{
+		UserID:     userID,
+		CreatedAt:  now.Unix(),
+		LastSeenAt: now.Unix(),
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		RememberMe: rememberMe,
 	}
 
 	sessionJSON, err := json.Marshal(sessionData)
@@ -319,9 +447,19 @@ func (r *UserRepository) CreateSession(userID, sessionID string) error {
 		return fmt.Errorf("failed to marshal session data: %w", err)
 	}
 
-	// Set session with 7-day TTL
-	ttl := 7 * 24 * time.Hour
-	err = r.client.Set(ctx, sessionKey, sessionJSON, ttl).Err()
+	// Set session with its TTL and index it under the user, scored by expiry
+	ttl := r.sessionTTL(rememberMe)
+	userSessionsKey := userSessionsKey(userID)
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, sessionKey, sessionJSON, ttl)
+	pipe.ZAdd(ctx, userSessionsKey, redislib.Z{
+		Score:  float64(now.Add(ttl).Unix()),
+		Member: sessionID,
+	})
+	// Drop sessions that have already expired from the index
+	pipe.ZRemRangeByScore(ctx, userSessionsKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
@@ -375,6 +513,155 @@ func (r *UserRepository) GetSessionUserID(sessionID string) (string, error) {
 	return userID, nil
 }
 
+// GetSession retrieves a session with its metadata
+// Returns domain.ErrSessionNotFound if the session does not exist or has expired
+func (r *UserRepository) GetSession(sessionID string) (*domain.Session, error) {
+	if strings.TrimSpace(sessionID) == "" {
+		return nil, errors.New("session ID is required")
+	}
+
+	ctx := context.Background()
+	sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
+
+	pipe := r.client.Pipeline()
+	dataCmd := pipe.Get(ctx, sessionKey)
+	ttlCmd := pipe.PTTL(ctx, sessionKey)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		if err == redislib.Nil {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	var session storedSession
+	if err := json.Unmarshal([]byte(dataCmd.Val()), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+	}
+
+	return session.toDomain(sessionID, ttlCmd.Val()), nil
+}
+
+// ListUserSessions retrieves every live session of a user, most recently seen first
+// Prunes index entries of sessions that have expired
+func (r *UserRepository) ListUserSessions(userID string) ([]*domain.Session, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	userSessionsKey := userSessionsKey(userID)
+
+	// Drop expired sessions from the index before reading it
+	r.client.ZRemRangeByScore(ctx, userSessionsKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+
+	sessionIDs, err := r.client.ZRange(ctx, userSessionsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+	if len(sessionIDs) == 0 {
+		return []*domain.Session{}, nil
+	}
+
+	// Fetch every session and its remaining lifetime in one round trip
+	pipe := r.client.Pipeline()
+	dataCmds := make([]*redislib.StringCmd, len(sessionIDs))
+	ttlCmds := make([]*redislib.DurationCmd, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
+		dataCmds[i] = pipe.Get(ctx, sessionKey)
+		ttlCmds[i] = pipe.PTTL(ctx, sessionKey)
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redislib.Nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]*domain.Session, 0, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		var session storedSession
+		if dataCmds[i].Err() != nil || json.Unmarshal([]byte(dataCmds[i].Val()), &session) != nil {
+			continue // Skip sessions deleted since the index was read
+		}
+		sessions = append(sessions, session.toDomain(sessionID, ttlCmds[i].Val()))
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// TouchSession records that a session was just used by the given client
+// Sliding sessions also get their full lifetime back; writes are skipped while the session was seen within the last minute from the same IP
+// Returns the renewed expiry of a remembered session, whose persistent cookie should be renewed with it, or the zero time
+func (r *UserRepository) TouchSession(sessionID string, client domain.SessionClient) (time.Time, error) {
+	if strings.TrimSpace(sessionID) == "" {
+		return time.Time{}, errors.New("session ID is required")
+	}
+
+	ctx := context.Background()
+	sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
+
+	sessionData, err := r.client.Get(ctx, sessionKey).Result()
+	if err != nil {
+		if err == redislib.Nil {
+			return time.Time{}, domain.ErrSessionNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	var session storedSession
+	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unmarshal session data: %w", err)
+	}
+
+	now := time.Now()
+	if now.Sub(time.Unix(session.LastSeenAt, 0)) < sessionTouchInterval && session.IP == client.IP {
+		return time.Time{}, nil
+	}
+
+	session.LastSeenAt = now.Unix()
+	session.IP = client.IP
+	if client.UserAgent != "" {
+		session.UserAgent = client.UserAgent
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to marshal session data: %w", err)
+	}
+
+	// XX keeps a session revoked in the meantime from being recreated
+	if !r.sessions.Sliding {
+		err = r.client.SetArgs(ctx, sessionKey, sessionJSON, redislib.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+		if err != nil && err != redislib.Nil {
+			return time.Time{}, fmt.Errorf("failed to update session: %w", err)
+		}
+		return time.Time{}, nil
+	}
+
+	ttl := r.sessionTTL(session.RememberMe)
+	pipe := r.client.TxPipeline()
+	renewed := pipe.SetArgs(ctx, sessionKey, sessionJSON, redislib.SetArgs{Mode: "XX", TTL: ttl})
+	pipe.ZAddXX(ctx, userSessionsKey(session.UserID), redislib.Z{
+		Score:  float64(now.Add(ttl).Unix()),
+		Member: sessionID,
+	})
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redislib.Nil {
+		return time.Time{}, fmt.Errorf("failed to renew session: %w", err)
+	}
+
+	// Cookies of sessions opened without remember me end with the browser, so they have no expiry to renew
+	if renewed.Err() != nil || !session.RememberMe {
+		return time.Time{}, nil
+	}
+
+	return now.Add(ttl), nil
+}
+
 // DeleteSession removes a session from Redis
 // Used for logout functionality
 func (r *UserRepository) DeleteSession(sessionID string) error {
@@ -385,7 +672,15 @@ func (r *UserRepository) DeleteSession(sessionID string) error {
 	ctx := context.Background()
 	sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
 
-	err := r.client.Del(ctx, sessionKey).Err()
+	// Look up the owner so the session can be removed from their index
+	userID, _ := r.GetSessionUserID(sessionID)
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, sessionKey)
+	if userID != "" {
+		pipe.ZRem(ctx, userSessionsKey(userID), sessionID)
+	}
+	_, err := pipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
@@ -403,38 +698,1060 @@ func (r *UserRepository) DeleteAllUserSessions(userID string) error {
 	ctx := context.Background()
 
 	// Find all sessions for this user
-	pattern := redis.GenerateKey(redis.SessionKeyPrefix, "*")
-	keys, err := r.client.Keys(ctx, pattern).Result()
+	userSessionsKey := userSessionsKey(userID)
+	sessionIDs, err := r.client.ZRange(ctx, userSessionsKey, 0, -1).Result()
 	if err != nil {
-		return fmt.Errorf("failed to get session keys: %w", err)
+		return fmt.Errorf("failed to get user sessions: %w", err)
 	}
 
-	// Check each session to see if it belongs to the user
-	var sessionsToDelete []string
-	for _, key := range keys {
-		sessionData, err := r.client.Get(ctx, key).Result()
-		if err != nil {
-			continue // Skip invalid sessions
-		}
+	// Delete all sessions together with the index
+	keys := make([]string, 0, len(sessionIDs)+1)
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, redis.GenerateKey(redis.SessionKeyPrefix, sessionID))
+	}
+	keys = append(keys, userSessionsKey)
 
-		var session map[string]interface{}
-		err = json.Unmarshal([]byte(sessionData), &session)
-		if err != nil {
-			continue // Skip invalid sessions
-		}
+	err = r.client.Del(ctx, keys...).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete user sessions: %w", err)
+	}
 
-		if sessionUserID, ok := session["user_id"].(string); ok && sessionUserID == userID {
-			sessionsToDelete = append(sessionsToDelete, key)
-		}
+	return nil
+}
+
+// DeleteOtherUserSessions removes every session of a user except the one to keep
+// Returns the number of sessions removed
+func (r *UserRepository) DeleteOtherUserSessions(userID, keepSessionID string) (int, error) {
+	if strings.TrimSpace(userID) == "" {
+		return 0, errors.New("user ID is required")
 	}
 
-	// Delete all matching sessions
-	if len(sessionsToDelete) > 0 {
-		err = r.client.Del(ctx, sessionsToDelete...).Err()
-		if err != nil {
-			return fmt.Errorf("failed to delete user sessions: %w", err)
+	ctx := context.Background()
+	userSessionsKey := userSessionsKey(userID)
+
+	sessionIDs, err := r.client.ZRange(ctx, userSessionsKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	members := make([]interface{}, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		if sessionID == keepSessionID {
+			continue
 		}
+		keys = append(keys, redis.GenerateKey(redis.SessionKeyPrefix, sessionID))
+		members = append(members, sessionID)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	pipe := r.client.TxPipeline()
+	deleted := pipe.Del(ctx, keys...)
+	pipe.ZRem(ctx, userSessionsKey, members...)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	return int(deleted.Val()), nil
+}
+
+// passwordResetKey returns the key of a password reset token, stored by hash
+func passwordResetKey(tokenHash string) string {
+	return redis.GenerateKey("password_reset", tokenHash)
+}
+
+// userPasswordResetKey returns the key pointing at the user's outstanding password reset token
+func userPasswordResetKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":password_reset"
+}
+
+// SavePasswordResetToken stores the hash of a password reset token for a user with the given lifetime
+// Replaces any token issued to the user before, so only the latest emailed link works
+func (r *UserRepository) SavePasswordResetToken(userID, tokenHash string, ttl time.Duration) error {
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(tokenHash) == "" {
+		return errors.New("user ID and token hash are required")
+	}
+
+	ctx := context.Background()
+	pointerKey := userPasswordResetKey(userID)
+
+	previous, err := r.client.Get(ctx, pointerKey).Result()
+	if err != nil && err != redislib.Nil {
+		return fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, passwordResetKey(previous))
+	}
+	pipe.Set(ctx, passwordResetKey(tokenHash), userID, ttl)
+	pipe.Set(ctx, pointerKey, tokenHash, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumePasswordResetToken redeems a password reset token and returns the user it was issued to
+// The token is deleted atomically, so it can be used only once; returns domain.ErrInvalidResetToken if it is unknown or expired
+func (r *UserRepository) ConsumePasswordResetToken(tokenHash string) (string, error) {
+	if strings.TrimSpace(tokenHash) == "" {
+		return "", domain.ErrInvalidResetToken
+	}
+
+	ctx := context.Background()
+	userID, err := r.client.GetDel(ctx, passwordResetKey(tokenHash)).Result()
+	if err == redislib.Nil {
+		return "", domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	r.client.Del(ctx, userPasswordResetKey(userID))
+
+	return userID, nil
+}
+
+// userLoginKey returns the key of the hash holding the user's last successful and failed logins
+func userLoginKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":login"
+}
+
+// userLoginFailuresKey returns the key counting the user's consecutive failed logins
+func userLoginFailuresKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":login_failures"
+}
+
+// userLockKey returns the key that exists while the user's account is locked
+func userLockKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":locked_until"
+}
+
+// userSecurityEventsKey returns the key of the user's security events, newest first
+func userSecurityEventsKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":security_events"
+}
+
+// loginAttemptFields returns the login hash fields recording an attempt under the given prefix
+func loginAttemptFields(prefix string, attempt domain.LoginAttempt) map[string]interface{} {
+	return map[string]interface{}{
+		prefix + "_at":         attempt.At.Unix(),
+		prefix + "_ip":         attempt.IP,
+		prefix + "_user_agent": attempt.UserAgent,
+	}
+}
+
+// parseLoginAttempt reads an attempt recorded with loginAttemptFields, or returns nil if there is none
+func parseLoginAttempt(fields map[string]string, prefix string) *domain.LoginAttempt {
+	at, err := strconv.ParseInt(fields[prefix+"_at"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &domain.LoginAttempt{
+		At:        time.Unix(at, 0),
+		IP:        fields[prefix+"_ip"],
+		UserAgent: fields[prefix+"_user_agent"],
+	}
+}
+
+// RecordLoginFailure records a failed login for a user and returns the number of consecutive failures
+// The count is forgotten 24 hours after the latest failure
+func (r *UserRepository) RecordLoginFailure(userID string, attempt domain.LoginAttempt) (int, error) {
+	if strings.TrimSpace(userID) == "" {
+		return 0, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	failuresKey := userLoginFailuresKey(userID)
+
+	pipe := r.client.TxPipeline()
+	failures := pipe.Incr(ctx, failuresKey)
+	pipe.Expire(ctx, failuresKey, loginFailureTTL)
+	pipe.HSet(ctx, userLoginKey(userID), loginAttemptFields("last_failure", attempt))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return int(failures.Val()), nil
+}
+
+// RecordLoginSuccess records a successful login for a user and resets the consecutive failure count
+func (r *UserRepository) RecordLoginSuccess(userID string, attempt domain.LoginAttempt) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, userLoginKey(userID), loginAttemptFields("last_success", attempt))
+	pipe.Del(ctx, userLoginFailuresKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record login success: %w", err)
+	}
+
+	return nil
+}
+
+// LockAccount locks a user's account until the given time
+// The lock expires on its own; locking an already locked account replaces the end time
+func (r *UserRepository) LockAccount(userID string, until time.Time) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return errors.New("lock must end in the future")
+	}
+
+	ctx := context.Background()
+	if err := r.client.Set(ctx, userLockKey(userID), until.Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccountLock returns when a user's account lock ends, or the zero time if it is not locked
+func (r *UserRepository) GetAccountLock(userID string) (time.Time, error) {
+	if strings.TrimSpace(userID) == "" {
+		return time.Time{}, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	until, err := r.client.Get(ctx, userLockKey(userID)).Int64()
+	if err == redislib.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get account lock: %w", err)
+	}
+
+	return time.Unix(until, 0), nil
+}
+
+// ClearLoginFailures unlocks a user's account and resets the consecutive failure count
+// The last failed login stays recorded
+func (r *UserRepository) ClearLoginFailures(userID string) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	if err := r.client.Del(ctx, userLoginFailuresKey(userID), userLockKey(userID)).Err(); err != nil {
+		return fmt.Errorf("failed to clear login failures: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetLoginActivity returns a user's last logins, consecutive failure count and lock
+// Security events are not included; they are listed with ListSecurityEvents
+func (r *UserRepository) GetLoginActivity(userID string) (*domain.LoginActivity, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	pipe := r.client.Pipeline()
+	login := pipe.HGetAll(ctx, userLoginKey(userID))
+	failures := pipe.Get(ctx, userLoginFailuresKey(userID))
+	lock := pipe.Get(ctx, userLockKey(userID))
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != redislib.Nil {
+		return nil, fmt.Errorf("failed to get login activity: %w", err)
+	}
+
+	// Missing counters mean no failures and no lock
+	activity := &domain.LoginActivity{
+		LastSuccess: parseLoginAttempt(login.Val(), "last_success"),
+		LastFailure: parseLoginAttempt(login.Val(), "last_failure"),
+	}
+	if count, err := failures.Int(); err == nil {
+		activity.FailedAttempts = count
+	}
+	if until, err := lock.Int64(); err == nil {
+		activity.LockedUntil = time.Unix(until, 0)
+	}
+
+	return activity, nil
+}
+
+// AddSecurityEvent records a security event for a user
+// Only the most recent 50 events are kept
+func (r *UserRepository) AddSecurityEvent(userID string, event *domain.SecurityEvent) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+	if event == nil {
+		return errors.New("event cannot be nil")
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal security event: %w", err)
+	}
+
+	ctx := context.Background()
+	key := userSecurityEventsKey(userID)
+
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, eventJSON)
+	pipe.LTrim(ctx, key, 0, maxSecurityEvents-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add security event: %w", err)
+	}
+
+	return nil
+}
+
+// ListSecurityEvents returns up to limit of a user's most recent security events, newest first
+func (r *UserRepository) ListSecurityEvents(userID string, limit int) ([]*domain.SecurityEvent, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if limit <= 0 {
+		return []*domain.SecurityEvent{}, nil
+	}
+
+	ctx := context.Background()
+	values, err := r.client.LRange(ctx, userSecurityEventsKey(userID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+
+	events := make([]*domain.SecurityEvent, 0, len(values))
+	for _, value := range values {
+		var event domain.SecurityEvent
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			continue // Skip corrupted entries
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// userTwoFactorKey returns the key of the hash holding the user's TOTP secret while two-factor authentication is on
+func userTwoFactorKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":two_factor"
+}
+
+// userTwoFactorSetupKey returns the key of the secret of an enrollment waiting to be confirmed
+func userTwoFactorSetupKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":two_factor_setup"
+}
+
+// userRecoveryCodesKey returns the key of the set of the user's unused recovery code hashes
+func userRecoveryCodesKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":recovery_codes"
+}
+
+// userTOTPStepKey returns the key of the last TOTP time step accepted for the user
+func userTOTPStepKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":totp_last_step"
+}
+
+// loginChallengeKey returns the key of a pending login challenge, stored by token hash
+func loginChallengeKey(tokenHash string) string {
+	return redis.GenerateKey("login_challenge", tokenHash)
+}
+
+// SaveTwoFactorSetup stores the secret of a new authenticator app enrollment with the given lifetime
+// Replaces any enrollment the user started before
+func (r *UserRepository) SaveTwoFactorSetup(userID, secret string, ttl time.Duration) error {
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(secret) == "" {
+		return errors.New("user ID and secret are required")
+	}
+
+	ctx := context.Background()
+	if err := r.client.Set(ctx, userTwoFactorSetupKey(userID), secret, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save two-factor setup: %w", err)
+	}
+
+	return nil
+}
+
+// GetTwoFactorSetup returns the secret of the user's pending enrollment, or "" if there is none
+func (r *UserRepository) GetTwoFactorSetup(userID string) (string, error) {
+	if strings.TrimSpace(userID) == "" {
+		return "", errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	secret, err := r.client.Get(ctx, userTwoFactorSetupKey(userID)).Result()
+	if err == redislib.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get two-factor setup: %w", err)
+	}
+
+	return secret, nil
+}
+
+// EnableTwoFactor turns on two-factor authentication with the given secret and recovery code hashes
+// The pending enrollment is removed and any earlier recovery codes are replaced
+func (r *UserRepository) EnableTwoFactor(userID, secret string, recoveryCodeHashes []string, enabledAt time.Time) error {
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(secret) == "" {
+		return errors.New("user ID and secret are required")
+	}
+
+	ctx := context.Background()
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, userTwoFactorKey(userID), map[string]interface{}{
+		"secret":     secret,
+		"enabled_at": enabledAt.Unix(),
+	})
+	pipe.Del(ctx, userTwoFactorSetupKey(userID), userRecoveryCodesKey(userID))
+	if len(recoveryCodeHashes) > 0 {
+		pipe.SAdd(ctx, userRecoveryCodesKey(userID), stringsToInterfaces(recoveryCodeHashes)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// GetTwoFactor returns the user's two-factor authentication settings, or nil if it is turned off
+func (r *UserRepository) GetTwoFactor(userID string) (*domain.TwoFactor, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	pipe := r.client.Pipeline()
+	settings := pipe.HGetAll(ctx, userTwoFactorKey(userID))
+	codes := pipe.SCard(ctx, userRecoveryCodesKey(userID))
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != redislib.Nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	fields := settings.Val()
+	if fields["secret"] == "" {
+		return nil, nil
+	}
+
+	twoFactor := &domain.TwoFactor{
+		Secret:                 fields["secret"],
+		RecoveryCodesRemaining: int(codes.Val()),
+	}
+	if enabledAt, err := strconv.ParseInt(fields["enabled_at"], 10, 64); err == nil {
+		twoFactor.EnabledAt = time.Unix(enabledAt, 0)
+	}
+
+	return twoFactor, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication and removes the secret and recovery codes
+func (r *UserRepository) DisableTwoFactor(userID string) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	err := r.client.Del(ctx, userTwoFactorKey(userID), userTwoFactorSetupKey(userID), userRecoveryCodesKey(userID), userTOTPStepKey(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces all of the user's recovery codes with the given hashes
+func (r *UserRepository) ReplaceRecoveryCodes(userID string, recoveryCodeHashes []string) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	key := userRecoveryCodesKey(userID)
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(recoveryCodeHashes) > 0 {
+		pipe.SAdd(ctx, key, stringsToInterfaces(recoveryCodeHashes)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode redeems one of the user's recovery codes by hash
+// The code is removed atomically, so it works only once; returns false if it is unknown or already used
+func (r *UserRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(codeHash) == "" {
+		return false, errors.New("user ID and code hash are required")
+	}
+
+	ctx := context.Background()
+	removed, err := r.client.SRem(ctx, userRecoveryCodesKey(userID), codeHash).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return removed == 1, nil
+}
+
+// UseTOTPStep records that a TOTP code from the given time step was accepted for the user
+// Returns false if a code from this or a later step was accepted before, so a code cannot be replayed
+func (r *UserRepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	if strings.TrimSpace(userID) == "" {
+		return false, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	accepted, err := useTOTPStepScript.Run(ctx, r.client.Client, []string{userTOTPStepKey(userID)}, step, totpStepTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+
+	return accepted == 1, nil
+}
+
+// SaveLoginChallenge stores a login waiting for its second factor under the hash of its token
+func (r *UserRepository) SaveLoginChallenge(tokenHash string, challenge *domain.LoginChallenge, ttl time.Duration) error {
+	if strings.TrimSpace(tokenHash) == "" {
+		return errors.New("token hash is required")
+	}
+	if challenge == nil || strings.TrimSpace(challenge.UserID) == "" {
+		return errors.New("challenge with a user ID is required")
+	}
+
+	ctx := context.Background()
+	key := loginChallengeKey(tokenHash)
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_id":     challenge.UserID,
+		"remember_me": strconv.FormatBool(challenge.RememberMe),
+		"attempts":    challenge.Attempts,
+	})
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save login challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetLoginChallenge returns the login challenge stored under a token hash
+// Returns domain.ErrInvalidLoginChallenge if it is unknown or expired
+func (r *UserRepository) GetLoginChallenge(tokenHash string) (*domain.LoginChallenge, error) {
+	if strings.TrimSpace(tokenHash) == "" {
+		return nil, domain.ErrInvalidLoginChallenge
+	}
+
+	ctx := context.Background()
+	fields, err := r.client.HGetAll(ctx, loginChallengeKey(tokenHash)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	if fields["user_id"] == "" {
+		return nil, domain.ErrInvalidLoginChallenge
+	}
+
+	rememberMe, _ := strconv.ParseBool(fields["remember_me"])
+	attempts, _ := strconv.Atoi(fields["attempts"])
+
+	return &domain.LoginChallenge{
+		UserID:     fields["user_id"],
+		RememberMe: rememberMe,
+		Attempts:   attempts,
+	}, nil
+}
+
+// RecordLoginChallengeFailure counts a wrong code against a login challenge and returns the number of wrong codes so far
+// Returns domain.ErrInvalidLoginChallenge if the challenge has expired
+func (r *UserRepository) RecordLoginChallengeFailure(tokenHash string) (int, error) {
+	if strings.TrimSpace(tokenHash) == "" {
+		return 0, domain.ErrInvalidLoginChallenge
+	}
+
+	ctx := context.Background()
+	attempts, err := recordChallengeFailureScript.Run(ctx, r.client.Client, []string{loginChallengeKey(tokenHash)}).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login challenge failure: %w", err)
+	}
+	if attempts == 0 {
+		return 0, domain.ErrInvalidLoginChallenge
+	}
+
+	return attempts, nil
+}
+
+// DeleteLoginChallenge removes a login challenge once it is completed or abandoned
+func (r *UserRepository) DeleteLoginChallenge(tokenHash string) error {
+	if strings.TrimSpace(tokenHash) == "" {
+		return errors.New("token hash is required")
+	}
+
+	ctx := context.Background()
+	if err := r.client.Del(ctx, loginChallengeKey(tokenHash)).Err(); err != nil {
+		return fmt.Errorf("failed to delete login challenge: %w", err)
+	}
+
+	return nil
+}
+
+// storedAPIToken is the JSON layout of an API token key
+type storedAPIToken struct {
+	ID         string   `json:"id"`
+	UserID     string   `json:"user_id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	LastUsedAt int64    `json:"last_used_at,omitempty"`
+}
+
+// toDomain converts the stored token into a domain API token
+func (t *storedAPIToken) toDomain(tokenHash string) *domain.APIToken {
+	token := &domain.APIToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		TokenHash: tokenHash,
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		CreatedAt: time.Unix(t.CreatedAt, 0),
+		ExpiresAt: time.Unix(t.ExpiresAt, 0),
+	}
+	if t.LastUsedAt != 0 {
+		lastUsed := time.Unix(t.LastUsedAt, 0)
+		token.LastUsedAt = &lastUsed
+	}
+	return token
+}
+
+// apiTokenKey returns the key of an API token, stored by hash
+func apiTokenKey(tokenHash string) string {
+	return redis.GenerateKey("api_token", tokenHash)
+}
+
+// userAPITokensKey returns the key of a user's API token index, holding token hashes scored by expiry
+func userAPITokensKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":api_tokens"
+}
+
+// CreateAPIToken stores a new API token under its hash until it expires
+// Indexes the token under its user so it can be listed and revoked
+func (r *UserRepository) CreateAPIToken(token *domain.APIToken) error {
+	if token == nil || strings.TrimSpace(token.UserID) == "" || strings.TrimSpace(token.TokenHash) == "" {
+		return errors.New("API token with a user ID and token hash is required")
+	}
+
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("API token has already expired")
+	}
+
+	tokenJSON, err := json.Marshal(storedAPIToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.Unix(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal API token: %w", err)
+	}
+
+	ctx := context.Background()
+	userTokensKey := userAPITokensKey(token.UserID)
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, apiTokenKey(token.TokenHash), tokenJSON, ttl)
+	pipe.ZAdd(ctx, userTokensKey, redislib.Z{
+		Score:  float64(token.ExpiresAt.Unix()),
+		Member: token.TokenHash,
+	})
+	// Drop tokens that have already expired from the index
+	pipe.ZRemRangeByScore(ctx, userTokensKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIToken retrieves the API token stored under a token hash
+// Returns domain.ErrAPITokenNotFound if it is unknown, revoked or expired
+func (r *UserRepository) GetAPIToken(tokenHash string) (*domain.APIToken, error) {
+	if strings.TrimSpace(tokenHash) == "" {
+		return nil, domain.ErrAPITokenNotFound
+	}
+
+	ctx := context.Background()
+	data, err := r.client.Get(ctx, apiTokenKey(tokenHash)).Result()
+	if err != nil {
+		if err == redislib.Nil {
+			return nil, domain.ErrAPITokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	var token storedAPIToken
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API token: %w", err)
+	}
+
+	return token.toDomain(tokenHash), nil
+}
+
+// ListUserAPITokens retrieves every live API token of a user, newest first
+// Prunes index entries of tokens that have expired
+func (r *UserRepository) ListUserAPITokens(userID string) ([]*domain.APIToken, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	userTokensKey := userAPITokensKey(userID)
+
+	// Drop expired tokens from the index before reading it
+	r.client.ZRemRangeByScore(ctx, userTokensKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+
+	tokenHashes, err := r.client.ZRange(ctx, userTokensKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user API tokens: %w", err)
+	}
+	if len(tokenHashes) == 0 {
+		return []*domain.APIToken{}, nil
+	}
+
+	// Fetch every token in one round trip
+	pipe := r.client.Pipeline()
+	dataCmds := make([]*redislib.StringCmd, len(tokenHashes))
+	for i, tokenHash := range tokenHashes {
+		dataCmds[i] = pipe.Get(ctx, apiTokenKey(tokenHash))
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redislib.Nil {
+		return nil, fmt.Errorf("failed to get user API tokens: %w", err)
+	}
+
+	tokens := make([]*domain.APIToken, 0, len(tokenHashes))
+	for i, tokenHash := range tokenHashes {
+		var token storedAPIToken
+		if dataCmds[i].Err() != nil || json.Unmarshal([]byte(dataCmds[i].Val()), &token) != nil {
+			continue // Skip tokens revoked since the index was read
+		}
+		tokens = append(tokens, token.toDomain(tokenHash))
+	}
+
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+
+	return tokens, nil
+}
+
+// TouchAPIToken records that an API token was just used
+// Writes are skipped while the token was used within the last minute
+func (r *UserRepository) TouchAPIToken(tokenHash string, at time.Time) error {
+	if strings.TrimSpace(tokenHash) == "" {
+		return errors.New("token hash is required")
+	}
+
+	ctx := context.Background()
+	key := apiTokenKey(tokenHash)
+
+	data, err := r.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redislib.Nil {
+			return domain.ErrAPITokenNotFound
+		}
+		return fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	var token storedAPIToken
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return fmt.Errorf("failed to unmarshal API token: %w", err)
+	}
+
+	if at.Sub(time.Unix(token.LastUsedAt, 0)) < sessionTouchInterval {
+		return nil
+	}
+	token.LastUsedAt = at.Unix()
+
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API token: %w", err)
+	}
+
+	// XX keeps a token revoked in the meantime from being recreated
+	err = r.client.SetArgs(ctx, key, tokenJSON, redislib.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err != nil && err != redislib.Nil {
+		return fmt.Errorf("failed to update API token: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAPIToken revokes one of a user's API tokens by ID
+// Returns domain.ErrAPITokenNotFound if the user has no live token with that ID
+func (r *UserRepository) DeleteAPIToken(userID, tokenID string) error {
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(tokenID) == "" {
+		return errors.New("user ID and token ID are required")
+	}
+
+	tokens, err := r.ListUserAPITokens(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.ID != tokenID {
+			continue
+		}
+
+		ctx := context.Background()
+		pipe := r.client.TxPipeline()
+		pipe.Del(ctx, apiTokenKey(token.TokenHash))
+		pipe.ZRem(ctx, userAPITokensKey(userID), token.TokenHash)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete API token: %w", err)
+		}
+		return nil
+	}
+
+	return domain.ErrAPITokenNotFound
+}
+
+// DeleteAllUserAPITokens revokes every API token of a user
+// Returns the number of tokens revoked
+func (r *UserRepository) DeleteAllUserAPITokens(userID string) (int, error) {
+	if strings.TrimSpace(userID) == "" {
+		return 0, errors.New("user ID is required")
+	}
+
+	ctx := context.Background()
+	userTokensKey := userAPITokensKey(userID)
+
+	tokenHashes, err := r.client.ZRange(ctx, userTokensKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user API tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(tokenHashes)+1)
+	for _, tokenHash := range tokenHashes {
+		keys = append(keys, apiTokenKey(tokenHash))
+	}
+
+	pipe := r.client.TxPipeline()
+	var deleted *redislib.IntCmd
+	if len(keys) > 0 {
+		deleted = pipe.Del(ctx, keys...)
+	}
+	pipe.Del(ctx, userTokensKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete user API tokens: %w", err)
+	}
+
+	if deleted == nil {
+		return 0, nil
+	}
+	return int(deleted.Val()), nil
+}
+
+// oidcStateKey returns the key of an identity provider sign-in in progress, stored by state hash
+func oidcStateKey(stateHash string) string {
+	return redis.GenerateKey("oidc_state", stateHash)
+}
+
+// oidcIdentityKey returns the key holding the ID of the user linked to an identity provider account
+func oidcIdentityKey(issuer, subject string) string {
+	return redis.GenerateKey("oidc_identity", issuer+":"+subject)
+}
+
+// userOIDCIdentitiesKey returns the key of the hash of a user's linked identity provider accounts, subject by issuer
+func userOIDCIdentitiesKey(userID string) string {
+	return redis.GenerateKey(redis.UserKeyPrefix, userID) + ":oidc_identities"
+}
+
+// SaveOIDCLoginState stores an identity provider sign-in under the hash of its state with the given lifetime
+func (r *UserRepository) SaveOIDCLoginState(stateHash string, state *domain.OIDCLoginState, ttl time.Duration) error {
+	if strings.TrimSpace(stateHash) == "" || state == nil {
+		return errors.New("state hash and sign-in state are required")
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sign-in state: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := r.client.Set(ctx, oidcStateKey(stateHash), stateJSON, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save sign-in state: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeOIDCLoginState returns and deletes the identity provider sign-in stored under a state hash, so each state is used once
+// Returns domain.ErrInvalidOIDCState if it is unknown or expired
+func (r *UserRepository) ConsumeOIDCLoginState(stateHash string) (*domain.OIDCLoginState, error) {
+	if strings.TrimSpace(stateHash) == "" {
+		return nil, domain.ErrInvalidOIDCState
+	}
+
+	ctx := context.Background()
+	stateJSON, err := r.client.GetDel(ctx, oidcStateKey(stateHash)).Result()
+	if err == redislib.Nil {
+		return nil, domain.ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume sign-in state: %w", err)
+	}
+
+	var state domain.OIDCLoginState
+	if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+		return nil, domain.ErrInvalidOIDCState
+	}
+
+	return &state, nil
+}
+
+// GetOIDCIdentity returns the ID of the user linked to an identity provider account
+// Returns an empty ID if the account is not linked
+func (r *UserRepository) GetOIDCIdentity(issuer, subject string) (string, error) {
+	if strings.TrimSpace(issuer) == "" || strings.TrimSpace(subject) == "" {
+		return "", errors.New("issuer and subject are required")
+	}
+
+	ctx := context.Background()
+	userID, err := r.client.Get(ctx, oidcIdentityKey(issuer, subject)).Result()
+	if err == redislib.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get identity provider account: %w", err)
+	}
+
+	return userID, nil
+}
+
+// LinkOIDCIdentity links an identity provider account to a user
+// Replaces any other account of the same provider linked to the user before
+func (r *UserRepository) LinkOIDCIdentity(issuer, subject, userID string) error {
+	if strings.TrimSpace(issuer) == "" || strings.TrimSpace(subject) == "" || strings.TrimSpace(userID) == "" {
+		return errors.New("issuer, subject and user ID are required")
+	}
+
+	ctx := context.Background()
+	identitiesKey := userOIDCIdentitiesKey(userID)
+
+	previous, err := r.client.HGet(ctx, identitiesKey, issuer).Result()
+	if err != nil && err != redislib.Nil {
+		return fmt.Errorf("failed to get linked identity provider account: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	if previous != "" && previous != subject {
+		pipe.Del(ctx, oidcIdentityKey(issuer, previous))
+	}
+	pipe.Set(ctx, oidcIdentityKey(issuer, subject), userID, 0)
+	pipe.HSet(ctx, identitiesKey, issuer, subject)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to link identity provider account: %w", err)
+	}
+
+	return nil
+}
+
+// stringsToInterfaces converts strings into the variadic arguments of Redis commands
+func stringsToInterfaces(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// BackfillIndexes adds users and sessions stored before the users and sessions indexes existed
+// Scans user and session keys incrementally; returns the number of users and sessions indexed
+// Once both scans complete a marker key is set, so later startups skip them entirely
+func (r *UserRepository) BackfillIndexes() (int, error) {
+	ctx := context.Background()
+	indexed := 0
+
+	done, err := r.client.Exists(ctx, migrationKey(userIndexesMigration)).Result()
+	if err != nil {
+		return indexed, fmt.Errorf("failed to check index backfill: %w", err)
+	}
+	if done > 0 {
+		return indexed, nil
+	}
+
+	// Index every user record (user:{id}); email lookups and per-user keys have more segments
+	iter := r.client.Scan(ctx, 0, redis.GenerateKey(redis.UserKeyPrefix, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		userID := strings.TrimPrefix(key, redis.UserKeyPrefix+":")
+		if strings.Contains(userID, ":") {
+			continue
+		}
+
+		user, err := r.GetByID(userID)
+		if err != nil {
+			continue // Skip keys that are not user records
+		}
+
+		added, err := r.client.ZAddNX(ctx, usersIndexKey, redislib.Z{
+			Score:  float64(user.CreatedAt.Unix()),
+			Member: user.ID,
+		}).Result()
+		if err != nil {
+			return indexed, fmt.Errorf("failed to index user: %w", err)
+		}
+		indexed += int(added)
+	}
+	if err := iter.Err(); err != nil {
+		return indexed, fmt.Errorf("failed to scan users: %w", err)
+	}
+
+	// Index every live session under its user, scored by expiry
+	iter = r.client.Scan(ctx, 0, redis.GenerateKey(redis.SessionKeyPrefix, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		sessionID := strings.TrimPrefix(key, redis.SessionKeyPrefix+":")
+
+		userID, err := r.GetSessionUserID(sessionID)
+		if err != nil {
+			continue // Skip sessions that expired or are invalid
+		}
+
+		ttl, err := r.client.TTL(ctx, key).Result()
+		if err != nil || ttl <= 0 {
+			continue
+		}
+
+		added, err := r.client.ZAddNX(ctx, userSessionsKey(userID), redislib.Z{
+			Score:  float64(time.Now().Add(ttl).Unix()),
+			Member: sessionID,
+		}).Result()
+		if err != nil {
+			return indexed, fmt.Errorf("failed to index session: %w", err)
+		}
+		indexed += int(added)
+	}
+	if err := iter.Err(); err != nil {
+		return indexed, fmt.Errorf("failed to scan sessions: %w", err)
+	}
+
+	if err := r.client.Set(ctx, migrationKey(userIndexesMigration), time.Now().Unix(), 0).Err(); err != nil {
+		return indexed, fmt.Errorf("failed to record index backfill: %w", err)
+	}
+
+	return indexed, nil
+}
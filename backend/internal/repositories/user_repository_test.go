@@ -12,6 +12,7 @@ import (
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
+	redislib "github.com/redis/go-redis/v9"
 )
 
 // setupTestRedis creates a miniredis instance for testing
@@ -533,7 +534,7 @@ func TestUserRepository_Sessions(t *testing.T) {
 				return user, sessionID
 			},
 			testFunc: func(repo *UserRepository, userID, sessionID string) error {
-				return repo.CreateSession(userID, sessionID)
+				return repo.CreateSession(userID, sessionID, true, domain.SessionClient{})
 			},
 			description: "should create session successfully",
 		},
@@ -546,7 +547,7 @@ func TestUserRepository_Sessions(t *testing.T) {
 					t.Fatalf("Failed to create test user: %v", err)
 				}
 				sessionID := uuid.New().String()
-				err = repo.CreateSession(user.ID, sessionID)
+				err = repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 				if err != nil {
 					t.Fatalf("Failed to create session: %v", err)
 				}
@@ -573,7 +574,7 @@ func TestUserRepository_Sessions(t *testing.T) {
 					t.Fatalf("Failed to create test user: %v", err)
 				}
 				sessionID := uuid.New().String()
-				err = repo.CreateSession(user.ID, sessionID)
+				err = repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 				if err != nil {
 					t.Fatalf("Failed to create session: %v", err)
 				}
@@ -631,7 +632,7 @@ func TestUserRepository_SessionTTL(t *testing.T) {
 
 	// Create a session
 	sessionID := uuid.New().String()
-	err = repo.CreateSession(user.ID, sessionID)
+	err = repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 	if err != nil {
 		t.Fatalf("Failed to create session: %v", err)
 	}
@@ -679,7 +680,7 @@ func TestUserRepository_ErrorCases(t *testing.T) {
 			testFunc: func(repo *UserRepository) error {
 				sessionID := uuid.New().String()
 				userID := uuid.New().String()
-				return repo.CreateSession(userID, sessionID)
+				return repo.CreateSession(userID, sessionID, true, domain.SessionClient{})
 			},
 			wantErr: false, // Should still create session even if user doesn't exist
 		},
@@ -758,7 +759,7 @@ func BenchmarkUserRepository_ValidateSession(b *testing.B) {
 	user := createTestUser()
 	repo.Create(user)
 	sessionID := uuid.New().String()
-	repo.CreateSession(user.ID, sessionID)
+	repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -949,7 +950,7 @@ func TestUserRepository_GetSessionUserID(t *testing.T) {
 				user := createTestUser()
 				repo.Create(user)
 				sessionID := uuid.New().String()
-				repo.CreateSession(user.ID, sessionID)
+				repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 				return user.ID, sessionID
 			},
 			wantErr: false,
@@ -1019,7 +1020,7 @@ func TestUserRepository_DeleteAllUserSessions(t *testing.T) {
 				// Create multiple sessions for the user
 				for i := 0; i < 3; i++ {
 					sessionID := uuid.New().String()
-					repo.CreateSession(user.ID, sessionID)
+					repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
 				}
 
 				return user.ID
@@ -1104,4 +1105,1136 @@ func TestUserRepository_NilUserHandling(t *testing.T) {
 	if err == nil {
 		t.Errorf("Expected error when updating nil user")
 	}
-}
\ No newline at end of file
+}
+func TestUserRepository_Indexes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("list users in creation order from the users index", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		ids := make([]string, 3)
+		for i := range ids {
+			user := createTestUser()
+			user.Email = "user" + strconv.Itoa(i) + "@example.com"
+			user.CreatedAt = time.Now().Add(time.Duration(i) * time.Minute)
+			if err := repo.Create(user); err != nil {
+				t.Fatalf("Failed to create user: %v", err)
+			}
+			ids[i] = user.ID
+		}
+
+		users, err := repo.List(2, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(users) != 2 || users[0].ID != ids[1] || users[1].ID != ids[2] {
+			t.Errorf("Expected users %v in creation order", ids[1:])
+		}
+
+		if err := repo.Delete(ids[0]); err != nil {
+			t.Fatalf("Failed to delete user: %v", err)
+		}
+		if client.ZScore(ctx, usersIndexKey, ids[0]).Err() == nil {
+			t.Errorf("Expected deleted user to be removed from the users index")
+		}
+	})
+
+	t.Run("track sessions in the per-user sessions index", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		sessionsKey := redis.GenerateKey(redis.UserKeyPrefix, user.ID) + ":sessions"
+
+		first, second := uuid.New().String(), uuid.New().String()
+		repo.CreateSession(user.ID, first, true, domain.SessionClient{})
+		repo.CreateSession(user.ID, second, true, domain.SessionClient{})
+		if count := client.ZCard(ctx, sessionsKey).Val(); count != 2 {
+			t.Errorf("Expected 2 indexed sessions but got %d", count)
+		}
+
+		if err := repo.DeleteSession(first); err != nil {
+			t.Fatalf("Failed to delete session: %v", err)
+		}
+		if members := client.ZRange(ctx, sessionsKey, 0, -1).Val(); len(members) != 1 || members[0] != second {
+			t.Errorf("Expected only session %s to remain indexed but got %v", second, members)
+		}
+
+		if err := repo.DeleteAllUserSessions(user.ID); err != nil {
+			t.Fatalf("Failed to delete sessions: %v", err)
+		}
+		if client.Exists(ctx, sessionsKey, redis.GenerateKey(redis.SessionKeyPrefix, second)).Val() != 0 {
+			t.Errorf("Expected sessions and their index to be deleted")
+		}
+	})
+
+	t.Run("backfill indexes for data written before they existed", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		sessionID := uuid.New().String()
+		repo.CreateSession(user.ID, sessionID, true, domain.SessionClient{})
+
+		// Simulate data written by an older version without indexes
+		sessionsKey := redis.GenerateKey(redis.UserKeyPrefix, user.ID) + ":sessions"
+		client.Del(ctx, usersIndexKey, sessionsKey)
+
+		indexed, err := repo.BackfillIndexes()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if indexed != 2 {
+			t.Errorf("Expected 2 index entries but got %d", indexed)
+		}
+		if client.ZScore(ctx, usersIndexKey, user.ID).Err() != nil {
+			t.Errorf("Expected user to be backfilled into the users index")
+		}
+		if client.ZScore(ctx, sessionsKey, sessionID).Err() != nil {
+			t.Errorf("Expected session to be backfilled into the sessions index")
+		}
+
+		// Running the backfill again skips the scans once the marker is recorded
+		client.Del(ctx, usersIndexKey)
+		if indexed, _ := repo.BackfillIndexes(); indexed != 0 {
+			t.Errorf("Expected repeated backfill to add nothing but got %d", indexed)
+		}
+		if client.Exists(ctx, usersIndexKey).Val() != 0 {
+			t.Errorf("Expected repeated backfill to skip the scan")
+		}
+	})
+}
+
+func TestUserRepository_SessionMetadata(t *testing.T) {
+	ctx := context.Background()
+	desktop := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+	phone := domain.SessionClient{IP: "198.51.100.7", UserAgent: "Safari"}
+
+	t.Run("store the client a session was opened from", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		sessionID := uuid.New().String()
+		if err := repo.CreateSession(user.ID, sessionID, true, desktop); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		session, err := repo.GetSession(sessionID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if session.UserID != user.ID || session.IP != desktop.IP || session.UserAgent != desktop.UserAgent {
+			t.Errorf("Unexpected session metadata: %+v", session)
+		}
+		if session.LastSeenAt.Before(session.CreatedAt) {
+			t.Errorf("Expected last seen time to start at creation")
+		}
+		if time.Until(session.ExpiresAt) < 6*24*time.Hour {
+			t.Errorf("Expected session to expire in 7 days but got %v", session.ExpiresAt)
+		}
+
+		if _, err := repo.GetSession(uuid.New().String()); err != domain.ErrSessionNotFound {
+			t.Errorf("Expected ErrSessionNotFound but got %v", err)
+		}
+	})
+
+	t.Run("touch records new activity", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		sessionID := uuid.New().String()
+		repo.CreateSession(user.ID, sessionID, true, desktop)
+
+		// Backdate the last seen time past the touch interval
+		sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
+		stale, _ := json.Marshal(storedSession{
+			UserID:     user.ID,
+			CreatedAt:  time.Now().Add(-time.Hour).Unix(),
+			LastSeenAt: time.Now().Add(-time.Hour).Unix(),
+			IP:         desktop.IP,
+			UserAgent:  desktop.UserAgent,
+		})
+		client.SetArgs(ctx, sessionKey, stale, redislib.SetArgs{KeepTTL: true})
+
+		if _, err := repo.TouchSession(sessionID, phone); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		session, _ := repo.GetSession(sessionID)
+		if time.Since(session.LastSeenAt) > time.Minute || session.IP != phone.IP || session.UserAgent != phone.UserAgent {
+			t.Errorf("Expected touch to record the new activity but got %+v", session)
+		}
+		if client.TTL(ctx, sessionKey).Val() <= 0 {
+			t.Errorf("Expected touch to keep the session TTL")
+		}
+	})
+
+	t.Run("touch does not recreate a revoked session", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		if _, err := repo.TouchSession(uuid.New().String(), desktop); err != domain.ErrSessionNotFound {
+			t.Errorf("Expected ErrSessionNotFound but got %v", err)
+		}
+		if keys := client.DBSize(ctx).Val(); keys != 0 {
+			t.Errorf("Expected no keys to be written but found %d", keys)
+		}
+	})
+
+	t.Run("list sessions skips revoked ones", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		first, second, revoked := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		repo.CreateSession(user.ID, first, true, desktop)
+		repo.CreateSession(user.ID, second, true, phone)
+		repo.CreateSession(user.ID, revoked, true, phone)
+
+		// A session key that vanished without its index entry is skipped
+		client.Del(ctx, redis.GenerateKey(redis.SessionKeyPrefix, revoked))
+
+		sessions, err := repo.ListUserSessions(user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("Expected 2 sessions but got %d", len(sessions))
+		}
+		for _, session := range sessions {
+			if session.ID == revoked {
+				t.Errorf("Expected revoked session to be skipped")
+			}
+		}
+
+		empty, err := repo.ListUserSessions(uuid.New().String())
+		if err != nil || len(empty) != 0 {
+			t.Errorf("Expected no sessions for an unknown user but got %v, %v", empty, err)
+		}
+	})
+
+	t.Run("delete other sessions keeps the current one", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		repo.Create(user)
+		current := uuid.New().String()
+		repo.CreateSession(user.ID, current, true, desktop)
+		for i := 0; i < 2; i++ {
+			repo.CreateSession(user.ID, uuid.New().String(), true, phone)
+		}
+
+		deleted, err := repo.DeleteOtherUserSessions(user.ID, current)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("Expected 2 sessions to be deleted but got %d", deleted)
+		}
+
+		sessions, _ := repo.ListUserSessions(user.ID)
+		if len(sessions) != 1 || sessions[0].ID != current {
+			t.Errorf("Expected only the current session to remain but got %v", sessions)
+		}
+
+		deleted, err = repo.DeleteOtherUserSessions(user.ID, current)
+		if err != nil || deleted != 0 {
+			t.Errorf("Expected nothing left to delete but got %d, %v", deleted, err)
+		}
+	})
+}
+
+func TestUserRepository_SessionLifetimes(t *testing.T) {
+	ctx := context.Background()
+	settings := SessionSettings{RememberTTL: 30 * 24 * time.Hour, ShortTTL: 2 * time.Hour}
+
+	// backdate makes a session look idle with only a few minutes left to live
+	backdate := func(client *redis.Client, sessionID string) {
+		sessionKey := redis.GenerateKey(redis.SessionKeyPrefix, sessionID)
+		var session storedSession
+		json.Unmarshal([]byte(client.Get(ctx, sessionKey).Val()), &session)
+		session.LastSeenAt = time.Now().Add(-time.Hour).Unix()
+		data, _ := json.Marshal(session)
+		client.Set(ctx, sessionKey, data, 5*time.Minute)
+	}
+
+	t.Run("remember me selects the long lifetime", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepositoryWithSessions(client, settings)
+
+		remembered, short := uuid.New().String(), uuid.New().String()
+		repo.CreateSession("user-1", remembered, true, domain.SessionClient{})
+		repo.CreateSession("user-1", short, false, domain.SessionClient{})
+
+		if ttl := client.TTL(ctx, redis.GenerateKey(redis.SessionKeyPrefix, remembered)).Val(); ttl != settings.RememberTTL {
+			t.Errorf("Expected remembered session TTL %v but got %v", settings.RememberTTL, ttl)
+		}
+		if ttl := client.TTL(ctx, redis.GenerateKey(redis.SessionKeyPrefix, short)).Val(); ttl != settings.ShortTTL {
+			t.Errorf("Expected short session TTL %v but got %v", settings.ShortTTL, ttl)
+		}
+
+		session, _ := repo.GetSession(remembered)
+		if !session.RememberMe {
+			t.Errorf("Expected session to be marked as remembered")
+		}
+	})
+
+	t.Run("sliding sessions are renewed on activity", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		sliding := settings
+		sliding.Sliding = true
+		repo := NewUserRepositoryWithSessions(client, sliding)
+
+		sessionID := uuid.New().String()
+		repo.CreateSession("user-1", sessionID, false, domain.SessionClient{})
+		backdate(client, sessionID)
+
+		expiresAt, err := repo.TouchSession(sessionID, domain.SessionClient{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !expiresAt.IsZero() {
+			t.Errorf("Expected no cookie renewal for a session that ends with the browser but got %v", expiresAt)
+		}
+
+		if ttl := client.TTL(ctx, redis.GenerateKey(redis.SessionKeyPrefix, sessionID)).Val(); ttl != settings.ShortTTL {
+			t.Errorf("Expected TTL to be renewed to %v but got %v", settings.ShortTTL, ttl)
+		}
+		expiry := client.ZScore(ctx, userSessionsKey("user-1"), sessionID).Val()
+		if time.Until(time.Unix(int64(expiry), 0)) < settings.ShortTTL-time.Minute {
+			t.Errorf("Expected the sessions index to follow the renewed expiry")
+		}
+	})
+
+	t.Run("renewed remembered sessions report the new expiry for their cookie", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		sliding := settings
+		sliding.Sliding = true
+		repo := NewUserRepositoryWithSessions(client, sliding)
+
+		sessionID := uuid.New().String()
+		repo.CreateSession("user-1", sessionID, true, domain.SessionClient{})
+		backdate(client, sessionID)
+
+		expiresAt, err := repo.TouchSession(sessionID, domain.SessionClient{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if remaining := time.Until(expiresAt); remaining < settings.RememberTTL-time.Minute || remaining > settings.RememberTTL {
+			t.Errorf("Expected the cookie to be renewed for %v but got %v", settings.RememberTTL, remaining)
+		}
+
+		// Within the touch interval nothing is written, so the cookie is left alone
+		expiresAt, err = repo.TouchSession(sessionID, domain.SessionClient{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !expiresAt.IsZero() {
+			t.Errorf("Expected no cookie renewal right after a renewal but got %v", expiresAt)
+		}
+	})
+
+	t.Run("fixed sessions keep their expiry on activity", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepositoryWithSessions(client, settings)
+
+		sessionID := uuid.New().String()
+		repo.CreateSession("user-1", sessionID, false, domain.SessionClient{})
+		backdate(client, sessionID)
+
+		if _, err := repo.TouchSession(sessionID, domain.SessionClient{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if ttl := client.TTL(ctx, redis.GenerateKey(redis.SessionKeyPrefix, sessionID)).Val(); ttl > 5*time.Minute {
+			t.Errorf("Expected TTL to stay at most 5m but got %v", ttl)
+		}
+	})
+}
+
+func TestUserRepository_PasswordResetTokens(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token can be consumed once", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		if err := repo.SavePasswordResetToken("user-1", "hash-1", time.Hour); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ttl := client.TTL(ctx, passwordResetKey("hash-1")).Val(); ttl != time.Hour {
+			t.Errorf("Expected token TTL %v but got %v", time.Hour, ttl)
+		}
+
+		userID, err := repo.ConsumePasswordResetToken("hash-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if userID != "user-1" {
+			t.Errorf("Expected user-1 but got %s", userID)
+		}
+		if client.Exists(ctx, userPasswordResetKey("user-1")).Val() != 0 {
+			t.Errorf("Expected the user's reset pointer to be removed")
+		}
+
+		if _, err := repo.ConsumePasswordResetToken("hash-1"); err != domain.ErrInvalidResetToken {
+			t.Errorf("Expected ErrInvalidResetToken on reuse but got %v", err)
+		}
+	})
+
+	t.Run("new token invalidates the previous one", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		repo.SavePasswordResetToken("user-1", "old", time.Hour)
+		repo.SavePasswordResetToken("user-1", "new", time.Hour)
+
+		if _, err := repo.ConsumePasswordResetToken("old"); err != domain.ErrInvalidResetToken {
+			t.Errorf("Expected old token to be invalid but got %v", err)
+		}
+		if userID, err := repo.ConsumePasswordResetToken("new"); err != nil || userID != "user-1" {
+			t.Errorf("Expected new token to be valid, got %q, %v", userID, err)
+		}
+	})
+
+	t.Run("unknown and empty tokens are invalid", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		if _, err := repo.ConsumePasswordResetToken("missing"); err != domain.ErrInvalidResetToken {
+			t.Errorf("Expected ErrInvalidResetToken but got %v", err)
+		}
+		if _, err := repo.ConsumePasswordResetToken(""); err != domain.ErrInvalidResetToken {
+			t.Errorf("Expected ErrInvalidResetToken but got %v", err)
+		}
+		if err := repo.SavePasswordResetToken("", "hash", time.Hour); err == nil {
+			t.Errorf("Expected error for empty user ID")
+		}
+	})
+
+	t.Run("deleting the user removes the token", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		repo.SavePasswordResetToken(user.ID, "hash-1", time.Hour)
+
+		if err := repo.Delete(user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if client.Exists(ctx, passwordResetKey("hash-1"), userPasswordResetKey(user.ID)).Val() != 0 {
+			t.Errorf("Expected reset token keys to be deleted with the user")
+		}
+	})
+}
+
+func TestUserRepository_EmailVerified(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	repo := NewUserRepository(client)
+
+	user := createTestUser()
+	if err := repo.Create(user); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	stored, _ := repo.GetByID(user.ID)
+	if stored.EmailVerified {
+		t.Errorf("Expected new user to be unverified")
+	}
+
+	stored.EmailVerified = true
+	if err := repo.Update(stored); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stored, _ = repo.GetByEmail(user.Email)
+	if !stored.EmailVerified {
+		t.Errorf("Expected verified flag to be persisted")
+	}
+}
+
+func TestUserRepository_LoginTracking(t *testing.T) {
+	ctx := context.Background()
+	attempt := domain.LoginAttempt{At: time.Unix(1700000000, 0), IP: "192.0.2.1", UserAgent: "Firefox"}
+
+	t.Run("failures are counted until a successful login", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		for i := 1; i <= 3; i++ {
+			failures, err := repo.RecordLoginFailure("user-1", attempt)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if failures != i {
+				t.Errorf("Expected %d failures but got %d", i, failures)
+			}
+		}
+		if ttl := client.TTL(ctx, userLoginFailuresKey("user-1")).Val(); ttl != loginFailureTTL {
+			t.Errorf("Expected failure count TTL %v but got %v", loginFailureTTL, ttl)
+		}
+
+		activity, err := repo.GetLoginActivity("user-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if activity.FailedAttempts != 3 || activity.LastSuccess != nil {
+			t.Errorf("Expected 3 failures and no success but got %+v", activity)
+		}
+		if activity.LastFailure == nil || *activity.LastFailure != attempt {
+			t.Errorf("Expected last failure %+v but got %+v", attempt, activity.LastFailure)
+		}
+
+		success := domain.LoginAttempt{At: attempt.At.Add(time.Minute), IP: "192.0.2.2", UserAgent: "Safari"}
+		if err := repo.RecordLoginSuccess("user-1", success); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		activity, _ = repo.GetLoginActivity("user-1")
+		if activity.FailedAttempts != 0 {
+			t.Errorf("Expected failures to be reset but got %d", activity.FailedAttempts)
+		}
+		if activity.LastSuccess == nil || *activity.LastSuccess != success {
+			t.Errorf("Expected last success %+v but got %+v", success, activity.LastSuccess)
+		}
+		if activity.LastFailure == nil {
+			t.Errorf("Expected the last failure to stay recorded")
+		}
+	})
+
+	t.Run("locks expire on their own", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		if until, err := repo.GetAccountLock("user-1"); err != nil || !until.IsZero() {
+			t.Errorf("Expected no lock, got %v, %v", until, err)
+		}
+
+		until := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+		if err := repo.LockAccount("user-1", until); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got, err := repo.GetAccountLock("user-1"); err != nil || !got.Equal(until) {
+			t.Errorf("Expected lock until %v, got %v, %v", until, got, err)
+		}
+		if activity, _ := repo.GetLoginActivity("user-1"); !activity.LockedUntil.Equal(until) {
+			t.Errorf("Expected activity to report lock until %v but got %v", until, activity.LockedUntil)
+		}
+		if ttl := client.TTL(ctx, userLockKey("user-1")).Val(); ttl <= 0 || ttl > 5*time.Minute {
+			t.Errorf("Expected lock TTL of up to 5 minutes but got %v", ttl)
+		}
+
+		if err := repo.LockAccount("user-1", time.Now().Add(-time.Second)); err == nil {
+			t.Errorf("Expected error for a lock ending in the past")
+		}
+	})
+
+	t.Run("clearing failures unlocks the account", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		repo.RecordLoginFailure("user-1", attempt)
+		repo.LockAccount("user-1", time.Now().Add(time.Hour))
+
+		if err := repo.ClearLoginFailures("user-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		activity, _ := repo.GetLoginActivity("user-1")
+		if activity.FailedAttempts != 0 || !activity.LockedUntil.IsZero() {
+			t.Errorf("Expected no failures and no lock but got %+v", activity)
+		}
+		if activity.LastFailure == nil {
+			t.Errorf("Expected the last failure to stay recorded")
+		}
+	})
+
+	t.Run("empty user IDs are rejected", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		if _, err := repo.RecordLoginFailure("", attempt); err == nil {
+			t.Errorf("Expected error for empty user ID")
+		}
+		if err := repo.RecordLoginSuccess("", attempt); err == nil {
+			t.Errorf("Expected error for empty user ID")
+		}
+		if _, err := repo.GetLoginActivity(""); err == nil {
+			t.Errorf("Expected error for empty user ID")
+		}
+	})
+}
+
+func TestUserRepository_SecurityEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("events are listed newest first and capped", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		start := time.Unix(1700000000, 0)
+		for i := 0; i < maxSecurityEvents+5; i++ {
+			event := &domain.SecurityEvent{Type: domain.SecurityEventLoginFailed, At: start.Add(time.Duration(i) * time.Second), IP: "192.0.2.1"}
+			if err := repo.AddSecurityEvent("user-1", event); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		if count := client.LLen(ctx, userSecurityEventsKey("user-1")).Val(); count != maxSecurityEvents {
+			t.Errorf("Expected %d stored events but got %d", maxSecurityEvents, count)
+		}
+
+		events, err := repo.ListSecurityEvents("user-1", 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 events but got %d", len(events))
+		}
+		newest := start.Add(time.Duration(maxSecurityEvents+4) * time.Second)
+		if !events[0].At.Equal(newest) || events[0].Type != domain.SecurityEventLoginFailed || events[0].IP != "192.0.2.1" {
+			t.Errorf("Expected the newest event first but got %+v", events[0])
+		}
+	})
+
+	t.Run("users without events get an empty list", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		events, err := repo.ListSecurityEvents("user-1", 10)
+		if err != nil || len(events) != 0 {
+			t.Errorf("Expected no events, got %v, %v", events, err)
+		}
+	})
+
+	t.Run("deleting the user removes login tracking and events", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		repo.RecordLoginFailure(user.ID, domain.LoginAttempt{At: time.Now()})
+		repo.LockAccount(user.ID, time.Now().Add(time.Hour))
+		repo.AddSecurityEvent(user.ID, &domain.SecurityEvent{Type: domain.SecurityEventAccountLocked, At: time.Now()})
+
+		if err := repo.Delete(user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := client.Exists(ctx, userLoginKey(user.ID), userLoginFailuresKey(user.ID), userLockKey(user.ID), userSecurityEventsKey(user.ID)).Val(); n != 0 {
+			t.Errorf("Expected login tracking keys to be deleted with the user, %d remain", n)
+		}
+	})
+}
+
+func TestUserRepository_TwoFactor(t *testing.T) {
+	ctx := context.Background()
+	enabledAt := time.Unix(1700000000, 0)
+
+	t.Run("setup is kept until two-factor authentication is enabled", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		secret, err := repo.GetTwoFactorSetup("user-1")
+		if err != nil || secret != "" {
+			t.Fatalf("Expected no setup but got %q, %v", secret, err)
+		}
+
+		if err := repo.SaveTwoFactorSetup("user-1", "SECRET1", 10*time.Minute); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ttl := client.TTL(ctx, userTwoFactorSetupKey("user-1")).Val(); ttl != 10*time.Minute {
+			t.Errorf("Expected setup TTL 10m but got %v", ttl)
+		}
+		if secret, _ := repo.GetTwoFactorSetup("user-1"); secret != "SECRET1" {
+			t.Errorf("Expected setup secret SECRET1 but got %q", secret)
+		}
+
+		if err := repo.EnableTwoFactor("user-1", "SECRET1", []string{"hash-a", "hash-b"}, enabledAt); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if secret, _ := repo.GetTwoFactorSetup("user-1"); secret != "" {
+			t.Errorf("Expected setup to be removed but got %q", secret)
+		}
+
+		twoFactor, err := repo.GetTwoFactor("user-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if twoFactor == nil || twoFactor.Secret != "SECRET1" || !twoFactor.EnabledAt.Equal(enabledAt) || twoFactor.RecoveryCodesRemaining != 2 {
+			t.Errorf("Unexpected two-factor settings: %+v", twoFactor)
+		}
+	})
+
+	t.Run("users without two-factor authentication get nil", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		twoFactor, err := repo.GetTwoFactor("user-1")
+		if err != nil || twoFactor != nil {
+			t.Errorf("Expected nil settings but got %+v, %v", twoFactor, err)
+		}
+	})
+
+	t.Run("recovery codes work once and can be replaced", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.EnableTwoFactor("user-1", "SECRET1", []string{"hash-a", "hash-b"}, enabledAt)
+
+		used, err := repo.UseRecoveryCode("user-1", "hash-a")
+		if err != nil || !used {
+			t.Fatalf("Expected recovery code to be accepted but got %v, %v", used, err)
+		}
+		if used, _ := repo.UseRecoveryCode("user-1", "hash-a"); used {
+			t.Error("Expected a used recovery code to be rejected")
+		}
+		if used, _ := repo.UseRecoveryCode("user-1", "hash-x"); used {
+			t.Error("Expected an unknown recovery code to be rejected")
+		}
+
+		if err := repo.ReplaceRecoveryCodes("user-1", []string{"hash-c", "hash-d", "hash-e"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if used, _ := repo.UseRecoveryCode("user-1", "hash-b"); used {
+			t.Error("Expected a replaced recovery code to be rejected")
+		}
+		twoFactor, _ := repo.GetTwoFactor("user-1")
+		if twoFactor.RecoveryCodesRemaining != 3 {
+			t.Errorf("Expected 3 recovery codes but got %d", twoFactor.RecoveryCodesRemaining)
+		}
+	})
+
+	t.Run("TOTP steps are accepted only once and in order", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		for _, tc := range []struct {
+			step     int64
+			accepted bool
+		}{
+			{100, true},
+			{100, false},
+			{99, false},
+			{101, true},
+		} {
+			accepted, err := repo.UseTOTPStep("user-1", tc.step)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if accepted != tc.accepted {
+				t.Errorf("Step %d: expected accepted %v but got %v", tc.step, tc.accepted, accepted)
+			}
+		}
+
+		if ttl := client.TTL(ctx, userTOTPStepKey("user-1")).Val(); ttl != totpStepTTL {
+			t.Errorf("Expected step TTL %v but got %v", totpStepTTL, ttl)
+		}
+		if accepted, _ := repo.UseTOTPStep("user-2", 99); !accepted {
+			t.Error("Expected steps to be tracked per user")
+		}
+	})
+
+	t.Run("disabling removes the secret, codes and used steps", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.EnableTwoFactor("user-1", "SECRET1", []string{"hash-a"}, enabledAt)
+		repo.UseTOTPStep("user-1", 100)
+
+		if err := repo.DisableTwoFactor("user-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if twoFactor, _ := repo.GetTwoFactor("user-1"); twoFactor != nil {
+			t.Errorf("Expected two-factor authentication to be off but got %+v", twoFactor)
+		}
+		for _, key := range []string{userTwoFactorKey("user-1"), userRecoveryCodesKey("user-1"), userTOTPStepKey("user-1")} {
+			if client.Exists(ctx, key).Val() != 0 {
+				t.Errorf("Expected %s to be deleted", key)
+			}
+		}
+	})
+
+	t.Run("deleting the user removes two-factor data", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		repo.SaveTwoFactorSetup(user.ID, "SECRET2", time.Minute)
+		repo.EnableTwoFactor(user.ID, "SECRET1", []string{"hash-a"}, enabledAt)
+
+		if err := repo.Delete(user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, key := range []string{userTwoFactorKey(user.ID), userTwoFactorSetupKey(user.ID), userRecoveryCodesKey(user.ID)} {
+			if client.Exists(ctx, key).Val() != 0 {
+				t.Errorf("Expected %s to be deleted", key)
+			}
+		}
+	})
+}
+
+func TestUserRepository_LoginChallenges(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("challenges are stored by token hash with a lifetime", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		challenge := &domain.LoginChallenge{UserID: "user-1", RememberMe: true}
+		if err := repo.SaveLoginChallenge("hash-1", challenge, 5*time.Minute); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ttl := client.TTL(ctx, loginChallengeKey("hash-1")).Val(); ttl != 5*time.Minute {
+			t.Errorf("Expected challenge TTL 5m but got %v", ttl)
+		}
+
+		stored, err := repo.GetLoginChallenge("hash-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if *stored != *challenge {
+			t.Errorf("Expected %+v but got %+v", challenge, stored)
+		}
+
+		if _, err := repo.GetLoginChallenge("hash-2"); err != domain.ErrInvalidLoginChallenge {
+			t.Errorf("Expected ErrInvalidLoginChallenge but got %v", err)
+		}
+	})
+
+	t.Run("wrong codes are counted", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.SaveLoginChallenge("hash-1", &domain.LoginChallenge{UserID: "user-1"}, 5*time.Minute)
+
+		for i := 1; i <= 2; i++ {
+			attempts, err := repo.RecordLoginChallengeFailure("hash-1")
+			if err != nil || attempts != i {
+				t.Errorf("Expected %d attempts but got %d, %v", i, attempts, err)
+			}
+		}
+		stored, _ := repo.GetLoginChallenge("hash-1")
+		if stored.Attempts != 2 {
+			t.Errorf("Expected 2 attempts but got %d", stored.Attempts)
+		}
+	})
+
+	t.Run("expired or deleted challenges are not revived", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.SaveLoginChallenge("hash-1", &domain.LoginChallenge{UserID: "user-1"}, 5*time.Minute)
+
+		if err := repo.DeleteLoginChallenge("hash-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := repo.RecordLoginChallengeFailure("hash-1"); err != domain.ErrInvalidLoginChallenge {
+			t.Errorf("Expected ErrInvalidLoginChallenge but got %v", err)
+		}
+		if client.Exists(ctx, loginChallengeKey("hash-1")).Val() != 0 {
+			t.Error("Expected a failure on a deleted challenge not to recreate it")
+		}
+	})
+}
+
+func TestUserRepository_APITokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	newToken := func(userID, id, hash string, createdAt time.Time) *domain.APIToken {
+		return &domain.APIToken{
+			ID:        id,
+			UserID:    userID,
+			TokenHash: hash,
+			Name:      "token " + id,
+			Scopes:    []string{domain.ScopeTasksRead},
+			CreatedAt: createdAt,
+			ExpiresAt: now.Add(24 * time.Hour),
+		}
+	}
+
+	t.Run("tokens are stored by hash until they expire", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		token := newToken("user-1", "token-1", "hash-1", now)
+		if err := repo.CreateAPIToken(token); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ttl := client.TTL(ctx, apiTokenKey("hash-1")).Val(); ttl <= 23*time.Hour || ttl > 24*time.Hour {
+			t.Errorf("Expected a TTL of about 24h but got %v", ttl)
+		}
+
+		stored, err := repo.GetAPIToken("hash-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stored.ID != "token-1" || stored.UserID != "user-1" || stored.TokenHash != "hash-1" || stored.Name != "token token-1" {
+			t.Errorf("Unexpected token %+v", stored)
+		}
+		if !stored.CreatedAt.Equal(now) || !stored.ExpiresAt.Equal(token.ExpiresAt) || stored.LastUsedAt != nil {
+			t.Errorf("Unexpected token times %+v", stored)
+		}
+		if !stored.HasScope(domain.ScopeTasksRead) || stored.HasScope(domain.ScopeTasksWrite) {
+			t.Errorf("Unexpected scopes %v", stored.Scopes)
+		}
+
+		if _, err := repo.GetAPIToken("hash-2"); err != domain.ErrAPITokenNotFound {
+			t.Errorf("Expected ErrAPITokenNotFound but got %v", err)
+		}
+
+		expired := newToken("user-1", "token-2", "hash-2", now)
+		expired.ExpiresAt = now.Add(-time.Second)
+		if err := repo.CreateAPIToken(expired); err == nil {
+			t.Error("Expected an error for an expired token")
+		}
+	})
+
+	t.Run("listing returns live tokens newest first", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		repo.CreateAPIToken(newToken("user-1", "token-1", "hash-1", now.Add(-time.Hour)))
+		repo.CreateAPIToken(newToken("user-1", "token-2", "hash-2", now))
+		repo.CreateAPIToken(newToken("user-2", "token-3", "hash-3", now))
+		client.Del(ctx, apiTokenKey("hash-1"))
+
+		tokens, err := repo.ListUserAPITokens("user-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(tokens) != 1 || tokens[0].ID != "token-2" {
+			t.Fatalf("Expected only token-2 but got %+v", tokens)
+		}
+
+		repo.CreateAPIToken(newToken("user-1", "token-4", "hash-4", now.Add(time.Minute)))
+		tokens, _ = repo.ListUserAPITokens("user-1")
+		if len(tokens) != 2 || tokens[0].ID != "token-4" || tokens[1].ID != "token-2" {
+			t.Errorf("Expected token-4 then token-2 but got %+v", tokens)
+		}
+
+		tokens, _ = repo.ListUserAPITokens("user-3")
+		if tokens == nil || len(tokens) != 0 {
+			t.Errorf("Expected an empty list but got %+v", tokens)
+		}
+	})
+
+	t.Run("use is recorded at most once a minute", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.CreateAPIToken(newToken("user-1", "token-1", "hash-1", now))
+
+		if err := repo.TouchAPIToken("hash-1", now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		repo.TouchAPIToken("hash-1", now.Add(30*time.Second))
+		stored, _ := repo.GetAPIToken("hash-1")
+		if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(now) {
+			t.Errorf("Expected last use at %v but got %v", now, stored.LastUsedAt)
+		}
+
+		repo.TouchAPIToken("hash-1", now.Add(2*time.Minute))
+		stored, _ = repo.GetAPIToken("hash-1")
+		if !stored.LastUsedAt.Equal(now.Add(2 * time.Minute)) {
+			t.Errorf("Expected last use at %v but got %v", now.Add(2*time.Minute), stored.LastUsedAt)
+		}
+		if ttl := client.TTL(ctx, apiTokenKey("hash-1")).Val(); ttl <= 0 {
+			t.Errorf("Expected the TTL to be kept but got %v", ttl)
+		}
+
+		if err := repo.TouchAPIToken("hash-2", now); err != domain.ErrAPITokenNotFound {
+			t.Errorf("Expected ErrAPITokenNotFound but got %v", err)
+		}
+		if client.Exists(ctx, apiTokenKey("hash-2")).Val() != 0 {
+			t.Error("Expected touching an unknown token not to create it")
+		}
+	})
+
+	t.Run("tokens are revoked by ID for their owner only", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+		repo.CreateAPIToken(newToken("user-1", "token-1", "hash-1", now))
+		repo.CreateAPIToken(newToken("user-1", "token-2", "hash-2", now))
+
+		if err := repo.DeleteAPIToken("user-2", "token-1"); err != domain.ErrAPITokenNotFound {
+			t.Errorf("Expected ErrAPITokenNotFound but got %v", err)
+		}
+		if err := repo.DeleteAPIToken("user-1", "token-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := repo.GetAPIToken("hash-1"); err != domain.ErrAPITokenNotFound {
+			t.Errorf("Expected the token to be gone but got %v", err)
+		}
+		if client.ZScore(ctx, userAPITokensKey("user-1"), "hash-1").Err() == nil {
+			t.Error("Expected the token to be removed from the index")
+		}
+		if err := repo.DeleteAPIToken("user-1", "token-1"); err != domain.ErrAPITokenNotFound {
+			t.Errorf("Expected ErrAPITokenNotFound but got %v", err)
+		}
+
+		revoked, err := repo.DeleteAllUserAPITokens("user-1")
+		if err != nil || revoked != 1 {
+			t.Errorf("Expected 1 token revoked but got %d, %v", revoked, err)
+		}
+		if client.Exists(ctx, apiTokenKey("hash-2"), userAPITokensKey("user-1")).Val() != 0 {
+			t.Error("Expected every token and the index to be deleted")
+		}
+
+		revoked, err = repo.DeleteAllUserAPITokens("user-1")
+		if err != nil || revoked != 0 {
+			t.Errorf("Expected no tokens revoked but got %d, %v", revoked, err)
+		}
+	})
+
+	t.Run("deleting the user removes their tokens", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		repo.CreateAPIToken(newToken(user.ID, "token-1", "hash-1", now))
+
+		if err := repo.Delete(user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if client.Exists(ctx, apiTokenKey("hash-1"), userAPITokensKey(user.ID)).Val() != 0 {
+			t.Error("Expected the user's tokens to be deleted")
+		}
+	})
+}
+
+func TestUserRepository_OIDC(t *testing.T) {
+	ctx := context.Background()
+	issuer := "https://idp.example.com"
+
+	t.Run("sign-in state is used once", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		state := &domain.OIDCLoginState{Nonce: "nonce", CodeVerifier: "verifier", RememberMe: true}
+		if err := repo.SaveOIDCLoginState("state-hash", state, 10*time.Minute); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ttl := client.TTL(ctx, oidcStateKey("state-hash")).Val(); ttl <= 0 || ttl > 10*time.Minute {
+			t.Errorf("Expected a TTL of up to 10m but got %v", ttl)
+		}
+
+		stored, err := repo.ConsumeOIDCLoginState("state-hash")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if *stored != *state {
+			t.Errorf("Expected %+v but got %+v", state, stored)
+		}
+
+		if _, err := repo.ConsumeOIDCLoginState("state-hash"); err != domain.ErrInvalidOIDCState {
+			t.Errorf("Expected ErrInvalidOIDCState but got %v", err)
+		}
+		if _, err := repo.ConsumeOIDCLoginState("unknown"); err != domain.ErrInvalidOIDCState {
+			t.Errorf("Expected ErrInvalidOIDCState but got %v", err)
+		}
+	})
+
+	t.Run("identities link to users", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		userID, err := repo.GetOIDCIdentity(issuer, "subject-1")
+		if err != nil || userID != "" {
+			t.Errorf("Expected no linked user but got %q, %v", userID, err)
+		}
+
+		if err := repo.LinkOIDCIdentity(issuer, "subject-1", "user-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		userID, err = repo.GetOIDCIdentity(issuer, "subject-1")
+		if err != nil || userID != "user-1" {
+			t.Errorf("Expected user-1 but got %q, %v", userID, err)
+		}
+
+		// Linking another account of the same provider replaces the first
+		if err := repo.LinkOIDCIdentity(issuer, "subject-2", "user-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if userID, _ := repo.GetOIDCIdentity(issuer, "subject-1"); userID != "" {
+			t.Errorf("Expected the old account to be unlinked but it links %q", userID)
+		}
+		if userID, _ := repo.GetOIDCIdentity(issuer, "subject-2"); userID != "user-1" {
+			t.Errorf("Expected user-1 but got %q", userID)
+		}
+	})
+
+	t.Run("deleting the user unlinks their identities", func(t *testing.T) {
+		client, cleanup := setupTestRedis(t)
+		defer cleanup()
+		repo := NewUserRepository(client)
+
+		user := createTestUser()
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		repo.LinkOIDCIdentity(issuer, "subject-1", user.ID)
+
+		if err := repo.Delete(user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if client.Exists(ctx, oidcIdentityKey(issuer, "subject-1"), userOIDCIdentitiesKey(user.ID)).Val() != 0 {
+			t.Error("Expected the user's identities to be unlinked")
+		}
+	})
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"backend/internal/domain"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,15 +14,22 @@ import (
 // TaskServiceInterface defines the interface for task business logic operations
 // Contains all business rules and validation logic for task operations
 type TaskServiceInterface interface {
-	CreateTask(userID, description, category string) (*domain.Task, error)
+	CreateTask(userID, description, category string, opts domain.TaskOptions) (*domain.Task, error)
 	GetTaskByID(id, userID string) (*domain.Task, error)
-	ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error)
+	ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error)
 	UpdateTaskCompletion(id, userID string, completed bool) (*domain.Task, error)
+	UpdateTask(id, userID string, updates domain.TaskUpdate) (*domain.Task, error)
+	GetTaskHistory(id, userID string) ([]*domain.Task, error)
+	ListSubtasks(parentID, userID string) ([]*domain.Task, error)
 	SoftDeleteTask(id, userID string) error
 	RestoreTask(id, userID string) (*domain.Task, error)
 	GetUserCategories(userID string) ([]string, error)
 	RenameCategory(userID, oldName, newName string) error
 	DeleteCategory(userID, categoryName string) error
+	GetUserTags(userID string) ([]domain.TagSummary, error)
+	RenameTag(userID, oldName, newName string) error
+	MergeTags(userID, sourceName, targetName string) error
+	SearchTasks(userID, query string, limit int) ([]*domain.Task, error)
 }
 
 // TaskService implements task business logic operations
@@ -34,14 +43,22 @@ type TaskService struct {
 type TaskRepository interface {
 	CreateTask(task *domain.Task) error
 	GetTaskByID(id string) (*domain.Task, error)
-	ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error)
+	ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error)
 	UpdateTaskCompletion(id string, completed bool) error
+	UpdateTask(task *domain.Task) error
+	GetSeriesTasks(userID, seriesID string) ([]*domain.Task, error)
+	CreateNextOccurrence(previousID string, next *domain.Task) (bool, error)
+	GetSubtasks(parentID string) ([]*domain.Task, error)
 	SoftDeleteTask(id string) error
 	RestoreTask(id string) error
 	GetUserCategories(userID string) ([]string, error)
 	RenameCategory(userID, oldName, newName string) error
 	DeleteCategory(userID, categoryName string) error
-	CleanupExpiredTasks() (int, error)
+	GetUserTags(userID string) ([]domain.TagSummary, error)
+	RenameTag(userID, oldName, newName string) error
+	MergeTags(userID, sourceName, targetName string) error
+	SearchTasks(userID, query string, limit int) ([]*domain.Task, error)
+	CleanupExpiredTasks(ctx context.Context) (*domain.CleanupReport, error)
 }
 
 // NewTaskService creates a new instance of TaskService
@@ -53,8 +70,8 @@ func NewTaskService(taskRepo TaskRepository) *TaskService {
 }
 
 // CreateTask creates a new task with validation and user context
-// Validates description length and format before creating the task
-func (s *TaskService) CreateTask(userID, description, category string) (*domain.Task, error) {
+// Validates description length and format before creating the task with its optional attributes
+func (s *TaskService) CreateTask(userID, description, category string, opts domain.TaskOptions) (*domain.Task, error) {
 	// Error code 3011: User ID required
 	if strings.TrimSpace(userID) == "" {
 		return nil, fmt.Errorf("3011: user ID is required")
@@ -70,18 +87,65 @@ func (s *TaskService) CreateTask(userID, description, category string) (*domain.
 		return nil, fmt.Errorf("3013: task description cannot exceed 10000 characters")
 	}
 
+	// Error code 3023: Invalid priority
+	priority := opts.Priority
+	if priority == "" {
+		priority = domain.PriorityNone
+	}
+	if !priority.IsValid() {
+		return nil, fmt.Errorf("3023: %w", domain.ErrInvalidPriority)
+	}
+
+	// Error code 3024: Invalid recurrence rule
+	var rule *domain.RecurrenceRule
+	if strings.TrimSpace(opts.Recurrence) != "" {
+		var err error
+		if rule, err = domain.ParseRecurrenceRule(opts.Recurrence); err != nil {
+			return nil, fmt.Errorf("3024: %w", err)
+		}
+	}
+
+	// Error code 3028: Invalid tags
+	tags, err := domain.NormalizeTags(opts.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("3028: %w", err)
+	}
+
+	// Error code 3026: Parent task must be an active top-level task owned by the user
+	parentID := strings.TrimSpace(opts.ParentID)
+	if parentID != "" {
+		parent, err := s.taskRepo.GetTaskByID(parentID)
+		if err != nil || parent.UserID != userID || parent.IsDeleted() {
+			return nil, fmt.Errorf("3026: %w", domain.ErrParentTaskNotFound)
+		}
+		if parent.IsSubtask() {
+			return nil, fmt.Errorf("3026: %w", domain.ErrNestedSubtask)
+		}
+	}
+
 	// Create new task
 	task := &domain.Task{
 		ID:          uuid.New().String(),
 		UserID:      userID,
 		Description: strings.TrimSpace(description),
 		Category:    strings.TrimSpace(category),
+		Tags:        tags,
 		Completed:   false,
+		Priority:    priority,
+		DueDate:     opts.DueDate,
+		ParentID:    parentID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		DeletedAt:   nil,
 	}
 
+	// Recurring tasks start a new series
+	if rule != nil {
+		task.Recurrence = anchoredRecurrence(rule, task)
+		task.SeriesID = task.ID
+		task.Occurrence = 1
+	}
+
 	// Validate task
 	if err := task.Validate(); err != nil {
 		return nil, fmt.Errorf("3014: %w", err)
@@ -111,23 +175,23 @@ func (s *TaskService) GetTaskByID(id, userID string) (*domain.Task, error) {
 	// Get task from repository
 	task, err := s.taskRepo.GetTaskByID(id)
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
-			return nil, fmt.Errorf("3017: task not found")
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			return nil, fmt.Errorf("3017: %w", domain.ErrTaskNotFound)
 		}
 		return nil, fmt.Errorf("3018: failed to get task: %w", err)
 	}
 
 	// Verify task ownership
 	if task.UserID != userID {
-		return nil, fmt.Errorf("3017: task not found") // Don't reveal that task exists but belongs to another user
+		return nil, fmt.Errorf("3017: %w", domain.ErrTaskNotFound) // Don't reveal that task exists but belongs to another user
 	}
 
 	return task, nil
 }
 
-// ListTasks retrieves tasks for a user with optional filtering
+// ListTasks retrieves a page of tasks for a user with optional filtering
 // Applies user context and validates filter parameters
-func (s *TaskService) ListTasks(userID string, filters domain.TaskFilters) ([]*domain.Task, error) {
+func (s *TaskService) ListTasks(userID string, filters domain.TaskFilters) (*domain.TaskPage, error) {
 	// Error code 3011: User ID required
 	if strings.TrimSpace(userID) == "" {
 		return nil, fmt.Errorf("3011: user ID is required")
@@ -139,16 +203,16 @@ func (s *TaskService) ListTasks(userID string, filters domain.TaskFilters) ([]*d
 	}
 
 	// Get tasks from repository
-	tasks, err := s.taskRepo.ListTasks(userID, filters)
+	page, err := s.taskRepo.ListTasks(userID, filters)
 	if err != nil {
 		return nil, fmt.Errorf("3018: failed to list tasks: %w", err)
 	}
 
-	return tasks, nil
+	return page, nil
 }
 
 // UpdateTaskCompletion updates the completion status of a task
-// Validates user ownership before allowing the update
+// Validates user ownership and schedules the next occurrence when a recurring task is completed
 func (s *TaskService) UpdateTaskCompletion(id, userID string, completed bool) (*domain.Task, error) {
 	// Error code 3011: User ID required
 	if strings.TrimSpace(userID) == "" {
@@ -161,7 +225,7 @@ func (s *TaskService) UpdateTaskCompletion(id, userID string, completed bool) (*
 	}
 
 	// Verify task exists and user owns it
-	_, err := s.GetTaskByID(id, userID)
+	task, err := s.GetTaskByID(id, userID)
 	if err != nil {
 		return nil, err // Error already has proper code from GetTaskByID
 	}
@@ -177,11 +241,173 @@ func (s *TaskService) UpdateTaskCompletion(id, userID string, completed bool) (*
 		return nil, fmt.Errorf("3018: failed to get updated task: %w", err)
 	}
 
+	// Generate the next occurrence of a recurring task
+	if completed && !task.Completed && updatedTask.IsRecurring() {
+		if err := s.scheduleNextOccurrence(updatedTask); err != nil {
+			return nil, err
+		}
+	}
+
 	return updatedTask, nil
 }
 
+// scheduleNextOccurrence creates the task following a completed occurrence in its series
+// Does nothing if the occurrence is deleted, already has a successor or the rule has ended
+func (s *TaskService) scheduleNextOccurrence(task *domain.Task) error {
+	// A deleted occurrence must not bring its series back
+	if task.IsDeleted() {
+		return nil
+	}
+
+	// Error code 3025: Next occurrence could not be scheduled
+	next, err := task.NextOccurrence(uuid.New().String(), time.Now())
+	if err != nil {
+		return fmt.Errorf("3025: %w", err)
+	}
+	if next == nil {
+		return nil
+	}
+
+	// The repository creates the successor at most once, even when the occurrence is completed again or twice at once
+	if _, err := s.taskRepo.CreateNextOccurrence(task.ID, next); err != nil {
+		return fmt.Errorf("3025: failed to create next occurrence: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTask applies a partial update to a task's description, category, tags, due date, priority and recurrence
+// Validates user ownership and the new values before persisting the changes
+func (s *TaskService) UpdateTask(id, userID string, updates domain.TaskUpdate) (*domain.Task, error) {
+	// Error code 3011: User ID required
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3011: user ID is required")
+	}
+
+	// Error code 3016: Task ID required
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("3016: task ID is required")
+	}
+
+	// Error code 3021: Nothing to update
+	if updates.IsEmpty() {
+		return nil, fmt.Errorf("3021: %w", domain.ErrEmptyTaskUpdate)
+	}
+
+	// Error code 3022: Conflicting due date changes
+	if updates.DueDate != nil && updates.ClearDueDate {
+		return nil, fmt.Errorf("3022: %w", domain.ErrConflictingDueDate)
+	}
+
+	// Error code 3023: Invalid priority
+	if updates.Priority != nil && !updates.Priority.IsValid() {
+		return nil, fmt.Errorf("3023: %w", domain.ErrInvalidPriority)
+	}
+
+	// Error code 3024: Invalid recurrence rule (empty clears the recurrence)
+	var rule *domain.RecurrenceRule
+	if updates.Recurrence != nil && strings.TrimSpace(*updates.Recurrence) != "" {
+		var err error
+		if rule, err = domain.ParseRecurrenceRule(*updates.Recurrence); err != nil {
+			return nil, fmt.Errorf("3024: %w", err)
+		}
+	}
+
+	// Error code 3028: Invalid tags (an empty list clears the tags)
+	if updates.Tags != nil {
+		tags, err := domain.NormalizeTags(*updates.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("3028: %w", err)
+		}
+		updates.Tags = &tags
+	}
+
+	if updates.Description != nil {
+		// Error code 3012: Task description validation
+		if strings.TrimSpace(*updates.Description) == "" {
+			return nil, fmt.Errorf("3012: task description cannot be empty")
+		}
+
+		// Error code 3013: Task description too long
+		if len(*updates.Description) > 10000 {
+			return nil, fmt.Errorf("3013: %w", domain.ErrTaskDescriptionTooLong)
+		}
+	}
+
+	// Verify task exists and user owns it
+	task, err := s.GetTaskByID(id, userID)
+	if err != nil {
+		return nil, err // Error already has proper code from GetTaskByID
+	}
+
+	// Deleted tasks must be restored before they can be edited
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("3017: %w", domain.ErrTaskNotFound)
+	}
+
+	task.ApplyUpdate(updates)
+
+	// A new recurrence rule starts a series at this task unless it already belongs to one
+	if rule != nil {
+		task.Recurrence = anchoredRecurrence(rule, task)
+		if task.SeriesID == "" {
+			task.SeriesID = task.ID
+			task.Occurrence = 1
+		}
+	}
+
+	// Validate task
+	if err := task.Validate(); err != nil {
+		return nil, fmt.Errorf("3014: %w", err)
+	}
+
+	// Save changes to repository
+	if err := s.taskRepo.UpdateTask(task); err != nil {
+		return nil, fmt.Errorf("3020: failed to update task: %w", err)
+	}
+
+	return task, nil
+}
+
+// GetTaskHistory retrieves every occurrence in a task's recurring series
+// Validates user ownership and returns only the task itself when it does not recur
+func (s *TaskService) GetTaskHistory(id, userID string) ([]*domain.Task, error) {
+	// Verify task exists and user owns it
+	task, err := s.GetTaskByID(id, userID)
+	if err != nil {
+		return nil, err // Error already has proper code from GetTaskByID
+	}
+
+	if task.SeriesID == "" {
+		return []*domain.Task{task}, nil
+	}
+
+	tasks, err := s.taskRepo.GetSeriesTasks(userID, task.SeriesID)
+	if err != nil {
+		return nil, fmt.Errorf("3018: failed to get task history: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// ListSubtasks retrieves the active subtasks of a parent task
+// Validates user ownership of the parent before listing its subtasks
+func (s *TaskService) ListSubtasks(parentID, userID string) ([]*domain.Task, error) {
+	// Verify parent exists and user owns it
+	if _, err := s.GetTaskByID(parentID, userID); err != nil {
+		return nil, err // Error already has proper code from GetTaskByID
+	}
+
+	subtasks, err := s.taskRepo.GetSubtasks(parentID)
+	if err != nil {
+		return nil, fmt.Errorf("3018: failed to get subtasks: %w", err)
+	}
+
+	return subtasks, nil
+}
+
 // SoftDeleteTask marks a task as deleted without removing it from storage
-// Validates user ownership before allowing the deletion
+// Validates user ownership before allowing the deletion; the repository cascades to subtasks
 func (s *TaskService) SoftDeleteTask(id, userID string) error {
 	// Error code 3011: User ID required
 	if strings.TrimSpace(userID) == "" {
@@ -208,7 +434,7 @@ func (s *TaskService) SoftDeleteTask(id, userID string) error {
 }
 
 // RestoreTask restores a soft-deleted task if within the 7-day window
-// Validates user ownership and deletion window before restoring
+// Validates user ownership and deletion window before restoring; subtasks need their parent restored first
 func (s *TaskService) RestoreTask(id, userID string) (*domain.Task, error) {
 	// Error code 3011: User ID required
 	if strings.TrimSpace(userID) == "" {
@@ -223,15 +449,15 @@ func (s *TaskService) RestoreTask(id, userID string) (*domain.Task, error) {
 	// Get task from repository (this includes deleted tasks)
 	task, err := s.taskRepo.GetTaskByID(id)
 	if err != nil {
-		if err == domain.ErrTaskNotFound {
-			return nil, fmt.Errorf("3017: task not found")
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			return nil, fmt.Errorf("3017: %w", domain.ErrTaskNotFound)
 		}
 		return nil, fmt.Errorf("3018: failed to get task: %w", err)
 	}
 
 	// Verify task ownership
 	if task.UserID != userID {
-		return nil, fmt.Errorf("3017: task not found") // Don't reveal that task exists but belongs to another user
+		return nil, fmt.Errorf("3017: %w", domain.ErrTaskNotFound) // Don't reveal that task exists but belongs to another user
 	}
 
 	// Check if task is actually deleted
@@ -245,6 +471,14 @@ func (s *TaskService) RestoreTask(id, userID string) (*domain.Task, error) {
 		return nil, fmt.Errorf("3017: task cannot be restored after 7 days")
 	}
 
+	// Error code 3027: Subtasks cannot be restored while their parent is deleted
+	if task.IsSubtask() {
+		parent, err := s.taskRepo.GetTaskByID(task.ParentID)
+		if err != nil || parent.IsDeleted() {
+			return nil, fmt.Errorf("3027: %w", domain.ErrParentTaskDeleted)
+		}
+	}
+
 	// Restore task in repository
 	if err := s.taskRepo.RestoreTask(id); err != nil {
 		return nil, fmt.Errorf("3020: failed to restore task: %w", err)
@@ -324,4 +558,118 @@ func (s *TaskService) DeleteCategory(userID, categoryName string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetUserTags retrieves all tags used by a user's tasks
+// Returns tag names with the number of active tasks using each tag
+func (s *TaskService) GetUserTags(userID string) ([]domain.TagSummary, error) {
+	// Error code 3011: User ID required
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3011: user ID is required")
+	}
+
+	// Get tags from repository
+	tags, err := s.taskRepo.GetUserTags(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3018: failed to get user tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// RenameTag renames a tag across all of a user's tasks
+// Validates tag names and ensures they are different
+func (s *TaskService) RenameTag(userID, oldName, newName string) error {
+	oldName, newName, err := normalizeTagPair(userID, oldName, newName)
+	if err != nil {
+		return err
+	}
+
+	// Rename tag in repository
+	if err := s.taskRepo.RenameTag(userID, oldName, newName); err != nil {
+		return fmt.Errorf("3020: failed to rename tag: %w", err)
+	}
+
+	return nil
+}
+
+// MergeTags folds a source tag into a target tag across all of a user's tasks
+// Validates tag names and ensures they are different
+func (s *TaskService) MergeTags(userID, sourceName, targetName string) error {
+	sourceName, targetName, err := normalizeTagPair(userID, sourceName, targetName)
+	if err != nil {
+		return err
+	}
+
+	// Merge tags in repository
+	if err := s.taskRepo.MergeTags(userID, sourceName, targetName); err != nil {
+		return fmt.Errorf("3020: failed to merge tags: %w", err)
+	}
+
+	return nil
+}
+
+// SearchTasks finds a user's active tasks matching a full-text query, most relevant first
+// A limit of 0 returns up to domain.DefaultSearchLimit tasks
+func (s *TaskService) SearchTasks(userID, query string, limit int) ([]*domain.Task, error) {
+	// Error code 3011: User ID required
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3011: user ID is required")
+	}
+
+	// Error code 3029: Search query and limit validation
+	if _, err := domain.ParseSearchQuery(query); err != nil {
+		return nil, fmt.Errorf("3029: %w", err)
+	}
+	if limit < 0 || limit > domain.MaxSearchLimit {
+		return nil, fmt.Errorf("3029: %w", domain.ErrInvalidSearchLimit)
+	}
+	if limit == 0 {
+		limit = domain.DefaultSearchLimit
+	}
+
+	// Search tasks in repository
+	tasks, err := s.taskRepo.SearchTasks(userID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("3018: failed to search tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// normalizeTagPair validates the user and the two tag names of a rename or merge
+// Returns the normalized names
+func normalizeTagPair(userID, from, to string) (string, string, error) {
+	// Error code 3011: User ID required
+	if strings.TrimSpace(userID) == "" {
+		return "", "", fmt.Errorf("3011: user ID is required")
+	}
+
+	// Error code 3028: Tag names validation
+	from, err := domain.NormalizeTag(from)
+	if err != nil {
+		return "", "", fmt.Errorf("3028: %w", err)
+	}
+	to, err = domain.NormalizeTag(to)
+	if err != nil {
+		return "", "", fmt.Errorf("3028: %w", err)
+	}
+
+	// Error code 3014: Same names validation
+	if from == to {
+		return "", "", fmt.Errorf("3014: new tag name must be different")
+	}
+
+	return from, to, nil
+}
+
+// anchoredRecurrence formats a recurrence rule pinned to the task's schedule
+// Uses the due date as the series start, falling back to the creation time
+func anchoredRecurrence(rule *domain.RecurrenceRule, task *domain.Task) string {
+	start := task.CreatedAt
+	if task.DueDate != nil {
+		start = *task.DueDate
+	}
+	rule.Anchor(start)
+	return rule.String()
+}
@@ -17,12 +17,14 @@ import (
 
 func TestTaskService_CreateTask(t *testing.T) {
 	userID := uuid.New().String()
+	dueDate := time.Now().Add(48 * time.Hour)
 
 	tests := []struct {
 		name          string
 		userID        string
 		description   string
 		category      string
+		opts          domain.TaskOptions
 		setupMock     func(*mocks.MockTaskRepository)
 		wantErr       bool
 		expectedError string
@@ -72,6 +74,78 @@ func TestTaskService_CreateTask(t *testing.T) {
 				assert.Equal(t, "Buy groceries", task.Description)
 				assert.Equal(t, "", task.Category)
 				assert.False(t, task.Completed)
+				assert.Equal(t, domain.PriorityNone, task.Priority)
+			},
+		},
+		{
+			name:        "successful recurring task creation",
+			userID:      userID,
+			description: "Pay rent",
+			category:    "Home",
+			opts:        domain.TaskOptions{DueDate: &dueDate, Recurrence: "monthly"},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("CreateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Recurrence != "" && task.SeriesID == task.ID && task.Occurrence == 1
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.Equal(t, fmt.Sprintf("FREQ=MONTHLY;BYMONTHDAY=%d", dueDate.Day()), task.Recurrence)
+				assert.Equal(t, task.ID, task.SeriesID)
+				assert.Equal(t, 1, task.Occurrence)
+			},
+		},
+		{
+			name:          "invalid recurrence",
+			userID:        userID,
+			description:   "Test task",
+			category:      "Test",
+			opts:          domain.TaskOptions{Recurrence: "every other blue moon"},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3024",
+		},
+		{
+			name:        "successful task creation with priority",
+			userID:      userID,
+			description: "Fix production outage",
+			category:    "Work",
+			opts:        domain.TaskOptions{Priority: domain.PriorityUrgent},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("CreateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Priority == domain.PriorityUrgent
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.Equal(t, domain.PriorityUrgent, task.Priority)
+			},
+		},
+		{
+			name:          "invalid priority",
+			userID:        userID,
+			description:   "Test task",
+			category:      "Test",
+			opts:          domain.TaskOptions{Priority: "critical"},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3023",
+		},
+		{
+			name:        "successful task creation with due date",
+			userID:      userID,
+			description: "File taxes",
+			category:    "Personal",
+			opts:        domain.TaskOptions{DueDate: &dueDate},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("CreateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.DueDate != nil && task.DueDate.Equal(dueDate)
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.NotNil(t, task.DueDate)
+				assert.True(t, task.DueDate.Equal(dueDate))
 			},
 		},
 		{
@@ -129,7 +203,7 @@ func TestTaskService_CreateTask(t *testing.T) {
 			tt.setupMock(mockRepo)
 
 			service := NewTaskService(mockRepo)
-			task, err := service.CreateTask(tt.userID, tt.description, tt.category)
+			task, err := service.CreateTask(tt.userID, tt.description, tt.category, tt.opts)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -303,7 +377,7 @@ func TestTaskService_ListTasks(t *testing.T) {
 			setupMock: func(mockRepo *mocks.MockTaskRepository) {
 				mockRepo.On("ListTasks", userID, mock.MatchedBy(func(filters domain.TaskFilters) bool {
 					return filters.Limit == 10 && filters.Offset == 0
-				})).Return(tasks, nil)
+				})).Return(&domain.TaskPage{Tasks: tasks, Total: 2}, nil)
 			},
 			wantErr: false,
 			validateTasks: func(t *testing.T, returnedTasks []*domain.Task) {
@@ -324,7 +398,7 @@ func TestTaskService_ListTasks(t *testing.T) {
 				workTasks := []*domain.Task{tasks[0]}
 				mockRepo.On("ListTasks", userID, mock.MatchedBy(func(filters domain.TaskFilters) bool {
 					return filters.Category == "Work"
-				})).Return(workTasks, nil)
+				})).Return(&domain.TaskPage{Tasks: workTasks, Total: 1}, nil)
 			},
 			wantErr: false,
 			validateTasks: func(t *testing.T, returnedTasks []*domain.Task) {
@@ -351,6 +425,17 @@ func TestTaskService_ListTasks(t *testing.T) {
 			wantErr:     true,
 			expectedErr: "invalid task filters",
 		},
+		{
+			name:   "invalid cursor",
+			userID: userID,
+			filters: domain.TaskFilters{
+				Limit:  10,
+				Cursor: "not-a-cursor",
+			},
+			setupMock:   func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:     true,
+			expectedErr: "3019",
+		},
 		{
 			name:   "repository error",
 			userID: userID,
@@ -372,17 +457,17 @@ func TestTaskService_ListTasks(t *testing.T) {
 			tt.setupMock(mockRepo)
 
 			service := NewTaskService(mockRepo)
-			tasks, err := service.ListTasks(tt.userID, tt.filters)
+			page, err := service.ListTasks(tt.userID, tt.filters)
 
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
-				assert.Nil(t, tasks)
+				assert.Nil(t, page)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, tasks)
+				require.NotNil(t, page)
 				if tt.validateTasks != nil {
-					tt.validateTasks(t, tasks)
+					tt.validateTasks(t, page.Tasks)
 				}
 			}
 		})
@@ -539,6 +624,492 @@ func TestTaskService_UpdateTaskCompletion(t *testing.T) {
 	}
 }
 
+func TestTaskService_UpdateTaskCompletion_Recurring(t *testing.T) {
+	userID := uuid.New().String()
+	taskID := uuid.New().String()
+	dueDate := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	newRecurringTask := func(completed bool) *domain.Task {
+		due := dueDate
+		return &domain.Task{
+			ID:          taskID,
+			UserID:      userID,
+			Description: "Weekly report",
+			Category:    "Work",
+			Completed:   completed,
+			DueDate:     &due,
+			Recurrence:  "FREQ=WEEKLY",
+			SeriesID:    taskID,
+			Occurrence:  1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+	}
+
+	t.Run("completing a recurring task creates the next occurrence", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(false), nil).Once()
+		mockRepo.On("UpdateTaskCompletion", taskID, true).Return(nil)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(true), nil).Once()
+		mockRepo.On("CreateNextOccurrence", taskID, mock.MatchedBy(func(next *domain.Task) bool {
+			return next.ID != taskID &&
+				next.SeriesID == taskID &&
+				next.Occurrence == 2 &&
+				!next.Completed &&
+				next.DueDate.Equal(dueDate.AddDate(0, 0, 7))
+		})).Return(true, nil)
+
+		service := NewTaskService(mockRepo)
+		task, err := service.UpdateTaskCompletion(taskID, userID, true)
+
+		assert.NoError(t, err)
+		assert.True(t, task.Completed)
+	})
+
+	t.Run("re-completing an occurrence that already has a successor succeeds", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(false), nil).Once()
+		mockRepo.On("UpdateTaskCompletion", taskID, true).Return(nil)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(true), nil).Once()
+		mockRepo.On("CreateNextOccurrence", taskID, mock.AnythingOfType("*domain.Task")).Return(false, nil)
+
+		service := NewTaskService(mockRepo)
+		task, err := service.UpdateTaskCompletion(taskID, userID, true)
+
+		assert.NoError(t, err)
+		assert.True(t, task.Completed)
+	})
+
+	t.Run("completing a deleted occurrence does not schedule", func(t *testing.T) {
+		deletedAt := time.Now()
+		deleted := newRecurringTask(false)
+		deleted.DeletedAt = &deletedAt
+		completed := newRecurringTask(true)
+		completed.DeletedAt = &deletedAt
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(deleted, nil).Once()
+		mockRepo.On("UpdateTaskCompletion", taskID, true).Return(nil)
+		mockRepo.On("GetTaskByID", taskID).Return(completed, nil).Once()
+
+		service := NewTaskService(mockRepo)
+		_, err := service.UpdateTaskCompletion(taskID, userID, true)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "CreateNextOccurrence", mock.Anything, mock.Anything)
+	})
+
+	t.Run("marking a recurring task incomplete does not schedule", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(true), nil).Once()
+		mockRepo.On("UpdateTaskCompletion", taskID, false).Return(nil)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(false), nil).Once()
+
+		service := NewTaskService(mockRepo)
+		task, err := service.UpdateTaskCompletion(taskID, userID, false)
+
+		assert.NoError(t, err)
+		assert.False(t, task.Completed)
+	})
+
+	t.Run("failure to create next occurrence is reported", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(false), nil).Once()
+		mockRepo.On("UpdateTaskCompletion", taskID, true).Return(nil)
+		mockRepo.On("GetTaskByID", taskID).Return(newRecurringTask(true), nil).Once()
+		mockRepo.On("CreateNextOccurrence", taskID, mock.AnythingOfType("*domain.Task")).Return(false, errors.New("database error"))
+
+		service := NewTaskService(mockRepo)
+		task, err := service.UpdateTaskCompletion(taskID, userID, true)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "3025")
+		assert.Nil(t, task)
+	})
+}
+
+func TestTaskService_GetTaskHistory(t *testing.T) {
+	userID := uuid.New().String()
+	taskID := uuid.New().String()
+
+	t.Run("returns series occurrences", func(t *testing.T) {
+		task := &domain.Task{ID: taskID, UserID: userID, Description: "Report", Recurrence: "FREQ=DAILY", SeriesID: "series-1", Occurrence: 2}
+		first := &domain.Task{ID: uuid.New().String(), UserID: userID, Description: "Report", SeriesID: "series-1", Occurrence: 1}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(task, nil)
+		mockRepo.On("GetSeriesTasks", userID, "series-1").Return([]*domain.Task{first, task}, nil)
+
+		service := NewTaskService(mockRepo)
+		history, err := service.GetTaskHistory(taskID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Task{first, task}, history)
+	})
+
+	t.Run("returns only the task when it does not recur", func(t *testing.T) {
+		task := &domain.Task{ID: taskID, UserID: userID, Description: "One-off"}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(task, nil)
+
+		service := NewTaskService(mockRepo)
+		history, err := service.GetTaskHistory(taskID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Task{task}, history)
+	})
+
+	t.Run("rejects tasks owned by another user", func(t *testing.T) {
+		task := &domain.Task{ID: taskID, UserID: uuid.New().String(), Description: "Not mine"}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(task, nil)
+
+		service := NewTaskService(mockRepo)
+		history, err := service.GetTaskHistory(taskID, userID)
+
+		assert.Error(t, err)
+		assert.Nil(t, history)
+	})
+}
+
+func TestTaskService_Subtasks(t *testing.T) {
+	userID := uuid.New().String()
+	parentID := uuid.New().String()
+	parent := &domain.Task{ID: parentID, UserID: userID, Description: "Release checklist"}
+
+	t.Run("creates subtask under parent", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", parentID).Return(parent, nil)
+		mockRepo.On("CreateTask", mock.MatchedBy(func(task *domain.Task) bool {
+			return task.ParentID == parentID && task.UserID == userID
+		})).Return(nil)
+
+		service := NewTaskService(mockRepo)
+		task, err := service.CreateTask(userID, "Write changelog", "", domain.TaskOptions{ParentID: parentID})
+
+		require.NoError(t, err)
+		assert.Equal(t, parentID, task.ParentID)
+	})
+
+	t.Run("rejects invalid parents", func(t *testing.T) {
+		deletedAt := time.Now()
+		tests := []struct {
+			name        string
+			parent      *domain.Task
+			repoErr     error
+			expectedErr error
+		}{
+			{name: "missing parent", repoErr: errors.New("2003: task not found"), expectedErr: domain.ErrParentTaskNotFound},
+			{name: "parent owned by another user", parent: &domain.Task{ID: parentID, UserID: uuid.New().String()}, expectedErr: domain.ErrParentTaskNotFound},
+			{name: "deleted parent", parent: &domain.Task{ID: parentID, UserID: userID, DeletedAt: &deletedAt}, expectedErr: domain.ErrParentTaskNotFound},
+			{name: "parent is a subtask", parent: &domain.Task{ID: parentID, UserID: userID, ParentID: uuid.New().String()}, expectedErr: domain.ErrNestedSubtask},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := mocks.NewMockTaskRepository(t)
+				mockRepo.On("GetTaskByID", parentID).Return(tt.parent, tt.repoErr)
+
+				service := NewTaskService(mockRepo)
+				task, err := service.CreateTask(userID, "Write changelog", "", domain.TaskOptions{ParentID: parentID})
+
+				assert.Nil(t, task)
+				assert.True(t, errors.Is(err, tt.expectedErr))
+				assert.Contains(t, err.Error(), "3026")
+			})
+		}
+	})
+
+	t.Run("lists subtasks of owned parent", func(t *testing.T) {
+		subtask := &domain.Task{ID: uuid.New().String(), UserID: userID, ParentID: parentID}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", parentID).Return(parent, nil)
+		mockRepo.On("GetSubtasks", parentID).Return([]*domain.Task{subtask}, nil)
+
+		service := NewTaskService(mockRepo)
+		subtasks, err := service.ListSubtasks(parentID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Task{subtask}, subtasks)
+	})
+
+	t.Run("does not list subtasks of another user's task", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", parentID).Return(parent, nil)
+
+		service := NewTaskService(mockRepo)
+		subtasks, err := service.ListSubtasks(parentID, uuid.New().String())
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "3017")
+		assert.Nil(t, subtasks)
+	})
+
+	t.Run("does not restore subtask while parent is deleted", func(t *testing.T) {
+		deletedAt := time.Now()
+		subtaskID := uuid.New().String()
+		subtask := &domain.Task{ID: subtaskID, UserID: userID, ParentID: parentID, DeletedAt: &deletedAt}
+		deletedParent := &domain.Task{ID: parentID, UserID: userID, DeletedAt: &deletedAt}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", subtaskID).Return(subtask, nil)
+		mockRepo.On("GetTaskByID", parentID).Return(deletedParent, nil)
+
+		service := NewTaskService(mockRepo)
+		task, err := service.RestoreTask(subtaskID, userID)
+
+		assert.Nil(t, task)
+		assert.True(t, errors.Is(err, domain.ErrParentTaskDeleted))
+		assert.Contains(t, err.Error(), "3027")
+	})
+}
+
+func TestTaskService_UpdateTask(t *testing.T) {
+	userID := uuid.New().String()
+	taskID := uuid.New().String()
+	otherUserID := uuid.New().String()
+
+	newTestTask := func() *domain.Task {
+		return &domain.Task{
+			ID:          taskID,
+			UserID:      userID,
+			Description: "Tpyo in description",
+			Category:    "Work",
+			Completed:   false,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+	}
+
+	strPtr := func(s string) *string { return &s }
+	dueDate := time.Now().Add(24 * time.Hour)
+	highPriority := domain.PriorityHigh
+	invalidPriority := domain.TaskPriority("critical")
+
+	tests := []struct {
+		name          string
+		taskID        string
+		userID        string
+		updates       domain.TaskUpdate
+		setupMock     func(*mocks.MockTaskRepository)
+		wantErr       bool
+		expectedError string
+		validateTask  func(*testing.T, *domain.Task)
+	}{
+		{
+			name:    "successful description update",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Description: strPtr("  Typo in description  ")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Description == "Typo in description" && task.Category == "Work"
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.Equal(t, "Typo in description", task.Description)
+				assert.Equal(t, "Work", task.Category)
+			},
+		},
+		{
+			name:    "successful category change",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Category: strPtr("Personal")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Category == "Personal"
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.Equal(t, "Personal", task.Category)
+			},
+		},
+		{
+			name:          "empty user ID",
+			taskID:        taskID,
+			userID:        "",
+			updates:       domain.TaskUpdate{Description: strPtr("New")},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "user ID is required",
+		},
+		{
+			name:          "empty task ID",
+			taskID:        "",
+			userID:        userID,
+			updates:       domain.TaskUpdate{Description: strPtr("New")},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "task ID is required",
+		},
+		{
+			name:          "empty update",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3021",
+		},
+		{
+			name:    "successful due date change",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{DueDate: &dueDate},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.DueDate != nil && task.DueDate.Equal(dueDate)
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.True(t, task.DueDate.Equal(dueDate))
+			},
+		},
+		{
+			name:    "successful recurrence change starts a series",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Recurrence: strPtr("FREQ=WEEKLY;BYDAY=MO")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Recurrence == "FREQ=WEEKLY;BYDAY=MO" && task.SeriesID == taskID && task.Occurrence == 1
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:          "invalid recurrence",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{Recurrence: strPtr("FREQ=SOMETIMES")},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3024",
+		},
+		{
+			name:          "conflicting due date changes",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{DueDate: &dueDate, ClearDueDate: true},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3022",
+		},
+		{
+			name:    "successful priority change",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Priority: &highPriority},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+					return task.Priority == domain.PriorityHigh
+				})).Return(nil)
+			},
+			wantErr: false,
+			validateTask: func(t *testing.T, task *domain.Task) {
+				assert.Equal(t, domain.PriorityHigh, task.Priority)
+			},
+		},
+		{
+			name:          "invalid priority",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{Priority: &invalidPriority},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "3023",
+		},
+		{
+			name:          "blank description",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{Description: strPtr("   ")},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "task description cannot be empty",
+		},
+		{
+			name:          "description too long",
+			taskID:        taskID,
+			userID:        userID,
+			updates:       domain.TaskUpdate{Description: strPtr(strings.Repeat("a", 10001))},
+			setupMock:     func(mockRepo *mocks.MockTaskRepository) {},
+			wantErr:       true,
+			expectedError: "task description cannot exceed 10000 characters",
+		},
+		{
+			name:    "task belongs to different user",
+			taskID:  taskID,
+			userID:  otherUserID,
+			updates: domain.TaskUpdate{Description: strPtr("New")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+			},
+			wantErr:       true,
+			expectedError: "task not found",
+		},
+		{
+			name:    "deleted task cannot be updated",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Description: strPtr("New")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				deletedTask := newTestTask()
+				deletedTask.SoftDelete()
+				mockRepo.On("GetTaskByID", taskID).Return(deletedTask, nil)
+			},
+			wantErr:       true,
+			expectedError: "task not found",
+		},
+		{
+			name:    "repository update error",
+			taskID:  taskID,
+			userID:  userID,
+			updates: domain.TaskUpdate{Description: strPtr("New")},
+			setupMock: func(mockRepo *mocks.MockTaskRepository) {
+				mockRepo.On("GetTaskByID", taskID).Return(newTestTask(), nil)
+				mockRepo.On("UpdateTask", mock.AnythingOfType("*domain.Task")).Return(errors.New("database error"))
+			},
+			wantErr:       true,
+			expectedError: "failed to update task",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockTaskRepository(t)
+			tt.setupMock(mockRepo)
+
+			service := NewTaskService(mockRepo)
+			task, err := service.UpdateTask(tt.taskID, tt.userID, tt.updates)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Nil(t, task)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, task)
+				if tt.validateTask != nil {
+					tt.validateTask(t, task)
+				}
+			}
+		})
+	}
+}
+
 func TestTaskService_SoftDeleteTask(t *testing.T) {
 	userID := uuid.New().String()
 	taskID := uuid.New().String()
@@ -962,6 +1533,146 @@ func TestTaskService_RenameCategory(t *testing.T) {
 	}
 }
 
+func TestTaskService_Tags(t *testing.T) {
+	userID := uuid.New().String()
+
+	t.Run("normalizes tags on create", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("CreateTask", mock.MatchedBy(func(task *domain.Task) bool {
+			return assert.ObjectsAreEqual([]string{"work", "urgent"}, task.Tags)
+		})).Return(nil)
+
+		service := NewTaskService(mockRepo)
+		task, err := service.CreateTask(userID, "Prepare slides", "", domain.TaskOptions{Tags: []string{" Work", "urgent", "WORK"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work", "urgent"}, task.Tags)
+	})
+
+	t.Run("rejects invalid tags on create and update", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		service := NewTaskService(mockRepo)
+
+		_, err := service.CreateTask(userID, "Prepare slides", "", domain.TaskOptions{Tags: []string{"a,b"}})
+		assert.ErrorIs(t, err, domain.ErrInvalidTag)
+		assert.Contains(t, err.Error(), "3028")
+
+		tags := []string{strings.Repeat("t", domain.MaxTagLength+1)}
+		_, err = service.UpdateTask(uuid.New().String(), userID, domain.TaskUpdate{Tags: &tags})
+		assert.ErrorIs(t, err, domain.ErrInvalidTag)
+		assert.Contains(t, err.Error(), "3028")
+	})
+
+	t.Run("replaces tags on update", func(t *testing.T) {
+		taskID := uuid.New().String()
+		task := &domain.Task{ID: taskID, UserID: userID, Description: "Prepare slides", Tags: []string{"work"}}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetTaskByID", taskID).Return(task, nil)
+		mockRepo.On("UpdateTask", mock.MatchedBy(func(task *domain.Task) bool {
+			return assert.ObjectsAreEqual([]string{"home"}, task.Tags)
+		})).Return(nil)
+
+		service := NewTaskService(mockRepo)
+		tags := []string{"Home"}
+		updated, err := service.UpdateTask(taskID, userID, domain.TaskUpdate{Tags: &tags})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"home"}, updated.Tags)
+	})
+
+	t.Run("lists tags", func(t *testing.T) {
+		summaries := []domain.TagSummary{{Name: "work", TaskCount: 2}}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetUserTags", userID).Return(summaries, nil)
+
+		service := NewTaskService(mockRepo)
+		tags, err := service.GetUserTags(userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, summaries, tags)
+	})
+
+	t.Run("renames and merges normalized tags", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("RenameTag", userID, "work", "office").Return(nil)
+		mockRepo.On("MergeTags", userID, "urgent", "office").Return(nil)
+
+		service := NewTaskService(mockRepo)
+		assert.NoError(t, service.RenameTag(userID, "Work", " office"))
+		assert.NoError(t, service.MergeTags(userID, "URGENT", "office"))
+	})
+
+	t.Run("rejects invalid tag names", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		service := NewTaskService(mockRepo)
+
+		err := service.RenameTag("", "work", "office")
+		assert.Contains(t, err.Error(), "3011")
+
+		err = service.RenameTag(userID, "", "office")
+		assert.ErrorIs(t, err, domain.ErrInvalidTag)
+
+		err = service.MergeTags(userID, "Work", "work")
+		assert.Contains(t, err.Error(), "3014")
+	})
+
+	t.Run("keeps repository errors inspectable", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("RenameTag", userID, "work", "urgent").Return(fmt.Errorf("2008: %w", domain.ErrTagExists))
+		mockRepo.On("MergeTags", userID, "missing", "work").Return(fmt.Errorf("2006: %w", domain.ErrTagNotFound))
+
+		service := NewTaskService(mockRepo)
+		assert.ErrorIs(t, service.RenameTag(userID, "work", "urgent"), domain.ErrTagExists)
+		assert.ErrorIs(t, service.MergeTags(userID, "missing", "work"), domain.ErrTagNotFound)
+	})
+}
+
+func TestTaskService_SearchTasks(t *testing.T) {
+	userID := uuid.New().String()
+
+	t.Run("searches with the default limit", func(t *testing.T) {
+		results := []*domain.Task{{ID: uuid.New().String(), UserID: userID, Description: "Deploy API"}}
+
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("SearchTasks", userID, "deploy", domain.DefaultSearchLimit).Return(results, nil)
+
+		service := NewTaskService(mockRepo)
+		tasks, err := service.SearchTasks(userID, "deploy", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, results, tasks)
+	})
+
+	t.Run("rejects invalid queries and limits", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		service := NewTaskService(mockRepo)
+
+		_, err := service.SearchTasks("", "deploy", 10)
+		assert.Contains(t, err.Error(), "3011")
+
+		_, err = service.SearchTasks(userID, " ... ", 10)
+		assert.ErrorIs(t, err, domain.ErrInvalidSearchQuery)
+		assert.Contains(t, err.Error(), "3029")
+
+		_, err = service.SearchTasks(userID, "deploy", domain.MaxSearchLimit+1)
+		assert.ErrorIs(t, err, domain.ErrInvalidSearchLimit)
+		assert.Contains(t, err.Error(), "3029")
+	})
+
+	t.Run("wraps repository errors", func(t *testing.T) {
+		mockRepo := mocks.NewMockTaskRepository(t)
+		mockRepo.On("SearchTasks", userID, "deploy", 5).Return(nil, errors.New("connection refused"))
+
+		service := NewTaskService(mockRepo)
+		_, err := service.SearchTasks(userID, "deploy", 5)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "3018")
+	})
+}
+
 func TestTaskService_DeleteCategory(t *testing.T) {
 	userID := uuid.New().String()
 
@@ -1034,17 +1745,17 @@ func TestTaskService_ErrorCodes(t *testing.T) {
 	service := NewTaskService(mockRepo)
 
 	// Test error code 3011 - User ID required
-	_, err := service.CreateTask("", "Valid description", "Category")
+	_, err := service.CreateTask("", "Valid description", "Category", domain.TaskOptions{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3011")
 
 	// Test error code 3012 - Task description validation
-	_, err = service.CreateTask(uuid.New().String(), "", "Category")
+	_, err = service.CreateTask(uuid.New().String(), "", "Category", domain.TaskOptions{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3012")
 
 	// Test error code 3013 - Task description too long
-	_, err = service.CreateTask(uuid.New().String(), strings.Repeat("a", 10001), "Category")
+	_, err = service.CreateTask(uuid.New().String(), strings.Repeat("a", 10001), "Category", domain.TaskOptions{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3013")
 }
@@ -1059,7 +1770,7 @@ func BenchmarkTaskService_CreateTask(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		description := fmt.Sprintf("Task %d", i)
-		service.CreateTask(userID, description, "Test")
+		service.CreateTask(userID, description, "Test", domain.TaskOptions{})
 	}
 }
 
@@ -2,9 +2,23 @@ package services
 
 import (
 	"backend/internal/domain"
+	"backend/pkg/apitoken"
+	"backend/pkg/cookie"
+	"backend/pkg/mailer"
+	"backend/pkg/oidc"
+	"backend/pkg/totp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,16 +28,204 @@ import (
 // UserServiceInterface defines the interface for user business logic operations
 // Contains all business rules and validation logic for user operations
 type UserServiceInterface interface {
-	Register(email, displayName, password string) (*domain.User, string, error)
-	Login(email, password string) (*domain.User, string, error)
+	Register(email, displayName, password string, client domain.SessionClient) (*domain.User, string, error)
+	Login(email, password string, rememberMe bool, client domain.SessionClient) (*domain.User, string, error)
 	Logout(sessionID string) error
 	GetCurrentUser(sessionID string) (*domain.User, error)
+	ListSessions(userID, currentSessionID string) ([]*domain.Session, error)
+	RevokeSession(userID, sessionID string) error
+	RevokeOtherSessions(userID, currentSessionID string) (int, error)
+	UpdateProfile(userID, displayName string) (*domain.User, error)
+	ChangePassword(userID, currentSessionID, oldPassword, newPassword string, client domain.SessionClient) (string, bool, error)
+	DeleteUser(userID, password string, client domain.SessionClient) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) error
+	SendVerificationEmail(userID string) error
+	VerifyEmail(token string) (*domain.User, error)
+	GetLoginActivity(userID string) (*domain.LoginActivity, error)
+	UnlockAccount(adminID, userID string) error
+	CompleteTwoFactorLogin(token, code string, client domain.SessionClient) (*domain.User, string, bool, error)
+	GetTwoFactorStatus(userID string) (*domain.TwoFactor, error)
+	BeginTwoFactorSetup(userID string) (*domain.TwoFactorSetup, error)
+	EnableTwoFactor(userID, code string, client domain.SessionClient) ([]string, error)
+	DisableTwoFactor(userID, password, code string, client domain.SessionClient) error
+	RegenerateRecoveryCodes(userID, password, code string, client domain.SessionClient) ([]string, error)
+	CreateAPIToken(userID, name string, scopes []string, ttl time.Duration, client domain.SessionClient) (*domain.APIToken, string, error)
+	ListAPITokens(userID string) ([]*domain.APIToken, error)
+	RevokeAPIToken(userID, tokenID string, client domain.SessionClient) error
+	BeginOIDCLogin(rememberMe bool) (string, string, error)
+	CompleteOIDCLogin(state, code string, client domain.SessionClient) (*domain.User, string, bool, error)
 }
 
 // UserService implements user business logic operations
 // Handles user registration, authentication, and session management
 type UserService struct {
-	userRepo UserRepository
+	userRepo     UserRepository
+	taskRepo     UserTaskRepository
+	loginRepo    LoginAttemptRepository
+	mailer       mailer.Mailer
+	signer       *cookie.Signer
+	appURL       string
+	resetTTL     time.Duration
+	verifyTTL    time.Duration
+	lockout      LockoutPolicy
+	adminEmails  []string
+	twoFactor    TwoFactorRepository
+	totpIssuer   string
+	apiTokens    APITokenRepository
+	oidcRepo     OIDCRepository
+	oidcProvider OIDCProvider
+	now          func() time.Time
+}
+
+// defaultPasswordResetTTL is how long password reset links stay valid when no lifetime is configured
+const defaultPasswordResetTTL = time.Hour
+
+// defaultVerificationTTL is how long email verification links stay valid when no lifetime is configured
+const defaultVerificationTTL = 48 * time.Hour
+
+// verificationPurpose is the first field of an email verification token
+// It keeps other values signed with the same secret, such as session cookies, from passing as tokens
+const verificationPurpose = "verify-email"
+
+// mailTimeout bounds sending a single email
+const mailTimeout = 10 * time.Second
+
+// defaultLockoutDuration is how long the first lockout lasts when no duration is configured
+const defaultLockoutDuration = 5 * time.Minute
+
+// defaultMaxLockoutDuration caps lockouts when no maximum is configured
+const defaultMaxLockoutDuration = 24 * time.Hour
+
+// securityEventLimit is how many recent security events are returned with the login activity
+const securityEventLimit = 20
+
+// defaultTOTPIssuer names the service in authenticator apps when no issuer is configured
+const defaultTOTPIssuer = "Task Tracker"
+
+// twoFactorSetupTTL is how long a user has to confirm a new authenticator app with a code
+const twoFactorSetupTTL = 10 * time.Minute
+
+// loginChallengeTTL is how long the login token issued after a correct password stays valid
+const loginChallengeTTL = 5 * time.Minute
+
+// maxLoginChallengeAttempts is how many wrong codes a login token survives before the password must be entered again
+const maxLoginChallengeAttempts = 5
+
+// totpSkew is how many 30 second steps a TOTP code may be early or late, allowing for clock drift
+const totpSkew = 1
+
+// recoveryCodeCount is how many recovery codes a user gets at a time
+const recoveryCodeCount = 10
+
+// recoveryCodeLength is the number of characters in a recovery code, not counting the separating hyphen
+const recoveryCodeLength = 10
+
+// recoveryCodeEncoding spells recovery codes in lowercase base32, which has no 0 or 1 to be misread as o or l
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// defaultAPITokenTTL is how long an API token stays valid when no lifetime is requested
+const defaultAPITokenTTL = 30 * 24 * time.Hour
+
+// maxAPITokenTTL is the longest lifetime an API token can be given
+const maxAPITokenTTL = 365 * 24 * time.Hour
+
+// maxAPITokenNameLength bounds the name users give an API token
+const maxAPITokenNameLength = 100
+
+// oidcStateTTL is how long a user has to sign in at the identity provider and come back
+const oidcStateTTL = 10 * time.Minute
+
+// oidcTimeout bounds exchanging a code with the identity provider
+const oidcTimeout = 10 * time.Second
+
+// LockoutPolicy controls how failed logins lock an account
+// After Threshold consecutive failures the account is locked for Duration, doubling with every further failure up to MaxDuration
+type LockoutPolicy struct {
+	Threshold   int           // consecutive failures before locking; 0 disables lockout
+	Duration    time.Duration // first lockout; five minutes when zero
+	MaxDuration time.Duration // longest lockout; 24 hours when zero
+}
+
+// UserServiceOptions holds the optional dependencies of the user service
+// Features whose dependency is missing fail with error code 3030
+type UserServiceOptions struct {
+	Tasks            UserTaskRepository     // needed to delete accounts
+	Mailer           mailer.Mailer          // needed to send password reset and verification emails
+	Signer           *cookie.Signer         // needed to sign email verification tokens
+	AppURL           string                 // frontend base URL for links in emails
+	PasswordResetTTL time.Duration          // lifetime of password reset links; one hour when zero
+	VerificationTTL  time.Duration          // lifetime of email verification links; 48 hours when zero
+	Logins           LoginAttemptRepository // needed to track logins and lock accounts
+	Lockout          LockoutPolicy          // when to lock accounts after failed logins
+	AdminEmails      []string               // accounts with these emails are administrators
+	TwoFactor        TwoFactorRepository    // needed for two-factor authentication
+	TOTPIssuer       string                 // service name shown in authenticator apps; "Task Tracker" when empty
+	APITokens        APITokenRepository     // needed for personal API tokens
+	OIDC             OIDCRepository         // needed to sign in with an identity provider
+	OIDCProvider     OIDCProvider           // identity provider to sign in with
+}
+
+// UserTaskRepository defines the methods needed from the task repository to delete accounts
+// Kept separate so the user service only depends on what account deletion needs
+type UserTaskRepository interface {
+	DeleteUserTasks(userID string) (int, error)
+}
+
+// LoginAttemptRepository defines the methods needed to track logins, lock accounts and record security events
+// Kept separate so login tracking stays optional for the user service
+type LoginAttemptRepository interface {
+	RecordLoginFailure(userID string, attempt domain.LoginAttempt) (int, error)
+	RecordLoginSuccess(userID string, attempt domain.LoginAttempt) error
+	LockAccount(userID string, until time.Time) error
+	GetAccountLock(userID string) (time.Time, error)
+	ClearLoginFailures(userID string) error
+	GetLoginActivity(userID string) (*domain.LoginActivity, error)
+	AddSecurityEvent(userID string, event *domain.SecurityEvent) error
+	ListSecurityEvents(userID string, limit int) ([]*domain.SecurityEvent, error)
+}
+
+// TwoFactorRepository defines the methods needed to store TOTP secrets, recovery codes and pending logins
+// Kept separate so two-factor authentication stays optional for the user service
+type TwoFactorRepository interface {
+	SaveTwoFactorSetup(userID, secret string, ttl time.Duration) error
+	GetTwoFactorSetup(userID string) (string, error)
+	EnableTwoFactor(userID, secret string, recoveryCodeHashes []string, enabledAt time.Time) error
+	GetTwoFactor(userID string) (*domain.TwoFactor, error)
+	DisableTwoFactor(userID string) error
+	ReplaceRecoveryCodes(userID string, recoveryCodeHashes []string) error
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+	UseTOTPStep(userID string, step int64) (bool, error)
+	SaveLoginChallenge(tokenHash string, challenge *domain.LoginChallenge, ttl time.Duration) error
+	GetLoginChallenge(tokenHash string) (*domain.LoginChallenge, error)
+	RecordLoginChallengeFailure(tokenHash string) (int, error)
+	DeleteLoginChallenge(tokenHash string) error
+}
+
+// APITokenRepository defines the methods needed to store personal API tokens
+// Kept separate so API tokens stay optional for the user service
+type APITokenRepository interface {
+	CreateAPIToken(token *domain.APIToken) error
+	ListUserAPITokens(userID string) ([]*domain.APIToken, error)
+	DeleteAPIToken(userID, tokenID string) error
+	DeleteAllUserAPITokens(userID string) (int, error)
+}
+
+// OIDCRepository defines the methods needed to store identity provider sign-ins and linked accounts
+// Kept separate so signing in with an identity provider stays optional for the user service
+type OIDCRepository interface {
+	SaveOIDCLoginState(stateHash string, state *domain.OIDCLoginState, ttl time.Duration) error
+	ConsumeOIDCLoginState(stateHash string) (*domain.OIDCLoginState, error)
+	GetOIDCIdentity(issuer, subject string) (string, error)
+	LinkOIDCIdentity(issuer, subject, userID string) error
+}
+
+// OIDCProvider defines the OpenID Connect identity provider users can sign in with
+// Implemented by *oidc.Provider
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(state, nonce, verifier string) string
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Claims, error)
 }
 
 // UserRepository defines the methods needed from the user repository
@@ -35,24 +237,76 @@ type UserRepository interface {
 	Update(user *domain.User) error
 	Delete(id string) error
 	List(limit, offset int) ([]*domain.User, error)
-	CreateSession(userID, sessionID string) error
+	CreateSession(userID, sessionID string, rememberMe bool, client domain.SessionClient) error
 	ValidateSession(sessionID string) (bool, error)
 	GetSessionUserID(sessionID string) (string, error)
+	GetSession(sessionID string) (*domain.Session, error)
+	ListUserSessions(userID string) ([]*domain.Session, error)
 	DeleteSession(sessionID string) error
 	DeleteAllUserSessions(userID string) error
+	DeleteOtherUserSessions(userID, keepSessionID string) (int, error)
+	SavePasswordResetToken(userID, tokenHash string, ttl time.Duration) error
+	ConsumePasswordResetToken(tokenHash string) (string, error)
 }
 
 // NewUserService creates a new instance of UserService
-// Initializes the service with the provided user repository
+// Initializes the service with the provided user repository and no optional dependencies
 func NewUserService(userRepo UserRepository) *UserService {
+	return NewUserServiceWithOptions(userRepo, UserServiceOptions{})
+}
+
+// NewUserServiceWithTasks creates a new instance of UserService that can delete accounts
+// The task repository is used to remove a deleted user's tasks
+func NewUserServiceWithTasks(userRepo UserRepository, taskRepo UserTaskRepository) *UserService {
+	return NewUserServiceWithOptions(userRepo, UserServiceOptions{Tasks: taskRepo})
+}
+
+// NewUserServiceWithOptions creates a new instance of UserService with optional dependencies
+// Used by the server to enable account deletion, password reset, email verification, login tracking, two-factor authentication, API tokens and identity provider sign-in
+func NewUserServiceWithOptions(userRepo UserRepository, options UserServiceOptions) *UserService {
+	resetTTL := options.PasswordResetTTL
+	if resetTTL <= 0 {
+		resetTTL = defaultPasswordResetTTL
+	}
+	verifyTTL := options.VerificationTTL
+	if verifyTTL <= 0 {
+		verifyTTL = defaultVerificationTTL
+	}
+	lockout := options.Lockout
+	if lockout.Duration <= 0 {
+		lockout.Duration = defaultLockoutDuration
+	}
+	if lockout.MaxDuration <= 0 {
+		lockout.MaxDuration = defaultMaxLockoutDuration
+	}
+	totpIssuer := options.TOTPIssuer
+	if totpIssuer == "" {
+		totpIssuer = defaultTOTPIssuer
+	}
+
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		taskRepo:     options.Tasks,
+		loginRepo:    options.Logins,
+		mailer:       options.Mailer,
+		signer:       options.Signer,
+		appURL:       strings.TrimRight(options.AppURL, "/"),
+		resetTTL:     resetTTL,
+		verifyTTL:    verifyTTL,
+		lockout:      lockout,
+		adminEmails:  options.AdminEmails,
+		twoFactor:    options.TwoFactor,
+		totpIssuer:   totpIssuer,
+		apiTokens:    options.APITokens,
+		oidcRepo:     options.OIDC,
+		oidcProvider: options.OIDCProvider,
+		now:          time.Now,
 	}
 }
 
 // Register creates a new user account with validation and session creation
 // Validates email format, display name, and password requirements before creating user
-func (s *UserService) Register(email, displayName, password string) (*domain.User, string, error) {
+func (s *UserService) Register(email, displayName, password string, client domain.SessionClient) (*domain.User, string, error) {
 	// Error code 3001: Invalid email format
 	if err := s.validateEmail(email); err != nil {
 		return nil, "", domain.ErrInvalidEmail
@@ -82,7 +336,6 @@ func (s *UserService) Register(email, displayName, password string) (*domain.Use
 		ID:          uuid.New().String(),
 		Email:       email,
 		DisplayName: displayName,
-		IsAdmin:     false,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -99,16 +352,24 @@ func (s *UserService) Register(email, displayName, password string) (*domain.Use
 
 	// Create session
 	sessionID := uuid.New().String()
-	if err := s.userRepo.CreateSession(user.ID, sessionID); err != nil {
+	if err := s.userRepo.CreateSession(user.ID, sessionID, false, client); err != nil {
 		return nil, "", fmt.Errorf("3008: failed to create session: %w", err)
 	}
 
+	// The account already exists, so a failed email only needs logging; the user can ask for another one
+	if s.verificationEnabled() {
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+		}
+	}
+
 	return user, sessionID, nil
 }
 
 // Login authenticates a user and creates a new session
-// Validates credentials and creates a 7-day session upon successful authentication
-func (s *UserService) Login(email, password string) (*domain.User, string, error) {
+// Validates credentials and creates a long-lived session when remember me is set; locked accounts fail with a *domain.AccountLockedError
+// Users with two-factor authentication get a *domain.TwoFactorRequiredError carrying a login token for CompleteTwoFactorLogin instead of a session
+func (s *UserService) Login(email, password string, rememberMe bool, client domain.SessionClient) (*domain.User, string, error) {
 	// Error code 3009: Required fields validation
 	if strings.TrimSpace(email) == "" || strings.TrimSpace(password) == "" {
 		return nil, "", fmt.Errorf("3009: email and password are required")
@@ -123,20 +384,137 @@ func (s *UserService) Login(email, password string) (*domain.User, string, error
 		return nil, "", fmt.Errorf("3004: failed to get user: %w", err)
 	}
 
-	// Check password
-	if !user.CheckPassword(password) {
-		return nil, "", domain.ErrInvalidCredentials
+	attempt := domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}
+
+	if err := s.checkPassword(user, password, attempt); err != nil {
+		return nil, "", err
+	}
+
+	// The session is only created once the second factor is checked
+	if s.twoFactor != nil {
+		twoFactor, err := s.twoFactor.GetTwoFactor(user.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+		}
+		if twoFactor != nil {
+			return nil, "", s.beginLoginChallenge(user.ID, rememberMe, attempt.At)
+		}
 	}
 
 	// Create session
 	sessionID := uuid.New().String()
-	if err := s.userRepo.CreateSession(user.ID, sessionID); err != nil {
+	if err := s.userRepo.CreateSession(user.ID, sessionID, rememberMe, client); err != nil {
 		return nil, "", fmt.Errorf("3008: failed to create session: %w", err)
 	}
 
+	s.recordLoginSuccess(user.ID, attempt)
+
 	return user, sessionID, nil
 }
 
+// checkPassword checks a user's password with the lockout accounting of Login
+// Locked accounts fail with a *domain.AccountLockedError before the password is checked, so guessing makes no progress; a wrong password counts as a failed login
+func (s *UserService) checkPassword(user *domain.User, password string, attempt domain.LoginAttempt) error {
+	if err := s.checkAccountLock(user.ID, attempt.At); err != nil {
+		return err
+	}
+
+	if !user.CheckPassword(password) {
+		return s.recordLoginFailure(user.ID, attempt)
+	}
+
+	return nil
+}
+
+// checkAccountLock returns a *domain.AccountLockedError if the user's account is locked at the given time
+func (s *UserService) checkAccountLock(userID string, now time.Time) error {
+	if s.loginRepo == nil {
+		return nil
+	}
+
+	lockedUntil, err := s.loginRepo.GetAccountLock(userID)
+	if err != nil {
+		return fmt.Errorf("3004: failed to get account lock: %w", err)
+	}
+	if lockedUntil.After(now) {
+		return &domain.AccountLockedError{Until: lockedUntil}
+	}
+
+	return nil
+}
+
+// recordLoginFailure records a failed login and locks the account once the lockout threshold is reached
+// Returns the error to report: a *domain.AccountLockedError when this failure locked the account, otherwise domain.ErrInvalidCredentials
+func (s *UserService) recordLoginFailure(userID string, attempt domain.LoginAttempt) error {
+	if s.loginRepo == nil {
+		return domain.ErrInvalidCredentials
+	}
+
+	// Tracking is best-effort: a Redis hiccup must not turn a wrong password into a server error
+	failures, err := s.loginRepo.RecordLoginFailure(userID, attempt)
+	if err != nil {
+		log.Printf("Failed to record login failure for user %s: %v", userID, err)
+		return domain.ErrInvalidCredentials
+	}
+	s.addSecurityEvent(userID, domain.SecurityEventLoginFailed, attempt, "")
+
+	if s.lockout.Threshold <= 0 || failures < s.lockout.Threshold {
+		return domain.ErrInvalidCredentials
+	}
+
+	duration := s.lockoutDuration(failures)
+	until := attempt.At.Add(duration)
+	if err := s.loginRepo.LockAccount(userID, until); err != nil {
+		log.Printf("Failed to lock account of user %s: %v", userID, err)
+		return domain.ErrInvalidCredentials
+	}
+	s.addSecurityEvent(userID, domain.SecurityEventAccountLocked, attempt,
+		fmt.Sprintf("locked for %s after %d failed logins", duration, failures))
+
+	return &domain.AccountLockedError{Until: until}
+}
+
+// recordLoginSuccess records a successful login, which also resets the failed login count
+func (s *UserService) recordLoginSuccess(userID string, attempt domain.LoginAttempt) {
+	if s.loginRepo == nil {
+		return
+	}
+
+	if err := s.loginRepo.RecordLoginSuccess(userID, attempt); err != nil {
+		log.Printf("Failed to record login for user %s: %v", userID, err)
+	}
+	s.addSecurityEvent(userID, domain.SecurityEventLoginSucceeded, attempt, "")
+}
+
+// lockoutDuration returns how long to lock an account after the given number of consecutive failures
+// The first lockout lasts the configured duration and each further failure doubles it, up to the maximum
+func (s *UserService) lockoutDuration(failures int) time.Duration {
+	duration := s.lockout.Duration
+	for i := s.lockout.Threshold; i < failures && duration < s.lockout.MaxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, s.lockout.MaxDuration)
+}
+
+// addSecurityEvent records a security event for a user, logging rather than failing if it cannot be stored
+// Does nothing when login tracking is not configured
+func (s *UserService) addSecurityEvent(userID string, eventType domain.SecurityEventType, attempt domain.LoginAttempt, detail string) {
+	if s.loginRepo == nil {
+		return
+	}
+
+	event := &domain.SecurityEvent{
+		Type:      eventType,
+		At:        attempt.At,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Detail:    detail,
+	}
+	if err := s.loginRepo.AddSecurityEvent(userID, event); err != nil {
+		log.Printf("Failed to record %s event for user %s: %v", eventType, userID, err)
+	}
+}
+
 // Logout terminates a user session
 // Removes the session from storage to prevent further authentication
 func (s *UserService) Logout(sessionID string) error {
@@ -179,6 +557,1270 @@ func (s *UserService) GetCurrentUser(sessionID string) (*domain.User, error) {
 	return user, nil
 }
 
+// ListSessions retrieves the signed-in devices of a user
+// Marks the session making the request as current
+func (s *UserService) ListSessions(userID, currentSessionID string) ([]*domain.Session, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	sessions, err := s.userRepo.ListUserSessions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs out one of the user's devices
+// Returns domain.ErrSessionNotFound if the session does not exist or belongs to another user
+func (s *UserService) RevokeSession(userID, sessionID string) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(sessionID) == "" {
+		return fmt.Errorf("3009: user ID and session ID are required")
+	}
+
+	session, err := s.userRepo.GetSession(sessionID)
+	if err != nil {
+		if err == domain.ErrSessionNotFound {
+			return domain.ErrSessionNotFound
+		}
+		return fmt.Errorf("3004: failed to get session: %w", err)
+	}
+
+	// Sessions of other users are reported as missing so their IDs cannot be probed
+	if session.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+
+	if err := s.userRepo.DeleteSession(sessionID); err != nil {
+		return fmt.Errorf("3004: failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions signs out every device of the user except the current one
+// Returns the number of sessions revoked
+func (s *UserService) RevokeOtherSessions(userID, currentSessionID string) (int, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(currentSessionID) == "" {
+		return 0, fmt.Errorf("3009: user ID and session ID are required")
+	}
+
+	revoked, err := s.userRepo.DeleteOtherUserSessions(userID, currentSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("3004: failed to delete sessions: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// UpdateProfile changes the display name of a user
+// Validates the display name with the same rules as registration
+func (s *UserService) UpdateProfile(userID, displayName string) (*domain.User, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3002: Display name validation
+	if err := s.validateDisplayName(displayName); err != nil {
+		return nil, domain.ErrInvalidDisplayName
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	user.DisplayName = strings.TrimSpace(displayName)
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("3004: failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces a user's password after checking the current one
+// Revokes every session of the user and opens a new one for the caller, keeping its remember me setting
+// A wrong current password counts as a failed login, so locked accounts fail with a *domain.AccountLockedError
+// Returns the new session ID and whether it is remembered
+func (s *UserService) ChangePassword(userID, currentSessionID, oldPassword, newPassword string, client domain.SessionClient) (string, bool, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || oldPassword == "" || newPassword == "" {
+		return "", false, fmt.Errorf("3009: user ID, current password and new password are required")
+	}
+
+	// Error code 3003: Password requirements validation
+	if err := domain.ValidatePassword(newPassword); err != nil {
+		return "", false, domain.ErrWeakPassword
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return "", false, domain.ErrUserNotFound
+		}
+		return "", false, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	if err := s.checkPassword(user, oldPassword, domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}); err != nil {
+		return "", false, err
+	}
+
+	// The replacement session is remembered if the one making the request was
+	rememberMe := false
+	if currentSessionID != "" {
+		session, err := s.userRepo.GetSession(currentSessionID)
+		if err != nil && err != domain.ErrSessionNotFound {
+			return "", false, fmt.Errorf("3004: failed to get session: %w", err)
+		}
+		if session != nil {
+			rememberMe = session.RememberMe
+		}
+	}
+
+	if err := user.HashPassword(newPassword); err != nil {
+		return "", false, fmt.Errorf("3006: failed to hash password: %w", err)
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return "", false, fmt.Errorf("3004: failed to update user: %w", err)
+	}
+
+	// Sign out every device, including sessions an attacker may hold with the old password
+	if err := s.userRepo.DeleteAllUserSessions(userID); err != nil {
+		return "", false, fmt.Errorf("3004: failed to delete sessions: %w", err)
+	}
+
+	// API tokens an attacker may have created with the old password stop working too
+	if s.apiTokens != nil {
+		if _, err := s.apiTokens.DeleteAllUserAPITokens(userID); err != nil {
+			return "", false, fmt.Errorf("3004: failed to revoke API tokens: %w", err)
+		}
+	}
+
+	sessionID := uuid.New().String()
+	if err := s.userRepo.CreateSession(userID, sessionID, rememberMe, client); err != nil {
+		return "", false, fmt.Errorf("3008: failed to create session: %w", err)
+	}
+
+	return sessionID, rememberMe, nil
+}
+
+// DeleteUser permanently deletes a user account after checking the password
+// Removes the user's tasks and all task indexes first, then every session and finally the user record
+// A wrong password counts as a failed login, so locked accounts fail with a *domain.AccountLockedError
+func (s *UserService) DeleteUser(userID, password string, client domain.SessionClient) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || password == "" {
+		return fmt.Errorf("3009: user ID and password are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.taskRepo == nil {
+		return fmt.Errorf("3030: account deletion is not configured")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return domain.ErrUserNotFound
+		}
+		return fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	if err := s.checkPassword(user, password, domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}); err != nil {
+		return err
+	}
+
+	// Tasks go first so a failure never leaves tasks behind without their owner
+	if _, err := s.taskRepo.DeleteUserTasks(userID); err != nil {
+		return fmt.Errorf("3004: failed to delete tasks: %w", err)
+	}
+
+	if err := s.userRepo.DeleteAllUserSessions(userID); err != nil {
+		return fmt.Errorf("3004: failed to delete sessions: %w", err)
+	}
+
+	if err := s.userRepo.Delete(userID); err != nil {
+		return fmt.Errorf("3004: failed to delete user: %w", err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset emails a single-use password reset link to the user with the given email
+// Unknown emails are silently ignored and the email is sent in the background, so neither the answer nor its timing reveals accounts
+func (s *UserService) RequestPasswordReset(email string) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(email) == "" {
+		return fmt.Errorf("3009: email is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.mailer == nil {
+		return fmt.Errorf("3030: password reset email is not configured")
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil
+		}
+		return fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return fmt.Errorf("3031: failed to generate reset token: %w", err)
+	}
+
+	// Only a hash is stored, so a leaked database does not reveal usable links
+	if err := s.userRepo.SavePasswordResetToken(user.ID, hashToken(token), s.resetTTL); err != nil {
+		return fmt.Errorf("3004: failed to save reset token: %w", err)
+	}
+
+	// Send in the background so known and unknown emails take the same time to answer
+	go s.sendPasswordResetEmail(user, s.appURL+"/reset-password?token="+url.QueryEscape(token))
+
+	return nil
+}
+
+// sendPasswordResetEmail emails a password reset link to a user
+// Nobody waits for the result, so failures are only logged; the user can ask for another link
+func (s *UserService) sendPasswordResetEmail(user *domain.User, link string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password for your account. Open this link within %d minutes to choose a new password:\n\n"+
+			"%s\n\n"+
+			"The link works once. If you didn't ask for this, ignore this email and your password stays the same.\n",
+			user.DisplayName, int(s.resetTTL.Minutes()), link),
+	})
+	if err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+}
+
+// ResetPassword sets a new password using a token from a password reset email
+// The token works only once, and every session and API token of the user is revoked afterwards
+func (s *UserService) ResetPassword(token, newPassword string) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(token) == "" || newPassword == "" {
+		return fmt.Errorf("3009: token and new password are required")
+	}
+
+	// Error code 3003: Validate before consuming so a rejected password does not burn the link
+	if err := domain.ValidatePassword(newPassword); err != nil {
+		return domain.ErrWeakPassword
+	}
+
+	userID, err := s.userRepo.ConsumePasswordResetToken(hashToken(token))
+	if err != nil {
+		if err == domain.ErrInvalidResetToken {
+			return domain.ErrInvalidResetToken
+		}
+		return fmt.Errorf("3004: failed to consume reset token: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return domain.ErrInvalidResetToken
+		}
+		return fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	if err := user.HashPassword(newPassword); err != nil {
+		return fmt.Errorf("3006: failed to hash password: %w", err)
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("3004: failed to update user: %w", err)
+	}
+
+	// Whoever knew the old password is signed out everywhere
+	if err := s.userRepo.DeleteAllUserSessions(userID); err != nil {
+		return fmt.Errorf("3004: failed to delete sessions: %w", err)
+	}
+
+	// API tokens created by whoever knew the old password stop working too
+	if s.apiTokens != nil {
+		if _, err := s.apiTokens.DeleteAllUserAPITokens(userID); err != nil {
+			return fmt.Errorf("3004: failed to revoke API tokens: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SendVerificationEmail emails a user a link to verify their address
+// Used to resend the link sent at registration; fails with domain.ErrEmailAlreadyVerified once verified
+func (s *UserService) SendVerificationEmail(userID string) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if !s.verificationEnabled() {
+		return fmt.Errorf("3030: email verification is not configured")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	if user.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	if err := s.sendVerificationEmail(user); err != nil {
+		return fmt.Errorf("3031: failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the address a verification token was issued for as verified
+// Verifying an already verified address succeeds, so opening the link twice is harmless
+func (s *UserService) VerifyEmail(token string) (*domain.User, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("3009: token is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.signer == nil {
+		return nil, fmt.Errorf("3030: email verification is not configured")
+	}
+
+	userID, email, ok := s.parseVerificationToken(token, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrInvalidVerificationToken
+		}
+		return nil, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	// A token issued for an earlier address must not verify the current one
+	if !strings.EqualFold(user.Email, email) {
+		return nil, domain.ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	// Listed administrators get their rights once they have proven they own the address
+	user.EmailVerified = true
+	user.IsAdmin = user.IsAdmin || s.isAdminEmail(user.Email)
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("3004: failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetLoginActivity returns a user's last successful and failed logins, lock state and recent security events
+// Returns domain.ErrUserNotFound for unknown users
+func (s *UserService) GetLoginActivity(userID string) (*domain.LoginActivity, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.loginRepo == nil {
+		return nil, fmt.Errorf("3030: login tracking is not configured")
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	activity, err := s.loginRepo.GetLoginActivity(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get login activity: %w", err)
+	}
+
+	events, err := s.loginRepo.ListSecurityEvents(userID, securityEventLimit)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to list security events: %w", err)
+	}
+	activity.Events = events
+
+	// A lock that ended between reads is no longer of interest
+	if !activity.LockedUntil.After(time.Now()) {
+		activity.LockedUntil = time.Time{}
+	}
+
+	return activity, nil
+}
+
+// UnlockAccount lifts a lockout and resets the failed login count of a user on behalf of an administrator
+// Unlocking an account that is not locked succeeds; returns domain.ErrUserNotFound for unknown users
+func (s *UserService) UnlockAccount(adminID, userID string) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(adminID) == "" || strings.TrimSpace(userID) == "" {
+		return fmt.Errorf("3009: admin ID and user ID are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.loginRepo == nil {
+		return fmt.Errorf("3030: login tracking is not configured")
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if err == domain.ErrUserNotFound {
+			return domain.ErrUserNotFound
+		}
+		return fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	if err := s.loginRepo.ClearLoginFailures(userID); err != nil {
+		return fmt.Errorf("3004: failed to unlock account: %w", err)
+	}
+
+	log.Printf("Account of user %s unlocked by administrator %s", userID, adminID)
+	s.addSecurityEvent(userID, domain.SecurityEventAccountUnlocked, domain.LoginAttempt{At: time.Now()}, "unlocked by an administrator")
+
+	return nil
+}
+
+// CompleteTwoFactorLogin finishes a login started with Login using the login token and a TOTP or recovery code
+// Returns the user, the new session ID and whether the session is remembered; wrong codes count towards the account lockout
+func (s *UserService) CompleteTwoFactorLogin(token, code string, client domain.SessionClient) (*domain.User, string, bool, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(token) == "" || strings.TrimSpace(code) == "" {
+		return nil, "", false, fmt.Errorf("3009: login token and code are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.twoFactor == nil {
+		return nil, "", false, fmt.Errorf("3030: two-factor authentication is not configured")
+	}
+
+	tokenHash := hashToken(token)
+	challenge, err := s.twoFactor.GetLoginChallenge(tokenHash)
+	if err != nil {
+		if err == domain.ErrInvalidLoginChallenge {
+			return nil, "", false, domain.ErrInvalidLoginChallenge
+		}
+		return nil, "", false, fmt.Errorf("3004: failed to get login challenge: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, "", false, domain.ErrInvalidLoginChallenge
+		}
+		return nil, "", false, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	attempt := domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}
+	if err := s.checkAccountLock(user.ID, attempt.At); err != nil {
+		return nil, "", false, err
+	}
+
+	twoFactor, err := s.twoFactor.GetTwoFactor(user.ID)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+	}
+	if twoFactor == nil {
+		// Turned off since the password was checked; logging in again does not need a code
+		return nil, "", false, domain.ErrInvalidLoginChallenge
+	}
+
+	usedRecoveryCode, err := s.verifySecondFactor(user.ID, twoFactor, code, attempt.At)
+	if err == domain.ErrInvalidTwoFactorCode {
+		return nil, "", false, s.recordSecondFactorFailure(user.ID, tokenHash, attempt)
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	// The token is single-use
+	if err := s.twoFactor.DeleteLoginChallenge(tokenHash); err != nil {
+		return nil, "", false, fmt.Errorf("3004: failed to delete login challenge: %w", err)
+	}
+
+	sessionID := uuid.New().String()
+	if err := s.userRepo.CreateSession(user.ID, sessionID, challenge.RememberMe, client); err != nil {
+		return nil, "", false, fmt.Errorf("3008: failed to create session: %w", err)
+	}
+
+	s.recordLoginSuccess(user.ID, attempt)
+	if usedRecoveryCode {
+		s.addSecurityEvent(user.ID, domain.SecurityEventRecoveryCodeUsed, attempt,
+			fmt.Sprintf("%d recovery codes left", twoFactor.RecoveryCodesRemaining-1))
+	}
+
+	return user, sessionID, challenge.RememberMe, nil
+}
+
+// GetTwoFactorStatus returns a user's two-factor authentication settings, or nil if it is turned off
+func (s *UserService) GetTwoFactorStatus(userID string) (*domain.TwoFactor, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("3030: two-factor authentication is not configured")
+	}
+
+	twoFactor, err := s.twoFactor.GetTwoFactor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+	}
+
+	return twoFactor, nil
+}
+
+// BeginTwoFactorSetup creates a TOTP secret for the user to add to an authenticator app
+// Two-factor authentication stays off until EnableTwoFactor confirms the app produces valid codes
+func (s *UserService) BeginTwoFactorSetup(userID string) (*domain.TwoFactorSetup, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("3030: two-factor authentication is not configured")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	existing, err := s.twoFactor.GetTwoFactor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+	}
+	if existing != nil {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("3032: failed to generate TOTP secret: %w", err)
+	}
+
+	if err := s.twoFactor.SaveTwoFactorSetup(userID, secret, twoFactorSetupTTL); err != nil {
+		return nil, fmt.Errorf("3004: failed to save two-factor setup: %w", err)
+	}
+
+	return &domain.TwoFactorSetup{
+		Secret:    secret,
+		URI:       totp.URI(secret, s.totpIssuer, user.Email),
+		ExpiresAt: s.now().Add(twoFactorSetupTTL),
+	}, nil
+}
+
+// EnableTwoFactor turns on two-factor authentication once the user enters a code from the app set up with BeginTwoFactorSetup
+// Returns the recovery codes, which are shown only this once
+func (s *UserService) EnableTwoFactor(userID, code string, client domain.SessionClient) ([]string, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("3009: user ID and code are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("3030: two-factor authentication is not configured")
+	}
+
+	existing, err := s.twoFactor.GetTwoFactor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+	}
+	if existing != nil {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.twoFactor.GetTwoFactorSetup(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get two-factor setup: %w", err)
+	}
+	if secret == "" {
+		return nil, domain.ErrTwoFactorSetupNotFound
+	}
+
+	now := s.now()
+	step, ok := totp.Validate(secret, code, now, totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	// The confirmation code must not also work for a login
+	if _, err := s.twoFactor.UseTOTPStep(userID, step); err != nil {
+		return nil, fmt.Errorf("3004: failed to record TOTP step: %w", err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("3032: failed to generate recovery codes: %w", err)
+	}
+
+	if err := s.twoFactor.EnableTwoFactor(userID, secret, hashes, now); err != nil {
+		return nil, fmt.Errorf("3004: failed to enable two-factor authentication: %w", err)
+	}
+
+	s.addSecurityEvent(userID, domain.SecurityEventTwoFactorEnabled, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent}, "")
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication
+// Requires the password and a TOTP or recovery code, so a stolen session alone cannot remove the second factor
+func (s *UserService) DisableTwoFactor(userID, password, code string, client domain.SessionClient) error {
+	twoFactor, now, err := s.confirmTwoFactorChange(userID, password, code, client)
+	if err != nil {
+		return err
+	}
+
+	if err := s.twoFactor.DisableTwoFactor(userID); err != nil {
+		return fmt.Errorf("3004: failed to disable two-factor authentication: %w", err)
+	}
+
+	s.addSecurityEvent(userID, domain.SecurityEventTwoFactorDisabled, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent},
+		fmt.Sprintf("enabled since %s", twoFactor.EnabledAt.UTC().Format(time.RFC3339)))
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all of the user's recovery codes with new ones
+// Requires the password and a TOTP or recovery code; returns the new codes, which are shown only this once
+func (s *UserService) RegenerateRecoveryCodes(userID, password, code string, client domain.SessionClient) ([]string, error) {
+	_, now, err := s.confirmTwoFactorChange(userID, password, code, client)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("3032: failed to generate recovery codes: %w", err)
+	}
+
+	if err := s.twoFactor.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("3004: failed to replace recovery codes: %w", err)
+	}
+
+	s.addSecurityEvent(userID, domain.SecurityEventRecoveryCodesRegenerated, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent}, "")
+
+	return codes, nil
+}
+
+// CreateAPIToken issues a personal API token that scripts send as an Authorization: Bearer header
+// Returns the token's details and the token itself, which is only stored hashed and cannot be retrieved again; a zero ttl gives the default lifetime
+func (s *UserService) CreateAPIToken(userID, name string, scopes []string, ttl time.Duration, client domain.SessionClient) (*domain.APIToken, string, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, "", fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.apiTokens == nil {
+		return nil, "", fmt.Errorf("3030: API tokens are not configured")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPITokenNameLength {
+		return nil, "", domain.ErrInvalidAPITokenName
+	}
+
+	scopes, ok := normalizeScopes(scopes)
+	if !ok {
+		return nil, "", domain.ErrInvalidAPITokenScopes
+	}
+
+	if ttl == 0 {
+		ttl = defaultAPITokenTTL
+	}
+	if ttl < 0 || ttl > maxAPITokenTTL {
+		return nil, "", domain.ErrInvalidAPITokenExpiry
+	}
+
+	raw, err := apitoken.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("3033: failed to generate API token: %w", err)
+	}
+	tokenHash, _ := apitoken.Hash(raw)
+
+	now := s.now().Truncate(time.Second)
+	token := &domain.APIToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TokenHash: tokenHash,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.apiTokens.CreateAPIToken(token); err != nil {
+		return nil, "", fmt.Errorf("3033: failed to store API token: %w", err)
+	}
+
+	s.addSecurityEvent(userID, domain.SecurityEventAPITokenCreated, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent}, name)
+
+	return token, raw, nil
+}
+
+// ListAPITokens returns the user's API tokens that have not expired or been revoked, newest first
+func (s *UserService) ListAPITokens(userID string) ([]*domain.APIToken, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" {
+		return nil, fmt.Errorf("3009: user ID is required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.apiTokens == nil {
+		return nil, fmt.Errorf("3030: API tokens are not configured")
+	}
+
+	tokens, err := s.apiTokens.ListUserAPITokens(userID)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to list API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes one of the user's API tokens so it stops working immediately
+// Returns domain.ErrAPITokenNotFound for tokens of other users, so their IDs are not confirmed
+func (s *UserService) RevokeAPIToken(userID, tokenID string, client domain.SessionClient) error {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || strings.TrimSpace(tokenID) == "" {
+		return fmt.Errorf("3009: user ID and token ID are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.apiTokens == nil {
+		return fmt.Errorf("3030: API tokens are not configured")
+	}
+
+	if err := s.apiTokens.DeleteAPIToken(userID, tokenID); err != nil {
+		if err == domain.ErrAPITokenNotFound {
+			return domain.ErrAPITokenNotFound
+		}
+		return fmt.Errorf("3004: failed to revoke API token: %w", err)
+	}
+
+	s.addSecurityEvent(userID, domain.SecurityEventAPITokenRevoked, domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}, tokenID)
+
+	return nil
+}
+
+// BeginOIDCLogin starts signing in with the identity provider
+// Returns the authorization URL to send the browser to and the state the provider sends back, which the caller binds to the browser
+func (s *UserService) BeginOIDCLogin(rememberMe bool) (string, string, error) {
+	// Error code 3030: Feature not configured
+	if s.oidcRepo == nil || s.oidcProvider == nil {
+		return "", "", fmt.Errorf("3030: identity provider sign-in is not configured")
+	}
+
+	state, err := newToken()
+	if err != nil {
+		return "", "", fmt.Errorf("3034: failed to generate sign-in state: %w", err)
+	}
+	nonce, err := newToken()
+	if err != nil {
+		return "", "", fmt.Errorf("3034: failed to generate sign-in nonce: %w", err)
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", "", fmt.Errorf("3034: failed to generate code verifier: %w", err)
+	}
+
+	// Only a hash of the state is stored, like login tokens
+	loginState := &domain.OIDCLoginState{Nonce: nonce, CodeVerifier: verifier, RememberMe: rememberMe}
+	if err := s.oidcRepo.SaveOIDCLoginState(hashToken(state), loginState, oidcStateTTL); err != nil {
+		return "", "", fmt.Errorf("3004: failed to save sign-in state: %w", err)
+	}
+
+	return s.oidcProvider.AuthCodeURL(state, nonce, verifier), state, nil
+}
+
+// CompleteOIDCLogin finishes signing in with the identity provider using the state and code the browser came back with
+// Signs in the user linked to the provider account, linking or creating one by verified email on first use
+// Returns the user, the new session ID and whether the session is remembered; users with two-factor authentication get a *domain.TwoFactorRequiredError as with Login
+func (s *UserService) CompleteOIDCLogin(state, code string, client domain.SessionClient) (*domain.User, string, bool, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(state) == "" || strings.TrimSpace(code) == "" {
+		return nil, "", false, fmt.Errorf("3009: state and code are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.oidcRepo == nil || s.oidcProvider == nil {
+		return nil, "", false, fmt.Errorf("3030: identity provider sign-in is not configured")
+	}
+
+	// The state is single-use, so a code cannot be replayed through it
+	loginState, err := s.oidcRepo.ConsumeOIDCLoginState(hashToken(state))
+	if err != nil {
+		if err == domain.ErrInvalidOIDCState {
+			return nil, "", false, domain.ErrInvalidOIDCState
+		}
+		return nil, "", false, fmt.Errorf("3004: failed to get sign-in state: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+	claims, err := s.oidcProvider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("3035: %w: %v", domain.ErrOIDCExchangeFailed, err)
+	}
+
+	// Accounts are matched by email, so only addresses the provider has verified are trusted
+	if strings.TrimSpace(claims.Email) == "" || !claims.EmailVerified {
+		return nil, "", false, domain.ErrOIDCEmailNotVerified
+	}
+
+	attempt := domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}
+	user, err := s.oidcUser(claims, attempt)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	// The identity provider stands in for the password, not for the second factor
+	if s.twoFactor != nil {
+		twoFactor, err := s.twoFactor.GetTwoFactor(user.ID)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+		}
+		if twoFactor != nil {
+			return nil, "", false, s.beginLoginChallenge(user.ID, loginState.RememberMe, attempt.At)
+		}
+	}
+
+	sessionID := uuid.New().String()
+	if err := s.userRepo.CreateSession(user.ID, sessionID, loginState.RememberMe, client); err != nil {
+		return nil, "", false, fmt.Errorf("3008: failed to create session: %w", err)
+	}
+
+	s.recordLoginSuccess(user.ID, attempt)
+
+	return user, sessionID, loginState.RememberMe, nil
+}
+
+// PromoteAdmins grants administrator rights to existing accounts whose verified email is in AdminEmails
+// Run at startup, as verification only covers addresses verified after they were listed; returns the number of accounts promoted
+// Accounts with unverified emails are skipped, since anyone can register an address they don't own
+func (s *UserService) PromoteAdmins() (int, error) {
+	promoted := 0
+	for _, email := range s.adminEmails {
+		user, err := s.userRepo.GetByEmail(email)
+		if err == domain.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			return promoted, fmt.Errorf("3004: failed to get user: %w", err)
+		}
+		if user.IsAdmin || !user.EmailVerified {
+			continue
+		}
+
+		user.IsAdmin = true
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(user); err != nil {
+			return promoted, fmt.Errorf("3004: failed to update user: %w", err)
+		}
+		promoted++
+	}
+
+	return promoted, nil
+}
+
+// isAdminEmail reports whether an email is listed as an administrator
+func (s *UserService) isAdminEmail(email string) bool {
+	for _, admin := range s.adminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// verificationEnabled reports whether verification emails can be sent
+func (s *UserService) verificationEnabled() bool {
+	return s.mailer != nil && s.signer != nil
+}
+
+// sendVerificationEmail emails the user a link containing a fresh verification token
+func (s *UserService) sendVerificationEmail(user *domain.User) error {
+	link := s.appURL + "/verify-email?token=" + url.QueryEscape(s.verificationToken(user, time.Now()))
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm this is your email address by opening this link within %d hours:\n\n"+
+			"%s\n\n"+
+			"If you didn't create an account, you can ignore this email.\n",
+			user.DisplayName, int(s.verifyTTL.Hours()), link),
+	})
+}
+
+// verificationToken creates a signed token for the user's current address that expires after the verification lifetime
+// The token is stateless: its payload names the user, address and expiry, and the signature makes it unforgeable
+func (s *UserService) verificationToken(user *domain.User, now time.Time) string {
+	payload := strings.Join([]string{
+		verificationPurpose,
+		user.ID,
+		user.Email,
+		strconv.FormatInt(now.Add(s.verifyTTL).Unix(), 10),
+	}, "\n")
+	return s.signer.Sign(base64.RawURLEncoding.EncodeToString([]byte(payload)))
+}
+
+// parseVerificationToken checks a verification token's signature and expiry
+// Returns the user ID and address the token was issued for
+func (s *UserService) parseVerificationToken(token string, now time.Time) (string, string, bool) {
+	encoded, ok := s.signer.Verify(token)
+	if !ok {
+		return "", "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+
+	fields := strings.Split(string(payload), "\n")
+	if len(fields) != 4 || fields[0] != verificationPurpose {
+		return "", "", false
+	}
+
+	expires, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", "", false
+	}
+
+	return fields[1], fields[2], true
+}
+
+// oidcUser returns the user an identity provider account signs in as
+// Uses the linked user if there is one, otherwise links the account with the same email, creating it if there is none
+func (s *UserService) oidcUser(claims *oidc.Claims, attempt domain.LoginAttempt) (*domain.User, error) {
+	issuer := s.oidcProvider.Issuer()
+
+	userID, err := s.oidcRepo.GetOIDCIdentity(issuer, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("3004: failed to get linked account: %w", err)
+	}
+	if userID != "" {
+		user, err := s.userRepo.GetByID(userID)
+		if err == nil {
+			return user, nil
+		}
+		// A link left behind by a deleted user is replaced below
+		if err != domain.ErrUserNotFound {
+			return nil, fmt.Errorf("3004: failed to get user: %w", err)
+		}
+	}
+
+	user, err := s.userRepo.GetByEmail(claims.Email)
+	if err != nil && err != domain.ErrUserNotFound {
+		return nil, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+	if user == nil {
+		if user, err = s.createOIDCUser(claims); err != nil {
+			return nil, err
+		}
+	} else if !user.EmailVerified {
+		if err := s.claimUnverifiedAccount(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.oidcRepo.LinkOIDCIdentity(issuer, claims.Subject, user.ID); err != nil {
+		return nil, fmt.Errorf("3004: failed to link identity provider account: %w", err)
+	}
+	s.addSecurityEvent(user.ID, domain.SecurityEventOIDCLinked, attempt, issuer)
+
+	return user, nil
+}
+
+// claimUnverifiedAccount hands an account whose email was never verified to the identity provider user who has verified it
+// Anyone could have registered the address, so the password is replaced with a random one and every session, API token and second factor is revoked
+func (s *UserService) claimUnverifiedAccount(user *domain.User) error {
+	password, err := newToken()
+	if err != nil {
+		return fmt.Errorf("3034: failed to generate password: %w", err)
+	}
+	if err := user.HashPassword(password); err != nil {
+		return fmt.Errorf("3006: failed to hash password: %w", err)
+	}
+	user.EmailVerified = true
+	user.IsAdmin = s.isAdminEmail(user.Email)
+	user.UpdatedAt = s.now()
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("3004: failed to update user: %w", err)
+	}
+
+	if err := s.userRepo.DeleteAllUserSessions(user.ID); err != nil {
+		return fmt.Errorf("3004: failed to delete sessions: %w", err)
+	}
+
+	if s.apiTokens != nil {
+		if _, err := s.apiTokens.DeleteAllUserAPITokens(user.ID); err != nil {
+			return fmt.Errorf("3004: failed to revoke API tokens: %w", err)
+		}
+	}
+
+	if s.twoFactor != nil {
+		if err := s.twoFactor.DisableTwoFactor(user.ID); err != nil {
+			return fmt.Errorf("3004: failed to disable two-factor authentication: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// createOIDCUser registers an account for a new identity provider user with a verified email
+// The account gets an unguessable random password; the user can choose one with a password reset
+func (s *UserService) createOIDCUser(claims *oidc.Claims) (*domain.User, error) {
+	if err := s.validateEmail(claims.Email); err != nil {
+		return nil, domain.ErrInvalidEmail
+	}
+
+	// Fall back to the mailbox name when the provider sends no usable name
+	displayName := strings.TrimSpace(claims.Name)
+	if err := s.validateDisplayName(displayName); err != nil {
+		displayName = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	now := s.now()
+	user := &domain.User{
+		ID:            uuid.New().String(),
+		Email:         claims.Email,
+		DisplayName:   displayName,
+		IsAdmin:       s.isAdminEmail(claims.Email),
+		EmailVerified: true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	password, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("3034: failed to generate password: %w", err)
+	}
+	if err := user.HashPassword(password); err != nil {
+		return nil, fmt.Errorf("3006: failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("3007: failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// beginLoginChallenge stores a login waiting for its second factor and returns the error that hands its token to the caller
+func (s *UserService) beginLoginChallenge(userID string, rememberMe bool, now time.Time) error {
+	token, err := newToken()
+	if err != nil {
+		return fmt.Errorf("3032: failed to generate login token: %w", err)
+	}
+
+	// Only a hash is stored, like password reset tokens
+	challenge := &domain.LoginChallenge{UserID: userID, RememberMe: rememberMe}
+	if err := s.twoFactor.SaveLoginChallenge(hashToken(token), challenge, loginChallengeTTL); err != nil {
+		return fmt.Errorf("3004: failed to save login challenge: %w", err)
+	}
+
+	return &domain.TwoFactorRequiredError{Token: token, ExpiresAt: now.Add(loginChallengeTTL)}
+}
+
+// recordSecondFactorFailure counts a wrong code against the login token and the account's failed logins
+// Returns the error for CompleteTwoFactorLogin to report: a *domain.AccountLockedError when this failure locked the account, otherwise domain.ErrInvalidTwoFactorCode
+func (s *UserService) recordSecondFactorFailure(userID, tokenHash string, attempt domain.LoginAttempt) error {
+	// The token is dropped after too many wrong codes, so the password has to be entered again
+	attempts, err := s.twoFactor.RecordLoginChallengeFailure(tokenHash)
+	if err != nil && err != domain.ErrInvalidLoginChallenge {
+		log.Printf("Failed to record two-factor failure for user %s: %v", userID, err)
+	}
+	if err == nil && attempts >= maxLoginChallengeAttempts {
+		if err := s.twoFactor.DeleteLoginChallenge(tokenHash); err != nil {
+			log.Printf("Failed to delete login challenge for user %s: %v", userID, err)
+		}
+	}
+
+	if err := s.recordLoginFailure(userID, attempt); err != domain.ErrInvalidCredentials {
+		return err
+	}
+	return domain.ErrInvalidTwoFactorCode
+}
+
+// confirmTwoFactorChange checks the password and a second factor before two-factor authentication is changed
+// Wrong passwords and codes count as failed logins, so locked accounts fail with a *domain.AccountLockedError
+// Returns the current settings and the time of the check
+func (s *UserService) confirmTwoFactorChange(userID, password, code string, client domain.SessionClient) (*domain.TwoFactor, time.Time, error) {
+	// Error code 3009: Required fields validation
+	if strings.TrimSpace(userID) == "" || password == "" || strings.TrimSpace(code) == "" {
+		return nil, time.Time{}, fmt.Errorf("3009: user ID, password and code are required")
+	}
+
+	// Error code 3030: Feature not configured
+	if s.twoFactor == nil {
+		return nil, time.Time{}, fmt.Errorf("3030: two-factor authentication is not configured")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, time.Time{}, domain.ErrUserNotFound
+		}
+		return nil, time.Time{}, fmt.Errorf("3004: failed to get user: %w", err)
+	}
+
+	attempt := domain.LoginAttempt{At: s.now(), IP: client.IP, UserAgent: client.UserAgent}
+	if err := s.checkPassword(user, password, attempt); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	twoFactor, err := s.twoFactor.GetTwoFactor(userID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("3004: failed to get two-factor settings: %w", err)
+	}
+	if twoFactor == nil {
+		return nil, time.Time{}, domain.ErrTwoFactorNotEnabled
+	}
+
+	if _, err := s.verifySecondFactor(userID, twoFactor, code, attempt.At); err != nil {
+		if err == domain.ErrInvalidTwoFactorCode {
+			if err := s.recordLoginFailure(userID, attempt); err != domain.ErrInvalidCredentials {
+				return nil, time.Time{}, err
+			}
+		}
+		return nil, time.Time{}, err
+	}
+
+	return twoFactor, attempt.At, nil
+}
+
+// verifySecondFactor checks a TOTP code or, failing that, redeems a recovery code
+// Each TOTP code and recovery code is accepted only once; reports whether a recovery code was used
+func (s *UserService) verifySecondFactor(userID string, twoFactor *domain.TwoFactor, code string, now time.Time) (bool, error) {
+	if step, ok := totp.Validate(twoFactor.Secret, code, now, totpSkew); ok {
+		fresh, err := s.twoFactor.UseTOTPStep(userID, step)
+		if err != nil {
+			return false, fmt.Errorf("3004: failed to record TOTP step: %w", err)
+		}
+		if !fresh {
+			return false, domain.ErrInvalidTwoFactorCode
+		}
+		return false, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false, domain.ErrInvalidTwoFactorCode
+	}
+
+	used, err := s.twoFactor.UseRecoveryCode(userID, hashToken(normalized))
+	if err != nil {
+		return false, fmt.Errorf("3004: failed to use recovery code: %w", err)
+	}
+	if !used {
+		return false, domain.ErrInvalidTwoFactorCode
+	}
+
+	return true, nil
+}
+
+// newRecoveryCodes generates a fresh set of recovery codes, formatted as xxxxx-xxxxx, and the hashes under which they are stored
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := recoveryCodeEncoding.EncodeToString(b)
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode lowercases a recovery code and removes the hyphen and any spaces users type
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// newToken generates a random URL-safe token for password reset links, login challenges and identity provider sign-ins
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash under which a password reset token or login token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes checks requested API token scopes and returns them without duplicates in a fixed order
+// Returns false if none are given or any is unknown
+func normalizeScopes(scopes []string) ([]string, bool) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[strings.TrimSpace(scope)] = true
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range domain.APITokenScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+			delete(requested, scope)
+		}
+	}
+	if len(normalized) == 0 || len(requested) > 0 {
+		return nil, false
+	}
+	return normalized, true
+}
+
 // validateEmail checks if the email format is valid
 // Uses regex to validate email format according to basic email rules
 func (s *UserService) validateEmail(email string) error {
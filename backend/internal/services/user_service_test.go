@@ -3,9 +3,18 @@ package services
 import (
 	"backend/internal/domain"
 	"backend/internal/mocks"
+	"backend/pkg/apitoken"
+	"backend/pkg/cookie"
+	"backend/pkg/mailer"
+	"backend/pkg/oidc"
+	"backend/pkg/oidc/oidctest"
+	"backend/pkg/totp"
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,7 +52,7 @@ func TestUserService_Register(t *testing.T) {
 						!user.IsAdmin
 				})).Return(nil)
 				// Should create a session
-				mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+				mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 			validateUser: func(t *testing.T, user *domain.User) {
@@ -154,7 +163,7 @@ func TestUserService_Register(t *testing.T) {
 			setupMock: func(mockRepo *mocks.MockUserRepository) {
 				mockRepo.On("GetByEmail", "test@example.com").Return(nil, domain.ErrUserNotFound)
 				mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
-				mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(errors.New("session error"))
+				mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(errors.New("session error"))
 			},
 			wantErr:       true,
 			expectedError: "failed to create session",
@@ -167,7 +176,7 @@ func TestUserService_Register(t *testing.T) {
 			tt.setupMock(mockRepo)
 
 			service := NewUserService(mockRepo)
-			user, sessionID, err := service.Register(tt.email, tt.displayName, tt.password)
+			user, sessionID, err := service.Register(tt.email, tt.displayName, tt.password, domain.SessionClient{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -213,7 +222,7 @@ func TestUserService_Login(t *testing.T) {
 			password: "Password123!",
 			setupMock: func(mockRepo *mocks.MockUserRepository) {
 				mockRepo.On("GetByEmail", "test@example.com").Return(testUser, nil)
-				mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string")).Return(nil)
+				mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 			},
 			wantErr: false,
 			validateUser: func(t *testing.T, user *domain.User) {
@@ -274,7 +283,7 @@ func TestUserService_Login(t *testing.T) {
 			password: "Password123!",
 			setupMock: func(mockRepo *mocks.MockUserRepository) {
 				mockRepo.On("GetByEmail", "test@example.com").Return(testUser, nil)
-				mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string")).Return(errors.New("session error"))
+				mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(errors.New("session error"))
 			},
 			wantErr:       true,
 			expectedError: "failed to create session",
@@ -287,7 +296,7 @@ func TestUserService_Login(t *testing.T) {
 			tt.setupMock(mockRepo)
 
 			service := NewUserService(mockRepo)
-			user, sessionID, err := service.Login(tt.email, tt.password)
+			user, sessionID, err := service.Login(tt.email, tt.password, false, domain.SessionClient{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -306,6 +315,35 @@ func TestUserService_Login(t *testing.T) {
 	}
 }
 
+func TestUserService_SessionLifetime(t *testing.T) {
+	testUser := &domain.User{ID: uuid.New().String(), Email: "test@example.com"}
+	testUser.HashPassword("Password123!")
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+
+	for _, rememberMe := range []bool{true, false} {
+		t.Run(fmt.Sprintf("login with remember me %v", rememberMe), func(t *testing.T) {
+			mockRepo := mocks.NewMockUserRepository(t)
+			mockRepo.On("GetByEmail", testUser.Email).Return(testUser, nil)
+			mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), rememberMe, client).Return(nil)
+
+			service := NewUserService(mockRepo)
+			_, _, err := service.Login(testUser.Email, "Password123!", rememberMe, client)
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("registration opens a session that is not remembered", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByEmail", "new@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), false, client).Return(nil)
+
+		service := NewUserService(mockRepo)
+		_, _, err := service.Register("new@example.com", "New User", "Password123!", client)
+		assert.NoError(t, err)
+	})
+}
+
 func TestUserService_Logout(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -489,33 +527,545 @@ func TestUserService_ErrorCodes(t *testing.T) {
 	service := NewUserService(mockRepo)
 
 	// Test error code 3001 - Invalid email format
-	_, _, err := service.Register("invalid-email", "Test User", "Password123!")
+	_, _, err := service.Register("invalid-email", "Test User", "Password123!", domain.SessionClient{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3001")
 
 	// Test error code 3002 - Display name validation
-	_, _, err = service.Register("test@example.com", "", "Password123!")
+	_, _, err = service.Register("test@example.com", "", "Password123!", domain.SessionClient{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3002")
 
 	// Test error code 3003 - Password requirements
-	_, _, err = service.Register("test@example.com", "Test User", "weak")
+	_, _, err = service.Register("test@example.com", "Test User", "weak", domain.SessionClient{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "3003")
 }
 
+func TestUserService_Sessions(t *testing.T) {
+	userID := uuid.New().String()
+	current := &domain.Session{ID: "current-session", UserID: userID}
+	other := &domain.Session{ID: "other-session", UserID: userID}
+
+	t.Run("list sessions marks the current one", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("ListUserSessions", userID).Return([]*domain.Session{other, current}, nil)
+
+		service := NewUserService(mockRepo)
+		sessions, err := service.ListSessions(userID, current.ID)
+
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+	})
+
+	t.Run("list sessions repository error", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("ListUserSessions", userID).Return(nil, errors.New("database error"))
+
+		service := NewUserService(mockRepo)
+		_, err := service.ListSessions(userID, current.ID)
+
+		assert.ErrorContains(t, err, "3004")
+	})
+
+	t.Run("revoke own session", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetSession", other.ID).Return(other, nil)
+		mockRepo.On("DeleteSession", other.ID).Return(nil)
+
+		service := NewUserService(mockRepo)
+		assert.NoError(t, service.RevokeSession(userID, other.ID))
+	})
+
+	t.Run("revoke another user's session reports not found", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetSession", other.ID).Return(other, nil)
+
+		service := NewUserService(mockRepo)
+		err := service.RevokeSession(uuid.New().String(), other.ID)
+
+		assert.Equal(t, domain.ErrSessionNotFound, err)
+	})
+
+	t.Run("revoke missing session", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetSession", "missing").Return(nil, domain.ErrSessionNotFound)
+
+		service := NewUserService(mockRepo)
+		err := service.RevokeSession(userID, "missing")
+
+		assert.Equal(t, domain.ErrSessionNotFound, err)
+	})
+
+	t.Run("revoke other sessions keeps the current one", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("DeleteOtherUserSessions", userID, current.ID).Return(2, nil)
+
+		service := NewUserService(mockRepo)
+		revoked, err := service.RevokeOtherSessions(userID, current.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, revoked)
+	})
+
+	t.Run("revoke other sessions requires the current session", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		_, err := service.RevokeOtherSessions(userID, "")
+
+		assert.ErrorContains(t, err, "3009")
+	})
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	userID := uuid.New().String()
+
+	t.Run("updates the display name", func(t *testing.T) {
+		user := &domain.User{ID: userID, DisplayName: "Old Name"}
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(user, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool {
+			return u.DisplayName == "New Name"
+		})).Return(nil)
+
+		service := NewUserService(mockRepo)
+		updated, err := service.UpdateProfile(userID, "  New Name ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", updated.DisplayName)
+	})
+
+	t.Run("rejects an invalid display name", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+
+		_, err := service.UpdateProfile(userID, "   ")
+		assert.Equal(t, domain.ErrInvalidDisplayName, err)
+
+		_, err = service.UpdateProfile(userID, strings.Repeat("a", 256))
+		assert.Equal(t, domain.ErrInvalidDisplayName, err)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(&domain.User{ID: userID}, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(errors.New("database error"))
+
+		service := NewUserService(mockRepo)
+		_, err := service.UpdateProfile(userID, "New Name")
+
+		assert.ErrorContains(t, err, "3004")
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	userID := uuid.New().String()
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "test"}
+
+	newUser := func(t *testing.T) *domain.User {
+		user := &domain.User{ID: userID}
+		require.NoError(t, user.HashPassword("OldPass1!"))
+		return user
+	}
+
+	t.Run("changes the password and replaces every session", func(t *testing.T) {
+		user := newUser(t)
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(user, nil)
+		mockRepo.On("GetSession", "current-session").Return(&domain.Session{ID: "current-session", UserID: userID, RememberMe: true}, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool {
+			return u.CheckPassword("NewPass2@")
+		})).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+		mockRepo.On("CreateSession", userID, mock.AnythingOfType("string"), true, client).Return(nil)
+
+		service := NewUserService(mockRepo)
+		sessionID, rememberMe, err := service.ChangePassword(userID, "current-session", "OldPass1!", "NewPass2@", client)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+		assert.NotEqual(t, "current-session", sessionID)
+		assert.True(t, rememberMe)
+	})
+
+	t.Run("changing the password also revokes API tokens", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockRepo.On("GetByID", userID).Return(newUser(t), nil)
+		mockRepo.On("GetSession", "current-session").Return(&domain.Session{ID: "current-session", UserID: userID}, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+		mockTokens.On("DeleteAllUserAPITokens", userID).Return(2, nil)
+		mockRepo.On("CreateSession", userID, mock.AnythingOfType("string"), false, client).Return(nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{APITokens: mockTokens})
+		_, _, err := service.ChangePassword(userID, "current-session", "OldPass1!", "NewPass2@", client)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("token revocation error", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockRepo.On("GetByID", userID).Return(newUser(t), nil)
+		mockRepo.On("GetSession", "current-session").Return(nil, domain.ErrSessionNotFound)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+		mockTokens.On("DeleteAllUserAPITokens", userID).Return(0, errors.New("database error"))
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{APITokens: mockTokens})
+		_, _, err := service.ChangePassword(userID, "current-session", "OldPass1!", "NewPass2@", client)
+
+		assert.ErrorContains(t, err, "3004")
+		mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a wrong current password", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(newUser(t), nil)
+
+		service := NewUserService(mockRepo)
+		_, _, err := service.ChangePassword(userID, "current-session", "WrongPass1!", "NewPass2@", client)
+
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("rejects a weak new password", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		_, _, err := service.ChangePassword(userID, "current-session", "OldPass1!", "weak", client)
+
+		assert.Equal(t, domain.ErrWeakPassword, err)
+	})
+
+	t.Run("session revocation error", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(newUser(t), nil)
+		mockRepo.On("GetSession", "current-session").Return(nil, domain.ErrSessionNotFound)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(errors.New("database error"))
+
+		service := NewUserService(mockRepo)
+		_, _, err := service.ChangePassword(userID, "current-session", "OldPass1!", "NewPass2@", client)
+
+		assert.ErrorContains(t, err, "3004")
+	})
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	userID := uuid.New().String()
+	user := &domain.User{ID: userID}
+	require.NoError(t, user.HashPassword("Pass123!"))
+
+	t.Run("deletes tasks, sessions and the user", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTasks := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetByID", userID).Return(user, nil)
+		mockTasks.On("DeleteUserTasks", userID).Return(3, nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+		mockRepo.On("Delete", userID).Return(nil)
+
+		service := NewUserServiceWithTasks(mockRepo, mockTasks)
+		assert.NoError(t, service.DeleteUser(userID, "Pass123!", domain.SessionClient{}))
+	})
+
+	t.Run("rejects a wrong password", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(user, nil)
+
+		service := NewUserServiceWithTasks(mockRepo, mocks.NewMockTaskRepository(t))
+		err := service.DeleteUser(userID, "WrongPass1!", domain.SessionClient{})
+
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("keeps the user when tasks cannot be deleted", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTasks := mocks.NewMockTaskRepository(t)
+		mockRepo.On("GetByID", userID).Return(user, nil)
+		mockTasks.On("DeleteUserTasks", userID).Return(0, errors.New("database error"))
+
+		service := NewUserServiceWithTasks(mockRepo, mockTasks)
+		err := service.DeleteUser(userID, "Pass123!", domain.SessionClient{})
+
+		assert.ErrorContains(t, err, "3004")
+	})
+
+	t.Run("requires a task repository", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		err := service.DeleteUser(userID, "Pass123!", domain.SessionClient{})
+
+		assert.ErrorContains(t, err, "3030")
+	})
+}
+
+// blockingMailer never finishes sending until the channel is closed
+type blockingMailer chan struct{}
+
+func (m blockingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	<-m
+	return nil
+}
+
+// recordingMailer captures sent messages and can be made to fail
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// sent returns the messages captured so far, for emails sent in the background
+func (m *recordingMailer) sent() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mailer.Message(nil), m.messages...)
+}
+
+func TestUserService_PasswordReset(t *testing.T) {
+	userID := uuid.New().String()
+	user := &domain.User{ID: userID, Email: "test@example.com", DisplayName: "Test User"}
+	require.NoError(t, user.HashPassword("Pass123!"))
+
+	newService := func(repo *mocks.MockUserRepository, m mailer.Mailer) *UserService {
+		return NewUserServiceWithOptions(repo, UserServiceOptions{
+			Mailer:           m,
+			AppURL:           "https://tasks.example.com",
+			PasswordResetTTL: 30 * time.Minute,
+		})
+	}
+
+	t.Run("emails a reset link and stores only the token hash", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		m := &recordingMailer{}
+		var storedHash string
+		mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+		mockRepo.On("SavePasswordResetToken", userID, mock.AnythingOfType("string"), 30*time.Minute).
+			Run(func(args mock.Arguments) { storedHash = args.String(1) }).Return(nil)
+
+		require.NoError(t, newService(mockRepo, m).RequestPasswordReset("test@example.com"))
+
+		require.Eventually(t, func() bool { return len(m.sent()) == 1 }, time.Second, 10*time.Millisecond)
+		msg := m.sent()[0]
+		assert.Equal(t, "test@example.com", msg.To)
+		assert.Contains(t, msg.Body, "30 minutes")
+
+		prefix := "https://tasks.example.com/reset-password?token="
+		start := strings.Index(msg.Body, prefix)
+		require.GreaterOrEqual(t, start, 0)
+		token := strings.Fields(msg.Body[start+len(prefix):])[0]
+		assert.NotEqual(t, token, storedHash)
+		assert.Equal(t, hashToken(token), storedHash)
+	})
+
+	t.Run("does nothing for unknown emails", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		m := &recordingMailer{}
+		mockRepo.On("GetByEmail", "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+		assert.NoError(t, newService(mockRepo, m).RequestPasswordReset("nobody@example.com"))
+		assert.Empty(t, m.sent())
+	})
+
+	t.Run("answers without waiting for the email", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+		mockRepo.On("SavePasswordResetToken", userID, mock.AnythingOfType("string"), 30*time.Minute).Return(nil)
+
+		// A failing mailer is only logged, so the response can't reveal that the account exists
+		err := newService(mockRepo, &recordingMailer{err: errors.New("connection refused")}).RequestPasswordReset("test@example.com")
+		assert.NoError(t, err)
+
+		slow := blockingMailer(make(chan struct{}))
+		defer close(slow)
+		assert.NoError(t, newService(mockRepo, slow).RequestPasswordReset("test@example.com"))
+	})
+
+	t.Run("requires a mailer", func(t *testing.T) {
+		err := NewUserService(mocks.NewMockUserRepository(t)).RequestPasswordReset("test@example.com")
+		assert.ErrorContains(t, err, "3030")
+	})
+
+	t.Run("resets the password and revokes sessions", func(t *testing.T) {
+		resetUser := *user
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("ConsumePasswordResetToken", hashToken("token-1")).Return(userID, nil)
+		mockRepo.On("GetByID", userID).Return(&resetUser, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+
+		require.NoError(t, newService(mockRepo, &recordingMailer{}).ResetPassword("token-1", "NewPass456!"))
+		assert.True(t, resetUser.CheckPassword("NewPass456!"))
+	})
+
+	t.Run("resetting also revokes API tokens", func(t *testing.T) {
+		resetUser := *user
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockRepo.On("ConsumePasswordResetToken", hashToken("token-1")).Return(userID, nil)
+		mockRepo.On("GetByID", userID).Return(&resetUser, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", userID).Return(nil)
+		mockTokens.On("DeleteAllUserAPITokens", userID).Return(2, nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Mailer: &recordingMailer{}, APITokens: mockTokens})
+		require.NoError(t, service.ResetPassword("token-1", "NewPass456!"))
+	})
+
+	t.Run("rejects a weak password without consuming the token", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+
+		err := newService(mockRepo, &recordingMailer{}).ResetPassword("token-1", "weak")
+		assert.Equal(t, domain.ErrWeakPassword, err)
+		mockRepo.AssertNotCalled(t, "ConsumePasswordResetToken", mock.Anything)
+	})
+
+	t.Run("rejects an invalid token", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("ConsumePasswordResetToken", hashToken("bogus")).Return("", domain.ErrInvalidResetToken)
+
+		err := newService(mockRepo, &recordingMailer{}).ResetPassword("bogus", "NewPass456!")
+		assert.Equal(t, domain.ErrInvalidResetToken, err)
+	})
+
+	t.Run("requires a token", func(t *testing.T) {
+		err := newService(mocks.NewMockUserRepository(t), &recordingMailer{}).ResetPassword("", "NewPass456!")
+		assert.ErrorContains(t, err, "3009")
+	})
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	signer := cookie.NewSigner("test-secret-key")
+	newService := func(repo *mocks.MockUserRepository, m mailer.Mailer) *UserService {
+		return NewUserServiceWithOptions(repo, UserServiceOptions{
+			Mailer:          m,
+			Signer:          signer,
+			AppURL:          "https://tasks.example.com",
+			VerificationTTL: 24 * time.Hour,
+		})
+	}
+	newUser := func() *domain.User {
+		return &domain.User{ID: "user-123", Email: "test@example.com", DisplayName: "Test User"}
+	}
+
+	t.Run("registration sends a verification link", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		m := &recordingMailer{}
+		mockRepo.On("GetByEmail", "test@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, mock.Anything, false, mock.Anything).Return(nil)
+
+		user, _, err := newService(mockRepo, m).Register("test@example.com", "Test User", "Pass123!", domain.SessionClient{})
+		require.NoError(t, err)
+		assert.False(t, user.EmailVerified)
+
+		require.Len(t, m.messages, 1)
+		assert.Equal(t, "test@example.com", m.messages[0].To)
+		assert.Contains(t, m.messages[0].Body, "https://tasks.example.com/verify-email?token=")
+		assert.Contains(t, m.messages[0].Body, "24 hours")
+	})
+
+	t.Run("registration succeeds when the email cannot be sent", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByEmail", "test@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, mock.Anything, false, mock.Anything).Return(nil)
+
+		_, _, err := newService(mockRepo, &recordingMailer{err: errors.New("connection refused")}).Register("test@example.com", "Test User", "Pass123!", domain.SessionClient{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("verifies the address the token was issued for", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		service := newService(mockRepo, &recordingMailer{})
+		token := service.verificationToken(newUser(), time.Now())
+		mockRepo.On("GetByID", "user-123").Return(newUser(), nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool { return u.EmailVerified })).Return(nil)
+
+		user, err := service.VerifyEmail(token)
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+	})
+
+	t.Run("verifying twice is harmless", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		service := newService(mockRepo, &recordingMailer{})
+		verified := newUser()
+		verified.EmailVerified = true
+		mockRepo.On("GetByID", "user-123").Return(verified, nil)
+
+		_, err := service.VerifyEmail(service.verificationToken(newUser(), time.Now()))
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		service := newService(mockRepo, &recordingMailer{})
+		changed := newUser()
+		changed.Email = "new@example.com"
+		mockRepo.On("GetByID", "user-123").Return(changed, nil).Maybe()
+
+		valid := service.verificationToken(newUser(), time.Now())
+		tokens := map[string]string{
+			"expired":        service.verificationToken(newUser(), time.Now().Add(-25*time.Hour)),
+			"tampered":       "x" + valid,
+			"other secret":   NewUserServiceWithOptions(mockRepo, UserServiceOptions{Signer: cookie.NewSigner("other-secret")}).verificationToken(newUser(), time.Now()),
+			"session cookie": signer.Sign(uuid.New().String()),
+			"earlier email":  valid,
+		}
+		for name, token := range tokens {
+			_, err := service.VerifyEmail(token)
+			assert.Equal(t, domain.ErrInvalidVerificationToken, err, name)
+		}
+	})
+
+	t.Run("resend fails once verified", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		verified := newUser()
+		verified.EmailVerified = true
+		mockRepo.On("GetByID", "user-123").Return(verified, nil)
+
+		err := newService(mockRepo, &recordingMailer{}).SendVerificationEmail("user-123")
+		assert.Equal(t, domain.ErrEmailAlreadyVerified, err)
+	})
+
+	t.Run("resend reports mail failures", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", "user-123").Return(newUser(), nil)
+
+		err := newService(mockRepo, &recordingMailer{err: errors.New("connection refused")}).SendVerificationEmail("user-123")
+		assert.ErrorContains(t, err, "3031")
+	})
+
+	t.Run("requires a mailer and signer", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		assert.ErrorContains(t, service.SendVerificationEmail("user-123"), "3030")
+		_, err := service.VerifyEmail("token")
+		assert.ErrorContains(t, err, "3030")
+	})
+}
+
 func BenchmarkUserService_Register(b *testing.B) {
 	mockRepo := mocks.NewMockUserRepository(b)
 	mockRepo.On("GetByEmail", mock.AnythingOfType("string")).Return(nil, domain.ErrUserNotFound)
 	mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
-	mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 
 	service := NewUserService(mockRepo)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		email := fmt.Sprintf("test%d@example.com", i)
-		service.Register(email, "Test User", "Password123!")
+		service.Register(email, "Test User", "Password123!", domain.SessionClient{})
 	}
 }
 
@@ -529,12 +1079,1083 @@ func BenchmarkUserService_Login(b *testing.B) {
 
 	mockRepo := mocks.NewMockUserRepository(b)
 	mockRepo.On("GetByEmail", "test@example.com").Return(testUser, nil)
-	mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string")).Return(nil)
+	mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 
 	service := NewUserService(mockRepo)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		service.Login("test@example.com", "Password123!")
+		service.Login("test@example.com", "Password123!", false, domain.SessionClient{})
+	}
+}
+func TestUserService_AccountLockout(t *testing.T) {
+	testUser := &domain.User{ID: uuid.New().String(), Email: "test@example.com"}
+	testUser.HashPassword("Password123!")
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+	policy := LockoutPolicy{Threshold: 5, Duration: 5 * time.Minute, MaxDuration: time.Hour}
+
+	setup := func(t *testing.T, policy LockoutPolicy) (*UserService, *mocks.MockUserRepository, *mocks.MockLoginAttemptRepository) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		mockRepo.On("GetByEmail", testUser.Email).Return(testUser, nil)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mockLogins, Lockout: policy})
+		return service, mockRepo, mockLogins
+	}
+	eventOfType := func(eventType domain.SecurityEventType) interface{} {
+		return mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == eventType && event.IP == client.IP && event.UserAgent == client.UserAgent
+		})
 	}
-}
\ No newline at end of file
+
+	t.Run("successful login is recorded", func(t *testing.T) {
+		service, mockRepo, mockLogins := setup(t, policy)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), false, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", testUser.ID, mock.MatchedBy(func(attempt domain.LoginAttempt) bool {
+			return attempt.IP == client.IP && attempt.UserAgent == client.UserAgent && !attempt.At.IsZero()
+		})).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventLoginSucceeded)).Return(nil)
+
+		_, sessionID, err := service.Login(testUser.Email, "Password123!", false, client)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+	})
+
+	t.Run("failure below the threshold is recorded", func(t *testing.T) {
+		service, _, mockLogins := setup(t, policy)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(4, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventLoginFailed)).Return(nil)
+
+		_, _, err := service.Login(testUser.Email, "Wrong123!", false, client)
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("reaching the threshold locks the account", func(t *testing.T) {
+		service, _, mockLogins := setup(t, policy)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(5, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventLoginFailed)).Return(nil)
+		mockLogins.On("LockAccount", testUser.ID, mock.MatchedBy(func(until time.Time) bool {
+			return until.Sub(time.Now()) > 4*time.Minute && until.Sub(time.Now()) <= 5*time.Minute
+		})).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventAccountLocked && strings.Contains(event.Detail, "after 5 failed logins")
+		})).Return(nil)
+
+		_, _, err := service.Login(testUser.Email, "Wrong123!", false, client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+		var locked *domain.AccountLockedError
+		require.ErrorAs(t, err, &locked)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), locked.Until, time.Second)
+	})
+
+	t.Run("locked account is rejected without checking the password", func(t *testing.T) {
+		service, _, mockLogins := setup(t, policy)
+		until := time.Now().Add(time.Minute)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(until, nil)
+
+		for _, password := range []string{"Password123!", "Wrong123!"} {
+			_, sessionID, err := service.Login(testUser.Email, password, false, client)
+			var locked *domain.AccountLockedError
+			require.ErrorAs(t, err, &locked)
+			assert.Equal(t, until, locked.Until)
+			assert.Empty(t, sessionID)
+		}
+	})
+
+	t.Run("password confirmations count as failed logins", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Tasks: mocks.NewMockTaskRepository(t), Logins: mockLogins, Lockout: policy})
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.MatchedBy(func(attempt domain.LoginAttempt) bool {
+			return attempt.IP == client.IP && attempt.UserAgent == client.UserAgent
+		})).Return(5, nil).Twice()
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventLoginFailed)).Return(nil).Twice()
+		mockLogins.On("LockAccount", testUser.ID, mock.AnythingOfType("time.Time")).Return(nil).Twice()
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventAccountLocked)).Return(nil).Twice()
+
+		_, _, err := service.ChangePassword(testUser.ID, "", "Wrong123!", "NewPass2@", client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+
+		err = service.DeleteUser(testUser.ID, "Wrong123!", client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+	})
+
+	t.Run("locked account cannot change its password or delete itself", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Tasks: mocks.NewMockTaskRepository(t), Logins: mockLogins, Lockout: policy})
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Now().Add(time.Minute), nil)
+
+		_, _, err := service.ChangePassword(testUser.ID, "", "Password123!", "NewPass2@", client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+
+		err = service.DeleteUser(testUser.ID, "Password123!", client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+	})
+
+	t.Run("lock check failure is reported", func(t *testing.T) {
+		service, _, mockLogins := setup(t, policy)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, errors.New("redis down"))
+
+		_, _, err := service.Login(testUser.Email, "Password123!", false, client)
+		assert.ErrorContains(t, err, "3004")
+	})
+
+	t.Run("tracking failures do not change the outcome", func(t *testing.T) {
+		service, mockRepo, mockLogins := setup(t, policy)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.Anything).Return(0, errors.New("redis down"))
+		mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), false, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", testUser.ID, mock.Anything).Return(errors.New("redis down"))
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.Anything).Return(errors.New("redis down"))
+
+		_, _, err := service.Login(testUser.Email, "Wrong123!", false, client)
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+
+		_, sessionID, err := service.Login(testUser.Email, "Password123!", false, client)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+	})
+
+	t.Run("zero threshold never locks", func(t *testing.T) {
+		service, _, mockLogins := setup(t, LockoutPolicy{})
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.Anything).Return(100, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, eventOfType(domain.SecurityEventLoginFailed)).Return(nil)
+
+		_, _, err := service.Login(testUser.Email, "Wrong123!", false, client)
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("lockout doubles with each further failure up to the maximum", func(t *testing.T) {
+		service := NewUserServiceWithOptions(mocks.NewMockUserRepository(t), UserServiceOptions{Lockout: policy})
+
+		expected := map[int]time.Duration{
+			5:  5 * time.Minute,
+			6:  10 * time.Minute,
+			7:  20 * time.Minute,
+			8:  40 * time.Minute,
+			9:  time.Hour,
+			64: time.Hour,
+		}
+		for failures, duration := range expected {
+			assert.Equal(t, duration, service.lockoutDuration(failures), "after %d failures", failures)
+		}
+	})
+}
+
+func TestUserService_LoginActivity(t *testing.T) {
+	userID := uuid.New().String()
+
+	t.Run("returns activity with recent events", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		events := []*domain.SecurityEvent{{Type: domain.SecurityEventLoginFailed, At: time.Now()}}
+		mockRepo.On("GetByID", userID).Return(&domain.User{ID: userID}, nil)
+		mockLogins.On("GetLoginActivity", userID).Return(&domain.LoginActivity{
+			FailedAttempts: 2,
+			LockedUntil:    time.Now().Add(-time.Second),
+		}, nil)
+		mockLogins.On("ListSecurityEvents", userID, securityEventLimit).Return(events, nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mockLogins})
+		activity, err := service.GetLoginActivity(userID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, activity.FailedAttempts)
+		assert.True(t, activity.LockedUntil.IsZero(), "an expired lock should not be reported")
+		assert.Equal(t, events, activity.Events)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(nil, domain.ErrUserNotFound)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mocks.NewMockLoginAttemptRepository(t)})
+		_, err := service.GetLoginActivity(userID)
+		assert.Equal(t, domain.ErrUserNotFound, err)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		_, err := service.GetLoginActivity(userID)
+		assert.ErrorContains(t, err, "3030")
+	})
+}
+
+func TestUserService_UnlockAccount(t *testing.T) {
+	adminID := uuid.New().String()
+	userID := uuid.New().String()
+
+	t.Run("clears failures and records an event", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		mockRepo.On("GetByID", userID).Return(&domain.User{ID: userID}, nil)
+		mockLogins.On("ClearLoginFailures", userID).Return(nil)
+		mockLogins.On("AddSecurityEvent", userID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventAccountUnlocked && event.IP == ""
+		})).Return(nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mockLogins})
+		assert.NoError(t, service.UnlockAccount(adminID, userID))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByID", userID).Return(nil, domain.ErrUserNotFound)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mocks.NewMockLoginAttemptRepository(t)})
+		assert.Equal(t, domain.ErrUserNotFound, service.UnlockAccount(adminID, userID))
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		mockRepo.On("GetByID", userID).Return(&domain.User{ID: userID}, nil)
+		mockLogins.On("ClearLoginFailures", userID).Return(errors.New("redis down"))
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{Logins: mockLogins})
+		assert.ErrorContains(t, service.UnlockAccount(adminID, userID), "3004")
+	})
+
+	t.Run("missing IDs and missing configuration", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+		assert.ErrorContains(t, service.UnlockAccount("", userID), "3009")
+		assert.ErrorContains(t, service.UnlockAccount(adminID, userID), "3030")
+	})
+}
+
+func TestUserService_AdminEmails(t *testing.T) {
+	t.Run("listed emails do not register as administrators", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByEmail", "admin@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return !user.IsAdmin })).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, mock.Anything, false, mock.Anything).Return(nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{AdminEmails: []string{"Admin@Example.com"}})
+		user, _, err := service.Register("admin@example.com", "Admin", "Password123!", domain.SessionClient{})
+		require.NoError(t, err)
+		assert.False(t, user.IsAdmin)
+	})
+
+	t.Run("verifying a listed email grants administrator rights", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{
+			Signer:      cookie.NewSigner("test-secret-key"),
+			AdminEmails: []string{"admin@example.com"},
+		})
+		unverified := &domain.User{ID: "1", Email: "admin@example.com"}
+		token := service.verificationToken(unverified, time.Now())
+		mockRepo.On("GetByID", "1").Return(unverified, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(user *domain.User) bool { return user.EmailVerified && user.IsAdmin })).Return(nil)
+
+		user, err := service.VerifyEmail(token)
+		require.NoError(t, err)
+		assert.True(t, user.IsAdmin)
+	})
+
+	t.Run("existing verified accounts are promoted", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockRepo.On("GetByEmail", "admin@example.com").Return(&domain.User{ID: "1", Email: "admin@example.com", EmailVerified: true}, nil)
+		mockRepo.On("GetByEmail", "already@example.com").Return(&domain.User{ID: "2", Email: "already@example.com", EmailVerified: true, IsAdmin: true}, nil)
+		mockRepo.On("GetByEmail", "unverified@example.com").Return(&domain.User{ID: "3", Email: "unverified@example.com"}, nil)
+		mockRepo.On("GetByEmail", "missing@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Update", mock.MatchedBy(func(user *domain.User) bool { return user.ID == "1" && user.IsAdmin })).Return(nil)
+
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{
+			AdminEmails: []string{"admin@example.com", "already@example.com", "unverified@example.com", "missing@example.com"},
+		})
+		promoted, err := service.PromoteAdmins()
+		require.NoError(t, err)
+		assert.Equal(t, 1, promoted)
+	})
+}
+
+func TestUserService_TwoFactorSetup(t *testing.T) {
+	testUser := &domain.User{ID: uuid.New().String(), Email: "test@example.com"}
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+	now := time.Unix(1700000000, 0)
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	setup := func(t *testing.T) (*UserService, *mocks.MockUserRepository, *mocks.MockTwoFactorRepository, *mocks.MockLoginAttemptRepository) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTwoFactor := mocks.NewMockTwoFactorRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{TwoFactor: mockTwoFactor, Logins: mockLogins, TOTPIssuer: "Tasks"})
+		service.now = func() time.Time { return now }
+		return service, mockRepo, mockTwoFactor, mockLogins
+	}
+
+	t.Run("setup returns a secret and otpauth URI", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, _ := setup(t)
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+		var saved string
+		mockTwoFactor.On("SaveTwoFactorSetup", testUser.ID, mock.AnythingOfType("string"), twoFactorSetupTTL).
+			Run(func(args mock.Arguments) { saved = args.String(1) }).Return(nil)
+
+		result, err := service.BeginTwoFactorSetup(testUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, saved, result.Secret)
+		assert.Len(t, result.Secret, 32)
+		assert.True(t, strings.HasPrefix(result.URI, "otpauth://totp/Tasks:test@example.com?"))
+		assert.Contains(t, result.URI, "secret="+result.Secret)
+		assert.Equal(t, now.Add(twoFactorSetupTTL), result.ExpiresAt)
+	})
+
+	t.Run("setup fails when already enabled", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, _ := setup(t)
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(&domain.TwoFactor{Secret: secret}, nil)
+
+		_, err := service.BeginTwoFactorSetup(testUser.ID)
+		assert.Equal(t, domain.ErrTwoFactorAlreadyEnabled, err)
+	})
+
+	t.Run("enable confirms the code and returns recovery codes", func(t *testing.T) {
+		service, _, mockTwoFactor, mockLogins := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+		mockTwoFactor.On("GetTwoFactorSetup", testUser.ID).Return(secret, nil)
+		mockTwoFactor.On("UseTOTPStep", testUser.ID, now.Unix()/30).Return(true, nil)
+		var hashes []string
+		mockTwoFactor.On("EnableTwoFactor", testUser.ID, secret, mock.AnythingOfType("[]string"), now).
+			Run(func(args mock.Arguments) { hashes = args.Get(2).([]string) }).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventTwoFactorEnabled && event.IP == client.IP
+		})).Return(nil)
+
+		code, err := totp.Code(secret, now)
+		require.NoError(t, err)
+
+		codes, err := service.EnableTwoFactor(testUser.ID, code, client)
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+		require.Len(t, hashes, recoveryCodeCount)
+		for i, recoveryCode := range codes {
+			assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, recoveryCode)
+			assert.Equal(t, hashToken(normalizeRecoveryCode(recoveryCode)), hashes[i], "only hashes are stored")
+		}
+	})
+
+	t.Run("enable accepts a code from the previous step but not older", func(t *testing.T) {
+		service, _, mockTwoFactor, _ := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+		mockTwoFactor.On("GetTwoFactorSetup", testUser.ID).Return(secret, nil)
+
+		stale, err := totp.Code(secret, now.Add(-2*totp.Period))
+		require.NoError(t, err)
+
+		_, err = service.EnableTwoFactor(testUser.ID, stale, client)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, err)
+	})
+
+	t.Run("enable without setup fails", func(t *testing.T) {
+		service, _, mockTwoFactor, _ := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+		mockTwoFactor.On("GetTwoFactorSetup", testUser.ID).Return("", nil)
+
+		_, err := service.EnableTwoFactor(testUser.ID, "123456", client)
+		assert.Equal(t, domain.ErrTwoFactorSetupNotFound, err)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+
+		_, err := service.BeginTwoFactorSetup(testUser.ID)
+		assert.ErrorContains(t, err, "3030")
+		_, err = service.EnableTwoFactor(testUser.ID, "123456", client)
+		assert.ErrorContains(t, err, "3030")
+		_, _, _, err = service.CompleteTwoFactorLogin("token", "123456", client)
+		assert.ErrorContains(t, err, "3030")
+	})
+}
+
+func TestUserService_TwoFactorLogin(t *testing.T) {
+	testUser := &domain.User{ID: uuid.New().String(), Email: "test@example.com"}
+	testUser.HashPassword("Password123!")
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+	now := time.Unix(1700000000, 0)
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	twoFactor := &domain.TwoFactor{Secret: secret, EnabledAt: now.Add(-time.Hour), RecoveryCodesRemaining: 3}
+	challenge := &domain.LoginChallenge{UserID: testUser.ID, RememberMe: true}
+
+	setup := func(t *testing.T) (*UserService, *mocks.MockUserRepository, *mocks.MockTwoFactorRepository, *mocks.MockLoginAttemptRepository) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTwoFactor := mocks.NewMockTwoFactorRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{
+			TwoFactor: mockTwoFactor,
+			Logins:    mockLogins,
+			Lockout:   LockoutPolicy{Threshold: 5},
+		})
+		service.now = func() time.Time { return now }
+		return service, mockRepo, mockTwoFactor, mockLogins
+	}
+	// startChallenge expects a lookup of a stored challenge and the user it belongs to
+	startChallenge := func(mockRepo *mocks.MockUserRepository, mockTwoFactor *mocks.MockTwoFactorRepository, mockLogins *mocks.MockLoginAttemptRepository) {
+		mockTwoFactor.On("GetLoginChallenge", hashToken("token-1")).Return(challenge, nil)
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+	}
+
+	t.Run("password login asks for a second factor", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		mockRepo.On("GetByEmail", testUser.Email).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+		var savedHash string
+		mockTwoFactor.On("SaveLoginChallenge", mock.AnythingOfType("string"), &domain.LoginChallenge{UserID: testUser.ID, RememberMe: true}, loginChallengeTTL).
+			Run(func(args mock.Arguments) { savedHash = args.String(0) }).Return(nil)
+
+		user, sessionID, err := service.Login(testUser.Email, "Password123!", true, client)
+		assert.Nil(t, user)
+		assert.Empty(t, sessionID, "no session before the second factor")
+		assert.ErrorIs(t, err, domain.ErrTwoFactorRequired)
+
+		var required *domain.TwoFactorRequiredError
+		require.ErrorAs(t, err, &required)
+		assert.NotEmpty(t, required.Token)
+		assert.Equal(t, hashToken(required.Token), savedHash, "only the hash is stored")
+		assert.Equal(t, now.Add(loginChallengeTTL), required.ExpiresAt)
+	})
+
+	t.Run("users without two-factor authentication log in directly", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		mockRepo.On("GetByEmail", testUser.Email).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+		mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), false, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", testUser.ID, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent}).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.AnythingOfType("*domain.SecurityEvent")).Return(nil)
+
+		_, sessionID, err := service.Login(testUser.Email, "Password123!", false, client)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+	})
+
+	t.Run("a valid TOTP code completes the login", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("UseTOTPStep", testUser.ID, now.Unix()/30).Return(true, nil)
+		mockTwoFactor.On("DeleteLoginChallenge", hashToken("token-1")).Return(nil)
+		mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), true, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventLoginSucceeded
+		})).Return(nil)
+
+		code, _ := totp.Code(secret, now)
+		user, sessionID, rememberMe, err := service.CompleteTwoFactorLogin("token-1", code, client)
+		require.NoError(t, err)
+		assert.Equal(t, testUser, user)
+		assert.NotEmpty(t, sessionID)
+		assert.True(t, rememberMe)
+	})
+
+	t.Run("a code that was already used is rejected", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("UseTOTPStep", testUser.ID, now.Unix()/30).Return(false, nil)
+		mockTwoFactor.On("RecordLoginChallengeFailure", hashToken("token-1")).Return(1, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(1, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.AnythingOfType("*domain.SecurityEvent")).Return(nil)
+
+		code, _ := totp.Code(secret, now)
+		_, _, _, err := service.CompleteTwoFactorLogin("token-1", code, client)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, err)
+		mockTwoFactor.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything)
+	})
+
+	t.Run("codes expire as the clock moves on", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("RecordLoginChallengeFailure", hashToken("token-1")).Return(1, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(1, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.AnythingOfType("*domain.SecurityEvent")).Return(nil)
+
+		code, _ := totp.Code(secret, now)
+		service.now = func() time.Time { return now.Add(2 * totp.Period) }
+
+		_, _, _, err := service.CompleteTwoFactorLogin("token-1", code, client)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, err)
+	})
+
+	t.Run("a recovery code completes the login once", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("UseRecoveryCode", testUser.ID, hashToken("abcde23456")).Return(true, nil)
+		mockTwoFactor.On("DeleteLoginChallenge", hashToken("token-1")).Return(nil)
+		mockRepo.On("CreateSession", testUser.ID, mock.AnythingOfType("string"), true, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventLoginSucceeded
+		})).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventRecoveryCodeUsed && event.Detail == "2 recovery codes left"
+		})).Return(nil)
+
+		_, sessionID, _, err := service.CompleteTwoFactorLogin("token-1", " ABCDE-23456 ", client)
+		require.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+	})
+
+	t.Run("too many wrong codes drop the login token", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("UseRecoveryCode", testUser.ID, mock.AnythingOfType("string")).Return(false, nil)
+		mockTwoFactor.On("RecordLoginChallengeFailure", hashToken("token-1")).Return(maxLoginChallengeAttempts, nil)
+		mockTwoFactor.On("DeleteLoginChallenge", hashToken("token-1")).Return(nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(2, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.AnythingOfType("*domain.SecurityEvent")).Return(nil)
+
+		_, _, _, err := service.CompleteTwoFactorLogin("token-1", "wrong-codes", client)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, err)
+	})
+
+	t.Run("wrong codes count towards the lockout", func(t *testing.T) {
+		service, mockRepo, mockTwoFactor, mockLogins := setup(t)
+		startChallenge(mockRepo, mockTwoFactor, mockLogins)
+		mockTwoFactor.On("RecordLoginChallengeFailure", hashToken("token-1")).Return(1, nil)
+		mockLogins.On("RecordLoginFailure", testUser.ID, mock.AnythingOfType("domain.LoginAttempt")).Return(5, nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.AnythingOfType("*domain.SecurityEvent")).Return(nil)
+		mockLogins.On("LockAccount", testUser.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		_, _, _, err := service.CompleteTwoFactorLogin("token-1", "000000", client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+	})
+
+	t.Run("unknown or expired tokens are rejected", func(t *testing.T) {
+		service, _, mockTwoFactor, _ := setup(t)
+		mockTwoFactor.On("GetLoginChallenge", hashToken("expired")).Return(nil, domain.ErrInvalidLoginChallenge)
+
+		_, _, _, err := service.CompleteTwoFactorLogin("expired", "123456", client)
+		assert.Equal(t, domain.ErrInvalidLoginChallenge, err)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		service, _, _, _ := setup(t)
+
+		_, _, _, err := service.CompleteTwoFactorLogin("", "123456", client)
+		assert.ErrorContains(t, err, "3009")
+		_, _, _, err = service.CompleteTwoFactorLogin("token-1", " ", client)
+		assert.ErrorContains(t, err, "3009")
+	})
+}
+
+func TestUserService_TwoFactorChanges(t *testing.T) {
+	testUser := &domain.User{ID: uuid.New().String(), Email: "test@example.com"}
+	testUser.HashPassword("Password123!")
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Firefox"}
+	now := time.Unix(1700000000, 0)
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	twoFactor := &domain.TwoFactor{Secret: secret, EnabledAt: now.Add(-time.Hour), RecoveryCodesRemaining: 3}
+
+	setup := func(t *testing.T) (*UserService, *mocks.MockUserRepository, *mocks.MockTwoFactorRepository, *mocks.MockLoginAttemptRepository) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockTwoFactor := mocks.NewMockTwoFactorRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{TwoFactor: mockTwoFactor, Logins: mockLogins})
+		service.now = func() time.Time { return now }
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil).Maybe()
+		mockLogins.On("GetAccountLock", testUser.ID).Return(time.Time{}, nil).Maybe()
+		return service, mockRepo, mockTwoFactor, mockLogins
+	}
+	failedLogin := func(mockLogins *mocks.MockLoginAttemptRepository) {
+		mockLogins.On("RecordLoginFailure", testUser.ID, domain.LoginAttempt{At: now, IP: client.IP, UserAgent: client.UserAgent}).Return(1, nil).Once()
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventLoginFailed
+		})).Return(nil).Once()
+	}
+	code, _ := totp.Code(secret, now)
+
+	t.Run("disable with password and code", func(t *testing.T) {
+		service, _, mockTwoFactor, mockLogins := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+		mockTwoFactor.On("UseTOTPStep", testUser.ID, now.Unix()/30).Return(true, nil)
+		mockTwoFactor.On("DisableTwoFactor", testUser.ID).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventTwoFactorDisabled
+		})).Return(nil)
+
+		assert.NoError(t, service.DisableTwoFactor(testUser.ID, "Password123!", code, client))
+	})
+
+	t.Run("disable requires the password", func(t *testing.T) {
+		service, _, _, mockLogins := setup(t)
+		failedLogin(mockLogins)
+
+		err := service.DisableTwoFactor(testUser.ID, "Wrong123!", code, client)
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("disable requires a valid code", func(t *testing.T) {
+		service, _, mockTwoFactor, mockLogins := setup(t)
+		failedLogin(mockLogins)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+		mockTwoFactor.On("UseRecoveryCode", testUser.ID, hashToken("abcde23456")).Return(false, nil)
+
+		err := service.DisableTwoFactor(testUser.ID, "Password123!", "abcde-23456", client)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, err)
+	})
+
+	t.Run("locked accounts cannot change two-factor authentication", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{TwoFactor: mocks.NewMockTwoFactorRepository(t), Logins: mockLogins})
+		service.now = func() time.Time { return now }
+		mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+		mockLogins.On("GetAccountLock", testUser.ID).Return(now.Add(time.Minute), nil)
+
+		err := service.DisableTwoFactor(testUser.ID, "Password123!", code, client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+
+		_, err = service.RegenerateRecoveryCodes(testUser.ID, "Password123!", code, client)
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+	})
+
+	t.Run("disable when not enabled", func(t *testing.T) {
+		service, _, mockTwoFactor, _ := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(nil, nil)
+
+		err := service.DisableTwoFactor(testUser.ID, "Password123!", code, client)
+		assert.Equal(t, domain.ErrTwoFactorNotEnabled, err)
+	})
+
+	t.Run("regenerate replaces the recovery codes", func(t *testing.T) {
+		service, _, mockTwoFactor, mockLogins := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+		mockTwoFactor.On("UseRecoveryCode", testUser.ID, hashToken("abcde23456")).Return(true, nil)
+		var hashes []string
+		mockTwoFactor.On("ReplaceRecoveryCodes", testUser.ID, mock.AnythingOfType("[]string")).
+			Run(func(args mock.Arguments) { hashes = args.Get(1).([]string) }).Return(nil)
+		mockLogins.On("AddSecurityEvent", testUser.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventRecoveryCodesRegenerated
+		})).Return(nil)
+
+		codes, err := service.RegenerateRecoveryCodes(testUser.ID, "Password123!", "abcde-23456", client)
+		require.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.Len(t, hashes, recoveryCodeCount)
+		assert.NotContains(t, hashes, hashToken("abcde23456"))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		service, _, mockTwoFactor, _ := setup(t)
+		mockTwoFactor.On("GetTwoFactor", testUser.ID).Return(twoFactor, nil)
+
+		status, err := service.GetTwoFactorStatus(testUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, twoFactor, status)
+	})
+}
+
+func TestUserService_APITokens(t *testing.T) {
+	userID := uuid.New().String()
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "curl/8.0"}
+	now := time.Unix(1700000000, 0)
+
+	setup := func(t *testing.T) (*UserService, *mocks.MockAPITokenRepository, *mocks.MockLoginAttemptRepository) {
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mocks.NewMockUserRepository(t), UserServiceOptions{APITokens: mockTokens, Logins: mockLogins})
+		service.now = func() time.Time { return now }
+		return service, mockTokens, mockLogins
+	}
+
+	t.Run("create stores only the hash and returns the token once", func(t *testing.T) {
+		service, mockTokens, mockLogins := setup(t)
+		var stored *domain.APIToken
+		mockTokens.On("CreateAPIToken", mock.AnythingOfType("*domain.APIToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(*domain.APIToken) }).Return(nil)
+		mockLogins.On("AddSecurityEvent", userID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventAPITokenCreated && event.Detail == "CI" && event.IP == "192.0.2.1"
+		})).Return(nil)
+
+		token, raw, err := service.CreateAPIToken(userID, " CI ", []string{"tasks:write", "tasks:read", "tasks:write"}, 0, client)
+		require.NoError(t, err)
+		assert.Same(t, stored, token)
+
+		assert.True(t, strings.HasPrefix(raw, apitoken.Prefix))
+		hash, ok := apitoken.Hash(raw)
+		require.True(t, ok)
+		assert.Equal(t, hash, token.TokenHash)
+		assert.NotContains(t, token.TokenHash, raw)
+
+		assert.NotEmpty(t, token.ID)
+		assert.Equal(t, userID, token.UserID)
+		assert.Equal(t, "CI", token.Name)
+		assert.Equal(t, []string{domain.ScopeTasksRead, domain.ScopeTasksWrite}, token.Scopes)
+		assert.Equal(t, now, token.CreatedAt)
+		assert.Equal(t, now.Add(defaultAPITokenTTL), token.ExpiresAt)
+		assert.Nil(t, token.LastUsedAt)
+	})
+
+	t.Run("create uses the requested lifetime", func(t *testing.T) {
+		service, mockTokens, mockLogins := setup(t)
+		mockTokens.On("CreateAPIToken", mock.AnythingOfType("*domain.APIToken")).Return(nil)
+		mockLogins.On("AddSecurityEvent", userID, mock.Anything).Return(nil)
+
+		token, _, err := service.CreateAPIToken(userID, "CI", []string{"tasks:read"}, 7*24*time.Hour, client)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(7*24*time.Hour), token.ExpiresAt)
+	})
+
+	t.Run("create validates the request", func(t *testing.T) {
+		service, _, _ := setup(t)
+
+		tests := []struct {
+			name     string
+			scopes   []string
+			ttl      time.Duration
+			expected error
+		}{
+			{name: "", scopes: []string{"tasks:read"}, expected: domain.ErrInvalidAPITokenName},
+			{name: "   ", scopes: []string{"tasks:read"}, expected: domain.ErrInvalidAPITokenName},
+			{name: strings.Repeat("a", maxAPITokenNameLength+1), scopes: []string{"tasks:read"}, expected: domain.ErrInvalidAPITokenName},
+			{name: "CI", expected: domain.ErrInvalidAPITokenScopes},
+			{name: "CI", scopes: []string{"tasks:read", "admin"}, expected: domain.ErrInvalidAPITokenScopes},
+			{name: "CI", scopes: []string{"tasks:read"}, ttl: -time.Hour, expected: domain.ErrInvalidAPITokenExpiry},
+			{name: "CI", scopes: []string{"tasks:read"}, ttl: maxAPITokenTTL + time.Hour, expected: domain.ErrInvalidAPITokenExpiry},
+		}
+		for _, tt := range tests {
+			_, _, err := service.CreateAPIToken(userID, tt.name, tt.scopes, tt.ttl, client)
+			assert.Equal(t, tt.expected, err, "name %q scopes %v ttl %v", tt.name, tt.scopes, tt.ttl)
+		}
+
+		_, _, err := service.CreateAPIToken("", "CI", []string{"tasks:read"}, 0, client)
+		assert.ErrorContains(t, err, "3009")
+	})
+
+	t.Run("create reports storage failures", func(t *testing.T) {
+		service, mockTokens, _ := setup(t)
+		mockTokens.On("CreateAPIToken", mock.Anything).Return(errors.New("redis unavailable"))
+
+		_, _, err := service.CreateAPIToken(userID, "CI", []string{"tasks:read"}, 0, client)
+		assert.ErrorContains(t, err, "3033")
+	})
+
+	t.Run("list returns the user's tokens", func(t *testing.T) {
+		service, mockTokens, _ := setup(t)
+		tokens := []*domain.APIToken{{ID: "token-1", UserID: userID}}
+		mockTokens.On("ListUserAPITokens", userID).Return(tokens, nil)
+
+		listed, err := service.ListAPITokens(userID)
+		require.NoError(t, err)
+		assert.Equal(t, tokens, listed)
+	})
+
+	t.Run("revoke deletes the token and records an event", func(t *testing.T) {
+		service, mockTokens, mockLogins := setup(t)
+		mockTokens.On("DeleteAPIToken", userID, "token-1").Return(nil)
+		mockLogins.On("AddSecurityEvent", userID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventAPITokenRevoked && event.Detail == "token-1"
+		})).Return(nil)
+
+		require.NoError(t, service.RevokeAPIToken(userID, "token-1", client))
+	})
+
+	t.Run("revoke reports unknown tokens", func(t *testing.T) {
+		service, mockTokens, _ := setup(t)
+		mockTokens.On("DeleteAPIToken", userID, "token-2").Return(domain.ErrAPITokenNotFound)
+
+		assert.Equal(t, domain.ErrAPITokenNotFound, service.RevokeAPIToken(userID, "token-2", client))
+	})
+
+	t.Run("requires the token repository", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+
+		_, _, err := service.CreateAPIToken(userID, "CI", []string{"tasks:read"}, 0, client)
+		assert.ErrorContains(t, err, "3030")
+		_, err = service.ListAPITokens(userID)
+		assert.ErrorContains(t, err, "3030")
+		assert.ErrorContains(t, service.RevokeAPIToken(userID, "token-1", client), "3030")
+	})
+}
+
+func TestUserService_OIDCLogin(t *testing.T) {
+	client := domain.SessionClient{IP: "192.0.2.1", UserAgent: "Mozilla/5.0"}
+	now := time.Now()
+
+	server := oidctest.NewServer(t, "task-tracker", "secret", "http://localhost:3000/auth/oidc/callback")
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		IssuerURL:    server.URL,
+		ClientID:     "task-tracker",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/auth/oidc/callback",
+	})
+	require.NoError(t, err)
+
+	setup := func(t *testing.T) (*UserService, *mocks.MockUserRepository, *mocks.MockOIDCRepository, *mocks.MockLoginAttemptRepository) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockOIDC := mocks.NewMockOIDCRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{OIDC: mockOIDC, OIDCProvider: provider, Logins: mockLogins})
+		service.now = func() time.Time { return now }
+		return service, mockRepo, mockOIDC, mockLogins
+	}
+
+	// begin starts a sign-in and follows it through the provider, returning the state and code the browser comes back with
+	begin := func(t *testing.T, service *UserService, mockOIDC *mocks.MockOIDCRepository, rememberMe bool) (string, string) {
+		var saved *domain.OIDCLoginState
+		mockOIDC.On("SaveOIDCLoginState", mock.AnythingOfType("string"), mock.AnythingOfType("*domain.OIDCLoginState"), oidcStateTTL).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.OIDCLoginState) }).Return(nil).Once()
+
+		authURL, state, err := service.BeginOIDCLogin(rememberMe)
+		require.NoError(t, err)
+		code, returnedState, err := server.Authorize(authURL)
+		require.NoError(t, err)
+		require.Equal(t, state, returnedState)
+
+		mockOIDC.On("ConsumeOIDCLoginState", hashToken(state)).Return(saved, nil).Once()
+		return state, code
+	}
+
+	expectLoginSuccess := func(mockRepo *mocks.MockUserRepository, mockLogins *mocks.MockLoginAttemptRepository, userID string, rememberMe bool) {
+		mockRepo.On("CreateSession", userID, mock.AnythingOfType("string"), rememberMe, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", userID, mock.Anything).Return(nil)
+		mockLogins.On("AddSecurityEvent", userID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventLoginSucceeded
+		})).Return(nil)
+	}
+
+	t.Run("begin stores a hash of the state with the nonce and PKCE verifier", func(t *testing.T) {
+		service, _, mockOIDC, _ := setup(t)
+		var stateHash string
+		var saved *domain.OIDCLoginState
+		mockOIDC.On("SaveOIDCLoginState", mock.AnythingOfType("string"), mock.AnythingOfType("*domain.OIDCLoginState"), oidcStateTTL).
+			Run(func(args mock.Arguments) {
+				stateHash = args.String(0)
+				saved = args.Get(1).(*domain.OIDCLoginState)
+			}).Return(nil)
+
+		authURL, state, err := service.BeginOIDCLogin(true)
+		require.NoError(t, err)
+
+		assert.Equal(t, hashToken(state), stateHash)
+		assert.True(t, saved.RememberMe)
+		assert.NotEmpty(t, saved.Nonce)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, state, parsed.Query().Get("state"))
+		assert.Equal(t, saved.Nonce, parsed.Query().Get("nonce"))
+		assert.Equal(t, oidc.Challenge(saved.CodeVerifier), parsed.Query().Get("code_challenge"))
+		assert.NotContains(t, authURL, saved.CodeVerifier)
+	})
+
+	t.Run("signs in the linked user", func(t *testing.T) {
+		service, mockRepo, mockOIDC, mockLogins := setup(t)
+		user := &domain.User{ID: uuid.New().String(), Email: "alice@example.com", EmailVerified: true}
+		server.SetUser(oidctest.User{Subject: "alice", Email: "alice@corp.example.com", EmailVerified: true})
+
+		state, code := begin(t, service, mockOIDC, true)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "alice").Return(user.ID, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		expectLoginSuccess(mockRepo, mockLogins, user.ID, true)
+
+		signedIn, sessionID, rememberMe, err := service.CompleteOIDCLogin(state, code, client)
+		require.NoError(t, err)
+		assert.Same(t, user, signedIn)
+		assert.NotEmpty(t, sessionID)
+		assert.True(t, rememberMe)
+	})
+
+	t.Run("links a verified account by email", func(t *testing.T) {
+		service, mockRepo, mockOIDC, mockLogins := setup(t)
+		user := &domain.User{ID: uuid.New().String(), Email: "bob@example.com", EmailVerified: true}
+		require.NoError(t, user.HashPassword("Password123!"))
+		server.SetUser(oidctest.User{Subject: "bob", Email: "bob@example.com", EmailVerified: true})
+
+		state, code := begin(t, service, mockOIDC, false)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "bob").Return("", nil)
+		mockRepo.On("GetByEmail", "bob@example.com").Return(user, nil)
+		mockOIDC.On("LinkOIDCIdentity", server.URL, "bob", user.ID).Return(nil)
+		mockLogins.On("AddSecurityEvent", user.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventOIDCLinked && event.Detail == server.URL && event.IP == "192.0.2.1"
+		})).Return(nil)
+		expectLoginSuccess(mockRepo, mockLogins, user.ID, false)
+
+		signedIn, _, rememberMe, err := service.CompleteOIDCLogin(state, code, client)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, signedIn.ID)
+		assert.True(t, signedIn.CheckPassword("Password123!"))
+		assert.False(t, rememberMe)
+	})
+
+	t.Run("takes over an account registered with the address but never verified", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockOIDC := mocks.NewMockOIDCRepository(t)
+		mockLogins := mocks.NewMockLoginAttemptRepository(t)
+		mockTwoFactor := mocks.NewMockTwoFactorRepository(t)
+		mockTokens := mocks.NewMockAPITokenRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{
+			OIDC:         mockOIDC,
+			OIDCProvider: provider,
+			Logins:       mockLogins,
+			TwoFactor:    mockTwoFactor,
+			APITokens:    mockTokens,
+			AdminEmails:  []string{"carol@example.com"},
+		})
+		service.now = func() time.Time { return now }
+
+		// Someone else registers the address first and never verifies it
+		var registered *domain.User
+		mockRepo.On("GetByEmail", "carol@example.com").Return(nil, domain.ErrUserNotFound).Once()
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).
+			Run(func(args mock.Arguments) { registered = args.Get(0).(*domain.User) }).Return(nil)
+		mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), false, client).Return(nil).Once()
+		_, _, err := service.Register("carol@example.com", "Squatter", "Squatter123!", client)
+		require.NoError(t, err)
+		require.False(t, registered.IsAdmin)
+
+		server.SetUser(oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: true})
+		state, code := begin(t, service, mockOIDC, false)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "carol").Return("", nil)
+		mockRepo.On("GetByEmail", "carol@example.com").Return(registered, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool {
+			return u.ID == registered.ID && u.EmailVerified && u.IsAdmin && !u.CheckPassword("Squatter123!")
+		})).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", registered.ID).Return(nil)
+		mockTokens.On("DeleteAllUserAPITokens", registered.ID).Return(1, nil)
+		mockTwoFactor.On("DisableTwoFactor", registered.ID).Return(nil)
+		mockOIDC.On("LinkOIDCIdentity", server.URL, "carol", registered.ID).Return(nil)
+		mockLogins.On("AddSecurityEvent", registered.ID, mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventOIDCLinked
+		})).Return(nil)
+		mockTwoFactor.On("GetTwoFactor", registered.ID).Return(nil, nil)
+		expectLoginSuccess(mockRepo, mockLogins, registered.ID, false)
+
+		signedIn, sessionID, _, err := service.CompleteOIDCLogin(state, code, client)
+		require.NoError(t, err)
+		assert.NotEmpty(t, sessionID)
+		assert.True(t, signedIn.EmailVerified)
+		assert.True(t, signedIn.IsAdmin)
+		assert.False(t, signedIn.CheckPassword("Squatter123!"))
+	})
+
+	t.Run("stops the takeover when old sessions cannot be revoked", func(t *testing.T) {
+		service, mockRepo, mockOIDC, _ := setup(t)
+		user := &domain.User{ID: uuid.New().String(), Email: "dave@example.com"}
+		server.SetUser(oidctest.User{Subject: "dave", Email: "dave@example.com", EmailVerified: true})
+
+		state, code := begin(t, service, mockOIDC, false)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "dave").Return("", nil)
+		mockRepo.On("GetByEmail", "dave@example.com").Return(user, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("DeleteAllUserSessions", user.ID).Return(errors.New("redis down"))
+
+		_, _, _, err := service.CompleteOIDCLogin(state, code, client)
+		assert.ErrorContains(t, err, "3004")
+	})
+
+	t.Run("creates an account for a new user", func(t *testing.T) {
+		service, mockRepo, mockOIDC, mockLogins := setup(t)
+		server.SetUser(oidctest.User{Subject: "carol", Email: "carol@example.com", EmailVerified: true, Name: "Carol"})
+
+		state, code := begin(t, service, mockOIDC, false)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "carol").Return("", nil)
+		mockRepo.On("GetByEmail", "carol@example.com").Return(nil, domain.ErrUserNotFound)
+		var created *domain.User
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).
+			Run(func(args mock.Arguments) { created = args.Get(0).(*domain.User) }).Return(nil)
+		mockOIDC.On("LinkOIDCIdentity", server.URL, "carol", mock.AnythingOfType("string")).Return(nil)
+		mockLogins.On("AddSecurityEvent", mock.AnythingOfType("string"), mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventOIDCLinked
+		})).Return(nil)
+		mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), false, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+		mockLogins.On("AddSecurityEvent", mock.AnythingOfType("string"), mock.MatchedBy(func(event *domain.SecurityEvent) bool {
+			return event.Type == domain.SecurityEventLoginSucceeded
+		})).Return(nil)
+
+		signedIn, _, _, err := service.CompleteOIDCLogin(state, code, client)
+		require.NoError(t, err)
+		assert.Same(t, created, signedIn)
+		assert.NotEmpty(t, created.ID)
+		assert.Equal(t, "carol@example.com", created.Email)
+		assert.Equal(t, "Carol", created.DisplayName)
+		assert.True(t, created.EmailVerified)
+		assert.NotEmpty(t, created.Password)
+		mockOIDC.AssertCalled(t, "LinkOIDCIdentity", server.URL, "carol", created.ID)
+	})
+
+	t.Run("new accounts without a name are named after the mailbox", func(t *testing.T) {
+		service, mockRepo, mockOIDC, mockLogins := setup(t)
+		server.SetUser(oidctest.User{Subject: "dave", Email: "dave@example.com", EmailVerified: true})
+
+		state, code := begin(t, service, mockOIDC, false)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "dave").Return("", nil)
+		mockRepo.On("GetByEmail", "dave@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.MatchedBy(func(u *domain.User) bool { return u.DisplayName == "dave" })).Return(nil)
+		mockOIDC.On("LinkOIDCIdentity", server.URL, "dave", mock.AnythingOfType("string")).Return(nil)
+		mockLogins.On("AddSecurityEvent", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+		mockRepo.On("CreateSession", mock.AnythingOfType("string"), mock.AnythingOfType("string"), false, client).Return(nil)
+		mockLogins.On("RecordLoginSuccess", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+
+		_, _, _, err := service.CompleteOIDCLogin(state, code, client)
+		require.NoError(t, err)
+	})
+
+	t.Run("requires a verified email", func(t *testing.T) {
+		service, _, mockOIDC, _ := setup(t)
+		server.SetUser(oidctest.User{Subject: "eve", Email: "alice@example.com", EmailVerified: false})
+
+		state, code := begin(t, service, mockOIDC, false)
+
+		_, _, _, err := service.CompleteOIDCLogin(state, code, client)
+		assert.Equal(t, domain.ErrOIDCEmailNotVerified, err)
+	})
+
+	t.Run("users with two-factor authentication still need a code", func(t *testing.T) {
+		mockRepo := mocks.NewMockUserRepository(t)
+		mockOIDC := mocks.NewMockOIDCRepository(t)
+		mockTwoFactor := mocks.NewMockTwoFactorRepository(t)
+		service := NewUserServiceWithOptions(mockRepo, UserServiceOptions{OIDC: mockOIDC, OIDCProvider: provider, TwoFactor: mockTwoFactor})
+		user := &domain.User{ID: uuid.New().String(), Email: "alice@example.com", EmailVerified: true}
+		server.SetUser(oidctest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: true})
+
+		state, code := begin(t, service, mockOIDC, true)
+		mockOIDC.On("GetOIDCIdentity", server.URL, "alice").Return(user.ID, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockTwoFactor.On("GetTwoFactor", user.ID).Return(&domain.TwoFactor{Secret: "secret"}, nil)
+		mockTwoFactor.On("SaveLoginChallenge", mock.AnythingOfType("string"), &domain.LoginChallenge{UserID: user.ID, RememberMe: true}, loginChallengeTTL).Return(nil)
+
+		_, _, _, err := service.CompleteOIDCLogin(state, code, client)
+		var required *domain.TwoFactorRequiredError
+		require.ErrorAs(t, err, &required)
+		assert.NotEmpty(t, required.Token)
+		mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects unknown or used state", func(t *testing.T) {
+		service, _, mockOIDC, _ := setup(t)
+		mockOIDC.On("ConsumeOIDCLoginState", hashToken("state")).Return(nil, domain.ErrInvalidOIDCState)
+
+		_, _, _, err := service.CompleteOIDCLogin("state", "code", client)
+		assert.Equal(t, domain.ErrInvalidOIDCState, err)
+	})
+
+	t.Run("reports codes the provider rejects", func(t *testing.T) {
+		service, _, mockOIDC, _ := setup(t)
+		state, _ := begin(t, service, mockOIDC, false)
+
+		_, _, _, err := service.CompleteOIDCLogin(state, "not-a-code", client)
+		assert.ErrorIs(t, err, domain.ErrOIDCExchangeFailed)
+		assert.True(t, strings.HasPrefix(err.Error(), "3035"))
+	})
+
+	t.Run("requires configuration and fields", func(t *testing.T) {
+		service := NewUserService(mocks.NewMockUserRepository(t))
+
+		_, _, err := service.BeginOIDCLogin(false)
+		assert.True(t, strings.HasPrefix(err.Error(), "3030"))
+		_, _, _, err = service.CompleteOIDCLogin("state", "code", client)
+		assert.True(t, strings.HasPrefix(err.Error(), "3030"))
+		_, _, _, err = service.CompleteOIDCLogin("", "code", client)
+		assert.True(t, strings.HasPrefix(err.Error(), "3009"))
+	})
+}
@@ -0,0 +1,56 @@
+package apitoken
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	first, err := Generate()
+	require.NoError(t, err)
+	second, err := Generate()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, Prefix))
+	assert.Len(t, first, len(Prefix)+43)
+	assert.NotEqual(t, first, second)
+}
+
+func TestHash(t *testing.T) {
+	token, err := Generate()
+	require.NoError(t, err)
+
+	t.Run("hashes generated tokens consistently", func(t *testing.T) {
+		hash, ok := Hash(token)
+		require.True(t, ok)
+		assert.Len(t, hash, 64)
+		assert.NotContains(t, hash, token)
+
+		again, ok := Hash(token)
+		require.True(t, ok)
+		assert.Equal(t, hash, again)
+
+		other, err := Generate()
+		require.NoError(t, err)
+		otherHash, ok := Hash(other)
+		require.True(t, ok)
+		assert.NotEqual(t, hash, otherHash)
+	})
+
+	t.Run("rejects values that are not tokens", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			Prefix,
+			strings.TrimPrefix(token, Prefix),
+			token[:len(token)-1],
+			token + "x",
+			"xx_" + strings.TrimPrefix(token, Prefix),
+		} {
+			_, ok := Hash(value)
+			assert.False(t, ok, value)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package cookie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Signer signs cookie values with HMAC-SHA256
+// Values are signed with the current secret and verified against it and any previous secrets, so secrets can be rotated
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner creates a signer that signs with secret
+// Values signed with any of the previous secrets are still accepted; empty secrets are ignored
+func NewSigner(secret string, previous ...string) *Signer {
+	keys := [][]byte{[]byte(secret)}
+	for _, key := range previous {
+		if key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+	return &Signer{keys: keys}
+}
+
+// Sign returns the value followed by a dot and its URL-safe signature
+func (s *Signer) Sign(value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(mac(s.keys[0], value))
+}
+
+// Verify returns the original value if the signed value carries a valid signature
+// Returns false for unsigned, truncated or forged values
+func (s *Signer) Verify(signed string) (string, bool) {
+	dot := strings.LastIndexByte(signed, '.')
+	if dot <= 0 {
+		return "", false
+	}
+
+	value := signed[:dot]
+	signature, err := base64.RawURLEncoding.DecodeString(signed[dot+1:])
+	if err != nil {
+		return "", false
+	}
+
+	for _, key := range s.keys {
+		if hmac.Equal(signature, mac(key, value)) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// mac computes the HMAC-SHA256 of value with key
+func mac(key []byte, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
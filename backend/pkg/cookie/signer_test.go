@@ -0,0 +1,58 @@
+package cookie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner_SignVerify(t *testing.T) {
+	signer := NewSigner("current-secret")
+	signed := signer.Sign("5f0c6f2e-8d7a-4c1b-9e3f-2a6b7c8d9e0f")
+
+	value, ok := signer.Verify(signed)
+	assert.True(t, ok)
+	assert.Equal(t, "5f0c6f2e-8d7a-4c1b-9e3f-2a6b7c8d9e0f", value)
+}
+
+func TestSigner_Rejects(t *testing.T) {
+	signer := NewSigner("current-secret")
+	signed := signer.Sign("session-123")
+
+	tests := []struct {
+		name   string
+		signed string
+	}{
+		{name: "unsigned value", signed: "session-123"},
+		{name: "empty value", signed: ""},
+		{name: "truncated signature", signed: signed[:len(signed)-4]},
+		{name: "missing value", signed: signed[len("session-123"):]},
+		{name: "tampered value", signed: "session-124" + signed[len("session-123"):]},
+		{name: "invalid encoding", signed: "session-123.%%%"},
+		{name: "signed with another secret", signed: NewSigner("other-secret").Sign("session-123")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := signer.Verify(tt.signed)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestSigner_Rotation(t *testing.T) {
+	old := NewSigner("old-secret")
+	rotated := NewSigner("new-secret", "old-secret", "")
+
+	t.Run("accepts values signed with a previous secret", func(t *testing.T) {
+		value, ok := rotated.Verify(old.Sign("session-123"))
+		assert.True(t, ok)
+		assert.Equal(t, "session-123", value)
+	})
+
+	t.Run("signs with the current secret", func(t *testing.T) {
+		_, ok := old.Verify(rotated.Sign("session-123"))
+		assert.False(t, ok)
+		assert.Equal(t, NewSigner("new-secret").Sign("session-123"), rotated.Sign("session-123"))
+	})
+}
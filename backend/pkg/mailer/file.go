@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes each message to its own .eml file instead of sending it
+// Intended for development and staging environments without an SMTP server
+type FileMailer struct {
+	dir  string
+	from mail.Address
+}
+
+// NewFileMailer creates a mailer that writes messages to dir, creating it if needed
+func NewFileMailer(dir string, from mail.Address) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file transport needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes the message to a file named after the time it was sent
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), messageID()[:8])
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
+
+// LogMailer writes messages to a logger instead of sending them
+// Message bodies may contain secrets such as reset links, so it must not be used in production
+type LogMailer struct {
+	logger *log.Logger
+	from   mail.Address
+}
+
+// NewLogMailer creates a mailer that logs messages to logger, or to the standard logger if nil
+func NewLogMailer(logger *log.Logger, from mail.Address) *LogMailer {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogMailer{logger: logger, from: from}
+}
+
+// Send logs the message headers and body
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if _, err := compose(m.from, msg, time.Now()); err != nil {
+		return err
+	}
+
+	m.logger.Printf("Email from %s to %s: %s\n%s", m.from.Address, msg.To, msg.Subject, msg.Body)
+	return nil
+}
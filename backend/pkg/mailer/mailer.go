@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Transport names accepted by New
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// Message is a plain text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+// Implementations are safe for concurrent use
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config holds mailer configuration
+// Transport selects how messages are delivered; the SMTP settings are only used by the SMTP transport
+type Config struct {
+	Transport    string `json:"transport"` // smtp, file or log
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	FromAddress  string `json:"from_address"`
+	FromName     string `json:"from_name"`
+	FileDir      string `json:"file_dir"` // directory the file transport writes messages to
+}
+
+// New creates a mailer for the configured transport
+// Returns an error for unknown transports or an invalid sender address
+func New(config *Config) (Mailer, error) {
+	if config == nil {
+		return nil, errors.New("mailer config is required")
+	}
+
+	from := mail.Address{Name: config.FromName, Address: config.FromAddress}
+	if _, err := mail.ParseAddress(from.String()); err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	switch config.Transport {
+	case TransportSMTP, "":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, from), nil
+	case TransportFile:
+		return NewFileMailer(config.FileDir, from)
+	case TransportLog:
+		return NewLogMailer(nil, from), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", config.Transport)
+	}
+}
+
+// compose renders a message as an RFC 5322 email with a quoted-printable UTF-8 body
+// Rejects recipients and subjects containing line breaks so headers cannot be injected
+func compose(from mail.Address, msg Message, now time.Time) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("recipient and subject must not contain line breaks")
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", messageID(), domainOf(from.Address))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// messageID returns a random identifier for the Message-ID header
+func messageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// domainOf returns the domain part of an email address
+func domainOf(address string) string {
+	if at := strings.LastIndexByte(address, '@'); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/pkg/mailer/mailertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFrom = mail.Address{Name: "Task Tracker", Address: "noreply@example.com"}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := mailertest.NewServer(t)
+	m := NewSMTPMailer(server.Host, server.Port, "", "", testFrom)
+
+	t.Run("should deliver the message", func(t *testing.T) {
+		err := m.Send(context.Background(), Message{
+			To:      "user@example.com",
+			Subject: "Réinitialiser le mot de passe",
+			Body:    "Hello,\n\n" + strings.Repeat("long line ", 20) + "\nhttps://example.com/reset?token=abc=def",
+		})
+		require.NoError(t, err)
+
+		messages := server.Messages()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "noreply@example.com", messages[0].From)
+		assert.Equal(t, []string{"user@example.com"}, messages[0].To)
+		assert.Equal(t, "Réinitialiser le mot de passe", messages[0].Subject)
+		assert.Contains(t, messages[0].Body, "https://example.com/reset?token=abc=def")
+		assert.Equal(t, `"Task Tracker" <noreply@example.com>`, messages[0].Header.Get("From"))
+	})
+
+	t.Run("should reject header injection", func(t *testing.T) {
+		err := m.Send(context.Background(), Message{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hi"})
+		assert.Error(t, err)
+
+		err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi\r\nBcc: victim@example.com"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject invalid recipients", func(t *testing.T) {
+		err := m.Send(context.Background(), Message{To: "not an address", Subject: "Hi"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail when the server is unreachable", func(t *testing.T) {
+		unreachable := NewSMTPMailer("127.0.0.1", 1, "", "", testFrom)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err := unreachable.Send(ctx, Message{To: "user@example.com", Subject: "Hi"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail when credentials are set but the server has no AUTH", func(t *testing.T) {
+		authenticated := NewSMTPMailer(server.Host, server.Port, "user", "secret", testFrom)
+		err := authenticated.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := NewFileMailer(dir, testFrom)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "user@example.com", Subject: "Welcome", Body: "Hello"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "other@example.com", Subject: "Welcome", Body: "Hello"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome", parsed.Header.Get("Subject"))
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(log.New(&buf, "", 0), testFrom)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "user@example.com", Subject: "Welcome", Body: "Hello"}))
+	assert.Contains(t, buf.String(), "user@example.com")
+	assert.Contains(t, buf.String(), "Welcome")
+	assert.Contains(t, buf.String(), "Hello")
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		expected    interface{}
+		expectError bool
+	}{
+		{name: "smtp by default", config: &Config{FromAddress: "noreply@example.com"}, expected: &SMTPMailer{}},
+		{name: "file", config: &Config{Transport: TransportFile, FileDir: t.TempDir(), FromAddress: "noreply@example.com"}, expected: &FileMailer{}},
+		{name: "log", config: &Config{Transport: TransportLog, FromAddress: "noreply@example.com"}, expected: &LogMailer{}},
+		{name: "file without directory", config: &Config{Transport: TransportFile, FromAddress: "noreply@example.com"}, expectError: true},
+		{name: "unknown transport", config: &Config{Transport: "pigeon", FromAddress: "noreply@example.com"}, expectError: true},
+		{name: "invalid sender", config: &Config{FromAddress: "not an address"}, expectError: true},
+		{name: "missing config", config: nil, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, m)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package mailertest provides an in-process SMTP server for testing code that sends email
+package mailertest
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message is an email received by the test server
+// Subject and Body are decoded; Raw holds the message exactly as it was transmitted
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+	Header  mail.Header
+	Raw     []byte
+}
+
+// Server is a minimal SMTP server that accepts every message and records it
+// It offers neither STARTTLS nor AUTH, like a local MailHog instance
+type Server struct {
+	Host string
+	Port int
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a random local port
+// The server is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mailertest: failed to listen: %v", err)
+	}
+
+	addr := listener.Addr().(*net.TCPAddr)
+	s := &Server{Host: addr.IP.String(), Port: addr.Port, listener: listener}
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// Messages returns the messages received so far, oldest first
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close stops the server and waits for open connections to finish
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle speaks just enough SMTP for net/smtp clients to deliver a message
+func (s *Server) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+
+	tp.PrintfLine("220 mailertest ready")
+
+	var from string
+	var to []string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(verb, "EHLO"), strings.HasPrefix(verb, "HELO"):
+			tp.PrintfLine("250 mailertest")
+		case strings.HasPrefix(verb, "MAIL FROM:"):
+			from = trimPath(line[len("MAIL FROM:"):])
+			to = nil
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(verb, "RCPT TO:"):
+			to = append(to, trimPath(line[len("RCPT TO:"):]))
+			tp.PrintfLine("250 OK")
+		case verb == "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			raw, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.record(from, to, raw)
+			tp.PrintfLine("250 OK")
+		case verb == "RSET", verb == "NOOP":
+			tp.PrintfLine("250 OK")
+		case verb == "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// record decodes and stores a received message
+func (s *Server) record(from string, to []string, raw []byte) {
+	msg := Message{From: from, To: to, Raw: raw}
+
+	if parsed, err := mail.ReadMessage(bytes.NewReader(raw)); err == nil {
+		msg.Header = parsed.Header
+		msg.Subject, _ = new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+
+		var body io.Reader = parsed.Body
+		if strings.EqualFold(parsed.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+			body = quotedprintable.NewReader(body)
+		}
+		decoded, _ := io.ReadAll(body)
+		msg.Body = strings.ReplaceAll(string(decoded), "\r\n", "\n")
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+}
+
+// trimPath extracts the address from an SMTP reverse or forward path such as <user@example.com>
+func trimPath(path string) string {
+	path = strings.TrimSpace(path)
+	if i := strings.IndexByte(path, ' '); i >= 0 {
+		path = path[:i] // drop ESMTP parameters
+	}
+	return strings.Trim(path, "<>")
+}
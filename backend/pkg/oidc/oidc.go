@@ -0,0 +1,440 @@
+// Package oidc implements the OpenID Connect authorization code flow with PKCE for signing in with an external identity provider
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScopes are requested when no scopes are configured
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// clockSkew is how far the provider's clock may be ahead of or behind ours when checking token times
+const clockSkew = time.Minute
+
+// keyRefreshInterval limits how often the provider's signing keys are fetched again for an unknown key ID
+const keyRefreshInterval = time.Minute
+
+// maxResponseSize bounds the responses read from the provider
+const maxResponseSize = 1 << 20
+
+// Config holds the settings of a client registered with an identity provider
+type Config struct {
+	IssuerURL    string       // issuer identifier; discovery is read from {IssuerURL}/.well-known/openid-configuration
+	ClientID     string       // client registered with the provider
+	ClientSecret string       // empty for public clients, which rely on PKCE alone
+	RedirectURL  string       // where the provider sends the browser back with the code
+	Scopes       []string     // DefaultScopes when empty; "openid" is always requested
+	HTTPClient   *http.Client // a client with a 10 second timeout when nil
+}
+
+// Claims holds the identity read from a verified ID token
+type Claims struct {
+	Issuer        string
+	Subject       string // stable user identifier at the provider
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an identity provider configured from its discovery document
+type Provider struct {
+	config        Config
+	client        *http.Client
+	issuer        string
+	authEndpoint  string
+	tokenEndpoint string
+	jwksURI       string
+	now           func() time.Time
+
+	mu            sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// discovery is the part of the provider's discovery document the client uses
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Discover reads the provider's discovery document and returns a provider for the configured client
+// Fails if the document does not name the configured issuer, so a misconfigured URL is caught at startup
+func Discover(ctx context.Context, config Config) (*Provider, error) {
+	if config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("issuer URL, client ID and redirect URL are required")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	issuer := strings.TrimRight(config.IssuerURL, "/")
+	var doc discovery
+	if err := getJSON(ctx, client, issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("failed to read discovery document: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document names issuer %q, expected %q", doc.Issuer, issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing the authorization, token or JWKS endpoint")
+	}
+
+	return &Provider{
+		config:        config,
+		client:        client,
+		issuer:        doc.Issuer,
+		authEndpoint:  doc.AuthorizationEndpoint,
+		tokenEndpoint: doc.TokenEndpoint,
+		jwksURI:       doc.JWKSURI,
+		now:           time.Now,
+	}, nil
+}
+
+// Issuer returns the provider's issuer identifier, which together with the subject identifies a user
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+// AuthCodeURL returns the authorization URL to send the browser to
+// The state is returned with the code, the nonce comes back in the ID token and the verifier's S256 challenge binds the code to this client
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.scopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", Challenge(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.authEndpoint, "?") {
+		separator = "&"
+	}
+	return p.authEndpoint + separator + query.Encode()
+}
+
+// Exchange trades an authorization code for tokens and returns the claims of the verified ID token
+// The ID token must be signed by the provider for this client and carry the nonce sent with the authorization request
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic, the default client authentication method
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("token endpoint returned status %d with an invalid body", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// GenerateVerifier returns a new random PKCE code verifier
+func GenerateVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE code challenge of a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// scopes returns the scopes to request, always including openid
+func (p *Provider) scopes() []string {
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	for _, scope := range scopes {
+		if scope == "openid" {
+			return scopes
+		}
+	}
+	return append([]string{"openid"}, scopes...)
+}
+
+// idTokenClaims is the payload of an ID token
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      audience        `json:"aud"`
+	AuthorizedBy  string          `json:"azp"`
+	ExpiresAt     int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+// audience is the aud claim, which is either a single string or an array of strings
+type audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// verifyIDToken checks the signature and claims of an ID token and returns the identity it carries
+// Only RS256, which every OpenID provider must support, is accepted
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("ID token is not a JWT")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid ID token header: %w", err)
+	}
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token signature: %w", err)
+	}
+	key, err := p.signingKey(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.New("ID token signature is invalid")
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	now := p.now()
+	if claims.Issuer != p.issuer {
+		return nil, fmt.Errorf("ID token issuer %q does not match %q", claims.Issuer, p.issuer)
+	}
+	if !claims.Audience.contains(p.config.ClientID) {
+		return nil, errors.New("ID token was not issued for this client")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.config.ClientID {
+		return nil, errors.New("ID token was not authorized for this client")
+	}
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, errors.New("ID token has expired")
+	}
+	if claims.IssuedAt != 0 && time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)) {
+		return nil, errors.New("ID token was issued in the future")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+
+	return &Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: parseBool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// contains reports whether the audience includes the client ID
+func (a audience) contains(clientID string) bool {
+	for _, value := range a {
+		if value == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBool reads a boolean claim, which some providers send as the string "true"
+func parseBool(raw json.RawMessage) bool {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text == "true"
+	}
+	return false
+}
+
+// signingKey returns the provider key with the given ID, fetching the key set again if the ID is unknown
+// A token without a key ID is accepted when the provider publishes a single key
+func (p *Provider) signingKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(keyID); key != nil {
+		return key, nil
+	}
+
+	// Providers rotate keys, so an unknown ID triggers a refetch; the interval stops forged IDs from hammering the provider
+	if !p.keysFetchedAt.IsZero() && p.now().Sub(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown ID token signing key %q", keyID)
+	}
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key := p.lookupKey(keyID); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", keyID)
+}
+
+// lookupKey returns a cached key by ID; callers hold p.mu
+func (p *Provider) lookupKey(keyID string) *rsa.PublicKey {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[keyID]
+}
+
+// fetchKeys reads the provider's RSA signing keys from its JWKS endpoint
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			Use     string `json:"use"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, p.client, p.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to read signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// getJSON fetches a URL and decodes its JSON body
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// decodeSegment decodes a base64url JWT segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,251 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID    = "task-tracker"
+	testSecret      = "s3cret:with/special&chars"
+	testRedirectURL = "http://localhost:3000/auth/oidc/callback"
+)
+
+// setupProvider starts a mock provider and discovers it
+func setupProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	server := oidctest.NewServer(t, testClientID, testSecret, testRedirectURL)
+
+	provider, err := Discover(context.Background(), Config{
+		IssuerURL:    server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testSecret,
+		RedirectURL:  testRedirectURL,
+	})
+	require.NoError(t, err)
+	return server, provider
+}
+
+func TestDiscover(t *testing.T) {
+	server, provider := setupProvider(t)
+	assert.Equal(t, server.URL, provider.Issuer())
+
+	t.Run("accepts a trailing slash on the issuer URL", func(t *testing.T) {
+		_, err := Discover(context.Background(), Config{
+			IssuerURL:   server.URL + "/",
+			ClientID:    testClientID,
+			RedirectURL: testRedirectURL,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects a document for another issuer", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"issuer":"https://elsewhere.example.com","authorization_endpoint":"a","token_endpoint":"t","jwks_uri":"j"}`))
+		}))
+		defer other.Close()
+
+		_, err := Discover(context.Background(), Config{IssuerURL: other.URL, ClientID: testClientID, RedirectURL: testRedirectURL})
+		assert.Error(t, err)
+	})
+
+	t.Run("requires the client settings", func(t *testing.T) {
+		_, err := Discover(context.Background(), Config{IssuerURL: server.URL})
+		assert.Error(t, err)
+	})
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	server, provider := setupProvider(t)
+
+	authURL, err := url.Parse(provider.AuthCodeURL("the-state", "the-nonce", "the-verifier"))
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+
+	query := authURL.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientID, query.Get("client_id"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "the-state", query.Get("state"))
+	assert.Equal(t, "the-nonce", query.Get("nonce"))
+	assert.Equal(t, Challenge("the-verifier"), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	t.Run("always requests openid", func(t *testing.T) {
+		provider.config.Scopes = []string{"email"}
+		defer func() { provider.config.Scopes = nil }()
+
+		authURL, err := url.Parse(provider.AuthCodeURL("s", "n", "v"))
+		require.NoError(t, err)
+		assert.Equal(t, "openid email", authURL.Query().Get("scope"))
+	})
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	server, provider := setupProvider(t)
+	ctx := context.Background()
+
+	authorize := func(t *testing.T, verifier, nonce string) string {
+		code, state, err := server.Authorize(provider.AuthCodeURL("state-1", nonce, verifier))
+		require.NoError(t, err)
+		require.Equal(t, "state-1", state)
+		return code
+	}
+
+	t.Run("returns the claims of the signed in user", func(t *testing.T) {
+		server.SetUser(oidctest.User{Subject: "abc", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+		verifier, err := GenerateVerifier()
+		require.NoError(t, err)
+
+		claims, err := provider.Exchange(ctx, authorize(t, verifier, "nonce-1"), verifier, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, &Claims{
+			Issuer:        server.URL,
+			Subject:       "abc",
+			Email:         "alice@example.com",
+			EmailVerified: true,
+			Name:          "Alice",
+		}, claims)
+	})
+
+	t.Run("rejects a wrong verifier", func(t *testing.T) {
+		code := authorize(t, "right-verifier-right-verifier-right-verifier", "nonce-1")
+		_, err := provider.Exchange(ctx, code, "wrong-verifier-wrong-verifier-wrong-verifier", "nonce-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a reused code", func(t *testing.T) {
+		verifier, err := GenerateVerifier()
+		require.NoError(t, err)
+		code := authorize(t, verifier, "nonce-1")
+
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects an ID token with another nonce", func(t *testing.T) {
+		verifier, err := GenerateVerifier()
+		require.NoError(t, err)
+		code := authorize(t, verifier, "nonce-1")
+
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-2")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a wrong client secret", func(t *testing.T) {
+		verifier, err := GenerateVerifier()
+		require.NoError(t, err)
+		code := authorize(t, verifier, "nonce-1")
+
+		wrong, err := Discover(ctx, Config{
+			IssuerURL:    server.URL,
+			ClientID:     testClientID,
+			ClientSecret: "wrong",
+			RedirectURL:  testRedirectURL,
+		})
+		require.NoError(t, err)
+		_, err = wrong.Exchange(ctx, code, verifier, "nonce-1")
+		assert.Error(t, err)
+	})
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	server, provider := setupProvider(t)
+	ctx := context.Background()
+	user := oidctest.User{Subject: "abc", Email: "alice@example.com", EmailVerified: true}
+
+	t.Run("accepts a valid token", func(t *testing.T) {
+		claims, err := provider.verifyIDToken(ctx, server.Sign(server.IDTokenClaims(user, "n")), "n")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", claims.Subject)
+		assert.True(t, claims.EmailVerified)
+	})
+
+	t.Run("accepts email_verified sent as a string and an audience list with azp", func(t *testing.T) {
+		claims := server.IDTokenClaims(user, "n")
+		claims["email_verified"] = "true"
+		claims["aud"] = []string{testClientID, "other"}
+		claims["azp"] = testClientID
+
+		verified, err := provider.verifyIDToken(ctx, server.Sign(claims), "n")
+		require.NoError(t, err)
+		assert.True(t, verified.EmailVerified)
+	})
+
+	t.Run("reports unverified email", func(t *testing.T) {
+		claims := server.IDTokenClaims(oidctest.User{Subject: "abc", Email: "alice@example.com"}, "n")
+		verified, err := provider.verifyIDToken(ctx, server.Sign(claims), "n")
+		require.NoError(t, err)
+		assert.False(t, verified.EmailVerified)
+	})
+
+	invalid := map[string]func(claims map[string]interface{}){
+		"wrong issuer":         func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"wrong audience":       func(c map[string]interface{}) { c["aud"] = "other-client" },
+		"audience without azp": func(c map[string]interface{}) { c["aud"] = []string{testClientID, "other"} },
+		"expired":              func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() },
+		"missing expiry":       func(c map[string]interface{}) { delete(c, "exp") },
+		"issued in the future": func(c map[string]interface{}) { c["iat"] = time.Now().Add(5 * time.Minute).Unix() },
+		"wrong nonce":          func(c map[string]interface{}) { c["nonce"] = "other" },
+		"missing subject":      func(c map[string]interface{}) { c["sub"] = "" },
+	}
+	for name, change := range invalid {
+		t.Run("rejects "+name, func(t *testing.T) {
+			claims := server.IDTokenClaims(user, "n")
+			change(claims)
+			_, err := provider.verifyIDToken(ctx, server.Sign(claims), "n")
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("rejects a tampered payload", func(t *testing.T) {
+		token := server.Sign(server.IDTokenClaims(user, "n"))
+		parts := strings.Split(token, ".")
+		forged := server.IDTokenClaims(oidctest.User{Subject: "admin", Email: "admin@example.com", EmailVerified: true}, "n")
+		other := strings.Split(server.Sign(forged), ".")
+
+		_, err := provider.verifyIDToken(ctx, parts[0]+"."+other[1]+"."+parts[2], "n")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unsigned tokens", func(t *testing.T) {
+		parts := strings.Split(server.Sign(server.IDTokenClaims(user, "n")), ".")
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+		_, err := provider.verifyIDToken(ctx, header+"."+parts[1]+".", "n")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects tokens signed by another key", func(t *testing.T) {
+		other := oidctest.NewServer(t, testClientID, testSecret, testRedirectURL)
+
+		claims := server.IDTokenClaims(user, "n")
+		_, err := provider.verifyIDToken(ctx, other.Sign(claims), "n")
+		assert.Error(t, err)
+	})
+}
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	first, err := GenerateVerifier()
+	require.NoError(t, err)
+	second, err := GenerateVerifier()
+	require.NoError(t, err)
+	assert.Len(t, first, 43)
+	assert.NotEqual(t, first, second)
+}
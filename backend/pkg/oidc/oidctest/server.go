@@ -0,0 +1,301 @@
+// Package oidctest provides a local OpenID Connect provider for tests of the authorization code flow with PKCE
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// KeyID names the server's signing key in ID token headers and its key set
+const KeyID = "oidctest"
+
+// User is the identity the server signs in at its authorization endpoint
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Server is an identity provider running on a local HTTP server
+// Its authorization endpoint signs in the current user without a login page and redirects straight back with a code
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string // empty to accept public clients without client authentication
+	RedirectURL  string
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	user  User
+	codes map[string]*authorization
+}
+
+// authorization is an issued code waiting to be exchanged for tokens
+type authorization struct {
+	user      User
+	nonce     string
+	challenge string
+}
+
+// NewServer starts a provider for one registered client
+// The server is closed when the test finishes
+func NewServer(t testing.TB, clientID, clientSecret, redirectURL string) *Server {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("oidctest: failed to generate signing key: %v", err)
+	}
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		key:          key,
+		user:         User{Subject: "user-1", Email: "user@example.com", EmailVerified: true, Name: "Test User"},
+		codes:        make(map[string]*authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/jwks", s.handleJWKS)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// SetUser changes the identity signed in by later authorization requests
+func (s *Server) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// Authorize follows an authorization URL like a browser whose user is already signed in at the provider
+// Returns the code and state from the redirect back to the client
+func (s *Server) Authorize(authURL string) (string, string, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorization endpoint returned status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	query := location.Query()
+	if query.Get("error") != "" {
+		return "", "", fmt.Errorf("authorization failed: %s", query.Get("error"))
+	}
+	return query.Get("code"), query.Get("state"), nil
+}
+
+// Sign returns a JWT of the given claims signed with the server's key
+// Tests use it to build ID tokens the authorization flow would not produce
+func (s *Server) Sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": KeyID})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: failed to sign token: %v", err))
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// IDTokenClaims returns the claims of an ID token for a user, valid for five minutes
+func (s *Server) IDTokenClaims(user User, nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":            s.URL,
+		"sub":            user.Subject,
+		"aud":            s.ClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+}
+
+// handleDiscovery serves the discovery document
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// handleAuthorize checks an authorization request and redirects back with a code for the current user
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Errors about the client or redirect URL must not be sent to the redirect URL
+	if query.Get("client_id") != s.ClientID || query.Get("redirect_uri") != s.RedirectURL {
+		http.Error(w, "unknown client or redirect URL", http.StatusBadRequest)
+		return
+	}
+
+	redirect, _ := url.Parse(s.RedirectURL)
+	params := redirect.Query()
+	params.Set("state", query.Get("state"))
+
+	switch {
+	case query.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case !containsScope(query.Get("scope"), "openid"):
+		params.Set("error", "invalid_scope")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+	default:
+		code := randomString()
+		s.mu.Lock()
+		s.codes[code] = &authorization{
+			user:      s.user,
+			nonce:     query.Get("nonce"),
+			challenge: query.Get("code_challenge"),
+		}
+		s.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// handleToken exchanges a code for an ID token once the client and its PKCE verifier check out
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	if !s.authenticateClient(r) {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// Codes are single use
+	s.mu.Lock()
+	auth := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if auth == nil || r.PostForm.Get("redirect_uri") != s.RedirectURL {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     s.Sign(s.IDTokenClaims(auth.user, auth.nonce)),
+	})
+}
+
+// authenticateClient checks the client ID and, for confidential clients, the secret sent with HTTP basic auth or in the form
+func (s *Server) authenticateClient(r *http.Request) bool {
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+	return clientID == s.ClientID && secret == s.ClientSecret
+}
+
+// handleJWKS serves the public signing key
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	public := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+// containsScope reports whether a space separated scope list includes a scope
+func containsScope(scopes, scope string) bool {
+	for _, value := range strings.Fields(scopes) {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenError writes an OAuth error response from the token endpoint
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// randomString returns a random URL-safe string for codes and access tokens
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("oidctest: failed to generate random value: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
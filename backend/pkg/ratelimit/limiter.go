@@ -0,0 +1,133 @@
+// Package ratelimit provides Redis-backed rate limiters shared by every replica of the server
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/pkg/redis"
+
+	redislib "github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix is the prefix of every Redis key written by the rate limiters
+const KeyPrefix = "ratelimit"
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // requests left before the limit is reached
+	Reset      time.Duration // until the current window ends, or until a request is allowed again when denied
+	RetryAfter time.Duration // until a request is allowed again; zero when allowed
+}
+
+// weightScale is the fixed-point scale of the previous window's weight, so the limit check uses exact integer arithmetic
+const weightScale = 1000000
+
+// slidingWindowScript counts a request against the current fixed window unless the sliding estimate is at the limit
+// KEYS: current window, previous window. ARGV: limit, weight of the previous window in millionths, TTL of the current window in ms
+// Returns whether the request was allowed and the counts of both windows
+var slidingWindowScript = redislib.NewScript(`
+local scale = 1000000
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+if previous * tonumber(ARGV[2]) + (current + 1) * scale > tonumber(ARGV[1]) * scale then
+	return {0, current, previous}
+end
+current = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return {1, current, previous}
+`)
+
+// Limiter allows a fixed number of requests per key within a sliding window
+// It approximates the window from the counts of the current and previous fixed windows, so each key costs two small counters
+type Limiter struct {
+	client *redis.Client
+	name   string
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewLimiter creates a limiter allowing limit requests per window for each key
+// The name separates the counters of different limiters in Redis
+func NewLimiter(client *redis.Client, name string, limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		client: client,
+		name:   name,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// Allow counts a request for key and reports whether it is within the limit
+// Denied requests are not counted, so clients that back off regain access on schedule
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	index := now.UnixNano() / int64(l.window)
+	elapsed := time.Duration(now.UnixNano() % int64(l.window))
+	// Rounded down, so the check never denies a request that exact arithmetic would allow
+	weight := int64(l.window-elapsed) * weightScale / int64(l.window)
+
+	keys := []string{l.windowKey(key, index), l.windowKey(key, index-1)}
+	values, err := slidingWindowScript.Run(ctx, l.client.Client, keys,
+		l.limit, weight, (2 * l.window).Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", values)
+	}
+
+	allowed, current, previous := values[0] == 1, values[1], values[2]
+	used := (previous*weight + current*weightScale + weightScale - 1) / weightScale
+	result := Result{
+		Allowed:   allowed,
+		Limit:     l.limit,
+		Remaining: int(max(0, int64(l.limit)-used)),
+		Reset:     l.window - elapsed,
+	}
+	if !allowed {
+		result.RetryAfter = l.retryAfter(current, previous, elapsed)
+		result.Reset = result.RetryAfter
+	}
+
+	return result, nil
+}
+
+// retryAfter returns how long until the sliding estimate leaves room for another request
+// Rounded up to whole seconds, as clients read it from the Retry-After header
+func (l *Limiter) retryAfter(current, previous int64, elapsed time.Duration) time.Duration {
+	limit := int64(l.limit)
+
+	var wait time.Duration
+	if current+1 <= limit && previous > 0 {
+		// Room opens up within this window once the previous window's share has decayed enough
+		wait = decayTime(l.window, previous, limit-current-1) - elapsed
+	} else {
+		// This window is full: wait for the next one, where this window's count decays in turn
+		wait = l.window - elapsed
+		if current > 0 {
+			wait += decayTime(l.window, current, limit-1)
+		}
+	}
+
+	wait = (wait + time.Second - 1).Truncate(time.Second)
+	return max(time.Second, wait)
+}
+
+// decayTime returns how far into a window a previous window's count of previous has decayed to at most allowed
+func decayTime(window time.Duration, previous, allowed int64) time.Duration {
+	if allowed >= previous {
+		return 0
+	}
+	return time.Duration((int64(window)*(previous-allowed) + previous - 1) / previous)
+}
+
+// windowKey returns the key of the counter for key in the fixed window with the given index
+func (l *Limiter) windowKey(key string, index int64) string {
+	return fmt.Sprintf("%s:%s:%s:%d", KeyPrefix, l.name, key, index)
+}
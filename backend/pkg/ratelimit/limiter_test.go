@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLimiter creates a limiter backed by miniredis whose clock is controlled by the returned pointer
+func setupLimiter(t *testing.T, limit int, window time.Duration) (*Limiter, *time.Time, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	require.NoError(t, err)
+
+	client, err := redis.NewClient(&redis.Config{Host: mr.Host(), Port: port})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	// Start at the beginning of a window so the tests control how much of it has elapsed
+	now := time.Unix(1700000000, 0).Truncate(window)
+	limiter := NewLimiter(client, "test", limit, window)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now, mr
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allows up to the limit and then denies", func(t *testing.T) {
+		limiter, _, _ := setupLimiter(t, 3, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow(ctx, "client")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 3, result.Limit)
+			assert.Equal(t, 2-i, result.Remaining)
+			assert.Equal(t, time.Minute, result.Reset)
+			assert.Zero(t, result.RetryAfter)
+		}
+
+		result, err := limiter.Allow(ctx, "client")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Greater(t, result.RetryAfter, time.Minute)
+		assert.Equal(t, result.RetryAfter, result.Reset)
+	})
+
+	t.Run("keys are limited independently", func(t *testing.T) {
+		limiter, _, _ := setupLimiter(t, 1, time.Minute)
+
+		first, _ := limiter.Allow(ctx, "a")
+		second, _ := limiter.Allow(ctx, "b")
+		assert.True(t, first.Allowed)
+		assert.True(t, second.Allowed)
+
+		again, _ := limiter.Allow(ctx, "a")
+		assert.False(t, again.Allowed)
+	})
+
+	t.Run("previous window counts in proportion to its overlap", func(t *testing.T) {
+		limiter, now, _ := setupLimiter(t, 10, time.Minute)
+		for i := 0; i < 10; i++ {
+			limiter.Allow(ctx, "client")
+		}
+
+		// A quarter into the next window, three quarters of the previous count still applies
+		*now = now.Add(time.Minute + 15*time.Second)
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			if result, _ := limiter.Allow(ctx, "client"); result.Allowed {
+				allowed++
+			}
+		}
+		assert.Equal(t, 2, allowed)
+	})
+
+	t.Run("retry after is when a request is allowed again", func(t *testing.T) {
+		limiter, now, _ := setupLimiter(t, 10, time.Minute)
+		for i := 0; i < 10; i++ {
+			limiter.Allow(ctx, "client")
+		}
+
+		*now = now.Add(time.Minute + 15*time.Second)
+		var denied Result
+		for {
+			result, _ := limiter.Allow(ctx, "client")
+			if !result.Allowed {
+				denied = result
+				break
+			}
+		}
+
+		*now = now.Add(denied.RetryAfter - time.Second)
+		early, _ := limiter.Allow(ctx, "client")
+		assert.False(t, early.Allowed, "should still be limited just before Retry-After")
+
+		*now = now.Add(time.Second)
+		onTime, _ := limiter.Allow(ctx, "client")
+		assert.True(t, onTime.Allowed, "should be allowed once Retry-After has passed")
+	})
+
+	t.Run("denied requests are not counted", func(t *testing.T) {
+		limiter, now, _ := setupLimiter(t, 2, time.Minute)
+		for i := 0; i < 20; i++ {
+			limiter.Allow(ctx, "client")
+		}
+
+		*now = now.Add(2 * time.Minute)
+		result, _ := limiter.Allow(ctx, "client")
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("counters expire", func(t *testing.T) {
+		limiter, _, mr := setupLimiter(t, 2, time.Minute)
+		limiter.Allow(ctx, "client")
+
+		keys := mr.Keys()
+		require.Len(t, keys, 1)
+		assert.Equal(t, 2*time.Minute, mr.TTL(keys[0]))
+	})
+
+	t.Run("reports Redis failures", func(t *testing.T) {
+		limiter, _, mr := setupLimiter(t, 2, time.Minute)
+		mr.Close()
+
+		_, err := limiter.Allow(ctx, "client")
+		assert.Error(t, err)
+	})
+}
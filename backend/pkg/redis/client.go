@@ -97,10 +97,46 @@ func GenerateSetKey(prefix string) string {
 	return fmt.Sprintf("%s:set", prefix)
 }
 
+// acquireLockScript takes a lock or renews it when it is already held under the same token
+var acquireLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLockScript deletes a lock only when it is still held under the given token
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock takes the lock at key for the holder identified by token, or renews it if the holder already owns it
+// Returns false when another holder owns the lock; the lock expires after ttl unless renewed
+func (c *Client) AcquireLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireLockScript.Run(ctx, c.Client, []string{key}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+// ReleaseLock releases the lock at key if it is still owned by the holder identified by token
+// Locks taken over by another holder after expiring are left untouched
+func (c *Client) ReleaseLock(ctx context.Context, key, token string) error {
+	return releaseLockScript.Run(ctx, c.Client, []string{key}, token).Err()
+}
+
 // Redis key prefixes for different domain objects
 const (
 	UserKeyPrefix    = "user"
 	TaskKeyPrefix    = "task"
 	SessionKeyPrefix = "session"
 	AdminKeyPrefix   = "admin"
+	LockKeyPrefix    = "lock"
 )
\ No newline at end of file
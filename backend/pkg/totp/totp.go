@@ -0,0 +1,119 @@
+// Package totp implements RFC 6238 time-based one-time passwords as used by authenticator apps
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Digits is the number of digits in a code
+const Digits = 6
+
+// Period is how long each code is valid
+const Period = 30 * time.Second
+
+// secretSize is the number of random bytes in a secret, the HMAC-SHA1 key size recommended by RFC 4226
+const secretSize = 20
+
+// encoding is the base32 alphabet authenticator apps expect, without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, base32 encoded for entry into an authenticator app
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// URI that authenticator apps read from a QR code
+// The issuer names the service and the account names the user within it
+func URI(secret, issuer, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step returns the time step containing t
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code returns the code for the time step containing t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, Step(t)), nil
+}
+
+// Validate reports whether input is a valid code at time t, accepting codes up to skew steps before or after to allow for clock drift
+// Returns the time step the code belongs to, so callers can refuse to accept the same code twice
+func Validate(secret, input string, t time.Time, skew int) (int64, bool) {
+	input = strings.TrimSpace(input)
+	if len(input) != Digits {
+		return 0, false
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := Step(t)
+	for offset := -int64(skew); offset <= int64(skew); offset++ {
+		step := current + offset
+		if hmac.Equal([]byte(code(key, step)), []byte(input)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// code computes the HOTP value of RFC 4226 for a counter, truncated to Digits digits
+func code(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation: the low nibble of the last byte picks four bytes of the digest
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%modulus)
+}
+
+// decodeSecret decodes a base32 secret, ignoring case, spaces and padding as typed by users
+func decodeSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(normalized, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("invalid secret: empty")
+	}
+	return key, nil
+}
@@ -0,0 +1,128 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors ("12345678901234567890") in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := Code(rfcSecret, time.Unix(v.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "time %d", v.unix)
+	}
+
+	t.Run("accepts lowercase secrets with spaces", func(t *testing.T) {
+		code, err := Code("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "287082", code)
+	})
+
+	t.Run("rejects invalid secrets", func(t *testing.T) {
+		_, err := Code("not base32!", time.Unix(59, 0))
+		assert.Error(t, err)
+
+		_, err = Code("", time.Unix(59, 0))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	t.Run("accepts the current code and returns its step", func(t *testing.T) {
+		step, ok := Validate(rfcSecret, "050471", now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+
+		_, ok = Validate(rfcSecret, " 050471 ", now, 1)
+		assert.True(t, ok)
+	})
+
+	t.Run("accepts neighbouring steps within the skew", func(t *testing.T) {
+		previous, err := Code(rfcSecret, now.Add(-Period))
+		require.NoError(t, err)
+		next, err := Code(rfcSecret, now.Add(Period))
+		require.NoError(t, err)
+
+		step, ok := Validate(rfcSecret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, current-1, step)
+
+		step, ok = Validate(rfcSecret, next, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, current+1, step)
+
+		_, ok = Validate(rfcSecret, previous, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects codes outside the skew", func(t *testing.T) {
+		old, err := Code(rfcSecret, now.Add(-2*Period))
+		require.NoError(t, err)
+
+		_, ok := Validate(rfcSecret, old, now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects malformed codes and secrets", func(t *testing.T) {
+		for _, code := range []string{"", "05047", "0504711", "abcdef"} {
+			_, ok := Validate(rfcSecret, code, now, 1)
+			assert.False(t, ok, code)
+		}
+
+		_, ok := Validate("not base32!", "050471", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := GenerateSecret()
+	require.NoError(t, err)
+	second, err := GenerateSecret()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+
+	// Generated secrets produce codes
+	code, err := Code(first, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, code, Digits)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI(rfcSecret, "Task Tracker", "user@example.com")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Task%20Tracker:user@example.com?"))
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, rfcSecret, query.Get("secret"))
+	assert.Equal(t, "Task Tracker", query.Get("issuer"))
+	assert.Equal(t, "6", query.Get("digits"))
+	assert.Equal(t, "30", query.Get("period"))
+}
@@ -1,11 +1,19 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"backend/pkg/oidc/oidctest"
+	"backend/pkg/totp"
+
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,6 +120,26 @@ func TestUserLoginFlow(t *testing.T) {
 		assert.True(t, found, "Session cookie should be set")
 	})
 
+	t.Run("login without remember me uses a short session", func(t *testing.T) {
+		body, err := json.Marshal(map[string]interface{}{
+			"email":    user.Email,
+			"password": user.Password,
+		})
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/login", body, nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		cookies := resp.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, 0, cookies[0].MaxAge) // Browser-session cookie
+
+		sessionID, ok := ts.Signer.Verify(cookies[0].Value)
+		require.True(t, ok)
+		ttl := ts.MiniRedis.TTL("session:" + sessionID)
+		assert.Equal(t, 24*time.Hour, ttl)
+	})
+
 	t.Run("invalid credentials should fail", func(t *testing.T) {
 		invalidUser := CreateTestUser()
 		invalidUser.Email = user.Email
@@ -163,6 +191,216 @@ func TestUserLoginFlow(t *testing.T) {
 	})
 }
 
+// TestSessionManagement tests listing and revoking a user's signed-in devices
+// Verifies that revoked sessions stop authenticating while the current one keeps working
+func TestSessionManagement(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+
+	// Sign in from three devices; registering opens a fourth, uncaptured session
+	desktop, laptop, phone := *user, *user, *user
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, &desktop).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, &laptop).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, &phone).Code)
+
+	listSessions := func(t *testing.T, device *TestUser) []map[string]interface{} {
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/sessions", nil, device)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response struct {
+			Sessions []map[string]interface{} `json:"sessions"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Sessions
+	}
+
+	t.Run("list sessions marks the current device", func(t *testing.T) {
+		sessions := listSessions(t, &laptop)
+		require.Len(t, sessions, 4)
+
+		for _, session := range sessions {
+			assert.Equal(t, session["id"] == ts.RawSessionID(t, &laptop), session["current"])
+			assert.NotEmpty(t, session["ip"])
+			assert.NotEmpty(t, session["lastSeenAt"])
+		}
+	})
+
+	t.Run("revoke another device", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/sessions/"+ts.RawSessionID(t, &phone), nil, &laptop)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &phone)
+		AssertErrorResponse(t, meResp, http.StatusUnauthorized, "4002")
+		assert.Len(t, listSessions(t, &laptop), 3)
+	})
+
+	t.Run("cannot revoke another user's session", func(t *testing.T) {
+		other := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, other).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, other).Code)
+
+		resp := ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/sessions/"+ts.RawSessionID(t, other), nil, &laptop)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4029")
+
+		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, other)
+		assert.Equal(t, http.StatusOK, meResp.Code)
+	})
+
+	t.Run("unsigned session cookie is rejected", func(t *testing.T) {
+		unsigned := TestUser{SessionID: ts.RawSessionID(t, &laptop)}
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &unsigned)
+		AssertErrorResponse(t, resp, http.StatusUnauthorized, "4002")
+	})
+
+	t.Run("log out everywhere else", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/sessions", nil, &laptop)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["revoked"])
+
+		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &desktop)
+		AssertErrorResponse(t, meResp, http.StatusUnauthorized, "4002")
+
+		sessions := listSessions(t, &laptop)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, ts.RawSessionID(t, &laptop), sessions[0]["id"])
+	})
+}
+
+// TestAccountManagement tests updating the profile, changing the password and deleting the account
+// Verifies that a password change signs out other devices and that deletion leaves no data behind
+func TestAccountManagement(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+
+	desktop, phone := *user, *user
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, &desktop).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, &phone).Code)
+	ts.SeedMultipleTasks(t, &desktop, 3)
+
+	t.Run("update display name", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"displayName": "Renamed User"})
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", "/api/v1/auth/me", body, &desktop)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &phone)
+		assert.Contains(t, meResp.Body.String(), "Renamed User")
+	})
+
+	t.Run("change password with wrong current password", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"currentPassword": "Wrong123!", "newPassword": "NewPass456@"})
+		resp := ts.MakeAuthenticatedRequest(t, "PUT", "/api/v1/auth/password", body, &desktop)
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4032")
+	})
+
+	t.Run("change password signs out other devices", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"currentPassword": user.Password, "newPassword": "NewPass456@"})
+		resp := ts.MakeAuthenticatedRequest(t, "PUT", "/api/v1/auth/password", body, &desktop)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		// The old cookie was revoked with the rest; the response carries a new one
+		oldDesktop := desktop
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == "session" {
+				desktop.SessionID = cookie.Value
+			}
+		}
+		require.NotEqual(t, oldDesktop.SessionID, desktop.SessionID)
+
+		AssertErrorResponse(t, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &phone), http.StatusUnauthorized, "4002")
+		AssertErrorResponse(t, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &oldDesktop), http.StatusUnauthorized, "4002")
+		assert.Equal(t, http.StatusOK, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &desktop).Code)
+
+		assert.Equal(t, http.StatusUnauthorized, ts.LoginUser(t, user).Code)
+		user.Password = "NewPass456@"
+		assert.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+	})
+
+	t.Run("delete account removes all user data", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"password": "Wrong123!"})
+		resp := ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/me", body, &desktop)
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4032")
+
+		body, _ = json.Marshal(map[string]string{"password": user.Password})
+		resp = ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/me", body, &desktop)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		AssertErrorResponse(t, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &desktop), http.StatusUnauthorized, "4002")
+		assert.Equal(t, http.StatusUnauthorized, ts.LoginUser(t, user).Code)
+
+		// Only global keys may remain, and none of them may mention the user
+		for _, key := range ts.MiniRedis.Keys() {
+			assert.NotContains(t, key, user.ID)
+			assert.False(t, strings.HasPrefix(key, "task:") || strings.HasPrefix(key, "session:"), "left behind %s", key)
+		}
+		assert.NotContains(t, ts.MiniRedis.Keys(), "user:email:"+user.Email)
+	})
+}
+
+// TestPasswordReset tests resetting a forgotten password through an emailed link
+// Verifies the link works once, signs out every session and does not reveal unknown emails
+func TestPasswordReset(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+	session := *user
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": email})
+		return ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/forgot-password", body, nil)
+	}
+	reset := func(token, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"token": token, "newPassword": password})
+		return ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/reset-password", body, nil)
+	}
+
+	t.Run("unknown email gets the same response and no email", func(t *testing.T) {
+		resp := forgot("nobody@example.com")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "If an account exists")
+		assert.Empty(t, ts.MessagesWithSubject("Reset your password"))
+	})
+
+	var token string
+	t.Run("known email receives a reset link", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, forgot(user.Email).Code)
+
+		// The email is sent in the background
+		require.Eventually(t, func() bool { return len(ts.MessagesWithSubject("Reset your password")) == 1 }, 5*time.Second, 10*time.Millisecond)
+		messages := ts.MessagesWithSubject("Reset your password")
+		assert.Equal(t, []string{user.Email}, messages[0].To)
+		token = LinkToken(t, messages[0].Body, "/reset-password")
+	})
+
+	t.Run("weak password keeps the link usable", func(t *testing.T) {
+		AssertErrorResponse(t, reset(token, "weak"), http.StatusBadRequest, "4033")
+	})
+
+	t.Run("reset signs out every session", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, reset(token, "NewPass456@").Code)
+
+		AssertErrorResponse(t, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, &session), http.StatusUnauthorized, "4002")
+		assert.Equal(t, http.StatusUnauthorized, ts.LoginUser(t, user).Code)
+		user.Password = "NewPass456@"
+		assert.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+	})
+
+	t.Run("link cannot be reused", func(t *testing.T) {
+		AssertErrorResponse(t, reset(token, "Another789#"), http.StatusBadRequest, "4035")
+	})
+}
+
 // TestTaskCRUDOperations tests complete task CRUD workflow with authentication
 // Verifies create, read, update, delete operations for tasks
 func TestTaskCRUDOperations(t *testing.T) {
@@ -244,6 +482,414 @@ func TestTaskCRUDOperations(t *testing.T) {
 		assert.True(t, updatedTask["completed"].(bool))
 	})
 
+	t.Run("edit task description and category", func(t *testing.T) {
+		// Create a task first
+		task := CreateTestTask(user.ID)
+		createResp := ts.CreateTaskWithAuth(t, user, task)
+		require.Equal(t, http.StatusCreated, createResp.Code)
+
+		// Edit description and move to another category
+		reqBody := map[string]string{
+			"description": "Edited description",
+			"category":    "edited",
+		}
+		body, err := json.Marshal(reqBody)
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", fmt.Sprintf("/api/v1/tasks/%s", task.ID), body, user)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		task.Description = "Edited description"
+		task.Category = "edited"
+		AssertTaskResponse(t, resp, task)
+
+		// Verify task is listed under its new category
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?category=edited", nil, user)
+		AssertTaskListResponse(t, resp, 1)
+	})
+
+	t.Run("edit nonexistent or deleted task should fail", func(t *testing.T) {
+		body, err := json.Marshal(map[string]string{"description": "Edited description"})
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", "/api/v1/tasks/nonexistent-id", body, user)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+
+		task := CreateTestTask(user.ID)
+		createResp := ts.CreateTaskWithAuth(t, user, task)
+		require.Equal(t, http.StatusCreated, createResp.Code)
+
+		resp = ts.MakeAuthenticatedRequest(t, "DELETE", fmt.Sprintf("/api/v1/tasks/%s", task.ID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		resp = ts.MakeAuthenticatedRequest(t, "PATCH", fmt.Sprintf("/api/v1/tasks/%s", task.ID), body, user)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+	})
+
+	t.Run("edit task with too long description should fail", func(t *testing.T) {
+		task := CreateTestTask(user.ID)
+		createResp := ts.CreateTaskWithAuth(t, user, task)
+		require.Equal(t, http.StatusCreated, createResp.Code)
+
+		body, err := json.Marshal(map[string]string{"description": strings.Repeat("a", 10001)})
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", fmt.Sprintf("/api/v1/tasks/%s", task.ID), body, user)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4015")
+	})
+
+	t.Run("create task with due date and list overdue", func(t *testing.T) {
+		// Create a task that is already past due
+		dueDate := time.Now().UTC().Add(-24 * time.Hour).Format(time.RFC3339)
+		reqBody := map[string]string{
+			"description": "Overdue task",
+			"category":    "deadlines",
+			"dueDate":     dueDate,
+		}
+		body, err := json.Marshal(reqBody)
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, user)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		assert.Equal(t, dueDate, created["dueDate"])
+		assert.Equal(t, true, created["overdue"])
+
+		// Only the past-due task is returned by the overdue filter
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?overdue=true", nil, user)
+		AssertTaskListResponse(t, resp, 1)
+
+		// Malformed dates are rejected
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?dueBefore=tomorrow", nil, user)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4021")
+	})
+
+	t.Run("create tasks with priorities and filter by priority", func(t *testing.T) {
+		for _, priority := range []string{"urgent", "low"} {
+			reqBody := map[string]string{
+				"description": "Task with " + priority + " priority",
+				"priority":    priority,
+			}
+			body, err := json.Marshal(reqBody)
+			require.NoError(t, err)
+
+			resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, user)
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			var created map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+			assert.Equal(t, priority, created["priority"])
+		}
+
+		// Only the urgent task matches the priority filter
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?priority=urgent", nil, user)
+		AssertTaskListResponse(t, resp, 1)
+
+		// Sorting by priority puts the urgent task first
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?sort=priority", nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var listResp map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &listResp))
+		tasks := listResp["tasks"].([]interface{})
+		require.NotEmpty(t, tasks)
+		assert.Equal(t, "urgent", tasks[0].(map[string]interface{})["priority"])
+
+		// Unknown priorities are rejected
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?priority=critical", nil, user)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4022")
+	})
+
+	t.Run("completing a recurring task schedules the next occurrence", func(t *testing.T) {
+		dueDate := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+		reqBody := map[string]string{
+			"description": "Weekly status report",
+			"dueDate":     dueDate.Format(time.RFC3339),
+			"recurrence":  "weekly",
+		}
+		body, err := json.Marshal(reqBody)
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, user)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		assert.Equal(t, "FREQ=WEEKLY", created["recurrence"])
+		taskID := created["id"].(string)
+
+		// Complete the first occurrence
+		body, err = json.Marshal(map[string]bool{"completed": true})
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "PUT", fmt.Sprintf("/api/v1/tasks/%s/complete", taskID), body, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		// History lists the completed occurrence followed by the new one
+		resp = ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s/history", taskID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var historyResp struct {
+			History []map[string]interface{} `json:"history"`
+			Total   int                      `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &historyResp))
+		require.Equal(t, 2, historyResp.Total)
+		assert.Equal(t, taskID, historyResp.History[0]["id"])
+		assert.Equal(t, true, historyResp.History[0]["completed"])
+		assert.NotEmpty(t, historyResp.History[0]["completedAt"])
+		assert.Equal(t, false, historyResp.History[1]["completed"])
+		assert.Equal(t, dueDate.AddDate(0, 0, 7).Format(time.RFC3339), historyResp.History[1]["dueDate"])
+	})
+
+	t.Run("subtasks report progress and follow their parent through delete and restore", func(t *testing.T) {
+		createTask := func(description, parentID string) string {
+			body, err := json.Marshal(map[string]string{
+				"description": description,
+				"parentId":    parentID,
+			})
+			require.NoError(t, err)
+
+			resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, user)
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			var created map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+			if parentID != "" {
+				assert.Equal(t, parentID, created["parentId"])
+			}
+			return created["id"].(string)
+		}
+
+		parentID := createTask("Release checklist", "")
+		firstID := createTask("Write changelog", parentID)
+		createTask("Tag release", parentID)
+
+		// Subtasks cannot be nested
+		body, err := json.Marshal(map[string]string{"description": "Too deep", "parentId": firstID})
+		require.NoError(t, err)
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, user)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4024")
+
+		body, err = json.Marshal(map[string]bool{"completed": true})
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "PUT", fmt.Sprintf("/api/v1/tasks/%s/complete", firstID), body, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		// The parent shows its subtasks and progress
+		resp = ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s", parentID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var parentResp struct {
+			Subtasks []map[string]interface{} `json:"subtasks"`
+			Progress struct {
+				Completed int `json:"completed"`
+				Total     int `json:"total"`
+			} `json:"progress"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &parentResp))
+		assert.Len(t, parentResp.Subtasks, 2)
+		assert.Equal(t, 1, parentResp.Progress.Completed)
+		assert.Equal(t, 2, parentResp.Progress.Total)
+
+		// Deleting the parent removes its subtasks too
+		resp = ts.MakeAuthenticatedRequest(t, "DELETE", fmt.Sprintf("/api/v1/tasks/%s", parentID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s/subtasks", parentID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var subtasksResp struct {
+			Total int `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &subtasksResp))
+		assert.Equal(t, 0, subtasksResp.Total)
+
+		// A subtask cannot be restored before its parent
+		resp = ts.MakeAuthenticatedRequest(t, "POST", fmt.Sprintf("/api/v1/tasks/%s/restore", firstID), nil, user)
+		AssertErrorResponse(t, resp, http.StatusConflict, "4024")
+
+		// Restoring the parent brings the subtasks back
+		resp = ts.MakeAuthenticatedRequest(t, "POST", fmt.Sprintf("/api/v1/tasks/%s/restore", parentID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s/subtasks", parentID), nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &subtasksResp))
+		assert.Equal(t, 2, subtasksResp.Total)
+	})
+
+	t.Run("tags can be filtered, renamed and merged", func(t *testing.T) {
+		tagUser := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, tagUser).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, tagUser).Code)
+
+		createTask := func(description string, tags []string) string {
+			body, err := json.Marshal(map[string]interface{}{
+				"description": description,
+				"tags":        tags,
+			})
+			require.NoError(t, err)
+
+			resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, tagUser)
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			var created map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+			return created["id"].(string)
+		}
+
+		createTask("Fix login bug", []string{"Urgent", "backend"})
+		createTask("Polish header", []string{"frontend"})
+		createTask("Tune queries", []string{"backend", "db"})
+
+		listTotal := func(query string) int {
+			resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?"+query, nil, tagUser)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var listResp struct {
+				Total int `json:"total"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &listResp))
+			return listResp.Total
+		}
+
+		assert.Equal(t, 3, listTotal("tags=urgent,frontend,db"))
+		assert.Equal(t, 2, listTotal("tags=backend"))
+		assert.Equal(t, 1, listTotal("tags=backend,urgent&tagMatch=all"))
+
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?tags=backend&tagMatch=some", nil, tagUser)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4025")
+
+		// Renaming onto an existing tag is rejected
+		body, err := json.Marshal(map[string]string{"newName": "frontend"})
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "PUT", "/api/v1/tags/db", body, tagUser)
+		AssertErrorResponse(t, resp, http.StatusConflict, "4026")
+
+		body, err = json.Marshal(map[string]string{"newName": "database"})
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "PUT", "/api/v1/tags/db", body, tagUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		body, err = json.Marshal(map[string]string{"into": "backend"})
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tags/database/merge", body, tagUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tags", nil, tagUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var tagsResp struct {
+			Tags []struct {
+				Name      string `json:"name"`
+				TaskCount int    `json:"taskCount"`
+			} `json:"tags"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &tagsResp))
+		counts := make(map[string]int)
+		for _, tag := range tagsResp.Tags {
+			counts[tag.Name] = tag.TaskCount
+		}
+		assert.Equal(t, map[string]int{"backend": 2, "frontend": 1, "urgent": 1}, counts)
+	})
+
+	t.Run("search ranks matching tasks and follows edits and deletes", func(t *testing.T) {
+		searchUser := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, searchUser).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, searchUser).Code)
+
+		createTask := func(description string) string {
+			body, err := json.Marshal(map[string]string{"description": description})
+			require.NoError(t, err)
+
+			resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/tasks", body, searchUser)
+			require.Equal(t, http.StatusCreated, resp.Code)
+
+			var created map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+			return created["id"].(string)
+		}
+
+		search := func(query string) []string {
+			resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks/search?q="+query, nil, searchUser)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var searchResp struct {
+				Tasks []struct {
+					ID string `json:"id"`
+				} `json:"tasks"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &searchResp))
+			ids := make([]string, len(searchResp.Tasks))
+			for i, task := range searchResp.Tasks {
+				ids[i] = task.ID
+			}
+			return ids
+		}
+
+		reportID := createTask("Quarterly report: draft the report and send the report")
+		reviewID := createTask("Review quarterly numbers")
+		templateID := createTask("Reporting template")
+		createTask("Water the plants")
+
+		// Repeated whole-word matches outrank a single prefix match
+		assert.Equal(t, []string{reportID, templateID}, search("report"))
+		assert.ElementsMatch(t, []string{reportID, reviewID}, search("QUARTER"))
+		assert.Equal(t, []string{reportID}, search("quarterly%20rep"))
+
+		// Edits re-index the description
+		body, err := json.Marshal(map[string]string{"description": "Review yearly numbers"})
+		require.NoError(t, err)
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", fmt.Sprintf("/api/v1/tasks/%s", reviewID), body, searchUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, []string{reportID}, search("quarterly"))
+		assert.Equal(t, []string{reviewID}, search("yearly"))
+
+		// Deleted tasks drop out of the results
+		resp = ts.MakeAuthenticatedRequest(t, "DELETE", fmt.Sprintf("/api/v1/tasks/%s", reportID), nil, searchUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, []string{templateID}, search("report"))
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks/search?q=", nil, searchUser)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4015")
+	})
+
+	t.Run("list tasks follows cursors with real totals", func(t *testing.T) {
+		pageUser := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, pageUser).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, pageUser).Code)
+		ts.SeedMultipleTasks(t, pageUser, 3)
+
+		type listResponse struct {
+			Tasks []struct {
+				ID string `json:"id"`
+			} `json:"tasks"`
+			Total      int     `json:"total"`
+			NextCursor *string `json:"nextCursor"`
+		}
+
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?limit=2", nil, pageUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var first listResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &first))
+		assert.Len(t, first.Tasks, 2)
+		assert.Equal(t, 3, first.Total)
+		require.NotNil(t, first.NextCursor)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?limit=2&cursor="+*first.NextCursor, nil, pageUser)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var second listResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &second))
+		require.Len(t, second.Tasks, 1)
+		assert.Equal(t, 3, second.Total)
+		assert.Nil(t, second.NextCursor)
+		assert.NotEqual(t, first.Tasks[0].ID, second.Tasks[0].ID)
+		assert.NotEqual(t, first.Tasks[1].ID, second.Tasks[0].ID)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks?cursor=bogus", nil, pageUser)
+		AssertErrorResponse(t, resp, http.StatusBadRequest, "4028")
+	})
+
 	t.Run("list tasks", func(t *testing.T) {
 		// Create multiple tasks
 		tasks := ts.SeedMultipleTasks(t, user, 5)
@@ -525,6 +1171,42 @@ func TestUserIsolation(t *testing.T) {
 		AssertErrorResponse(t, resp, http.StatusNotFound, "4017") // Should not find the task
 	})
 
+	t.Run("user cannot edit another user's task", func(t *testing.T) {
+		task1 := CreateTestTask(user1.ID)
+		createResp1 := ts.CreateTaskWithAuth(t, user1, task1)
+		require.Equal(t, http.StatusCreated, createResp1.Code)
+
+		body, err := json.Marshal(map[string]string{"description": "Hijacked"})
+		require.NoError(t, err)
+
+		resp := ts.MakeAuthenticatedRequest(t, "PATCH", fmt.Sprintf("/api/v1/tasks/%s", task1.ID), body, user2)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+	})
+
+	t.Run("user cannot read another user's task history", func(t *testing.T) {
+		task1 := CreateTestTask(user1.ID)
+		createResp1 := ts.CreateTaskWithAuth(t, user1, task1)
+		require.Equal(t, http.StatusCreated, createResp1.Code)
+
+		resp := ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s/history", task1.ID), nil, user2)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks/nonexistent-id/history", nil, user2)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+	})
+
+	t.Run("user cannot list subtasks of another user's task", func(t *testing.T) {
+		task1 := CreateTestTask(user1.ID)
+		createResp1 := ts.CreateTaskWithAuth(t, user1, task1)
+		require.Equal(t, http.StatusCreated, createResp1.Code)
+
+		resp := ts.MakeAuthenticatedRequest(t, "GET", fmt.Sprintf("/api/v1/tasks/%s/subtasks", task1.ID), nil, user2)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/tasks/nonexistent-id/subtasks", nil, user2)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4017")
+	})
+
 	t.Run("user can only see their own categories", func(t *testing.T) {
 		// Create tasks with categories for both users
 		task1 := CreateTestTask(user1.ID)
@@ -678,4 +1360,564 @@ func TestFullWorkflow(t *testing.T) {
 		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, user)
 		AssertErrorResponse(t, meResp, http.StatusUnauthorized, "4002")
 	})
-}
\ No newline at end of file
+}
+// TestEmailVerification tests verifying the email address after registration
+// Verifies the emailed link marks the address verified and unblocks task creation when verification is required
+func TestEmailVerification(t *testing.T) {
+	ts := SetupTestServerWithOptions(t, TestServerOptions{RequireVerifiedEmail: true})
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+	verify := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"token": token})
+		return ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/verify-email", body, nil)
+	}
+
+	t.Run("registration sends a verification email", func(t *testing.T) {
+		messages := ts.MessagesWithSubject("Verify your email address")
+		require.Len(t, messages, 1)
+		assert.Equal(t, []string{user.Email}, messages[0].To)
+
+		meResp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, user)
+		assert.Contains(t, meResp.Body.String(), `"emailVerified":false`)
+	})
+
+	t.Run("unverified users cannot create tasks", func(t *testing.T) {
+		resp := ts.CreateTaskWithAuth(t, user, CreateTestTask(user.ID))
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4039")
+	})
+
+	t.Run("forged token is rejected", func(t *testing.T) {
+		AssertErrorResponse(t, verify("bm90LWEtdG9rZW4.c2lnbmF0dXJl"), http.StatusBadRequest, "4036")
+	})
+
+	t.Run("resent link verifies the address", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/resend-verification", nil, user)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		messages := ts.MessagesWithSubject("Verify your email address")
+		require.Len(t, messages, 2)
+		token := LinkToken(t, messages[1].Body, "/verify-email")
+
+		resp = verify(token)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"emailVerified":true`)
+
+		// Opening the link again is harmless
+		assert.Equal(t, http.StatusOK, verify(token).Code)
+	})
+
+	t.Run("verified users can create tasks", func(t *testing.T) {
+		resp := ts.CreateTaskWithAuth(t, user, CreateTestTask(user.ID))
+		assert.Equal(t, http.StatusCreated, resp.Code)
+	})
+
+	t.Run("resending after verification is rejected", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/resend-verification", nil, user)
+		AssertErrorResponse(t, resp, http.StatusConflict, "4037")
+	})
+}
+
+// TestRateLimiting tests the API and login rate limits
+// Verifies the limits are enforced per IP and per email with standard headers, and cannot be dodged with X-Forwarded-For
+func TestRateLimiting(t *testing.T) {
+	login := func(ts *TestServer, email, ip, forwardedFor string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": "Wrong123!"})
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":40000"
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		resp := httptest.NewRecorder()
+		ts.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("login attempts are limited per email", func(t *testing.T) {
+		ts := SetupTestServerWithOptions(t, TestServerOptions{AuthRateLimit: 100, AuthEmailRateLimit: 3})
+		defer ts.TeardownTestServer()
+
+		for i := 0; i < 3; i++ {
+			resp := login(ts, "victim@example.com", fmt.Sprintf("10.0.0.%d", i+1), "")
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+			assert.Equal(t, "3", resp.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, fmt.Sprint(2-i), resp.Header().Get("RateLimit-Remaining"))
+		}
+
+		resp := login(ts, "victim@example.com", "10.0.0.9", "")
+		AssertErrorResponse(t, resp, http.StatusTooManyRequests, "4040")
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+		assert.NotEmpty(t, resp.Header().Get("RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusUnauthorized, login(ts, "other@example.com", "10.0.0.9", "").Code)
+	})
+
+	t.Run("login attempts are limited per IP", func(t *testing.T) {
+		ts := SetupTestServerWithOptions(t, TestServerOptions{AuthRateLimit: 2, AuthEmailRateLimit: 100})
+		defer ts.TeardownTestServer()
+
+		assert.Equal(t, http.StatusUnauthorized, login(ts, "a@example.com", "10.0.0.1", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(ts, "b@example.com", "10.0.0.1", "").Code)
+
+		// A forged X-Forwarded-For header does not give the client a fresh IP
+		resp := login(ts, "c@example.com", "10.0.0.1", "203.0.113.7")
+		AssertErrorResponse(t, resp, http.StatusTooManyRequests, "4040")
+
+		assert.Equal(t, http.StatusUnauthorized, login(ts, "c@example.com", "10.0.0.2", "").Code)
+	})
+
+	t.Run("API requests are limited per IP", func(t *testing.T) {
+		ts := SetupTestServerWithOptions(t, TestServerOptions{RateLimit: 5})
+		defer ts.TeardownTestServer()
+
+		user := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, user).Code)
+		}
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, user)
+		AssertErrorResponse(t, resp, http.StatusTooManyRequests, "4040")
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+	})
+}
+
+// TestAccountLockout tests locking accounts after repeated failed logins
+// Verifies the lock, the login activity seen by the user and unlocking by an administrator
+func TestAccountLockout(t *testing.T) {
+	ts := SetupTestServerWithOptions(t, TestServerOptions{LockoutThreshold: 3, AdminEmails: []string{"admin@example.com"}})
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+	admin := CreateTestUser()
+	admin.Email = "admin@example.com"
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, admin).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, admin).Code)
+
+	wrongPassword := &TestUser{Email: user.Email, Password: "Wrong123!"}
+	loginActivity := func(path string, as *TestUser) map[string]interface{} {
+		resp := ts.MakeAuthenticatedRequest(t, "GET", path, nil, as)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var activity map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &activity))
+		return activity
+	}
+	eventTypes := func(activity map[string]interface{}) []string {
+		var types []string
+		for _, event := range activity["events"].([]interface{}) {
+			types = append(types, event.(map[string]interface{})["type"].(string))
+		}
+		return types
+	}
+
+	t.Run("repeated failures lock the account", func(t *testing.T) {
+		AssertErrorResponse(t, ts.LoginUser(t, wrongPassword), http.StatusUnauthorized, "4010")
+		AssertErrorResponse(t, ts.LoginUser(t, wrongPassword), http.StatusUnauthorized, "4010")
+
+		resp := ts.LoginUser(t, wrongPassword)
+		AssertErrorResponse(t, resp, http.StatusLocked, "4041")
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+
+		// The right password does not get in either while the lock lasts
+		AssertErrorResponse(t, ts.LoginUser(t, &TestUser{Email: user.Email, Password: user.Password}), http.StatusLocked, "4041")
+	})
+
+	t.Run("user sees failed logins and the lock", func(t *testing.T) {
+		activity := loginActivity("/api/v1/auth/login-activity", user)
+		assert.Equal(t, float64(3), activity["failedAttempts"])
+		assert.NotNil(t, activity["lockedUntil"])
+		assert.NotNil(t, activity["lastFailedLogin"])
+		assert.NotNil(t, activity["lastSuccessfulLogin"])
+		assert.Equal(t, []string{"account_locked", "login_failed", "login_failed", "login_failed", "login_succeeded"}, eventTypes(activity))
+	})
+
+	t.Run("only administrators can unlock accounts", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/admin/users/"+user.ID+"/unlock", nil, user)
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4042")
+
+		// Registering a listed email is not enough until the address is verified
+		resp = ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/admin/users/"+user.ID+"/unlock", nil, admin)
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4042")
+
+		ts.VerifyEmail(t, admin)
+		resp = ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/admin/users/"+uuid.New().String()+"/unlock", nil, admin)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4044")
+	})
+
+	t.Run("administrator unlocks the account", func(t *testing.T) {
+		activity := loginActivity("/api/v1/admin/users/"+user.ID+"/login-activity", admin)
+		assert.NotNil(t, activity["lockedUntil"])
+
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/admin/users/"+user.ID+"/unlock", nil, admin)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+		activity = loginActivity("/api/v1/auth/login-activity", user)
+		assert.Equal(t, float64(0), activity["failedAttempts"])
+		assert.Nil(t, activity["lockedUntil"])
+		assert.Equal(t, []string{"login_succeeded", "account_unlocked"}, eventTypes(activity)[:2])
+	})
+}
+
+// TestTwoFactorAuthentication tests TOTP enrollment, the two-step login and recovery codes
+// Verifies codes and recovery codes work once and that turning two-factor authentication off restores password-only login
+func TestTwoFactorAuthentication(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		return ts.MakeAuthenticatedRequest(t, "POST", path, data, user)
+	}
+	decode := func(resp *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body), resp.Body.String())
+		return body
+	}
+	// startLogin enters the password and returns the login token for the second step
+	startLogin := func() string {
+		resp := ts.LoginUser(t, &TestUser{Email: user.Email, Password: user.Password})
+		AssertErrorResponse(t, resp, http.StatusUnauthorized, "4046")
+		assert.Empty(t, resp.Result().Cookies(), "no session before the second factor")
+		return decode(resp)["details"].(map[string]interface{})["loginToken"].(string)
+	}
+	finishLogin := func(token, code string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(map[string]string{"loginToken": token, "code": code})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/v1/auth/login/2fa", bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		ts.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var secret, enrollmentCode string
+	var recoveryCodes []interface{}
+
+	t.Run("enroll an authenticator app", func(t *testing.T) {
+		resp := post("/api/v1/auth/2fa/setup", map[string]string{})
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		setup := decode(resp)
+		secret = setup["secret"].(string)
+		assert.Contains(t, setup["uri"], "otpauth://totp/")
+
+		AssertErrorResponse(t, post("/api/v1/auth/2fa/enable", map[string]string{"code": "000000"}), http.StatusBadRequest, "4047")
+
+		var err error
+		enrollmentCode, err = totp.Code(secret, time.Now())
+		require.NoError(t, err)
+		resp = post("/api/v1/auth/2fa/enable", map[string]string{"code": enrollmentCode})
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		recoveryCodes = decode(resp)["recoveryCodes"].([]interface{})
+		assert.Len(t, recoveryCodes, 10)
+
+		status := decode(ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/2fa", nil, user))
+		assert.Equal(t, true, status["enabled"])
+		assert.Equal(t, float64(10), status["recoveryCodesRemaining"])
+
+		AssertErrorResponse(t, post("/api/v1/auth/2fa/setup", map[string]string{}), http.StatusConflict, "4049")
+	})
+
+	t.Run("login needs a fresh code", func(t *testing.T) {
+		token := startLogin()
+
+		// The code used to enroll cannot be replayed
+		AssertErrorResponse(t, finishLogin(token, enrollmentCode), http.StatusUnauthorized, "4047")
+
+		next, err := totp.Code(secret, time.Now().Add(totp.Period))
+		require.NoError(t, err)
+		resp := finishLogin(token, next)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Len(t, resp.Result().Cookies(), 1)
+
+		// The token is single-use
+		AssertErrorResponse(t, finishLogin(token, next), http.StatusUnauthorized, "4048")
+	})
+
+	t.Run("recovery codes work once", func(t *testing.T) {
+		resp := finishLogin(startLogin(), recoveryCodes[0].(string))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		user.SessionID = resp.Result().Cookies()[0].Value
+
+		AssertErrorResponse(t, finishLogin(startLogin(), recoveryCodes[0].(string)), http.StatusUnauthorized, "4047")
+
+		status := decode(ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/2fa", nil, user))
+		assert.Equal(t, float64(9), status["recoveryCodesRemaining"])
+	})
+
+	t.Run("regenerated recovery codes replace the old ones", func(t *testing.T) {
+		resp := post("/api/v1/auth/2fa/recovery-codes", map[string]string{"password": user.Password, "code": recoveryCodes[1].(string)})
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		fresh := decode(resp)["recoveryCodes"].([]interface{})
+		assert.Len(t, fresh, 10)
+
+		AssertErrorResponse(t, finishLogin(startLogin(), recoveryCodes[2].(string)), http.StatusUnauthorized, "4047")
+		recoveryCodes = fresh
+	})
+
+	t.Run("disable restores password-only login", func(t *testing.T) {
+		resp := post("/api/v1/auth/2fa/disable", map[string]string{"password": "Wrong123!", "code": recoveryCodes[0].(string)})
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4032")
+
+		resp = post("/api/v1/auth/2fa/disable", map[string]string{"password": user.Password, "code": recoveryCodes[0].(string)})
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+		status := decode(ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/2fa", nil, user))
+		assert.Equal(t, false, status["enabled"])
+	})
+}
+
+// TestAPITokens tests personal API tokens from creation through use to revocation
+// Verifies scopes limit what a token can do and that tokens cannot reach account endpoints
+func TestAPITokens(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.TeardownTestServer()
+
+	user := CreateTestUser()
+	require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+	require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+	decode := func(resp *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body), resp.Body.String())
+		return body
+	}
+	createToken := func(body string) *httptest.ResponseRecorder {
+		return ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/tokens", []byte(body), user)
+	}
+	// withToken sends a request authenticated only by the API token
+	withToken := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		ts.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var readToken, writeToken, readTokenID string
+
+	t.Run("create tokens", func(t *testing.T) {
+		resp := createToken(`{"name":"Reporting","scopes":["tasks:read"]}`)
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		body := decode(resp)
+		readToken = body["token"].(string)
+		readTokenID = body["id"].(string)
+		assert.True(t, strings.HasPrefix(readToken, "tt_"))
+		assert.Equal(t, []interface{}{"tasks:read"}, body["scopes"])
+
+		resp = createToken(`{"name":"CI","scopes":["tasks:read","tasks:write"],"expiresInDays":7}`)
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+		writeToken = decode(resp)["token"].(string)
+
+		AssertErrorResponse(t, createToken(`{"name":"CI","scopes":["admin"]}`), http.StatusBadRequest, "4056")
+		AssertErrorResponse(t, createToken(`{"name":"CI","scopes":["tasks:read"],"expiresInDays":400}`), http.StatusBadRequest, "4056")
+	})
+
+	t.Run("scopes limit what a token can do", func(t *testing.T) {
+		resp := withToken("POST", "/api/v1/tasks", `{"description":"Created by CI","category":"ci"}`, writeToken)
+		require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+		resp = withToken("GET", "/api/v1/tasks", "", readToken)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Contains(t, resp.Body.String(), "Created by CI")
+
+		resp = withToken("POST", "/api/v1/tasks", `{"description":"Not allowed"}`, readToken)
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4054")
+	})
+
+	t.Run("tokens cannot reach account endpoints", func(t *testing.T) {
+		AssertErrorResponse(t, withToken("GET", "/api/v1/auth/me", "", writeToken), http.StatusForbidden, "4055")
+		AssertErrorResponse(t, withToken("POST", "/api/v1/auth/tokens", `{"name":"x","scopes":["tasks:read"]}`, writeToken), http.StatusForbidden, "4055")
+		AssertErrorResponse(t, withToken("GET", "/api/v1/tasks", "", "tt_forged"), http.StatusUnauthorized, "4053")
+	})
+
+	t.Run("list shows last use without the tokens", func(t *testing.T) {
+		resp := ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/tokens", nil, user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.NotContains(t, resp.Body.String(), readToken)
+		assert.NotContains(t, resp.Body.String(), writeToken)
+
+		tokens := decode(resp)["tokens"].([]interface{})
+		require.Len(t, tokens, 2)
+		for _, token := range tokens {
+			assert.NotNil(t, token.(map[string]interface{})["lastUsedAt"])
+		}
+	})
+
+	t.Run("revoked tokens stop working", func(t *testing.T) {
+		other := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, other).Code)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, other).Code)
+		resp := ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/tokens/"+readTokenID, nil, other)
+		AssertErrorResponse(t, resp, http.StatusNotFound, "4057")
+
+		resp = ts.MakeAuthenticatedRequest(t, "DELETE", "/api/v1/auth/tokens/"+readTokenID, nil, user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		AssertErrorResponse(t, withToken("GET", "/api/v1/tasks", "", readToken), http.StatusUnauthorized, "4053")
+		assert.Equal(t, http.StatusOK, withToken("GET", "/api/v1/tasks", "", writeToken).Code)
+	})
+
+	t.Run("changing the password revokes every token", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"currentPassword": user.Password, "newPassword": "NewPass456@"})
+		resp := ts.MakeAuthenticatedRequest(t, "PUT", "/api/v1/auth/password", body, user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		user.Password = "NewPass456@"
+
+		AssertErrorResponse(t, withToken("GET", "/api/v1/tasks", "", writeToken), http.StatusUnauthorized, "4053")
+	})
+}
+
+// TestOIDCLogin tests signing in with an identity provider through the authorization code flow
+// Verifies accounts are created or linked by verified email and the usual session is issued
+func TestOIDCLogin(t *testing.T) {
+	ts := SetupTestServerWithOptions(t, TestServerOptions{OIDC: true})
+	defer ts.TeardownTestServer()
+
+	decode := func(resp *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body), resp.Body.String())
+		return body
+	}
+
+	t.Run("first sign-in creates a verified account", func(t *testing.T) {
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-new", Email: "new.person@example.com", EmailVerified: true, Name: "New Person"})
+		user := &TestUser{}
+
+		resp := ts.LoginWithIdP(t, user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		require.NotEmpty(t, user.SessionID)
+
+		me := decode(ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, user))
+		assert.Equal(t, user.ID, me["id"])
+		assert.Equal(t, "new.person@example.com", me["email"])
+		assert.Equal(t, "New Person", me["displayName"])
+		assert.Equal(t, true, me["emailVerified"])
+
+		t.Run("later sign-ins follow the subject even if the email changes", func(t *testing.T) {
+			ts.IdP.SetUser(oidctest.User{Subject: "sub-new", Email: "renamed@example.com", EmailVerified: true})
+			again := &TestUser{}
+			resp := ts.LoginWithIdP(t, again)
+			require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			assert.Equal(t, user.ID, again.ID)
+			assert.NotEqual(t, user.SessionID, again.SessionID)
+		})
+	})
+
+	t.Run("sign-in links an existing account with the same email", func(t *testing.T) {
+		user := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+		ts.VerifyEmail(t, user)
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-existing", Email: user.Email, EmailVerified: true})
+
+		linked := &TestUser{}
+		resp := ts.LoginWithIdP(t, linked)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Equal(t, user.ID, linked.ID)
+
+		resp = ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/login-activity", nil, linked)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Contains(t, resp.Body.String(), "oidc_linked")
+
+		// The password keeps working alongside the identity provider
+		assert.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+	})
+
+	t.Run("sign-in takes over an account whose email was never verified", func(t *testing.T) {
+		squatter := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, squatter).Code)
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-takeover", Email: squatter.Email, EmailVerified: true})
+
+		owner := &TestUser{}
+		resp := ts.LoginWithIdP(t, owner)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		assert.Equal(t, squatter.ID, owner.ID)
+
+		// Whoever registered the address first is signed out and cannot sign in again
+		AssertErrorResponse(t, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, squatter), http.StatusUnauthorized, "4001")
+		AssertErrorResponse(t, ts.LoginUser(t, squatter), http.StatusUnauthorized, "4010")
+		assert.Equal(t, http.StatusOK, ts.MakeAuthenticatedRequest(t, "GET", "/api/v1/auth/me", nil, owner).Code)
+	})
+
+	t.Run("unverified emails are rejected", func(t *testing.T) {
+		user := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-unverified", Email: user.Email})
+
+		resp := ts.LoginWithIdP(t, &TestUser{})
+		AssertErrorResponse(t, resp, http.StatusForbidden, "4061")
+		assert.Empty(t, sessionCookie(resp))
+	})
+
+	t.Run("the state must come from this browser and is single use", func(t *testing.T) {
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-state", Email: "state@example.com", EmailVerified: true})
+
+		authURL, stateCookie := ts.StartOIDCLogin(t)
+		code, state, err := ts.IdP.Authorize(authURL)
+		require.NoError(t, err)
+		AssertErrorResponse(t, ts.CompleteOIDCLogin(t, code, state, nil), http.StatusUnauthorized, "4060")
+
+		authURL, stateCookie = ts.StartOIDCLogin(t)
+		code, state, err = ts.IdP.Authorize(authURL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, ts.CompleteOIDCLogin(t, code, state, stateCookie).Code)
+		AssertErrorResponse(t, ts.CompleteOIDCLogin(t, code, state, stateCookie), http.StatusUnauthorized, "4060")
+	})
+
+	t.Run("two-factor authentication still applies", func(t *testing.T) {
+		user := CreateTestUser()
+		require.Equal(t, http.StatusCreated, ts.RegisterUser(t, user).Code)
+		ts.VerifyEmail(t, user)
+		require.Equal(t, http.StatusOK, ts.LoginUser(t, user).Code)
+
+		resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/2fa/setup", []byte(`{}`), user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		code, err := totp.Code(decode(resp)["secret"].(string), time.Now())
+		require.NoError(t, err)
+		resp = ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/2fa/enable", []byte(`{"code":"`+code+`"}`), user)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		ts.IdP.SetUser(oidctest.User{Subject: "sub-2fa", Email: user.Email, EmailVerified: true})
+		resp = ts.LoginWithIdP(t, &TestUser{})
+		AssertErrorResponse(t, resp, http.StatusUnauthorized, "4046")
+		assert.Empty(t, sessionCookie(resp))
+		assert.NotEmpty(t, decode(resp)["details"].(map[string]interface{})["loginToken"])
+	})
+
+	t.Run("routes are not offered without an identity provider", func(t *testing.T) {
+		plain := SetupTestServer(t)
+		defer plain.TeardownTestServer()
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/oidc/login", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		plain.Router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+// sessionCookie returns the session cookie set by a response, or an empty string
+func sessionCookie(resp *httptest.ResponseRecorder) string {
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == "session" {
+			return cookie.Value
+		}
+	}
+	return ""
+}
@@ -5,12 +5,22 @@ import (
 	"backend/internal/middleware"
 	"backend/internal/repositories"
 	"backend/internal/services"
+	"backend/pkg/cookie"
+	"backend/pkg/mailer"
+	"backend/pkg/mailer/mailertest"
+	"backend/pkg/oidc"
+	"backend/pkg/oidc/oidctest"
+	"backend/pkg/ratelimit"
 	"backend/pkg/redis"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
@@ -32,6 +42,9 @@ type TestServer struct {
 	TaskRepo    *repositories.TaskRepository
 	UserService services.UserServiceInterface
 	TaskService services.TaskServiceInterface
+	Signer      *cookie.Signer
+	MailServer  *mailertest.Server
+	IdP         *oidctest.Server // nil unless the server was set up with OIDC
 }
 
 // TestUser represents a test user with credentials
@@ -41,7 +54,7 @@ type TestUser struct {
 	Email       string `json:"email"`
 	DisplayName string `json:"display_name"`
 	Password    string `json:"-"` // Raw password for testing
-	SessionID   string `json:"-"` // Session ID after login
+	SessionID   string `json:"-"` // Signed session cookie value after login
 }
 
 // TestTask represents a test task
@@ -54,9 +67,26 @@ type TestTask struct {
 	Completed   bool   `json:"completed"`
 }
 
+// TestServerOptions holds the settings that differ between test servers
+type TestServerOptions struct {
+	RequireVerifiedEmail bool     // block task creation until the email address is verified
+	RateLimit            int      // API requests per minute per IP; 0 disables
+	AuthRateLimit        int      // login, registration and password reset requests per minute per IP; 0 disables
+	AuthEmailRateLimit   int      // login, registration and password reset requests per minute per email; 0 disables
+	LockoutThreshold     int      // consecutive failed logins before an account is locked; 0 disables
+	AdminEmails          []string // accounts with these emails are administrators
+	OIDC                 bool     // start a mock identity provider and offer sign-in with it
+}
+
 // SetupTestServer creates a new test server with miniredis
 // Returns a fully configured test server ready for integration testing
 func SetupTestServer(t *testing.T) *TestServer {
+	return SetupTestServerWithOptions(t, TestServerOptions{})
+}
+
+// SetupTestServerWithOptions creates a new test server with miniredis and the given settings
+// Mirrors the optional routing of the real server, such as the email verification requirement
+func SetupTestServerWithOptions(t *testing.T, options TestServerOptions) *TestServer {
 	// Create miniredis instance for testing
 	mr, err := miniredis.Run()
 	require.NoError(t, err, "Failed to start miniredis")
@@ -80,29 +110,86 @@ func SetupTestServer(t *testing.T) *TestServer {
 	taskRepo := repositories.NewTaskRepository(redisClient)
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	signer := cookie.NewSigner("test-secret-key")
+	mailServer := mailertest.NewServer(t)
+	userOptions := services.UserServiceOptions{
+		Tasks:  taskRepo,
+		Mailer: mailer.NewSMTPMailer(mailServer.Host, mailServer.Port, "", "", mail.Address{Name: "Task Tracker", Address: "noreply@example.com"}),
+		Signer: signer,
+		AppURL: "http://localhost:3000",
+		Logins: userRepo,
+		Lockout: services.LockoutPolicy{
+			Threshold: options.LockoutThreshold,
+		},
+		AdminEmails: options.AdminEmails,
+		TwoFactor:   userRepo,
+		APITokens:   userRepo,
+		OIDC:        userRepo,
+	}
+	var idp *oidctest.Server
+	if options.OIDC {
+		idp = oidctest.NewServer(t, "task-tracker", "test-client-secret", "http://localhost:3000/auth/oidc/callback")
+		provider, err := oidc.Discover(context.Background(), oidc.Config{
+			IssuerURL:    idp.URL,
+			ClientID:     idp.ClientID,
+			ClientSecret: idp.ClientSecret,
+			RedirectURL:  idp.RedirectURL,
+		})
+		require.NoError(t, err, "Failed to discover the mock identity provider")
+		userOptions.OIDCProvider = provider
+	}
+	userService := services.NewUserServiceWithOptions(userRepo, userOptions)
 	taskService := services.NewTaskService(taskRepo)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, signer)
 	taskHandler := handlers.NewTaskHandler(taskService)
+	adminHandler := handlers.NewAdminHandler(userService)
 
 	// Initialize middleware
-	authMiddleware := middleware.AuthMiddleware(userRepo)
+	sessionCookies := middleware.CookieSettings{HTTPOnly: true}
+	authMiddleware := middleware.AuthMiddleware(userRepo, signer, sessionCookies)
+	tokenAuthMiddleware := middleware.AuthMiddlewareWithTokens(userRepo, userRepo, signer, sessionCookies)
+	requireVerified := func(c *gin.Context) { c.Next() }
+	if options.RequireVerifiedEmail {
+		requireVerified = middleware.RequireVerifiedEmail()
+	}
+	apiLimit := func(c *gin.Context) { c.Next() }
+	if options.RateLimit > 0 {
+		apiLimit = middleware.RateLimit(ratelimit.NewLimiter(redisClient, "api", options.RateLimit, time.Minute))
+	}
+	var authIPLimiter, authEmailLimiter middleware.RateLimiter
+	if options.AuthRateLimit > 0 {
+		authIPLimiter = ratelimit.NewLimiter(redisClient, "auth-ip", options.AuthRateLimit, time.Minute)
+	}
+	if options.AuthEmailRateLimit > 0 {
+		authEmailLimiter = ratelimit.NewLimiter(redisClient, "auth-email", options.AuthEmailRateLimit, time.Minute)
+	}
+	credentialLimit := middleware.CredentialRateLimit(authIPLimiter, authEmailLimiter)
 
 	// Setup Gin router
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(nil))
 
 	// API version 1 routes group
 	v1 := router.Group("/api/v1")
+	v1.Use(apiLimit)
 	{
 		// Auth routes (no authentication required)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/register", credentialLimit, authHandler.Register)
+			auth.POST("/login", credentialLimit, authHandler.Login)
+			auth.POST("/login/2fa", credentialLimit, authHandler.LoginTwoFactor)
 			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/forgot-password", credentialLimit, authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			if options.OIDC {
+				auth.POST("/oidc/login", credentialLimit, authHandler.StartOIDCLogin)
+				auth.POST("/oidc/callback", credentialLimit, authHandler.CompleteOIDCLogin)
+			}
 		}
 
 		// Protected routes (authentication required)
@@ -111,18 +198,56 @@ func SetupTestServer(t *testing.T) *TestServer {
 		{
 			// Auth routes that require authentication
 			protected.GET("/auth/me", authHandler.Me)
-			// Task routes
-			protected.GET("/tasks", taskHandler.ListTasks)
-			protected.POST("/tasks", taskHandler.CreateTask)
-			protected.GET("/tasks/:id", taskHandler.GetTask)
-			protected.PUT("/tasks/:id/complete", taskHandler.UpdateTaskCompletion)
-			protected.DELETE("/tasks/:id", taskHandler.DeleteTask)
-			protected.POST("/tasks/:id/restore", taskHandler.RestoreTask)
+			protected.PATCH("/auth/me", authHandler.UpdateProfile)
+			protected.DELETE("/auth/me", authHandler.DeleteAccount)
+			protected.PUT("/auth/password", authHandler.ChangePassword)
+			protected.POST("/auth/resend-verification", authHandler.ResendVerification)
+			protected.GET("/auth/sessions", authHandler.ListSessions)
+			protected.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+			protected.GET("/auth/login-activity", authHandler.LoginActivity)
+			protected.GET("/auth/2fa", authHandler.TwoFactorStatus)
+			protected.POST("/auth/2fa/setup", authHandler.SetupTwoFactor)
+			protected.POST("/auth/2fa/enable", authHandler.EnableTwoFactor)
+			protected.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+			protected.GET("/auth/tokens", authHandler.ListAPITokens)
+			protected.POST("/auth/tokens", authHandler.CreateAPIToken)
+			protected.DELETE("/auth/tokens/:id", authHandler.RevokeAPIToken)
+		}
+
+		// Task routes (a session, or an API token with the tasks:read scope to read and tasks:write to change)
+		tasks := v1.Group("/")
+		tasks.Use(tokenAuthMiddleware)
+		{
+			tasks.GET("/tasks", taskHandler.ListTasks)
+			tasks.POST("/tasks", requireVerified, taskHandler.CreateTask)
+			tasks.GET("/tasks/search", taskHandler.SearchTasks)
+			tasks.GET("/tasks/:id", taskHandler.GetTask)
+			tasks.PATCH("/tasks/:id", taskHandler.UpdateTask)
+			tasks.PUT("/tasks/:id/complete", taskHandler.UpdateTaskCompletion)
+			tasks.DELETE("/tasks/:id", taskHandler.DeleteTask)
+			tasks.POST("/tasks/:id/restore", taskHandler.RestoreTask)
+			tasks.GET("/tasks/:id/history", taskHandler.GetTaskHistory)
+			tasks.GET("/tasks/:id/subtasks", taskHandler.ListSubtasks)
 
 			// Category routes
-			protected.GET("/categories", taskHandler.GetCategories)
-			protected.PUT("/categories/:categoryName", taskHandler.RenameCategory)
-			protected.DELETE("/categories/:categoryName", taskHandler.DeleteCategory)
+			tasks.GET("/categories", taskHandler.GetCategories)
+			tasks.PUT("/categories/:categoryName", taskHandler.RenameCategory)
+			tasks.DELETE("/categories/:categoryName", taskHandler.DeleteCategory)
+
+			// Tag routes
+			tasks.GET("/tags", taskHandler.GetTags)
+			tasks.PUT("/tags/:tagName", taskHandler.RenameTag)
+			tasks.POST("/tags/:tagName/merge", taskHandler.MergeTags)
+		}
+
+		// Admin routes (administrator accounts only)
+		admin := v1.Group("/admin")
+		admin.Use(authMiddleware, middleware.RequireAdmin(), middleware.RequireVerifiedEmail())
+		{
+			admin.GET("/users/:id/login-activity", adminHandler.GetUserLoginActivity)
+			admin.POST("/users/:id/unlock", adminHandler.UnlockUser)
 		}
 	}
 
@@ -134,7 +259,54 @@ func SetupTestServer(t *testing.T) *TestServer {
 		TaskRepo:    taskRepo,
 		UserService: userService,
 		TaskService: taskService,
+		Signer:      signer,
+		MailServer:  mailServer,
+		IdP:         idp,
+	}
+}
+
+// RawSessionID returns the session ID carried by the user's signed session cookie
+// Used where the API expects the bare session ID, such as revoking a session
+func (ts *TestServer) RawSessionID(t *testing.T, user *TestUser) string {
+	sessionID, ok := ts.Signer.Verify(user.SessionID)
+	require.True(t, ok, "Session cookie should carry a valid signature")
+	return sessionID
+}
+
+// MessagesWithSubject returns the emails received by the test mail server with the given subject, oldest first
+func (ts *TestServer) MessagesWithSubject(subject string) []mailertest.Message {
+	var messages []mailertest.Message
+	for _, msg := range ts.MailServer.Messages() {
+		if msg.Subject == subject {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// VerifyEmail opens the link of the latest verification email sent to the user
+func (ts *TestServer) VerifyEmail(t *testing.T, user *TestUser) {
+	var token string
+	for _, msg := range ts.MessagesWithSubject("Verify your email address") {
+		if len(msg.To) == 1 && msg.To[0] == user.Email {
+			token = LinkToken(t, msg.Body, "/verify-email")
+		}
 	}
+	require.NotEmpty(t, token, "A verification email should have been sent to %s", user.Email)
+
+	body, _ := json.Marshal(map[string]string{"token": token})
+	resp := ts.MakeAuthenticatedRequest(t, "POST", "/api/v1/auth/verify-email", body, nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+}
+
+// LinkToken extracts the token from the link to the given frontend path in an email body
+func LinkToken(t *testing.T, body, path string) string {
+	match := regexp.MustCompile(regexp.QuoteMeta("http://localhost:3000"+path+"?token=") + `(\S+)`).FindStringSubmatch(body)
+	require.Len(t, match, 2, "Email should contain a link to %s", path)
+
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
 }
 
 // TeardownTestServer cleans up the test server and its resources
@@ -221,6 +393,68 @@ func (ts *TestServer) LoginUser(t *testing.T, user *TestUser) *httptest.Response
 	return resp
 }
 
+// StartOIDCLogin starts a sign-in with the identity provider through the API
+// Returns the provider's authorization URL and the state cookie to send back with the callback
+func (ts *TestServer) StartOIDCLogin(t *testing.T) (string, *http.Cookie) {
+	req := httptest.NewRequest("POST", "/api/v1/auth/oidc/login", bytes.NewBufferString(`{"rememberMe":true}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	ts.Router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+
+	var stateCookie *http.Cookie
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == "oidc_state" {
+			stateCookie = cookie
+		}
+	}
+	require.NotNil(t, stateCookie, "Starting a sign-in should set the state cookie")
+	return response["authorizationUrl"].(string), stateCookie
+}
+
+// CompleteOIDCLogin posts the code and state the identity provider redirected back with
+// Sends the state cookie when given one and returns the HTTP response
+func (ts *TestServer) CompleteOIDCLogin(t *testing.T, code, state string, stateCookie *http.Cookie) *httptest.ResponseRecorder {
+	body, err := json.Marshal(map[string]string{"code": code, "state": state})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/auth/oidc/callback", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if stateCookie != nil {
+		req.AddCookie(&http.Cookie{Name: stateCookie.Name, Value: stateCookie.Value})
+	}
+
+	resp := httptest.NewRecorder()
+	ts.Router.ServeHTTP(resp, req)
+	return resp
+}
+
+// LoginWithIdP signs the identity provider's current user in through the whole authorization code flow
+// Returns the callback response and stores the session cookie and user ID on success
+func (ts *TestServer) LoginWithIdP(t *testing.T, user *TestUser) *httptest.ResponseRecorder {
+	authURL, stateCookie := ts.StartOIDCLogin(t)
+	code, state, err := ts.IdP.Authorize(authURL)
+	require.NoError(t, err)
+
+	resp := ts.CompleteOIDCLogin(t, code, state, stateCookie)
+	if resp.Code == http.StatusOK {
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		user.ID = response["id"].(string)
+		user.Email = response["email"].(string)
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == "session" && cookie.Value != "" {
+				user.SessionID = cookie.Value
+			}
+		}
+	}
+	return resp
+}
+
 // CreateTestTask creates a test task with default values
 // Returns a TestTask with generated description and category
 func CreateTestTask(userID string) *TestTask {